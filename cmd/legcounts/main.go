@@ -0,0 +1,64 @@
+// Command legcounts recalcule les compteurs de membres et d'actifs de chaque jambe
+// (leftMembers, rightMembers, leftActives, rightActives) stockés sur les clients.
+// À lancer après la mise en place des compteurs, une reprise de données ou une
+// modification manuelle de l'arbre; le serveur les tient ensuite à jour.
+//
+//	go run ./cmd/legcounts
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"bureau/internal/config"
+	"bureau/internal/service"
+	"bureau/internal/store"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+func main() {
+	if err := run(os.Args[1:]); err != nil {
+		fmt.Fprintln(os.Stderr, "legcounts:", err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string) error {
+	cfg := config.Load()
+
+	fs := flag.NewFlagSet("legcounts", flag.ContinueOnError)
+	timeout := fs.Duration("timeout", 30*time.Minute, "durée maximale du recalcul")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	logger, err := zap.NewProduction()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = logger.Sync() }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(cfg.MongoURI))
+	if err != nil {
+		return fmt.Errorf("connexion à MongoDB: %w", err)
+	}
+	defer func() { _ = client.Disconnect(context.Background()) }()
+
+	legCountService := service.NewLegCountService(store.NewClientRepository(client.Database(cfg.MongoDBName)), logger)
+	corrected, err := legCountService.Rebuild(ctx)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Compteurs de jambes recalculés: %d membre(s) corrigé(s)\n", corrected)
+	return nil
+}
@@ -0,0 +1,65 @@
+// Command moneymigrate convertit les montants et volumes enregistrés en nombres flottants
+// avant models.Money (soldes, commissions, ventes, volumes des jambes...) en Decimal128
+// arrondis au centime. Le serveur lit encore les anciens documents, mais les agrégations
+// Mongo ($sum) ne sont exactes qu'une fois les données migrées. Elle renseigne aussi les points
+// des ventes enregistrées avant que la vente porte ses points (points du produit × quantité);
+// relancer ensuite le recalcul de l'activité (tâche activity-refresh). La commande est idempotente.
+//
+//	go run ./cmd/moneymigrate
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"bureau/internal/config"
+	"bureau/internal/store"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func main() {
+	if err := run(os.Args[1:]); err != nil {
+		fmt.Fprintln(os.Stderr, "moneymigrate:", err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string) error {
+	cfg := config.Load()
+
+	fs := flag.NewFlagSet("moneymigrate", flag.ContinueOnError)
+	timeout := fs.Duration("timeout", 30*time.Minute, "durée maximale de la migration")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(cfg.MongoURI))
+	if err != nil {
+		return fmt.Errorf("connexion à MongoDB: %w", err)
+	}
+	defer func() { _ = client.Disconnect(context.Background()) }()
+
+	db := client.Database(cfg.MongoDBName)
+	backfilled, err := store.BackfillSalePoints(ctx, db)
+	fmt.Printf("sales: %d vente(s) sans points renseignée(s)\n", backfilled)
+	if err != nil {
+		return fmt.Errorf("points des ventes: %w", err)
+	}
+
+	results, err := store.MigrateMoneyFields(ctx, db)
+	for _, result := range results {
+		fmt.Printf("%s: %d document(s) converti(s)\n", result.Collection, result.Modified)
+	}
+	if err != nil {
+		return fmt.Errorf("migration des montants: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+// Command placementparents migre les clients créés avant la séparation du parrain
+// (sponsorId, membre qui a inscrit le client) et du parent de placement
+// (placementParentId, parent dans l'arbre binaire). Jusque-là le parrain était
+// toujours le parent de placement: placementParentId reprend donc sponsorId.
+// La commande est idempotente et doit être lancée une fois avant de démarrer le
+// serveur sur les données existantes.
+//
+//	go run ./cmd/placementparents
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"bureau/internal/config"
+	"bureau/internal/store"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func main() {
+	if err := run(os.Args[1:]); err != nil {
+		fmt.Fprintln(os.Stderr, "placementparents:", err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string) error {
+	cfg := config.Load()
+
+	fs := flag.NewFlagSet("placementparents", flag.ContinueOnError)
+	timeout := fs.Duration("timeout", 10*time.Minute, "durée maximale de la migration")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(cfg.MongoURI))
+	if err != nil {
+		return fmt.Errorf("connexion à MongoDB: %w", err)
+	}
+	defer func() { _ = client.Disconnect(context.Background()) }()
+
+	migrated, err := store.NewClientRepository(client.Database(cfg.MongoDBName)).BackfillPlacementParents(ctx)
+	if err != nil {
+		return fmt.Errorf("migration des parents de placement: %w", err)
+	}
+
+	fmt.Printf("Parents de placement renseignés: %d client(s) migré(s)\n", migrated)
+	return nil
+}
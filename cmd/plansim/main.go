@@ -0,0 +1,231 @@
+// Command plansim rejoue des ventes sur un arbre binaire en mémoire pour évaluer un plan
+// de rémunération avant sa mise en production. Il utilise le même moteur que le serveur
+// (BinaryCommissionService) et ne se connecte pas à MongoDB.
+//
+//	go run ./cmd/plansim -members 2000 -days 30 -sales-per-day 150 -daily-limit 4
+//	go run ./cmd/plansim -tree clients.json -sales sales.json -rate 0.12
+//	go run ./cmd/plansim -members 500 -matching 0.10,0.05
+//
+// Les fichiers -tree et -sales sont des exports mongoexport des collections clients et
+// sales (un document par ligne ou --jsonArray). Les valeurs par défaut du plan sont celles
+// de la configuration d'environnement (BINARY_*), comme la version 0 du plan.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"bureau/internal/config"
+	"bureau/internal/models"
+
+	"go.uber.org/zap"
+)
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, "plansim:", err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string, out io.Writer) error {
+	cfg := config.Load()
+
+	fs := flag.NewFlagSet("plansim", flag.ContinueOnError)
+	treePath := fs.String("tree", "", "export mongoexport de la collection clients (arbre synthétique si vide)")
+	salesPath := fs.String("sales", "", "export mongoexport de la collection sales (ventes synthétiques si vide)")
+	members := fs.Int("members", 1000, "taille de l'arbre synthétique")
+	days := fs.Int("days", 30, "nombre de jours de ventes synthétiques")
+	salesPerDay := fs.Int("sales-per-day", 100, "ventes synthétiques par jour")
+	price := fs.Float64("price", cfg.DefaultProductPrice, "prix unitaire des ventes synthétiques")
+	points := fs.Float64("points", cfg.DefaultProductPrice, "points (volume) unitaires des ventes synthétiques")
+	start := fs.String("start", "", "premier jour des ventes synthétiques (AAAA-MM-JJ, aujourd'hui par défaut)")
+	seed := fs.Int64("seed", 1, "graine du générateur aléatoire")
+
+	rate := fs.Float64("rate", cfg.BinaryCommissionRate, "taux de commission sur le volume apparié")
+	cycleValue := fs.Float64("cycle-value", cfg.BinaryCycleValue, "montant fixe par cycle (0 = volume × taux)")
+	minVolume := fs.Float64("min-volume", cfg.BinaryMinVolumePerLeg, "volume minimum par jambe pour un cycle")
+	dailyLimit := fs.Int("daily-limit", cfg.BinaryDailyCycleLimit, "cycles payés maximum par jour (0 = illimité)")
+	weeklyLimit := fs.Int("weekly-limit", cfg.BinaryWeeklyCycleLimit, "cycles payés maximum par semaine (0 = illimité)")
+	weekStart := fs.Int("week-start", int(cfg.BinaryWeekStartDay), "premier jour de la semaine de capping (0 = dimanche ... 6 = samedi)")
+	matching := fs.String("matching", formatRates(cfg.MatchingBonusRates), "taux du bonus de matching par génération, séparés par des virgules (vide = aucun)")
+
+	activeMinPoints := fs.Float64("active-min-points", cfg.ActivityMinPoints, "points personnels minimum pour être actif")
+	activeWindow := fs.String("active-window", cfg.ActivityWindow, "fenêtre d'activité: lifetime, rolling ou month")
+	activeDays := fs.Int("active-days", cfg.ActivityWindowDays, "longueur de la fenêtre d'activité glissante en jours")
+
+	asJSON := fs.Bool("json", false, "écrire le rapport en JSON")
+	daily := fs.Bool("daily", false, "afficher le détail par jour")
+	verbose := fs.Bool("v", false, "journaliser les calculs du moteur")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *weekStart < 0 || *weekStart > 6 {
+		return fmt.Errorf("-week-start doit être compris entre 0 et 6")
+	}
+	matchingRates, err := parseRates(*matching)
+	if err != nil {
+		return fmt.Errorf("-matching invalide: %w", err)
+	}
+
+	plan := models.BinaryConfig{
+		Engine:             models.BinaryEngineCycle,
+		CycleValue:         models.NewMoney(*cycleValue),
+		CommissionRate:     *rate,
+		DailyCycleLimit:    *dailyLimit,
+		WeeklyCycleLimit:   *weeklyLimit,
+		WeekStartDay:       time.Weekday(*weekStart),
+		MinVolumePerLeg:    models.NewVolume(*minVolume),
+		RequireDirectLeft:  true,
+		RequireDirectRight: true,
+		MatchingBonusRates: matchingRates,
+	}
+
+	startDay := time.Now().UTC().Truncate(24 * time.Hour)
+	if *start != "" {
+		parsed, err := time.Parse("2006-01-02", *start)
+		if err != nil {
+			return fmt.Errorf("-start invalide: %w", err)
+		}
+		startDay = parsed
+	}
+
+	logger := zap.NewNop()
+	if *verbose {
+		logger, _ = zap.NewDevelopment()
+	}
+
+	rng := rand.New(rand.NewSource(*seed))
+	store := newMemoryStore()
+
+	var tree []*models.Client
+	if *treePath != "" {
+		loaded, err := loadExportedTree(store, *treePath)
+		if err != nil {
+			return err
+		}
+		tree = loaded
+	} else {
+		if *members < 1 {
+			return fmt.Errorf("-members doit être positif")
+		}
+		tree = buildSyntheticTree(store, *members, rng, startDay)
+	}
+
+	var sales []*models.Sale
+	if *salesPath != "" {
+		loaded, err := loadExportedSales(store, *salesPath)
+		if err != nil {
+			return err
+		}
+		sales = loaded
+	} else {
+		sales = syntheticSales(tree, startDay, *days, *salesPerDay, models.NewMoney(*price), models.NewVolume(*points), rng)
+	}
+
+	activity := models.ActivityRule{MinPoints: models.NewVolume(*activeMinPoints), Window: *activeWindow, WindowDays: *activeDays}
+	report, err := newSimulator(store, tree, plan, activity, logger).run(context.Background(), sales)
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	}
+	printReport(out, report, *daily)
+	return nil
+}
+
+// printReport écrit le rapport lisible
+func printReport(out io.Writer, r *Report, daily bool) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Plan\ttaux %.2f%%, valeur de cycle %s, volume min/jambe %s, limite jour %s, limite semaine %s\n",
+		r.Plan.CommissionRate*100, r.Plan.CycleValue, r.Plan.MinVolumePerLeg,
+		limitLabel(r.Plan.DailyCycleLimit), limitLabel(r.Plan.WeeklyCycleLimit))
+	fmt.Fprintf(w, "Activité\t%s points minimum, fenêtre %s\n", r.Activity.MinPoints, activityLabel(r.Activity))
+	fmt.Fprintf(w, "Membres\t%d\n", r.Members)
+	fmt.Fprintf(w, "Jours\t%d\n", r.Days)
+	fmt.Fprintf(w, "Ventes\t%d (CA %s, volume %s)\n", r.Sales, r.SalesAmount, r.SalesVolume)
+	fmt.Fprintln(w, "\t")
+	fmt.Fprintf(w, "Total payé\t%s\n", r.TotalPaid)
+	if len(r.Plan.MatchingBonusRates) > 0 {
+		fmt.Fprintf(w, "Dont bonus de matching\t%s (taux %s)\n", r.MatchingPaid, formatRates(r.Plan.MatchingBonusRates))
+	}
+	fmt.Fprintf(w, "Ratio de paiement\t%.2f%%\n", r.PayoutRatio*100)
+	fmt.Fprintf(w, "Commissions\t%d (%d cycles, volume apparié %s par jambe)\n", r.Commissions, r.CyclesPaid, r.VolumeMatched)
+	fmt.Fprintf(w, "Plafonné (reporté)\t%d cycles, volume %s\n", r.CappedCycles, r.CappedVolume)
+	fmt.Fprintf(w, "Bloqué (non qualifiés)\tvolume %s\n", r.UnqualifiedVolume)
+	fmt.Fprintf(w, "Non apparié (jambe forte)\tvolume %s\n", r.UnmatchedVolume)
+	fmt.Fprintln(w, "\t")
+	e := r.Earnings
+	fmt.Fprintf(w, "Gagnants\t%d (%.1f%% des membres)\n", e.Earners, e.EarnerRate*100)
+	fmt.Fprintf(w, "Gains moyen / médian\t%s / %s\n", e.Mean, e.Median)
+	fmt.Fprintf(w, "Gains P90 / P99 / max\t%s / %s / %s\n", e.P90, e.P99, e.Max)
+	fmt.Fprintf(w, "Part du top 1%% / 10%%\t%.1f%% / %.1f%%\n", e.Top1Share*100, e.Top10Share*100)
+	w.Flush()
+
+	if !daily {
+		return
+	}
+	fmt.Fprintln(out)
+	w = tabwriter.NewWriter(out, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, strings.Join([]string{"Jour", "Ventes", "CA", "Payé", "Cycles", "Plafonnés", ""}, "\t"))
+	for _, d := range r.Daily {
+		fmt.Fprintf(w, "%s\t%d\t%s\t%s\t%d\t%d\t\n", d.Date, d.Sales, d.SalesAmount, d.Paid, d.CyclesPaid, d.MembersCapped)
+	}
+	w.Flush()
+}
+
+func activityLabel(rule models.ActivityRule) string {
+	if rule.Window == models.ActivityWindowRolling {
+		return fmt.Sprintf("%s (%d jours)", rule.Window, rule.WindowDays)
+	}
+	return rule.Window
+}
+
+// parseRates lit une liste de taux séparés par des virgules ("0.10,0.05")
+func parseRates(value string) ([]float64, error) {
+	var rates []float64
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		rate, err := strconv.ParseFloat(item, 64)
+		if err != nil {
+			return nil, err
+		}
+		if rate < 0 || rate > 1 {
+			return nil, fmt.Errorf("le taux %s doit être compris entre 0 et 1", item)
+		}
+		rates = append(rates, rate)
+	}
+	return rates, nil
+}
+
+func formatRates(rates []float64) string {
+	items := make([]string, len(rates))
+	for i, rate := range rates {
+		items[i] = strconv.FormatFloat(rate, 'f', -1, 64)
+	}
+	return strings.Join(items, ",")
+}
+
+func limitLabel(limit int) string {
+	if limit <= 0 {
+		return "illimitée"
+	}
+	return fmt.Sprintf("%d", limit)
+}
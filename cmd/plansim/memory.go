@@ -0,0 +1,251 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"bureau/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// memoryStore remplace les dépôts Mongo du moteur binaire: tout l'état de la simulation
+// (arbre, ventes, commissions, capping) vit en mémoire
+type memoryStore struct {
+	clients     map[string]*models.Client
+	sales       map[string][]*models.Sale
+	commissions []*models.Commission
+	cycles      int
+	cappings    map[primitive.ObjectID]map[int64]*models.BinaryCapping
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{
+		clients:  make(map[string]*models.Client),
+		sales:    make(map[string][]*models.Sale),
+		cappings: make(map[primitive.ObjectID]map[int64]*models.BinaryCapping),
+	}
+}
+
+// addClient enregistre un membre; ses liens de placement sont déjà renseignés
+func (m *memoryStore) addClient(client *models.Client) {
+	m.clients[client.ID.Hex()] = client
+}
+
+// GetByID retourne une copie, comme une lecture Mongo
+func (m *memoryStore) GetByID(ctx context.Context, id string) (*models.Client, error) {
+	client, ok := m.clients[id]
+	if !ok {
+		return nil, fmt.Errorf("client %s introuvable", id)
+	}
+	copied := *client
+	return &copied, nil
+}
+
+func (m *memoryStore) UpdateNetworkVolumes(ctx context.Context, id string, left, right models.Volume) error {
+	client, ok := m.clients[id]
+	if !ok {
+		return fmt.Errorf("client %s introuvable", id)
+	}
+	client.NetworkVolumeLeft = left
+	client.NetworkVolumeRight = right
+	return nil
+}
+
+func (m *memoryStore) DeductLegVolumes(ctx context.Context, id string, volume models.Volume) (*models.Client, error) {
+	client, ok := m.clients[id]
+	if !ok {
+		return nil, fmt.Errorf("client %s introuvable", id)
+	}
+	if client.NetworkVolumeLeft < volume || client.NetworkVolumeRight < volume {
+		return nil, nil
+	}
+	client.NetworkVolumeLeft -= volume
+	client.NetworkVolumeRight -= volume
+	copied := *client
+	return &copied, nil
+}
+
+func (m *memoryStore) UpdateActiveUntil(ctx context.Context, id string, activeUntil *time.Time) error {
+	client, ok := m.clients[id]
+	if !ok {
+		return fmt.Errorf("client %s introuvable", id)
+	}
+	client.ActiveUntil = activeUntil
+	return nil
+}
+
+func (m *memoryStore) GetAllIDs(ctx context.Context) ([]primitive.ObjectID, error) {
+	ids := make([]primitive.ObjectID, 0, len(m.clients))
+	for _, client := range m.clients {
+		ids = append(ids, client.ID)
+	}
+	return ids, nil
+}
+
+func (m *memoryStore) IncrementLegCounts(ctx context.Context, id string, side string, members, actives int) error {
+	client, ok := m.clients[id]
+	if !ok {
+		return fmt.Errorf("client %s introuvable", id)
+	}
+	if side == "left" {
+		client.LeftMembers += members
+		client.LeftActives += actives
+	} else {
+		client.RightMembers += members
+		client.RightActives += actives
+	}
+	return nil
+}
+
+func (m *memoryStore) SetCountedActive(ctx context.Context, id string, active bool) (bool, error) {
+	client, ok := m.clients[id]
+	if !ok {
+		return false, fmt.Errorf("client %s introuvable", id)
+	}
+	changed := client.CountedActive != active
+	client.CountedActive = active
+	return changed, nil
+}
+
+func (m *memoryStore) GetExpiredCountedActives(ctx context.Context, now time.Time) ([]*models.Client, error) {
+	var expired []*models.Client
+	for _, client := range m.clients {
+		if client.CountedActive && (client.ActiveUntil == nil || !now.Before(*client.ActiveUntil)) {
+			copied := *client
+			expired = append(expired, &copied)
+		}
+	}
+	return expired, nil
+}
+
+// GetPlacementTree retourne des copies, comme une lecture Mongo
+func (m *memoryStore) GetPlacementTree(ctx context.Context) ([]*models.Client, error) {
+	clients := make([]*models.Client, 0, len(m.clients))
+	for _, client := range m.clients {
+		copied := *client
+		clients = append(clients, &copied)
+	}
+	return clients, nil
+}
+
+func (m *memoryStore) ApplyLegCountDeltas(ctx context.Context, deltas []models.LegCountDelta) error {
+	for _, delta := range deltas {
+		client, ok := m.clients[delta.ClientID.Hex()]
+		if !ok {
+			return fmt.Errorf("client %s introuvable", delta.ClientID.Hex())
+		}
+		client.LeftMembers += delta.LeftMembers
+		client.RightMembers += delta.RightMembers
+		client.LeftActives += delta.LeftActives
+		client.RightActives += delta.RightActives
+	}
+	return nil
+}
+
+// memorySales expose les ventes du magasin avec la signature du dépôt de ventes
+type memorySales struct{ *memoryStore }
+
+func (m memorySales) GetByClientID(ctx context.Context, clientID string) ([]*models.Sale, error) {
+	return m.sales[clientID], nil
+}
+
+// memoryCommissions expose les commissions du magasin avec la signature du dépôt de commissions
+type memoryCommissions struct{ *memoryStore }
+
+func (m memoryCommissions) Create(ctx context.Context, commission *models.Commission) (*models.Commission, error) {
+	commission.ID = primitive.NewObjectID()
+	m.commissions = append(m.commissions, commission)
+	return commission, nil
+}
+
+// memoryWallet applique les écritures de portefeuille aux clients sans conserver le journal
+type memoryWallet struct{ *memoryStore }
+
+func (m memoryWallet) Post(ctx context.Context, entry *models.WalletTransaction) (*models.WalletTransaction, error) {
+	client, ok := m.clients[entry.ClientID.Hex()]
+	if !ok {
+		return nil, fmt.Errorf("client %s introuvable", entry.ClientID.Hex())
+	}
+	client.WalletBalance += entry.SignedAmount()
+	client.TotalEarnings += entry.Earnings
+	entry.BalanceAfter = client.WalletBalance
+	return entry, nil
+}
+
+// memoryCycles compte l'historique des cycles sans le conserver
+type memoryCycles struct{ *memoryStore }
+
+func (m memoryCycles) Create(ctx context.Context, cycle *models.BinaryCycle) (*models.BinaryCycle, error) {
+	m.cycles += cycle.Cycles
+	return cycle, nil
+}
+
+func (m memoryCycles) GetByClientID(ctx context.Context, clientID string, filter *models.FilterInput, paging *models.PagingInput) ([]*models.BinaryCycle, error) {
+	return []*models.BinaryCycle{}, nil
+}
+
+// memoryCapping tient les compteurs de cycles par membre et par jour (clé: début du jour en secondes Unix)
+type memoryCapping struct{ *memoryStore }
+
+func (m memoryCapping) day(clientID primitive.ObjectID, date, weekStart time.Time, create bool) *models.BinaryCapping {
+	days, ok := m.cappings[clientID]
+	if !ok {
+		if !create {
+			return nil
+		}
+		days = make(map[int64]*models.BinaryCapping)
+		m.cappings[clientID] = days
+	}
+	capping, ok := days[date.Unix()]
+	if !ok && create {
+		capping = &models.BinaryCapping{
+			ID:            primitive.NewObjectID(),
+			ClientID:      clientID,
+			Date:          date,
+			WeekStart:     weekStart,
+			LastResetDate: date,
+		}
+		days[date.Unix()] = capping
+	}
+	return capping
+}
+
+// weekly additionne les cycles payés sur les 7 jours commençant à weekStart
+func (m memoryCapping) weekly(clientID primitive.ObjectID, weekStart time.Time) int {
+	total := 0
+	for d := 0; d < 7; d++ {
+		if capping, ok := m.cappings[clientID][weekStart.AddDate(0, 0, d).Unix()]; ok {
+			total += capping.CyclesPaidToday
+		}
+	}
+	return total
+}
+
+func (m memoryCapping) GetByClientIDAndDate(ctx context.Context, clientID primitive.ObjectID, date time.Time, weekStart time.Time) (*models.BinaryCapping, error) {
+	result := *m.day(clientID, date, weekStart, true)
+	result.CyclesPaidThisWeek = m.weekly(clientID, weekStart)
+	return &result, nil
+}
+
+func (m memoryCapping) Peek(ctx context.Context, clientID primitive.ObjectID, date time.Time, weekStart time.Time) (*models.BinaryCapping, error) {
+	result := models.BinaryCapping{ClientID: clientID, Date: date, WeekStart: weekStart}
+	if existing := m.day(clientID, date, weekStart, false); existing != nil {
+		result = *existing
+	}
+	result.CyclesPaidThisWeek = m.weekly(clientID, weekStart)
+	return &result, nil
+}
+
+func (m memoryCapping) Update(ctx context.Context, capping *models.BinaryCapping) error {
+	stored := *capping
+	m.day(capping.ClientID, capping.Date, capping.WeekStart, true)
+	m.cappings[capping.ClientID][capping.Date.Unix()] = &stored
+	return nil
+}
+
+func (m memoryCapping) IncrementCycles(ctx context.Context, clientID primitive.ObjectID, date time.Time, weekStart time.Time, cycles int) error {
+	m.day(clientID, date, weekStart, true).CyclesPaidToday += cycles
+	return nil
+}
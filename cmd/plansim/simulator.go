@@ -0,0 +1,309 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"bureau/internal/models"
+	"bureau/internal/service"
+
+	"go.uber.org/zap"
+)
+
+// simulator rejoue des ventes à travers le même moteur que la production
+// (BinaryCommissionService) branché sur le magasin en mémoire
+type simulator struct {
+	store    *memoryStore
+	engine   *service.BinaryCommissionService
+	activity *service.ActivityService
+	legs     *service.LegCountService
+	config   models.BinaryConfig
+	clock    time.Time
+	members  []*models.Client
+}
+
+func newSimulator(store *memoryStore, members []*models.Client, config models.BinaryConfig, activity models.ActivityRule, logger *zap.Logger) *simulator {
+	engine := service.NewBinaryCommissionService(
+		store,
+		memoryCommissions{store},
+		memoryCapping{store},
+		memoryCycles{store},
+		memoryWallet{store},
+		logger,
+		config,
+		nil,
+		time.UTC,
+	)
+	sim := &simulator{
+		store:    store,
+		engine:   engine,
+		activity: service.NewActivityService(store, memorySales{store}, logger, activity, time.UTC),
+		legs:     service.NewLegCountService(store, logger),
+		config:   config,
+		members:  members,
+	}
+	engine.SetClock(func() time.Time { return sim.clock })
+	sim.legs.SetClock(func() time.Time { return sim.clock })
+	return sim
+}
+
+// DayStats résume une journée simulée
+type DayStats struct {
+	Date        string       `json:"date"`
+	Sales       int          `json:"sales"`
+	SalesAmount models.Money `json:"salesAmount"`
+	Paid        models.Money `json:"paid"`
+	CyclesPaid  int          `json:"cyclesPaid"`
+	// Membres dont des cycles restaient bloqués par le plafond en fin de journée
+	MembersCapped int `json:"membersCapped"`
+}
+
+// Report est le résultat d'une simulation
+type Report struct {
+	Plan          models.BinaryConfig `json:"plan"`
+	Activity      models.ActivityRule `json:"activity"`
+	Members       int                 `json:"members"`
+	Days          int                 `json:"days"`
+	Sales         int                 `json:"sales"`
+	SalesAmount   models.Money        `json:"salesAmount"`
+	SalesVolume   models.Volume       `json:"salesVolume"`
+	TotalPaid     models.Money        `json:"totalPaid"`
+	MatchingPaid  models.Money        `json:"matchingPaid"` // Part du total versée en bonus de matching
+	PayoutRatio   float64             `json:"payoutRatio"`  // Total payé / chiffre d'affaires
+	Commissions   int                 `json:"commissions"`
+	CyclesPaid    int                 `json:"cyclesPaid"`
+	VolumeMatched models.Volume       `json:"volumeMatched"` // Volume consommé par les cycles payés (par jambe)
+	// Fin de simulation: cycles gagnés mais retenus par le plafond (reportés)
+	CappedCycles int           `json:"cappedCycles"`
+	CappedVolume models.Volume `json:"cappedVolume"`
+	// Fin de simulation: volume appariable de membres non qualifiés
+	UnqualifiedVolume models.Volume `json:"unqualifiedVolume"`
+	// Fin de simulation: excédent de la jambe forte, perdu par un plan à remise à zéro (flush)
+	UnmatchedVolume models.Volume        `json:"unmatchedVolume"`
+	Earnings        EarningsDistribution `json:"earnings"`
+	Daily           []DayStats           `json:"daily"`
+}
+
+// EarningsDistribution décrit la répartition des gains binaires entre les membres
+type EarningsDistribution struct {
+	Earners    int          `json:"earners"`
+	EarnerRate float64      `json:"earnerRate"` // Part des membres ayant gagné quelque chose
+	Mean       models.Money `json:"mean"`       // Moyenne parmi les gagnants
+	Median     models.Money `json:"median"`
+	P90        models.Money `json:"p90"`
+	P99        models.Money `json:"p99"`
+	Max        models.Money `json:"max"`
+	Top1Share  float64      `json:"top1Share"`  // Part du total versée au 1% des gagnants les mieux payés
+	Top10Share float64      `json:"top10Share"` // Part du total versée aux 10% les mieux payés
+}
+
+// run rejoue les ventes dans l'ordre chronologique. Chaque vente payée confirme son volume
+// sur l'acheteur et sur la jambe de chaque ancêtre, puis chaque ancêtre est évalué,
+// comme ClientService le fait à la confirmation d'une vente.
+func (sim *simulator) run(ctx context.Context, sales []*models.Sale) (*Report, error) {
+	report := &Report{Plan: sim.config, Activity: sim.activity.Rule(), Members: len(sim.members)}
+	var current *DayStats
+
+	// Compteurs de jambes de l'arbre de départ, comme cmd/legcounts
+	if len(sales) > 0 {
+		sim.clock = sales[0].Date
+	}
+	if _, err := sim.legs.Rebuild(ctx); err != nil {
+		return nil, err
+	}
+
+	for _, sale := range sales {
+		day := sim.engine.PeriodKey(sale.Date)
+		if current == nil || current.Date != day {
+			if current != nil {
+				sim.closeDay(ctx, current)
+				report.Daily = append(report.Daily, *current)
+			}
+			current = &DayStats{Date: day}
+		}
+
+		sim.clock = sale.Date
+		paid, cycles, err := sim.applySale(ctx, sale)
+		if err != nil {
+			return nil, err
+		}
+
+		current.Sales++
+		current.SalesAmount += sale.Amount
+		current.Paid += paid
+		current.CyclesPaid += cycles
+		report.Sales++
+		report.SalesAmount += sale.Amount
+		report.SalesVolume += sale.Points
+	}
+	if current != nil {
+		sim.closeDay(ctx, current)
+		report.Daily = append(report.Daily, *current)
+	}
+	report.Days = len(report.Daily)
+
+	for _, commission := range sim.store.commissions {
+		report.TotalPaid += commission.Amount
+		if commission.Type == "matching" {
+			report.MatchingPaid += commission.Amount
+		}
+	}
+	report.Commissions = len(sim.store.commissions)
+	report.CyclesPaid = sim.store.cycles
+	report.VolumeMatched = models.Volume(report.CyclesPaid) * minVolumePerLeg(sim.config)
+	if report.SalesAmount > 0 {
+		report.PayoutRatio = float64(report.TotalPaid) / float64(report.SalesAmount)
+	}
+
+	if err := sim.measureCarryover(ctx, report); err != nil {
+		return nil, err
+	}
+	report.Earnings = sim.earnings()
+	return report, nil
+}
+
+// applySale enregistre une vente payée et évalue les ancêtres de l'acheteur
+func (sim *simulator) applySale(ctx context.Context, sale *models.Sale) (models.Money, int, error) {
+	buyer := sim.store.clients[sale.ClientID.Hex()]
+	if buyer == nil {
+		return 0, 0, fmt.Errorf("acheteur %s introuvable", sale.ClientID.Hex())
+	}
+	sim.store.sales[buyer.ID.Hex()] = append(sim.store.sales[buyer.ID.Hex()], sale)
+	buyer.Points += sale.Points
+	if _, err := sim.activity.Refresh(ctx, buyer.ID.Hex()); err != nil {
+		return 0, 0, err
+	}
+	if err := sim.legs.SyncActive(ctx, buyer); err != nil {
+		return 0, 0, err
+	}
+
+	var paid models.Money
+	var cycles int
+	visited := map[string]bool{buyer.ID.Hex(): true}
+	current := buyer
+	for current.PlacementParentID != nil && current.Position != nil {
+		ancestor := sim.store.clients[current.PlacementParentID.Hex()]
+		if ancestor == nil || visited[ancestor.ID.Hex()] {
+			break
+		}
+		visited[ancestor.ID.Hex()] = true
+
+		if *current.Position == "left" {
+			ancestor.NetworkVolumeLeft += sale.Points
+		} else {
+			ancestor.NetworkVolumeRight += sale.Points
+		}
+
+		result, err := sim.engine.ComputeBinaryCommission(ctx, ancestor.ID.Hex())
+		if err != nil {
+			return 0, 0, fmt.Errorf("calcul binaire de %s: %w", ancestor.ClientID, err)
+		}
+		paid += result.Amount
+		cycles += result.CyclesPaid
+
+		current = ancestor
+	}
+	return paid, cycles, nil
+}
+
+// closeDay compte, à la dernière seconde du jour, les membres dont des cycles restent plafonnés
+func (sim *simulator) closeDay(ctx context.Context, day *DayStats) {
+	date, err := time.Parse("2006-01-02", day.Date)
+	if err != nil {
+		return
+	}
+	sim.clock = date.Add(24*time.Hour - time.Second)
+
+	// Retirer des compteurs d'actifs les membres expirés dans la journée (tâche leg-count-expire)
+	if _, err := sim.legs.SyncExpired(ctx); err != nil {
+		return
+	}
+
+	for _, member := range sim.members {
+		result, err := sim.engine.PreviewBinaryCommission(ctx, member.ID.Hex())
+		if err == nil && result.CyclesCapped > 0 {
+			day.MembersCapped++
+		}
+	}
+}
+
+// measureCarryover mesure le volume resté dans les jambes en fin de simulation
+func (sim *simulator) measureCarryover(ctx context.Context, report *Report) error {
+	minVolume := minVolumePerLeg(sim.config)
+	for _, member := range sim.members {
+		client := sim.store.clients[member.ID.Hex()]
+		report.UnmatchedVolume += max(client.NetworkVolumeLeft-client.NetworkVolumeRight, client.NetworkVolumeRight-client.NetworkVolumeLeft)
+
+		result, err := sim.engine.PreviewBinaryCommission(ctx, client.ID.Hex())
+		if err != nil {
+			return fmt.Errorf("aperçu binaire de %s: %w", client.ClientID, err)
+		}
+		if !result.Qualified {
+			matchable := min(client.NetworkVolumeLeft, client.NetworkVolumeRight) / minVolume * minVolume
+			report.UnqualifiedVolume += matchable
+			continue
+		}
+		report.CappedCycles += result.CyclesCapped
+		report.CappedVolume += models.Volume(result.CyclesCapped) * minVolume
+	}
+	return nil
+}
+
+// earnings calcule la répartition des gains accumulés pendant la simulation
+func (sim *simulator) earnings() EarningsDistribution {
+	var amounts []models.Money
+	var total models.Money
+	for _, member := range sim.members {
+		if earned := sim.store.clients[member.ID.Hex()].TotalEarnings; earned > 0 {
+			amounts = append(amounts, earned)
+			total += earned
+		}
+	}
+
+	dist := EarningsDistribution{Earners: len(amounts)}
+	if len(sim.members) > 0 {
+		dist.EarnerRate = float64(len(amounts)) / float64(len(sim.members))
+	}
+	if len(amounts) == 0 {
+		return dist
+	}
+
+	sort.Slice(amounts, func(i, j int) bool { return amounts[i] > amounts[j] })
+	dist.Mean = models.NewMoney(total.Float64() / float64(len(amounts)))
+	dist.Max = amounts[0]
+	dist.Median = percentile(amounts, 0.50)
+	dist.P90 = percentile(amounts, 0.90)
+	dist.P99 = percentile(amounts, 0.99)
+	dist.Top1Share = topShare(amounts, total, 0.01)
+	dist.Top10Share = topShare(amounts, total, 0.10)
+	return dist
+}
+
+// percentile lit le centile p d'une liste triée par ordre décroissant
+func percentile(desc []models.Money, p float64) models.Money {
+	idx := int(math.Ceil((1-p)*float64(len(desc)))) - 1
+	if idx < 0 {
+		idx = 0
+	}
+	return desc[idx]
+}
+
+// topShare retourne la part du total versée à la fraction des gagnants les mieux payés (au moins un)
+func topShare(desc []models.Money, total models.Money, fraction float64) float64 {
+	n := int(math.Ceil(fraction * float64(len(desc))))
+	var sum models.Money
+	for _, amount := range desc[:n] {
+		sum += amount
+	}
+	return float64(sum) / float64(total)
+}
+
+func minVolumePerLeg(config models.BinaryConfig) models.Volume {
+	if config.MinVolumePerLeg <= 0 {
+		return models.NewVolume(1)
+	}
+	return config.MinVolumePerLeg
+}
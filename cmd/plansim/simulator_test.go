@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"math/rand"
+	"testing"
+	"time"
+
+	"bureau/internal/models"
+
+	"go.uber.org/zap"
+)
+
+func TestSimulator_ThreeMemberTree(t *testing.T) {
+	store := newMemoryStore()
+	start := time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC)
+	tree := buildSyntheticTree(store, 3, rand.New(rand.NewSource(1)), start)
+	root := tree[0]
+
+	plan := models.BinaryConfig{CommissionRate: 0.10, MinVolumePerLeg: models.NewVolume(10), DailyCycleLimit: 3}
+	sales := []*models.Sale{
+		{ClientID: *root.LeftChildID, Amount: models.NewMoney(100), Points: models.NewVolume(50), Date: start.Add(time.Hour), Status: "paid"},
+		{ClientID: *root.RightChildID, Amount: models.NewMoney(100), Points: models.NewVolume(50), Date: start.Add(2 * time.Hour), Status: "paid"},
+	}
+
+	report, err := newSimulator(store, tree, plan, models.ActivityRule{}, zap.NewNop()).run(context.Background(), sales)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// 5 cycles disponibles, 3 payés (limite jour) à 10 × 10%, 2 reportés
+	if report.CyclesPaid != 3 || report.TotalPaid != models.NewMoney(3) {
+		t.Errorf("Expected 3 cycles paid for 3.00, got %d for %s", report.CyclesPaid, report.TotalPaid)
+	}
+	if report.CappedCycles != 2 || report.CappedVolume != models.NewVolume(20) {
+		t.Errorf("Expected 2 capped cycles (volume 20), got %d (volume %s)", report.CappedCycles, report.CappedVolume)
+	}
+	if report.PayoutRatio != 3.0/200 {
+		t.Errorf("Expected payout ratio 1.5%%, got %f", report.PayoutRatio)
+	}
+	if report.Earnings.Earners != 1 || report.Earnings.Max != models.NewMoney(3) {
+		t.Errorf("Expected the root as only earner with 3.00, got %+v", report.Earnings)
+	}
+	if len(report.Daily) != 1 || report.Daily[0].MembersCapped != 1 {
+		t.Errorf("Expected one day with the root capped, got %+v", report.Daily)
+	}
+}
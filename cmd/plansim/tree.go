@@ -0,0 +1,192 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"os"
+	"sort"
+	"time"
+
+	"bureau/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// buildSyntheticTree génère un arbre binaire de size membres: chaque nouveau membre
+// prend une position libre tirée au hasard parmi celles des membres existants
+func buildSyntheticTree(store *memoryStore, size int, rng *rand.Rand, joinDate time.Time) []*models.Client {
+	type slot struct {
+		parent   *models.Client
+		position string
+	}
+
+	members := make([]*models.Client, 0, size)
+	var slots []slot
+	for i := 0; i < size; i++ {
+		member := &models.Client{
+			ID:       primitive.NewObjectID(),
+			ClientID: fmt.Sprintf("%08d", i+1),
+			Name:     fmt.Sprintf("Membre %d", i+1),
+			JoinDate: joinDate,
+		}
+
+		if len(slots) > 0 {
+			k := rng.Intn(len(slots))
+			chosen := slots[k]
+			slots[k] = slots[len(slots)-1]
+			slots = slots[:len(slots)-1]
+
+			position := chosen.position
+			member.SponsorID = &chosen.parent.ID
+			member.PlacementParentID = &chosen.parent.ID
+			member.Position = &position
+			if position == "left" {
+				chosen.parent.LeftChildID = &member.ID
+			} else {
+				chosen.parent.RightChildID = &member.ID
+			}
+		}
+
+		store.addClient(member)
+		members = append(members, member)
+		slots = append(slots, slot{member, "left"}, slot{member, "right"})
+	}
+	return members
+}
+
+// loadExportedTree charge un export mongoexport de la collection clients (un document par
+// ligne ou --jsonArray). Volumes, gains et activité sont remis à zéro: la simulation part d'un
+// arbre vierge et ne rejoue que les ventes fournies.
+func loadExportedTree(store *memoryStore, path string) ([]*models.Client, error) {
+	var members []*models.Client
+	err := readExtJSON(path, func(raw []byte) error {
+		var client models.Client
+		if err := bson.UnmarshalExtJSON(raw, false, &client); err != nil {
+			return err
+		}
+		client.NetworkVolumeLeft, client.NetworkVolumeRight = 0, 0
+		client.PendingVolumeLeft, client.PendingVolumeRight = 0, 0
+		client.Points, client.PendingPoints = 0, 0
+		client.TotalEarnings, client.WalletBalance = 0, 0
+		client.BinaryPairs = 0
+		client.ActiveUntil = nil
+		// Export antérieur à la migration des parents de placement: le parrain était le parent
+		if client.PlacementParentID == nil && client.Position != nil {
+			client.PlacementParentID = client.SponsorID
+		}
+		members = append(members, &client)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("lecture de l'arbre %s: %w", path, err)
+	}
+	if len(members) == 0 {
+		return nil, fmt.Errorf("aucun membre dans %s", path)
+	}
+
+	for _, member := range members {
+		store.addClient(member)
+	}
+	// Ignorer les liens vers des membres absents de l'export
+	for _, member := range members {
+		if member.SponsorID != nil && store.clients[member.SponsorID.Hex()] == nil {
+			member.SponsorID = nil
+		}
+		if member.PlacementParentID != nil && store.clients[member.PlacementParentID.Hex()] == nil {
+			member.PlacementParentID, member.Position = nil, nil
+		}
+		if member.LeftChildID != nil && store.clients[member.LeftChildID.Hex()] == nil {
+			member.LeftChildID = nil
+		}
+		if member.RightChildID != nil && store.clients[member.RightChildID.Hex()] == nil {
+			member.RightChildID = nil
+		}
+	}
+	return members, nil
+}
+
+// loadExportedSales charge un export mongoexport de la collection sales. Seules les ventes
+// payées de membres connus sont gardées, triées par date.
+func loadExportedSales(store *memoryStore, path string) ([]*models.Sale, error) {
+	var sales []*models.Sale
+	err := readExtJSON(path, func(raw []byte) error {
+		var sale models.Sale
+		if err := bson.UnmarshalExtJSON(raw, false, &sale); err != nil {
+			return err
+		}
+		if sale.Status == "paid" && store.clients[sale.ClientID.Hex()] != nil {
+			sales = append(sales, &sale)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("lecture des ventes %s: %w", path, err)
+	}
+
+	sort.SliceStable(sales, func(i, j int) bool { return sales[i].Date.Before(sales[j].Date) })
+	return sales, nil
+}
+
+// syntheticSales tire salesPerDay ventes payées par jour pendant days jours, réparties
+// uniformément sur la journée; la quantité varie de 1 à 3
+func syntheticSales(members []*models.Client, start time.Time, days, salesPerDay int, price models.Money, points models.Volume, rng *rand.Rand) []*models.Sale {
+	sales := make([]*models.Sale, 0, days*salesPerDay)
+	for d := 0; d < days; d++ {
+		day := start.AddDate(0, 0, d)
+		for i := 0; i < salesPerDay; i++ {
+			buyer := members[rng.Intn(len(members))]
+			quantity := 1 + rng.Intn(3)
+			sales = append(sales, &models.Sale{
+				ID:       primitive.NewObjectID(),
+				ClientID: buyer.ID,
+				Amount:   price * models.Money(quantity),
+				Quantity: quantity,
+				Points:   points * models.Volume(quantity),
+				Date:     day.Add(time.Duration(rng.Int63n(int64(24 * time.Hour)))),
+				Status:   "paid",
+			})
+		}
+	}
+
+	sort.SliceStable(sales, func(i, j int) bool { return sales[i].Date.Before(sales[j].Date) })
+	return sales
+}
+
+// readExtJSON appelle fn pour chaque document d'un fichier JSON étendu Mongo
+func readExtJSON(path string, fn func(raw []byte) error) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	trimmed := bytes.TrimSpace(data)
+	if bytes.HasPrefix(trimmed, []byte("[")) {
+		var docs []json.RawMessage
+		if err := json.Unmarshal(trimmed, &docs); err != nil {
+			return err
+		}
+		for _, doc := range docs {
+			if err := fn(doc); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(trimmed))
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		if err := fn(line); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
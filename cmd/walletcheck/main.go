@@ -0,0 +1,96 @@
+// Command walletcheck compare le solde et le total des gains stockés sur chaque client
+// (walletBalance, totalEarnings) au journal des portefeuilles (wallet_transactions) et
+// liste les écarts. Avec -open, les soldes antérieurs au journal y sont repris par une
+// écriture d'ouverture (une seule fois, à la migration); avec -fix, la projection des
+// clients en écart est reconstruite depuis le journal.
+//
+//	go run ./cmd/walletcheck [-open | -fix]
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"bureau/internal/config"
+	"bureau/internal/service"
+	"bureau/internal/store"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+func main() {
+	if err := run(os.Args[1:]); err != nil {
+		fmt.Fprintln(os.Stderr, "walletcheck:", err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string) error {
+	cfg := config.Load()
+
+	fs := flag.NewFlagSet("walletcheck", flag.ContinueOnError)
+	fix := fs.Bool("fix", false, "reconstruire la projection des clients en écart depuis le journal")
+	open := fs.Bool("open", false, "reprendre au journal les soldes antérieurs (migration)")
+	timeout := fs.Duration("timeout", 30*time.Minute, "durée maximale de la vérification")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *fix && *open {
+		return errors.New("-fix et -open sont exclusifs")
+	}
+
+	logger, err := zap.NewProduction()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = logger.Sync() }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(cfg.MongoURI))
+	if err != nil {
+		return fmt.Errorf("connexion à MongoDB: %w", err)
+	}
+	defer func() { _ = client.Disconnect(context.Background()) }()
+
+	walletService := service.NewWalletService(store.NewWalletRepository(client.Database(cfg.MongoDBName)), logger)
+
+	switch {
+	case *open:
+		opened, err := walletService.OpenBalances(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Soldes d'ouverture enregistrés: %d membre(s)\n", opened)
+		return nil
+	case *fix:
+		corrected, err := walletService.Rebuild(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Portefeuilles reconstruits: %d membre(s) corrigé(s)\n", corrected)
+		return nil
+	}
+
+	discrepancies, err := walletService.Check(ctx)
+	if err != nil {
+		return err
+	}
+	for _, d := range discrepancies {
+		fmt.Printf("%s\tjournal: solde %s, gains %s\tclient: solde %s, gains %s\n",
+			d.ClientID.Hex(), d.Ledger.Balance, d.Ledger.Earnings, d.Projection.Balance, d.Projection.Earnings)
+	}
+	if len(discrepancies) > 0 {
+		return fmt.Errorf("%d portefeuille(s) en écart avec le journal", len(discrepancies))
+	}
+
+	fmt.Println("Portefeuilles cohérents avec le journal")
+	return nil
+}
@@ -0,0 +1,79 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"bureau/internal/models"
+	"bureau/internal/validation"
+
+	"github.com/99designs/gqlgen/graphql"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// bearerToken retourne le jeton d'accès porté par l'en-tête Authorization de la requête ("" si absent)
+func bearerToken(ctx context.Context) string {
+	if oc := graphql.GetOperationContext(ctx); oc != nil {
+		if oc.Headers != nil {
+			auth := oc.Headers.Get("Authorization")
+			if strings.HasPrefix(strings.ToLower(auth), "bearer ") {
+				return strings.TrimSpace(auth[7:])
+			}
+		}
+	}
+	return ""
+}
+
+// requireAdmin vérifie que la requête porte un jeton d'accès admin valide et retourne l'admin
+func (r *Resolver) requireAdmin(ctx context.Context) (*models.Admin, error) {
+	token := bearerToken(ctx)
+	if token == "" {
+		return nil, errors.New("authentification admin requise")
+	}
+
+	admin, err := r.authService.ValidateToken(ctx, token)
+	if err != nil {
+		return nil, errors.New("authentification admin requise")
+	}
+
+	return admin, nil
+}
+
+// requireAdminOrClient vérifie que la requête porte un jeton d'accès admin ou client valide.
+// Retourne l'admin, ou à défaut l'ID du client connecté.
+func (r *Resolver) requireAdminOrClient(ctx context.Context) (*models.Admin, *primitive.ObjectID, error) {
+	token := bearerToken(ctx)
+	if token == "" {
+		return nil, nil, errors.New("authentification requise")
+	}
+
+	if admin, err := r.authService.ValidateToken(ctx, token); err == nil && admin != nil {
+		return admin, nil, nil
+	}
+
+	claims, err := r.authService.GetJWTService().ValidateAccessToken(token)
+	if err != nil || claims == nil || claims.ClientID == "" {
+		return nil, nil, errors.New("authentification invalide")
+	}
+	clientID, err := primitive.ObjectIDFromHex(claims.ClientID)
+	if err != nil {
+		return nil, nil, errors.New("authentification invalide")
+	}
+	return nil, &clientID, nil
+}
+
+// requireAdminOrSelf vérifie que la requête vient d'un admin ou du membre clientID lui-même
+func (r *Resolver) requireAdminOrSelf(ctx context.Context, clientID string) error {
+	_, selfID, err := r.requireAdminOrClient(ctx)
+	if err != nil {
+		return err
+	}
+	if err := validation.ValidateObjectID(clientID); err != nil {
+		return err
+	}
+	if selfID != nil && selfID.Hex() != clientID {
+		return errors.New("accès refusé aux données d'un autre membre")
+	}
+	return nil
+}
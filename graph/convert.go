@@ -0,0 +1,494 @@
+package graph
+
+import (
+	"time"
+
+	"bureau/graph/model"
+	"bureau/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Conversions partagées entre plusieurs resolvers
+
+func toBinaryCommissionRunModel(run *models.BinaryCommissionRun) *model.BinaryCommissionRun {
+	out := &model.BinaryCommissionRun{
+		ID:               run.ID.Hex(),
+		Period:           run.Period,
+		Status:           run.Status,
+		StartedAt:        run.StartedAt.Format(time.RFC3339),
+		ClientsTotal:     int32(run.ClientsTotal),
+		ClientsProcessed: int32(run.ClientsProcessed),
+		ClientsSkipped:   int32(run.ClientsSkipped),
+		ClientsPaid:      int32(run.ClientsPaid),
+		CyclesPaid:       int32(run.CyclesPaid),
+		TotalPaid:        run.TotalPaid,
+		Errors:           make([]*model.BinaryRunError, 0, len(run.Errors)),
+	}
+	if run.FinishedAt != nil {
+		finishedAt := run.FinishedAt.Format(time.RFC3339)
+		out.FinishedAt = &finishedAt
+	}
+	for _, e := range run.Errors {
+		out.Errors = append(out.Errors, &model.BinaryRunError{
+			ClientID: e.ClientID.Hex(),
+			Error:    e.Error,
+			Date:     e.Date.Format(time.RFC3339),
+		})
+	}
+	return out
+}
+
+func toJobExecutionModel(execution *models.JobExecution) *model.JobExecution {
+	out := &model.JobExecution{
+		ID:          execution.ID.Hex(),
+		JobName:     execution.JobName,
+		InstanceID:  execution.InstanceID,
+		ScheduledAt: execution.ScheduledAt.Format(time.RFC3339),
+		StartedAt:   execution.StartedAt.Format(time.RFC3339),
+		Status:      execution.Status,
+		Error:       execution.Error,
+	}
+	if execution.FinishedAt != nil {
+		finishedAt := execution.FinishedAt.Format(time.RFC3339)
+		out.FinishedAt = &finishedAt
+	}
+	return out
+}
+
+// formatTimePtr formate une date optionnelle en RFC3339
+func formatTimePtr(t *time.Time) *string {
+	if t == nil {
+		return nil
+	}
+	formatted := t.Format(time.RFC3339)
+	return &formatted
+}
+
+// hexPtr retourne l'identifiant hexadécimal d'un ObjectID optionnel
+func hexPtr(id *primitive.ObjectID) *string {
+	if id == nil {
+		return nil
+	}
+	hex := id.Hex()
+	return &hex
+}
+
+// toPagingInput convertit la pagination GraphQL
+func toPagingInput(paging *model.PagingInput) *models.PagingInput {
+	if paging == nil {
+		return nil
+	}
+	out := &models.PagingInput{}
+	if paging.Page != nil {
+		p := int(*paging.Page)
+		out.Page = &p
+	}
+	if paging.Limit != nil {
+		l := int(*paging.Limit)
+		out.Limit = &l
+	}
+	return out
+}
+
+// planVersionPtr retourne nil pour les enregistrements antérieurs au versionnement du plan
+func planVersionPtr(version int) *int32 {
+	if version == 0 {
+		return nil
+	}
+	v := int32(version)
+	return &v
+}
+
+func toCompPlanVersionModel(plan *models.CompPlanVersion) *model.CompPlanVersion {
+	out := &model.CompPlanVersion{
+		Version: int32(plan.Version),
+		Status:  plan.Status,
+		Binary: &model.BinaryPlanRules{
+			Engine:           plan.Binary.Engine,
+			Threshold:        plan.Binary.Threshold.Float64(),
+			CycleValue:       plan.Binary.CycleValue,
+			CommissionRate:   plan.Binary.CommissionRate,
+			DailyCycleLimit:  int32(plan.Binary.DailyCycleLimit),
+			WeeklyCycleLimit: int32(plan.Binary.WeeklyCycleLimit),
+			WeekStartDay:     int32(plan.Binary.WeekStartDay),
+			MinVolumePerLeg:  plan.Binary.MinVolumePerLeg.Float64(),
+			// Liste non nulle même si le plan n'a pas de bonus de matching
+			MatchingBonusRates: append([]float64{}, plan.Binary.MatchingBonusRates...),
+		},
+	}
+	// La version 0 (configuration d'environnement) n'est pas stockée
+	if !plan.ID.IsZero() {
+		id := plan.ID.Hex()
+		out.ID = &id
+		createdAt := plan.CreatedAt.Format(time.RFC3339)
+		out.CreatedAt = &createdAt
+	}
+	if plan.Notes != "" {
+		out.Notes = &plan.Notes
+	}
+	if plan.EffectiveFrom != nil {
+		effectiveFrom := plan.EffectiveFrom.Format(time.RFC3339)
+		out.EffectiveFrom = &effectiveFrom
+	}
+	if plan.ActivatedAt != nil {
+		activatedAt := plan.ActivatedAt.Format(time.RFC3339)
+		out.ActivatedAt = &activatedAt
+	}
+	return out
+}
+
+// applyBinaryPlanRulesInput reporte les champs renseignés de input sur les règles de base
+func applyBinaryPlanRulesInput(base models.BinaryConfig, input *model.BinaryPlanRulesInput) models.BinaryConfig {
+	rules := base
+	if input == nil {
+		return rules
+	}
+	if input.Engine != nil {
+		rules.Engine = *input.Engine
+	}
+	if input.Threshold != nil {
+		rules.Threshold = models.NewVolume(*input.Threshold)
+	}
+	if input.CycleValue != nil {
+		rules.CycleValue = *input.CycleValue
+	}
+	if input.CommissionRate != nil {
+		rules.CommissionRate = *input.CommissionRate
+	}
+	if input.DailyCycleLimit != nil {
+		rules.DailyCycleLimit = int(*input.DailyCycleLimit)
+	}
+	if input.WeeklyCycleLimit != nil {
+		rules.WeeklyCycleLimit = int(*input.WeeklyCycleLimit)
+	}
+	if input.WeekStartDay != nil {
+		rules.WeekStartDay = time.Weekday(*input.WeekStartDay)
+	}
+	if input.MinVolumePerLeg != nil {
+		rules.MinVolumePerLeg = models.NewVolume(*input.MinVolumePerLeg)
+	}
+	if input.MatchingBonusRates != nil {
+		rules.MatchingBonusRates = input.MatchingBonusRates
+	}
+	return rules
+}
+
+func toBinaryCommissionResultModel(result *models.BinaryCommissionResult) *model.BinaryCommissionResult {
+	out := &model.BinaryCommissionResult{
+		Success:              result.Success,
+		Qualified:            result.Qualified,
+		DryRun:               result.DryRun,
+		PlanVersion:          int32(result.PlanVersion),
+		CyclesAvailable:      int32(result.CyclesAvailable),
+		CyclesPaid:           int32(result.CyclesPaid),
+		CyclesCapped:         int32(result.CyclesCapped),
+		CyclesPaidToday:      int32(result.CyclesPaidToday),
+		CyclesPaidThisWeek:   int32(result.CyclesPaidThisWeek),
+		Amount:               result.Amount,
+		LeftVolumeRemaining:  result.LeftVolumeRemaining.Float64(),
+		RightVolumeRemaining: result.RightVolumeRemaining.Float64(),
+		Reason:               result.Reason,
+		CommissionID:         result.CommissionID,
+	}
+	if q := result.Qualification; q != nil {
+		out.Qualification = &model.BinaryQualification{
+			IsQualified:      q.IsQualified,
+			HasDirectLeft:    q.HasDirectLeft,
+			HasDirectRight:   q.HasDirectRight,
+			DirectLeftCount:  int32(q.DirectLeftCount),
+			DirectRightCount: int32(q.DirectRightCount),
+		}
+	}
+	if legs := result.Legs; legs != nil {
+		out.Legs = &model.BinaryLegs{
+			LeftVolume:   legs.LeftVolume.Float64(),
+			RightVolume:  legs.RightVolume.Float64(),
+			LeftActives:  int32(legs.LeftActives),
+			RightActives: int32(legs.RightActives),
+		}
+	}
+	return out
+}
+
+// optionalString retourne nil pour une chaîne vide
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+func toRankDefinitionModel(rank *models.RankDefinition) *model.RankDefinition {
+	return &model.RankDefinition{
+		ID:                rank.ID.Hex(),
+		Code:              rank.Code,
+		Name:              rank.Name,
+		Level:             int32(rank.Level),
+		MinPairedVolume:   rank.MinPairedVolume.Float64(),
+		MinPersonalVolume: rank.MinPersonalVolume.Float64(),
+		MinQualifiedLegs:  int32(rank.MinQualifiedLegs),
+	}
+}
+
+func toRankHistoryEntryModel(entry *models.RankHistory) *model.RankHistoryEntry {
+	return &model.RankHistoryEntry{
+		ID:             entry.ID.Hex(),
+		Rank:           optionalString(entry.Rank),
+		Level:          int32(entry.Level),
+		PreviousRank:   optionalString(entry.PreviousRank),
+		PreviousLevel:  int32(entry.PreviousLevel),
+		PairedVolume:   entry.PairedVolume.Float64(),
+		PersonalVolume: entry.PersonalVolume.Float64(),
+		QualifiedLegs:  int32(entry.QualifiedLegs),
+		PeriodKey:      optionalString(entry.PeriodKey),
+		Source:         entry.Source,
+		AchievedAt:     entry.AchievedAt.Format(time.RFC3339),
+	}
+}
+
+func toRankEvaluationModel(evaluation *models.RankEvaluation) *model.RankEvaluation {
+	return &model.RankEvaluation{
+		ClientID:       evaluation.ClientID.Hex(),
+		Rank:           optionalString(evaluation.Rank),
+		PreviousRank:   optionalString(evaluation.PreviousRank),
+		HighestRank:    optionalString(evaluation.HighestRank),
+		Changed:        evaluation.Changed,
+		PairedVolume:   evaluation.PairedVolume.Float64(),
+		PersonalVolume: evaluation.PersonalVolume.Float64(),
+		QualifiedLegs:  int32(evaluation.QualifiedLegs),
+		PeriodKey:      evaluation.PeriodKey,
+	}
+}
+
+// toClientModel convertit un client sans hydrater ses relations (parrain, enfants, achats)
+func toClientModel(c *models.Client) *model.Client {
+	out := &model.Client{
+		ID:                 c.ID.Hex(),
+		ClientID:           c.ClientID,
+		Name:               c.Name,
+		Phone:              c.Phone,
+		Nn:                 c.NN,
+		Address:            c.Address,
+		Avatar:             c.Avatar,
+		JoinDate:           c.JoinDate.Format(time.RFC3339),
+		Position:           c.Position,
+		HoldingTankUntil:   formatTimePtr(c.HoldingTankUntil),
+		TotalEarnings:      c.TotalEarnings,
+		WalletBalance:      c.WalletBalance,
+		Points:             c.Points.Float64(),
+		NetworkVolumeLeft:  c.NetworkVolumeLeft.Float64(),
+		NetworkVolumeRight: c.NetworkVolumeRight.Float64(),
+		PendingPoints:      c.PendingPoints.Float64(),
+		PendingVolumeLeft:  c.PendingVolumeLeft.Float64(),
+		PendingVolumeRight: c.PendingVolumeRight.Float64(),
+		BinaryPairs:        int32(c.BinaryPairs),
+		ActiveUntil:        formatTimePtr(c.ActiveUntil),
+		LeftMembers:        int32(c.LeftMembers),
+		RightMembers:       int32(c.RightMembers),
+		LeftActives:        int32(c.LeftActives),
+		RightActives:       int32(c.RightActives),
+		Rank:               optionalString(c.Rank),
+		HighestRank:        optionalString(c.HighestRank),
+	}
+	if c.SponsorID != nil {
+		sid := c.SponsorID.Hex()
+		out.SponsorID = &sid
+	}
+	if c.PlacementParentID != nil {
+		pid := c.PlacementParentID.Hex()
+		out.PlacementParentID = &pid
+	}
+	if c.LeftChildID != nil {
+		lid := c.LeftChildID.Hex()
+		out.LeftChildID = &lid
+	}
+	if c.RightChildID != nil {
+		rid := c.RightChildID.Hex()
+		out.RightChildID = &rid
+	}
+	return out
+}
+
+func toClawbackModel(clawback *models.Clawback) *model.Clawback {
+	out := &model.Clawback{
+		ID:                  clawback.ID.Hex(),
+		SaleID:              clawback.SaleID.Hex(),
+		ClientID:            clawback.ClientID.Hex(),
+		Reason:              clawback.Reason,
+		SaleStatus:          clawback.SaleStatus,
+		Volume:              clawback.Volume.Float64(),
+		PairedVolume:        clawback.PairedVolume.Float64(),
+		StockRestored:       int32(clawback.StockRestored),
+		CaisseAmount:        clawback.CaisseAmount,
+		CaisseTransactionID: hexPtr(clawback.CaisseTransactionID),
+		Commissions:         make([]*model.ClawbackCommission, 0, len(clawback.Commissions)),
+		TotalAmount:         clawback.TotalAmount,
+		CreatedAt:           clawback.CreatedAt.Format(time.RFC3339),
+	}
+	for _, c := range clawback.Commissions {
+		out.Commissions = append(out.Commissions, &model.ClawbackCommission{
+			CommissionID: c.CommissionID.Hex(),
+			ReversalID:   c.ReversalID.Hex(),
+			ClientID:     c.ClientID.Hex(),
+			Type:         c.Type,
+			Amount:       c.Amount,
+		})
+	}
+	return out
+}
+
+func toPayPeriodModel(period *models.PayPeriod) *model.PayPeriod {
+	return &model.PayPeriod{
+		ID:               period.ID.Hex(),
+		Key:              period.Key,
+		Frequency:        period.Frequency,
+		Start:            period.Start.Format(time.RFC3339),
+		End:              period.End.Format(time.RFC3339),
+		Status:           period.Status,
+		ClosedAt:         formatTimePtr(period.ClosedAt),
+		ClosedBy:         hexPtr(period.ClosedBy),
+		MemberCount:      int32(period.MemberCount),
+		TotalCommissions: period.TotalCommissions,
+		TotalAdjustments: period.TotalAdjustments,
+	}
+}
+
+func toStatementLineModels(lines []models.StatementLine) []*model.StatementLine {
+	out := make([]*model.StatementLine, 0, len(lines))
+	for _, line := range lines {
+		out = append(out, &model.StatementLine{
+			CommissionID:       line.CommissionID.Hex(),
+			Type:               line.Type,
+			Amount:             line.Amount,
+			Level:              int32(line.Level),
+			SourceClientID:     line.SourceClientID.Hex(),
+			SaleID:             hexPtr(line.SaleID),
+			SourceCommissionID: hexPtr(line.SourceCommissionID),
+			Date:               line.Date.Format(time.RFC3339),
+		})
+	}
+	return out
+}
+
+func toMemberStatementModel(statement *models.MemberStatement) *model.MemberStatement {
+	return &model.MemberStatement{
+		ID:               statement.ID.Hex(),
+		PeriodKey:        statement.PeriodKey,
+		ClientID:         statement.ClientID.Hex(),
+		PersonalVolume:   statement.PersonalVolume.Float64(),
+		PairedVolume:     statement.PairedVolume.Float64(),
+		Cycles:           int32(statement.Cycles),
+		LeftVolumeCarry:  statement.LeftVolumeCarry.Float64(),
+		RightVolumeCarry: statement.RightVolumeCarry.Float64(),
+		Commissions:      toStatementLineModels(statement.Commissions),
+		Adjustments:      toStatementLineModels(statement.Adjustments),
+		TotalCommissions: statement.TotalCommissions,
+		TotalAdjustments: statement.TotalAdjustments,
+		NetAmount:        statement.NetAmount,
+		CreatedAt:        statement.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+func toCaisseDailyReportModel(report *models.CaisseDailyReport) *model.CaisseDailyReport {
+	out := &model.CaisseDailyReport{
+		Date:             report.Date,
+		Start:            report.Start.Format(time.RFC3339),
+		End:              report.End.Format(time.RFC3339),
+		TotalEntrees:     report.TotalEntrees,
+		TotalSorties:     report.TotalSorties,
+		Net:              report.Net,
+		TransactionCount: int32(report.TransactionCount),
+		Transactions:     make([]*model.CaisseTransaction, 0, len(report.Transactions)),
+	}
+	for _, t := range report.Transactions {
+		out.Transactions = append(out.Transactions, &model.CaisseTransaction{
+			ID:            t.ID.Hex(),
+			Type:          t.Type,
+			Amount:        t.Amount,
+			Description:   t.Description,
+			Reference:     t.Reference,
+			ReferenceType: t.ReferenceType,
+			Date:          t.Date.Format(time.RFC3339),
+			CreatedBy:     t.CreatedBy,
+		})
+	}
+	return out
+}
+
+func toWalletTransactionModel(entry *models.WalletTransaction) *model.WalletTransaction {
+	return &model.WalletTransaction{
+		ID:             entry.ID.Hex(),
+		ClientID:       entry.ClientID.Hex(),
+		Direction:      entry.Direction,
+		Type:           entry.Type,
+		Amount:         entry.Amount,
+		Earnings:       entry.Earnings,
+		BalanceAfter:   entry.BalanceAfter,
+		CounterAccount: entry.CounterAccount,
+		ReferenceType:  entry.ReferenceType,
+		ReferenceID:    entry.ReferenceID.Hex(),
+		Description:    optionalString(entry.Description),
+		CreatedAt:      entry.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+func toWithdrawalModel(withdrawal *models.Withdrawal) *model.Withdrawal {
+	return &model.Withdrawal{
+		ID:                  withdrawal.ID.Hex(),
+		ClientID:            withdrawal.ClientID.Hex(),
+		Amount:              withdrawal.Amount,
+		Fee:                 withdrawal.Fee,
+		NetAmount:           withdrawal.NetAmount,
+		Method:              withdrawal.Method,
+		Destination:         withdrawal.Destination,
+		Status:              withdrawal.Status,
+		RequestedAt:         withdrawal.RequestedAt.Format(time.RFC3339),
+		ReviewedAt:          formatTimePtr(withdrawal.ReviewedAt),
+		ReviewedBy:          hexPtr(withdrawal.ReviewedBy),
+		RejectionReason:     withdrawal.RejectionReason,
+		FailureReason:       withdrawal.FailureReason,
+		PayoutBatchID:       hexPtr(withdrawal.PayoutBatchID),
+		PaymentID:           hexPtr(withdrawal.PaymentID),
+		CaisseTransactionID: hexPtr(withdrawal.CaisseTransactionID),
+		WalletTransactionID: hexPtr(withdrawal.WalletTransactionID),
+	}
+}
+
+func toPayoutBatchModel(batch *models.PayoutBatch) *model.PayoutBatch {
+	lines := make([]*model.PayoutLine, 0, len(batch.Lines))
+	for _, line := range batch.Lines {
+		lines = append(lines, &model.PayoutLine{
+			Reference:         line.Reference,
+			WithdrawalID:      line.WithdrawalID.Hex(),
+			PaymentID:         line.PaymentID.Hex(),
+			ClientID:          line.ClientID.Hex(),
+			Name:              line.Name,
+			Phone:             line.Phone,
+			Amount:            line.Amount,
+			Status:            line.Status,
+			ProviderReference: line.ProviderReference,
+			Error:             line.Error,
+		})
+	}
+
+	result := &model.PayoutBatch{
+		ID:             batch.ID.Hex(),
+		Provider:       batch.Provider,
+		Source:         batch.Source,
+		Currency:       batch.Currency,
+		Status:         batch.Status,
+		LineCount:      int32(len(batch.Lines)),
+		Lines:          lines,
+		TotalAmount:    batch.TotalAmount,
+		CompletedCount: int32(batch.CompletedCount),
+		FailedCount:    int32(batch.FailedCount),
+		CreatedAt:      batch.CreatedAt.Format(time.RFC3339),
+		CreatedBy:      hexPtr(batch.CreatedBy),
+		ImportedAt:     formatTimePtr(batch.ImportedAt),
+	}
+	if batch.Source == models.PayoutSourceBalances {
+		result.MinBalance = &batch.MinBalance
+	}
+	return result
+}
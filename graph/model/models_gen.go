@@ -2,39 +2,149 @@
 
 package model
 
+import (
+	"bureau/internal/models"
+)
+
 type AuthPayload struct {
 	AccessToken  string `json:"accessToken"`
 	RefreshToken string `json:"refreshToken"`
 	User         *User  `json:"user"`
 }
 
+type BinaryCommissionResult struct {
+	Success              bool                 `json:"success"`
+	Qualified            bool                 `json:"qualified"`
+	DryRun               bool                 `json:"dryRun"`
+	PlanVersion          int32                `json:"planVersion"`
+	Qualification        *BinaryQualification `json:"qualification,omitempty"`
+	Legs                 *BinaryLegs          `json:"legs,omitempty"`
+	CyclesAvailable      int32                `json:"cyclesAvailable"`
+	CyclesPaid           int32                `json:"cyclesPaid"`
+	CyclesCapped         int32                `json:"cyclesCapped"`
+	CyclesPaidToday      int32                `json:"cyclesPaidToday"`
+	CyclesPaidThisWeek   int32                `json:"cyclesPaidThisWeek"`
+	Amount               models.Money         `json:"amount"`
+	LeftVolumeRemaining  float64              `json:"leftVolumeRemaining"`
+	RightVolumeRemaining float64              `json:"rightVolumeRemaining"`
+	Reason               string               `json:"reason"`
+	CommissionID         *string              `json:"commissionId,omitempty"`
+}
+
+type BinaryCommissionRun struct {
+	ID               string            `json:"id"`
+	Period           string            `json:"period"`
+	Status           string            `json:"status"`
+	StartedAt        string            `json:"startedAt"`
+	FinishedAt       *string           `json:"finishedAt,omitempty"`
+	ClientsTotal     int32             `json:"clientsTotal"`
+	ClientsProcessed int32             `json:"clientsProcessed"`
+	ClientsSkipped   int32             `json:"clientsSkipped"`
+	ClientsPaid      int32             `json:"clientsPaid"`
+	CyclesPaid       int32             `json:"cyclesPaid"`
+	TotalPaid        models.Money      `json:"totalPaid"`
+	Errors           []*BinaryRunError `json:"errors"`
+}
+
+type BinaryCycle struct {
+	ID                string       `json:"id"`
+	ClientID          string       `json:"clientId"`
+	CommissionID      *string      `json:"commissionId,omitempty"`
+	CyclesAvailable   int32        `json:"cyclesAvailable"`
+	Cycles            int32        `json:"cycles"`
+	Amount            models.Money `json:"amount"`
+	LeftVolumeBefore  float64      `json:"leftVolumeBefore"`
+	RightVolumeBefore float64      `json:"rightVolumeBefore"`
+	LeftVolumeUsed    float64      `json:"leftVolumeUsed"`
+	RightVolumeUsed   float64      `json:"rightVolumeUsed"`
+	Date              string       `json:"date"`
+	ProcessedAt       string       `json:"processedAt"`
+	PlanVersion       *int32       `json:"planVersion,omitempty"`
+}
+
+type BinaryLegs struct {
+	LeftVolume   float64 `json:"leftVolume"`
+	RightVolume  float64 `json:"rightVolume"`
+	LeftActives  int32   `json:"leftActives"`
+	RightActives int32   `json:"rightActives"`
+}
+
+type BinaryPlanRules struct {
+	Engine             string       `json:"engine"`
+	Threshold          float64      `json:"threshold"`
+	CycleValue         models.Money `json:"cycleValue"`
+	CommissionRate     float64      `json:"commissionRate"`
+	DailyCycleLimit    int32        `json:"dailyCycleLimit"`
+	WeeklyCycleLimit   int32        `json:"weeklyCycleLimit"`
+	WeekStartDay       int32        `json:"weekStartDay"`
+	MinVolumePerLeg    float64      `json:"minVolumePerLeg"`
+	MatchingBonusRates []float64    `json:"matchingBonusRates"`
+}
+
+type BinaryPlanRulesInput struct {
+	Engine             *string       `json:"engine,omitempty"`
+	Threshold          *float64      `json:"threshold,omitempty"`
+	CycleValue         *models.Money `json:"cycleValue,omitempty"`
+	CommissionRate     *float64      `json:"commissionRate,omitempty"`
+	DailyCycleLimit    *int32        `json:"dailyCycleLimit,omitempty"`
+	WeeklyCycleLimit   *int32        `json:"weeklyCycleLimit,omitempty"`
+	WeekStartDay       *int32        `json:"weekStartDay,omitempty"`
+	MinVolumePerLeg    *float64      `json:"minVolumePerLeg,omitempty"`
+	MatchingBonusRates []float64     `json:"matchingBonusRates,omitempty"`
+}
+
+type BinaryQualification struct {
+	IsQualified      bool  `json:"isQualified"`
+	HasDirectLeft    bool  `json:"hasDirectLeft"`
+	HasDirectRight   bool  `json:"hasDirectRight"`
+	DirectLeftCount  int32 `json:"directLeftCount"`
+	DirectRightCount int32 `json:"directRightCount"`
+}
+
+type BinaryRunError struct {
+	ClientID string `json:"clientId"`
+	Error    string `json:"error"`
+	Date     string `json:"date"`
+}
+
 type Caisse struct {
 	ID           string               `json:"id"`
-	Balance      float64              `json:"balance"`
-	TotalEntrees float64              `json:"totalEntrees"`
-	TotalSorties float64              `json:"totalSorties"`
+	Balance      models.Money         `json:"balance"`
+	TotalEntrees models.Money         `json:"totalEntrees"`
+	TotalSorties models.Money         `json:"totalSorties"`
 	CreatedAt    string               `json:"createdAt"`
 	UpdatedAt    string               `json:"updatedAt"`
 	Transactions []*CaisseTransaction `json:"transactions"`
 }
 
+type CaisseDailyReport struct {
+	Date             string               `json:"date"`
+	Start            string               `json:"start"`
+	End              string               `json:"end"`
+	TotalEntrees     models.Money         `json:"totalEntrees"`
+	TotalSorties     models.Money         `json:"totalSorties"`
+	Net              models.Money         `json:"net"`
+	TransactionCount int32                `json:"transactionCount"`
+	Transactions     []*CaisseTransaction `json:"transactions"`
+}
+
 type CaisseTransaction struct {
-	ID            string  `json:"id"`
-	Type          string  `json:"type"`
-	Amount        float64 `json:"amount"`
-	Description   *string `json:"description,omitempty"`
-	Reference     *string `json:"reference,omitempty"`
-	ReferenceType *string `json:"referenceType,omitempty"`
-	Date          string  `json:"date"`
-	CreatedBy     *string `json:"createdBy,omitempty"`
+	ID            string       `json:"id"`
+	Type          string       `json:"type"`
+	Amount        models.Money `json:"amount"`
+	Description   *string      `json:"description,omitempty"`
+	Reference     *string      `json:"reference,omitempty"`
+	ReferenceType *string      `json:"referenceType,omitempty"`
+	Date          string       `json:"date"`
+	CreatedBy     *string      `json:"createdBy,omitempty"`
 }
 
 type CaisseTransactionInput struct {
-	Type          string  `json:"type"`
-	Amount        float64 `json:"amount"`
-	Description   *string `json:"description,omitempty"`
-	Reference     *string `json:"reference,omitempty"`
-	ReferenceType *string `json:"referenceType,omitempty"`
+	Type          string       `json:"type"`
+	Amount        models.Money `json:"amount"`
+	Description   *string      `json:"description,omitempty"`
+	Reference     *string      `json:"reference,omitempty"`
+	ReferenceType *string      `json:"referenceType,omitempty"`
 }
 
 type ChangePasswordInput struct {
@@ -42,41 +152,81 @@ type ChangePasswordInput struct {
 	NewPassword     string `json:"newPassword"`
 }
 
+type Clawback struct {
+	ID                  string                `json:"id"`
+	SaleID              string                `json:"saleId"`
+	ClientID            string                `json:"clientId"`
+	Reason              string                `json:"reason"`
+	SaleStatus          string                `json:"saleStatus"`
+	Volume              float64               `json:"volume"`
+	PairedVolume        float64               `json:"pairedVolume"`
+	StockRestored       int32                 `json:"stockRestored"`
+	CaisseAmount        models.Money          `json:"caisseAmount"`
+	CaisseTransactionID *string               `json:"caisseTransactionId,omitempty"`
+	Commissions         []*ClawbackCommission `json:"commissions"`
+	TotalAmount         models.Money          `json:"totalAmount"`
+	CreatedAt           string                `json:"createdAt"`
+}
+
+type ClawbackCommission struct {
+	CommissionID string       `json:"commissionId"`
+	ReversalID   string       `json:"reversalId"`
+	ClientID     string       `json:"clientId"`
+	Type         string       `json:"type"`
+	Amount       models.Money `json:"amount"`
+}
+
 type Client struct {
-	ID                 string     `json:"id"`
-	ClientID           string     `json:"clientId"`
-	Name               string     `json:"name"`
-	Phone              *string    `json:"phone,omitempty"`
-	Nn                 *string    `json:"nn,omitempty"`
-	Address            *string    `json:"address,omitempty"`
-	Avatar             *string    `json:"avatar,omitempty"`
-	SponsorID          *string    `json:"sponsorId,omitempty"`
-	Position           *string    `json:"position,omitempty"`
-	LeftChildID        *string    `json:"leftChildId,omitempty"`
-	RightChildID       *string    `json:"rightChildId,omitempty"`
-	JoinDate           string     `json:"joinDate"`
-	TotalEarnings      float64    `json:"totalEarnings"`
-	WalletBalance      float64    `json:"walletBalance"`
-	Points             float64    `json:"points"`
-	NetworkVolumeLeft  float64    `json:"networkVolumeLeft"`
-	NetworkVolumeRight float64    `json:"networkVolumeRight"`
-	BinaryPairs        int32      `json:"binaryPairs"`
-	Sponsor            *Client    `json:"sponsor,omitempty"`
-	LeftChild          *Client    `json:"leftChild,omitempty"`
-	RightChild         *Client    `json:"rightChild,omitempty"`
-	Transactions       []*Payment `json:"transactions"`
-	Purchases          []*Sale    `json:"purchases"`
+	ID                 string              `json:"id"`
+	ClientID           string              `json:"clientId"`
+	Name               string              `json:"name"`
+	Phone              *string             `json:"phone,omitempty"`
+	Nn                 *string             `json:"nn,omitempty"`
+	Address            *string             `json:"address,omitempty"`
+	Avatar             *string             `json:"avatar,omitempty"`
+	SponsorID          *string             `json:"sponsorId,omitempty"`
+	PlacementParentID  *string             `json:"placementParentId,omitempty"`
+	Position           *string             `json:"position,omitempty"`
+	HoldingTankUntil   *string             `json:"holdingTankUntil,omitempty"`
+	LeftChildID        *string             `json:"leftChildId,omitempty"`
+	RightChildID       *string             `json:"rightChildId,omitempty"`
+	JoinDate           string              `json:"joinDate"`
+	TotalEarnings      models.Money        `json:"totalEarnings"`
+	WalletBalance      models.Money        `json:"walletBalance"`
+	Points             float64             `json:"points"`
+	NetworkVolumeLeft  float64             `json:"networkVolumeLeft"`
+	NetworkVolumeRight float64             `json:"networkVolumeRight"`
+	PendingPoints      float64             `json:"pendingPoints"`
+	PendingVolumeLeft  float64             `json:"pendingVolumeLeft"`
+	PendingVolumeRight float64             `json:"pendingVolumeRight"`
+	BinaryPairs        int32               `json:"binaryPairs"`
+	ActiveUntil        *string             `json:"activeUntil,omitempty"`
+	LeftMembers        int32               `json:"leftMembers"`
+	RightMembers       int32               `json:"rightMembers"`
+	LeftActives        int32               `json:"leftActives"`
+	RightActives       int32               `json:"rightActives"`
+	Rank               *string             `json:"rank,omitempty"`
+	HighestRank        *string             `json:"highestRank,omitempty"`
+	RankHistory        []*RankHistoryEntry `json:"rankHistory"`
+	Sponsor            *Client             `json:"sponsor,omitempty"`
+	LeftChild          *Client             `json:"leftChild,omitempty"`
+	RightChild         *Client             `json:"rightChild,omitempty"`
+	Transactions       []*Payment          `json:"transactions"`
+	Purchases          []*Sale             `json:"purchases"`
 }
 
 type ClientInput struct {
-	Name      string  `json:"name"`
-	Password  string  `json:"password"`
-	Position  *string `json:"position,omitempty"`
-	SponsorID *string `json:"sponsorId,omitempty"`
-	Phone     *string `json:"phone,omitempty"`
-	Nn        *string `json:"nn,omitempty"`
-	Address   *string `json:"address,omitempty"`
-	Avatar    *string `json:"avatar,omitempty"`
+	Name              string  `json:"name"`
+	Password          string  `json:"password"`
+	Position          *string `json:"position,omitempty"`
+	SponsorID         *string `json:"sponsorId,omitempty"`
+	PlacementParentID *string `json:"placementParentId,omitempty"`
+	PlacementStrategy *string `json:"placementStrategy,omitempty"`
+	HoldingTank       *bool   `json:"holdingTank,omitempty"`
+	Phone             *string `json:"phone,omitempty"`
+	Nn                *string `json:"nn,omitempty"`
+	Address           *string `json:"address,omitempty"`
+	Avatar            *string `json:"avatar,omitempty"`
 }
 
 type ClientLoginInput struct {
@@ -92,62 +242,85 @@ type ClientTree struct {
 }
 
 type ClientTreeNode struct {
-	ID                 string  `json:"id"`
-	ClientID           string  `json:"clientId"`
-	Name               string  `json:"name"`
-	Phone              *string `json:"phone,omitempty"`
-	ParentID           *string `json:"parentId,omitempty"`
-	Level              int32   `json:"level"`
-	Position           *string `json:"position,omitempty"`
-	NetworkVolumeLeft  float64 `json:"networkVolumeLeft"`
-	NetworkVolumeRight float64 `json:"networkVolumeRight"`
-	BinaryPairs        int32   `json:"binaryPairs"`
-	TotalEarnings      float64 `json:"totalEarnings"`
-	WalletBalance      float64 `json:"walletBalance"`
-	IsActive           bool    `json:"isActive"`
-	LeftActives        int32   `json:"leftActives"`
-	RightActives       int32   `json:"rightActives"`
-	IsQualified        bool    `json:"isQualified"`
-	CyclesAvailable    *int32  `json:"cyclesAvailable,omitempty"`
-	CyclesPaidToday    *int32  `json:"cyclesPaidToday,omitempty"`
+	ID                 string       `json:"id"`
+	ClientID           string       `json:"clientId"`
+	Name               string       `json:"name"`
+	Phone              *string      `json:"phone,omitempty"`
+	ParentID           *string      `json:"parentId,omitempty"`
+	SponsorID          *string      `json:"sponsorId,omitempty"`
+	Level              int32        `json:"level"`
+	Position           *string      `json:"position,omitempty"`
+	NetworkVolumeLeft  float64      `json:"networkVolumeLeft"`
+	NetworkVolumeRight float64      `json:"networkVolumeRight"`
+	BinaryPairs        int32        `json:"binaryPairs"`
+	TotalEarnings      models.Money `json:"totalEarnings"`
+	WalletBalance      models.Money `json:"walletBalance"`
+	IsActive           bool         `json:"isActive"`
+	LeftMembers        int32        `json:"leftMembers"`
+	RightMembers       int32        `json:"rightMembers"`
+	LeftActives        int32        `json:"leftActives"`
+	RightActives       int32        `json:"rightActives"`
+	IsQualified        bool         `json:"isQualified"`
+	CyclesAvailable    *int32       `json:"cyclesAvailable,omitempty"`
+	CyclesPaidToday    *int32       `json:"cyclesPaidToday,omitempty"`
 }
 
 type Commission struct {
-	ID             string  `json:"id"`
-	ClientID       string  `json:"clientId"`
-	SourceClientID string  `json:"sourceClientId"`
-	Amount         float64 `json:"amount"`
-	Level          int32   `json:"level"`
-	Type           string  `json:"type"`
-	Date           string  `json:"date"`
-	Client         *Client `json:"client,omitempty"`
-	SourceClient   *Client `json:"sourceClient,omitempty"`
+	ID                 string       `json:"id"`
+	ClientID           string       `json:"clientId"`
+	SourceClientID     string       `json:"sourceClientId"`
+	Amount             models.Money `json:"amount"`
+	Level              int32        `json:"level"`
+	Type               string       `json:"type"`
+	Date               string       `json:"date"`
+	PlanVersion        *int32       `json:"planVersion,omitempty"`
+	SaleID             *string      `json:"saleId,omitempty"`
+	SourceCommissionID *string      `json:"sourceCommissionId,omitempty"`
+	Client             *Client      `json:"client,omitempty"`
+	SourceClient       *Client      `json:"sourceClient,omitempty"`
 }
 
 type CommissionInput struct {
-	ClientID       string  `json:"clientId"`
-	SourceClientID string  `json:"sourceClientId"`
-	Amount         float64 `json:"amount"`
-	Level          int32   `json:"level"`
-	Type           string  `json:"type"`
+	ClientID       string       `json:"clientId"`
+	SourceClientID string       `json:"sourceClientId"`
+	Amount         models.Money `json:"amount"`
+	Level          int32        `json:"level"`
+	Type           string       `json:"type"`
 }
 
 type CommissionResult struct {
-	CommissionsCreated int32   `json:"commissionsCreated"`
-	TotalAmount        float64 `json:"totalAmount"`
-	Message            string  `json:"message"`
+	CommissionsCreated int32        `json:"commissionsCreated"`
+	TotalAmount        models.Money `json:"totalAmount"`
+	Message            string       `json:"message"`
+}
+
+type CompPlanDraftInput struct {
+	Binary        *BinaryPlanRulesInput `json:"binary"`
+	EffectiveFrom *string               `json:"effectiveFrom,omitempty"`
+	Notes         *string               `json:"notes,omitempty"`
+}
+
+type CompPlanVersion struct {
+	ID            *string          `json:"id,omitempty"`
+	Version       int32            `json:"version"`
+	Status        string           `json:"status"`
+	Notes         *string          `json:"notes,omitempty"`
+	Binary        *BinaryPlanRules `json:"binary"`
+	EffectiveFrom *string          `json:"effectiveFrom,omitempty"`
+	CreatedAt     *string          `json:"createdAt,omitempty"`
+	ActivatedAt   *string          `json:"activatedAt,omitempty"`
 }
 
 type DashboardStats struct {
 	TotalProducts    int32             `json:"totalProducts"`
 	TotalClients     int32             `json:"totalClients"`
-	TotalSales       float64           `json:"totalSales"`
-	TotalRevenue     float64           `json:"totalRevenue"`
+	TotalSales       models.Money      `json:"totalSales"`
+	TotalRevenue     models.Money      `json:"totalRevenue"`
 	ActiveClients    int32             `json:"activeClients"`
 	LeftVolume       float64           `json:"leftVolume"`
 	RightVolume      float64           `json:"rightVolume"`
 	BinaryPairs      int32             `json:"binaryPairs"`
-	TotalCommissions float64           `json:"totalCommissions"`
+	TotalCommissions models.Money      `json:"totalCommissions"`
 	NetworkBalance   float64           `json:"networkBalance"`
 	MonthlySales     []*MonthlySales   `json:"monthlySales"`
 	NetworkGrowth    []*NetworkGrowth  `json:"networkGrowth"`
@@ -163,15 +336,43 @@ type FilterInput struct {
 	Status   *string `json:"status,omitempty"`
 }
 
+type JobExecution struct {
+	ID          string  `json:"id"`
+	JobName     string  `json:"jobName"`
+	InstanceID  string  `json:"instanceId"`
+	ScheduledAt string  `json:"scheduledAt"`
+	StartedAt   string  `json:"startedAt"`
+	FinishedAt  *string `json:"finishedAt,omitempty"`
+	Status      string  `json:"status"`
+	Error       *string `json:"error,omitempty"`
+}
+
 type LoginInput struct {
 	Email    string `json:"email"`
 	Password string `json:"password"`
 }
 
+type MemberStatement struct {
+	ID               string           `json:"id"`
+	PeriodKey        string           `json:"periodKey"`
+	ClientID         string           `json:"clientId"`
+	PersonalVolume   float64          `json:"personalVolume"`
+	PairedVolume     float64          `json:"pairedVolume"`
+	Cycles           int32            `json:"cycles"`
+	LeftVolumeCarry  float64          `json:"leftVolumeCarry"`
+	RightVolumeCarry float64          `json:"rightVolumeCarry"`
+	Commissions      []*StatementLine `json:"commissions"`
+	Adjustments      []*StatementLine `json:"adjustments"`
+	TotalCommissions models.Money     `json:"totalCommissions"`
+	TotalAdjustments models.Money     `json:"totalAdjustments"`
+	NetAmount        models.Money     `json:"netAmount"`
+	CreatedAt        string           `json:"createdAt"`
+}
+
 type MonthlySales struct {
-	Month   string  `json:"month"`
-	Sales   float64 `json:"sales"`
-	Revenue float64 `json:"revenue"`
+	Month   string       `json:"month"`
+	Sales   float64      `json:"sales"`
+	Revenue models.Money `json:"revenue"`
 }
 
 type Mutation struct {
@@ -188,54 +389,154 @@ type PagingInput struct {
 	Limit *int32 `json:"limit,omitempty"`
 }
 
+type PayPeriod struct {
+	ID               string       `json:"id"`
+	Key              string       `json:"key"`
+	Frequency        string       `json:"frequency"`
+	Start            string       `json:"start"`
+	End              string       `json:"end"`
+	Status           string       `json:"status"`
+	ClosedAt         *string      `json:"closedAt,omitempty"`
+	ClosedBy         *string      `json:"closedBy,omitempty"`
+	MemberCount      int32        `json:"memberCount"`
+	TotalCommissions models.Money `json:"totalCommissions"`
+	TotalAdjustments models.Money `json:"totalAdjustments"`
+}
+
 type Payment struct {
-	ID          string  `json:"id"`
-	ClientID    string  `json:"clientId"`
-	Amount      float64 `json:"amount"`
-	Date        string  `json:"date"`
-	Method      string  `json:"method"`
-	Status      string  `json:"status"`
-	Description *string `json:"description,omitempty"`
-	Client      *Client `json:"client,omitempty"`
+	ID          string       `json:"id"`
+	ClientID    string       `json:"clientId"`
+	Amount      models.Money `json:"amount"`
+	Date        string       `json:"date"`
+	Method      string       `json:"method"`
+	Status      string       `json:"status"`
+	Description *string      `json:"description,omitempty"`
+	Client      *Client      `json:"client,omitempty"`
 }
 
 type PaymentInput struct {
-	ClientID    string  `json:"clientId"`
-	Amount      float64 `json:"amount"`
-	Method      string  `json:"method"`
-	Description *string `json:"description,omitempty"`
+	ClientID    string       `json:"clientId"`
+	Amount      models.Money `json:"amount"`
+	Method      string       `json:"method"`
+	Description *string      `json:"description,omitempty"`
+}
+
+type PayoutBatch struct {
+	ID             string        `json:"id"`
+	Provider       string        `json:"provider"`
+	Source         string        `json:"source"`
+	MinBalance     *models.Money `json:"minBalance,omitempty"`
+	Currency       string        `json:"currency"`
+	Status         string        `json:"status"`
+	LineCount      int32         `json:"lineCount"`
+	Lines          []*PayoutLine `json:"lines"`
+	TotalAmount    models.Money  `json:"totalAmount"`
+	CompletedCount int32         `json:"completedCount"`
+	FailedCount    int32         `json:"failedCount"`
+	CreatedAt      string        `json:"createdAt"`
+	CreatedBy      *string       `json:"createdBy,omitempty"`
+	ImportedAt     *string       `json:"importedAt,omitempty"`
+}
+
+type PayoutBatchInput struct {
+	Provider   string        `json:"provider"`
+	Source     string        `json:"source"`
+	MinBalance *models.Money `json:"minBalance,omitempty"`
+}
+
+type PayoutFile struct {
+	Filename string `json:"filename"`
+	Content  string `json:"content"`
+}
+
+type PayoutLine struct {
+	Reference         string       `json:"reference"`
+	WithdrawalID      string       `json:"withdrawalId"`
+	PaymentID         string       `json:"paymentId"`
+	ClientID          string       `json:"clientId"`
+	Name              string       `json:"name"`
+	Phone             string       `json:"phone"`
+	Amount            models.Money `json:"amount"`
+	Status            string       `json:"status"`
+	ProviderReference *string      `json:"providerReference,omitempty"`
+	Error             *string      `json:"error,omitempty"`
 }
 
 type Product struct {
-	ID          string  `json:"id"`
-	Name        string  `json:"name"`
-	Description string  `json:"description"`
-	Price       float64 `json:"price"`
-	Stock       int32   `json:"stock"`
-	Points      float64 `json:"points"`
-	ImageURL    string  `json:"imageUrl"`
-	CreatedAt   string  `json:"createdAt"`
-	UpdatedAt   string  `json:"updatedAt"`
+	ID          string       `json:"id"`
+	Name        string       `json:"name"`
+	Description string       `json:"description"`
+	Price       models.Money `json:"price"`
+	Stock       int32        `json:"stock"`
+	Points      float64      `json:"points"`
+	ImageURL    string       `json:"imageUrl"`
+	CreatedAt   string       `json:"createdAt"`
+	UpdatedAt   string       `json:"updatedAt"`
 }
 
 type ProductInput struct {
-	Name        string  `json:"name"`
-	Description string  `json:"description"`
-	Price       float64 `json:"price"`
-	Stock       int32   `json:"stock"`
-	Points      float64 `json:"points"`
-	ImageURL    string  `json:"imageUrl"`
+	Name        string       `json:"name"`
+	Description string       `json:"description"`
+	Price       models.Money `json:"price"`
+	Stock       int32        `json:"stock"`
+	Points      float64      `json:"points"`
+	ImageURL    string       `json:"imageUrl"`
 }
 
 type Query struct {
 }
 
+type RankDefinition struct {
+	ID                string  `json:"id"`
+	Code              string  `json:"code"`
+	Name              string  `json:"name"`
+	Level             int32   `json:"level"`
+	MinPairedVolume   float64 `json:"minPairedVolume"`
+	MinPersonalVolume float64 `json:"minPersonalVolume"`
+	MinQualifiedLegs  int32   `json:"minQualifiedLegs"`
+}
+
+type RankDefinitionInput struct {
+	Code              string   `json:"code"`
+	Name              string   `json:"name"`
+	Level             int32    `json:"level"`
+	MinPairedVolume   *float64 `json:"minPairedVolume,omitempty"`
+	MinPersonalVolume *float64 `json:"minPersonalVolume,omitempty"`
+	MinQualifiedLegs  *int32   `json:"minQualifiedLegs,omitempty"`
+}
+
+type RankEvaluation struct {
+	ClientID       string  `json:"clientId"`
+	Rank           *string `json:"rank,omitempty"`
+	PreviousRank   *string `json:"previousRank,omitempty"`
+	HighestRank    *string `json:"highestRank,omitempty"`
+	Changed        bool    `json:"changed"`
+	PairedVolume   float64 `json:"pairedVolume"`
+	PersonalVolume float64 `json:"personalVolume"`
+	QualifiedLegs  int32   `json:"qualifiedLegs"`
+	PeriodKey      string  `json:"periodKey"`
+}
+
+type RankHistoryEntry struct {
+	ID             string  `json:"id"`
+	Rank           *string `json:"rank,omitempty"`
+	Level          int32   `json:"level"`
+	PreviousRank   *string `json:"previousRank,omitempty"`
+	PreviousLevel  int32   `json:"previousLevel"`
+	PairedVolume   float64 `json:"pairedVolume"`
+	PersonalVolume float64 `json:"personalVolume"`
+	QualifiedLegs  int32   `json:"qualifiedLegs"`
+	PeriodKey      *string `json:"periodKey,omitempty"`
+	Source         string  `json:"source"`
+	AchievedAt     string  `json:"achievedAt"`
+}
+
 type RecentActivity struct {
-	ID          string   `json:"id"`
-	Type        string   `json:"type"`
-	Description string   `json:"description"`
-	Date        string   `json:"date"`
-	Amount      *float64 `json:"amount,omitempty"`
+	ID          string        `json:"id"`
+	Type        string        `json:"type"`
+	Description string        `json:"description"`
+	Date        string        `json:"date"`
+	Amount      *models.Money `json:"amount,omitempty"`
 }
 
 type RefreshTokenInput struct {
@@ -258,28 +559,29 @@ type ResetPasswordInput struct {
 }
 
 type Sale struct {
-	ID         string   `json:"id"`
-	ClientID   string   `json:"clientId"`
-	ProductID  *string  `json:"productId,omitempty"`
-	Amount     float64  `json:"amount"`
-	PaidAmount *float64 `json:"paidAmount,omitempty"`
-	Quantity   int32    `json:"quantity"`
-	Side       *string  `json:"side,omitempty"`
-	Date       string   `json:"date"`
-	Status     string   `json:"status"`
-	Note       *string  `json:"note,omitempty"`
-	Client     *Client  `json:"client,omitempty"`
-	Product    *Product `json:"product,omitempty"`
+	ID         string        `json:"id"`
+	ClientID   string        `json:"clientId"`
+	ProductID  *string       `json:"productId,omitempty"`
+	Amount     models.Money  `json:"amount"`
+	PaidAmount *models.Money `json:"paidAmount,omitempty"`
+	Quantity   int32         `json:"quantity"`
+	Points     float64       `json:"points"`
+	Side       *string       `json:"side,omitempty"`
+	Date       string        `json:"date"`
+	Status     string        `json:"status"`
+	Note       *string       `json:"note,omitempty"`
+	Client     *Client       `json:"client,omitempty"`
+	Product    *Product      `json:"product,omitempty"`
 }
 
 type SaleInput struct {
-	ClientID   string   `json:"clientId"`
-	ProductID  *string  `json:"productId,omitempty"`
-	Quantity   int32    `json:"quantity"`
-	Amount     float64  `json:"amount"`
-	PaidAmount *float64 `json:"paidAmount,omitempty"`
-	Status     *string  `json:"status,omitempty"`
-	Note       *string  `json:"note,omitempty"`
+	ClientID   string        `json:"clientId"`
+	ProductID  *string       `json:"productId,omitempty"`
+	Quantity   int32         `json:"quantity"`
+	Amount     models.Money  `json:"amount"`
+	PaidAmount *models.Money `json:"paidAmount,omitempty"`
+	Status     *string       `json:"status,omitempty"`
+	Note       *string       `json:"note,omitempty"`
 }
 
 type SalesStatus struct {
@@ -288,6 +590,26 @@ type SalesStatus struct {
 	Partial float64 `json:"partial"`
 }
 
+type ScheduledJob struct {
+	Name        string          `json:"name"`
+	Schedule    string          `json:"schedule"`
+	Description string          `json:"description"`
+	NextRunAt   *string         `json:"nextRunAt,omitempty"`
+	LastRun     *JobExecution   `json:"lastRun,omitempty"`
+	RecentRuns  []*JobExecution `json:"recentRuns"`
+}
+
+type StatementLine struct {
+	CommissionID       string       `json:"commissionId"`
+	Type               string       `json:"type"`
+	Amount             models.Money `json:"amount"`
+	Level              int32        `json:"level"`
+	SourceClientID     string       `json:"sourceClientId"`
+	SaleID             *string      `json:"saleId,omitempty"`
+	SourceCommissionID *string      `json:"sourceCommissionId,omitempty"`
+	Date               string       `json:"date"`
+}
+
 type Subscription struct {
 }
 
@@ -303,3 +625,44 @@ type User struct {
 	Role      string `json:"role"`
 	CreatedAt string `json:"createdAt"`
 }
+
+type WalletTransaction struct {
+	ID             string       `json:"id"`
+	ClientID       string       `json:"clientId"`
+	Direction      string       `json:"direction"`
+	Type           string       `json:"type"`
+	Amount         models.Money `json:"amount"`
+	Earnings       models.Money `json:"earnings"`
+	BalanceAfter   models.Money `json:"balanceAfter"`
+	CounterAccount string       `json:"counterAccount"`
+	ReferenceType  string       `json:"referenceType"`
+	ReferenceID    string       `json:"referenceId"`
+	Description    *string      `json:"description,omitempty"`
+	CreatedAt      string       `json:"createdAt"`
+}
+
+type Withdrawal struct {
+	ID                  string       `json:"id"`
+	ClientID            string       `json:"clientId"`
+	Amount              models.Money `json:"amount"`
+	Fee                 models.Money `json:"fee"`
+	NetAmount           models.Money `json:"netAmount"`
+	Method              string       `json:"method"`
+	Destination         *string      `json:"destination,omitempty"`
+	Status              string       `json:"status"`
+	RequestedAt         string       `json:"requestedAt"`
+	ReviewedAt          *string      `json:"reviewedAt,omitempty"`
+	ReviewedBy          *string      `json:"reviewedBy,omitempty"`
+	RejectionReason     *string      `json:"rejectionReason,omitempty"`
+	FailureReason       *string      `json:"failureReason,omitempty"`
+	PayoutBatchID       *string      `json:"payoutBatchId,omitempty"`
+	PaymentID           *string      `json:"paymentId,omitempty"`
+	CaisseTransactionID *string      `json:"caisseTransactionId,omitempty"`
+	WalletTransactionID *string      `json:"walletTransactionId,omitempty"`
+}
+
+type WithdrawalRequestInput struct {
+	Amount      models.Money `json:"amount"`
+	Method      string       `json:"method"`
+	Destination *string      `json:"destination,omitempty"`
+}
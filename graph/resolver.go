@@ -2,6 +2,7 @@ package graph
 
 import (
 	"bureau/graph/model"
+	"bureau/internal/scheduler"
 	"bureau/internal/service"
 	"context"
 )
@@ -29,6 +30,16 @@ type Resolver struct {
 	adminService            *service.AdminService
 	caisseService           *service.CaisseService
 	binaryCommissionService *service.BinaryCommissionService
+	binaryEngine            service.BinaryEngine
+	binaryBatchService      *service.BinaryBatchService
+	compPlanService         *service.CompPlanService
+	rankService             *service.RankService
+	clawbackService         *service.ClawbackService
+	payPeriodService        *service.PayPeriodService
+	walletService           *service.WalletService
+	withdrawalService       *service.WithdrawalService
+	payoutBatchService      *service.PayoutBatchService
+	jobScheduler            *scheduler.Scheduler
 }
 
 func NewResolver(
@@ -41,6 +52,16 @@ func NewResolver(
 	adminService *service.AdminService,
 	caisseService *service.CaisseService,
 	binaryCommissionService *service.BinaryCommissionService,
+	binaryEngine service.BinaryEngine,
+	binaryBatchService *service.BinaryBatchService,
+	compPlanService *service.CompPlanService,
+	rankService *service.RankService,
+	clawbackService *service.ClawbackService,
+	payPeriodService *service.PayPeriodService,
+	walletService *service.WalletService,
+	withdrawalService *service.WithdrawalService,
+	payoutBatchService *service.PayoutBatchService,
+	jobScheduler *scheduler.Scheduler,
 ) *Resolver {
 	return &Resolver{
 		productService:          productService,
@@ -52,5 +73,15 @@ func NewResolver(
 		adminService:            adminService,
 		caisseService:           caisseService,
 		binaryCommissionService: binaryCommissionService,
+		binaryEngine:            binaryEngine,
+		binaryBatchService:      binaryBatchService,
+		compPlanService:         compPlanService,
+		rankService:             rankService,
+		clawbackService:         clawbackService,
+		payPeriodService:        payPeriodService,
+		walletService:           walletService,
+		withdrawalService:       withdrawalService,
+		payoutBatchService:      payoutBatchService,
+		jobScheduler:            jobScheduler,
 	}
 }
@@ -8,6 +8,7 @@ import (
 	"bureau/graph/model"
 	"bureau/internal/auth"
 	"bureau/internal/models"
+	"bureau/internal/service"
 	"bureau/internal/validation"
 	"context"
 	"errors"
@@ -274,7 +275,7 @@ func (r *mutationResolver) ProductCreate(ctx context.Context, input model.Produc
 	if err := validation.ValidateStock(input.Stock); err != nil {
 		return nil, err
 	}
-	if err := validation.ValidateAmount(input.Points); err != nil {
+	if err := validation.ValidatePoints(models.NewVolume(input.Points)); err != nil {
 		return nil, err
 	}
 
@@ -284,7 +285,7 @@ func (r *mutationResolver) ProductCreate(ctx context.Context, input model.Produc
 		Description: input.Description,
 		Price:       input.Price,
 		Stock:       int(input.Stock),
-		Points:      input.Points,
+		Points:      models.NewVolume(input.Points),
 		ImageURL:    input.ImageURL,
 		CreatedAt:   now,
 		UpdatedAt:   now,
@@ -299,7 +300,7 @@ func (r *mutationResolver) ProductCreate(ctx context.Context, input model.Produc
 		Description: created.Description,
 		Price:       created.Price,
 		Stock:       int32(created.Stock),
-		Points:      created.Points,
+		Points:      created.Points.Float64(),
 		ImageURL:    created.ImageURL,
 		CreatedAt:   created.CreatedAt.Format(time.RFC3339),
 		UpdatedAt:   created.UpdatedAt.Format(time.RFC3339),
@@ -323,7 +324,7 @@ func (r *mutationResolver) ProductUpdate(ctx context.Context, id string, input m
 	if err := validation.ValidateStock(input.Stock); err != nil {
 		return nil, err
 	}
-	if err := validation.ValidateAmount(input.Points); err != nil {
+	if err := validation.ValidatePoints(models.NewVolume(input.Points)); err != nil {
 		return nil, err
 	}
 
@@ -333,7 +334,7 @@ func (r *mutationResolver) ProductUpdate(ctx context.Context, id string, input m
 		Description: input.Description,
 		Price:       input.Price,
 		Stock:       int(input.Stock),
-		Points:      input.Points,
+		Points:      models.NewVolume(input.Points),
 		ImageURL:    input.ImageURL,
 		UpdatedAt:   now,
 	}
@@ -347,7 +348,7 @@ func (r *mutationResolver) ProductUpdate(ctx context.Context, id string, input m
 		Description: updated.Description,
 		Price:       updated.Price,
 		Stock:       int32(updated.Stock),
-		Points:      updated.Points,
+		Points:      updated.Points.Float64(),
 		ImageURL:    updated.ImageURL,
 		CreatedAt:   updated.CreatedAt.Format(time.RFC3339),
 		UpdatedAt:   updated.UpdatedAt.Format(time.RFC3339),
@@ -374,6 +375,9 @@ func (r *mutationResolver) ClientCreate(ctx context.Context, input model.ClientI
 	if err := validation.ValidateObjectIDPtr(input.SponsorID); err != nil {
 		return nil, err
 	}
+	if err := validation.ValidateObjectIDPtr(input.PlacementParentID); err != nil {
+		return nil, err
+	}
 	if err := validation.ValidatePositionPtr(input.Position); err != nil {
 		return nil, err
 	}
@@ -386,6 +390,14 @@ func (r *mutationResolver) ClientCreate(ctx context.Context, input model.ClientI
 		}
 	}
 
+	var placementParentOID *primitive.ObjectID
+	if input.PlacementParentID != nil {
+		oid, err := primitive.ObjectIDFromHex(*input.PlacementParentID)
+		if err == nil {
+			placementParentOID = &oid
+		}
+	}
+
 	var requestedPosition *string
 	if input.Position != nil && *input.Position != "" {
 		requestedPosition = input.Position
@@ -401,7 +413,20 @@ func (r *mutationResolver) ClientCreate(ctx context.Context, input model.ClientI
 		Avatar:       input.Avatar,
 		JoinDate:     now,
 	}
-	created, err := r.Resolver.clientService.CreateWithBinaryPlacement(ctx, m, sponsorOID, requestedPosition)
+	var created *models.Client
+	var err error
+	if input.HoldingTank != nil && *input.HoldingTank {
+		// Salle d'attente: le membre sera placé plus tard par son parrain (placeClient)
+		if sponsorOID == nil {
+			return nil, errors.New("la salle d'attente nécessite un sponsor")
+		}
+		if placementParentOID != nil || requestedPosition != nil {
+			return nil, errors.New("un membre en salle d'attente est placé plus tard avec placeClient")
+		}
+		created, err = r.Resolver.clientService.CreateInHoldingTank(ctx, m, *sponsorOID)
+	} else {
+		created, err = r.Resolver.clientService.CreateWithBinaryPlacement(ctx, m, sponsorOID, placementParentOID, requestedPosition, input.PlacementStrategy)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -417,15 +442,30 @@ func (r *mutationResolver) ClientCreate(ctx context.Context, input model.ClientI
 		Position:           created.Position,
 		TotalEarnings:      created.TotalEarnings,
 		WalletBalance:      created.WalletBalance,
-		Points:             created.Points,
-		NetworkVolumeLeft:  created.NetworkVolumeLeft,
-		NetworkVolumeRight: created.NetworkVolumeRight,
+		Points:             created.Points.Float64(),
+		NetworkVolumeLeft:  created.NetworkVolumeLeft.Float64(),
+		NetworkVolumeRight: created.NetworkVolumeRight.Float64(),
+		PendingPoints:      created.PendingPoints.Float64(),
+		PendingVolumeLeft:  created.PendingVolumeLeft.Float64(),
+		PendingVolumeRight: created.PendingVolumeRight.Float64(),
 		BinaryPairs:        int32(created.BinaryPairs),
+		ActiveUntil:        formatTimePtr(created.ActiveUntil),
+		HoldingTankUntil:   formatTimePtr(created.HoldingTankUntil),
+		LeftMembers:        int32(created.LeftMembers),
+		RightMembers:       int32(created.RightMembers),
+		LeftActives:        int32(created.LeftActives),
+		RightActives:       int32(created.RightActives),
+		Rank:               optionalString(created.Rank),
+		HighestRank:        optionalString(created.HighestRank),
 	}
 	if created.SponsorID != nil {
 		sid := created.SponsorID.Hex()
 		out.SponsorID = &sid
 	}
+	if created.PlacementParentID != nil {
+		pid := created.PlacementParentID.Hex()
+		out.PlacementParentID = &pid
+	}
 	if created.LeftChildID != nil {
 		lid := created.LeftChildID.Hex()
 		out.LeftChildID = &lid
@@ -462,15 +502,30 @@ func (r *mutationResolver) ClientUpdate(ctx context.Context, id string, input mo
 		Position:           updated.Position,
 		TotalEarnings:      updated.TotalEarnings,
 		WalletBalance:      updated.WalletBalance,
-		Points:             updated.Points,
-		NetworkVolumeLeft:  updated.NetworkVolumeLeft,
-		NetworkVolumeRight: updated.NetworkVolumeRight,
+		Points:             updated.Points.Float64(),
+		NetworkVolumeLeft:  updated.NetworkVolumeLeft.Float64(),
+		NetworkVolumeRight: updated.NetworkVolumeRight.Float64(),
+		PendingPoints:      updated.PendingPoints.Float64(),
+		PendingVolumeLeft:  updated.PendingVolumeLeft.Float64(),
+		PendingVolumeRight: updated.PendingVolumeRight.Float64(),
 		BinaryPairs:        int32(updated.BinaryPairs),
+		ActiveUntil:        formatTimePtr(updated.ActiveUntil),
+		HoldingTankUntil:   formatTimePtr(updated.HoldingTankUntil),
+		LeftMembers:        int32(updated.LeftMembers),
+		RightMembers:       int32(updated.RightMembers),
+		LeftActives:        int32(updated.LeftActives),
+		RightActives:       int32(updated.RightActives),
+		Rank:               optionalString(updated.Rank),
+		HighestRank:        optionalString(updated.HighestRank),
 	}
 	if updated.SponsorID != nil {
 		sid := updated.SponsorID.Hex()
 		out.SponsorID = &sid
 	}
+	if updated.PlacementParentID != nil {
+		pid := updated.PlacementParentID.Hex()
+		out.PlacementParentID = &pid
+	}
 	if updated.LeftChildID != nil {
 		lid := updated.LeftChildID.Hex()
 		out.LeftChildID = &lid
@@ -490,6 +545,32 @@ func (r *mutationResolver) ClientDelete(ctx context.Context, id string) (bool, e
 	return r.Resolver.clientService.Delete(ctx, id)
 }
 
+// PlaceClient is the resolver for the placeClient field.
+// Le parrain du membre peut le placer pendant le délai de la salle d'attente; un admin à tout moment
+func (r *mutationResolver) PlaceClient(ctx context.Context, clientID string, parentID string, side string) (*model.Client, error) {
+	if err := validation.ValidateObjectID(clientID); err != nil {
+		return nil, err
+	}
+	if err := validation.ValidateObjectID(parentID); err != nil {
+		return nil, err
+	}
+	if err := validation.ValidatePosition(side); err != nil {
+		return nil, err
+	}
+
+	// Un admin n'a pas d'ID client: il n'est pas soumis aux restrictions du parrain
+	_, sponsorID, err := r.Resolver.requireAdminOrClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	placed, err := r.Resolver.clientService.PlaceFromHoldingTank(ctx, clientID, parentID, side, sponsorID)
+	if err != nil {
+		return nil, err
+	}
+	return toClientModel(placed), nil
+}
+
 // SaleCreate is the resolver for the saleCreate field.
 func (r *mutationResolver) SaleCreate(ctx context.Context, input model.SaleInput) (*model.Sale, error) {
 	// Validate input
@@ -526,7 +607,7 @@ func (r *mutationResolver) SaleCreate(ctx context.Context, input model.SaleInput
 
 	// Resolve product and get points
 	var productOID *primitive.ObjectID
-	var pointsToAdd float64
+	var pointsToAdd models.Volume
 	if input.ProductID != nil && *input.ProductID != "" {
 		poid, err := primitive.ObjectIDFromHex(*input.ProductID)
 		if err == nil {
@@ -546,14 +627,7 @@ func (r *mutationResolver) SaleCreate(ctx context.Context, input model.SaleInput
 			}
 
 			// Calculate points: product points * quantity
-			pointsToAdd = product.Points * float64(input.Quantity)
-
-			// Diminuer le stock du produit
-			product.Stock = product.Stock - int(input.Quantity)
-			_, err = r.Resolver.productService.Update(ctx, *input.ProductID, product)
-			if err != nil {
-				return nil, fmt.Errorf("échec de la mise à jour du stock: %w", err)
-			}
+			pointsToAdd = product.Points * models.Volume(input.Quantity)
 		}
 	}
 
@@ -581,62 +655,19 @@ func (r *mutationResolver) SaleCreate(ctx context.Context, input model.SaleInput
 		Amount:     input.Amount,
 		PaidAmount: input.PaidAmount,
 		Quantity:   int(input.Quantity),
+		Points:     pointsToAdd,
+		Side:       client.Position, // Jambe de l'acheteur sous son parent de placement
 		Date:       time.Now(),
 		Status:     status,
 		Note:       input.Note,
 	}
-	created, err := r.Resolver.saleService.Create(ctx, m)
+	// Stock, points et volume de l'upline, entrée de caisse et bonus de démarrage rapide
+	// sont écrits dans la transaction de la vente
+	created, err := r.Resolver.saleService.Create(ctx, m, client)
 	if err != nil {
 		return nil, err
 	}
 
-	// Ajouter les points au client selon le produit acheté et la quantité
-	// Les points = points du produit × quantité achetée
-	if input.ProductID != nil && *input.ProductID != "" && pointsToAdd > 0 {
-		err = r.Resolver.clientService.AddPoints(ctx, input.ClientID, pointsToAdd)
-		if err != nil {
-			// Si l'ajout de points échoue, on retourne une erreur car c'est important
-			return nil, fmt.Errorf("échec de l'ajout des points au client: %w", err)
-		}
-	}
-
-	// Ajouter une entrée à la caisse pour cette vente
-	// Si le statut est "paid", on ajoute le montant total
-	// Si le statut est "partial", on ajoute seulement le paidAmount
-	if created.Status == "paid" {
-		saleRef := created.ID.Hex()
-		refType := "sale"
-		desc := fmt.Sprintf("Vente de produit - Client: %s", client.Name)
-		caisseTransaction := &models.CaisseTransaction{
-			Type:          "entree",
-			Amount:        created.Amount,
-			Description:   &desc,
-			Reference:     &saleRef,
-			ReferenceType: &refType,
-		}
-		_, err = r.Resolver.caisseService.AddTransaction(ctx, caisseTransaction)
-		if err != nil {
-			// Log error but don't fail the sale creation
-			// In production, you might want to handle this differently
-		}
-	} else if created.Status == "partial" && created.PaidAmount != nil {
-		saleRef := created.ID.Hex()
-		refType := "sale"
-		desc := fmt.Sprintf("Vente partielle - Client: %s (Montant payé: %.2f / %.2f)", client.Name, *created.PaidAmount, created.Amount)
-		caisseTransaction := &models.CaisseTransaction{
-			Type:          "entree",
-			Amount:        *created.PaidAmount,
-			Description:   &desc,
-			Reference:     &saleRef,
-			ReferenceType: &refType,
-		}
-		_, err = r.Resolver.caisseService.AddTransaction(ctx, caisseTransaction)
-		if err != nil {
-			// Log error but don't fail the sale creation
-			// In production, you might want to handle this differently
-		}
-	}
-
 	var prodIdStr *string
 	if created.ProductID != nil {
 		s := created.ProductID.Hex()
@@ -649,6 +680,7 @@ func (r *mutationResolver) SaleCreate(ctx context.Context, input model.SaleInput
 		Amount:     created.Amount,
 		PaidAmount: created.PaidAmount,
 		Quantity:   int32(created.Quantity),
+		Points:     created.Points.Float64(),
 		Side:       created.Side,
 		Date:       created.Date.Format(time.RFC3339),
 		Status:     created.Status,
@@ -698,7 +730,12 @@ func (r *mutationResolver) SaleUpdate(ctx context.Context, id string, input mode
 			productOID = &poid
 		}
 	}
-	status := "pending"
+	existing, err := r.Resolver.saleService.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("vente introuvable: %w", err)
+	}
+	// Sans statut, la vente garde le sien (modification de la note seule)
+	status := existing.Status
 	if input.Status != nil {
 		status = *input.Status
 	}
@@ -722,13 +759,18 @@ func (r *mutationResolver) SaleUpdate(ctx context.Context, id string, input mode
 		Amount:     input.Amount,
 		PaidAmount: input.PaidAmount,
 		Quantity:   int(input.Quantity),
+		Points:     existing.Points,
+		Side:       existing.Side,
 		Status:     status,
 		Note:       input.Note,
 	}
+	// La confirmation du volume (passage à "paid") et la reprise d'une annulation sont
+	// écrites dans la transaction de la vente
 	updated, err := r.Resolver.saleService.Update(ctx, id, m)
 	if err != nil {
 		return nil, err
 	}
+
 	var prodIdStr *string
 	if updated.ProductID != nil {
 		s := updated.ProductID.Hex()
@@ -741,6 +783,7 @@ func (r *mutationResolver) SaleUpdate(ctx context.Context, id string, input mode
 		Amount:     updated.Amount,
 		PaidAmount: updated.PaidAmount,
 		Quantity:   int32(updated.Quantity),
+		Points:     updated.Points.Float64(),
 		Side:       updated.Side,
 		Date:       updated.Date.Format(time.RFC3339),
 		Status:     updated.Status,
@@ -904,20 +947,19 @@ func (r *mutationResolver) CommissionManualCreate(ctx context.Context, input mod
 	}
 	return &model.Commission{
 		ID: created.ID.Hex(), ClientID: created.ClientID.Hex(), SourceClientID: created.SourceClientID.Hex(), Amount: created.Amount,
-		Level: int32(created.Level), Type: created.Type, Date: created.Date.Format(time.RFC3339),
+		Level: int32(created.Level), Type: created.Type, Date: created.Date.Format(time.RFC3339), PlanVersion: planVersionPtr(created.PlanVersion),
 	}, nil
 }
 
 // RunBinaryCommissionCheck is the resolver for the runBinaryCommissionCheck field.
-// Utilise le nouvel algorithme binaire amélioré
+// Utilise le moteur binaire désigné par le plan
 func (r *mutationResolver) RunBinaryCommissionCheck(ctx context.Context, clientID string) (*model.CommissionResult, error) {
 	// Validate input
 	if err := validation.ValidateObjectID(clientID); err != nil {
 		return nil, err
 	}
 
-	// Utiliser le nouveau service d'algorithme binaire amélioré
-	result, err := r.Resolver.binaryCommissionService.ComputeBinaryCommission(ctx, clientID)
+	result, err := r.Resolver.binaryEngine.ComputeBinaryCommission(ctx, clientID)
 	if err != nil {
 		return nil, err
 	}
@@ -930,7 +972,7 @@ func (r *mutationResolver) RunBinaryCommissionCheck(ctx context.Context, clientI
 
 	message := result.Reason
 	if result.Success && result.Qualified && result.CyclesPaid > 0 {
-		message = fmt.Sprintf("Commission binaire calculée: %d cycles payés, montant: %.2f$", result.CyclesPaid, result.Amount)
+		message = fmt.Sprintf("Commission binaire calculée: %d cycles payés, montant: %s$", result.CyclesPaid, result.Amount)
 	}
 
 	return &model.CommissionResult{
@@ -940,6 +982,281 @@ func (r *mutationResolver) RunBinaryCommissionCheck(ctx context.Context, clientI
 	}, nil
 }
 
+// RunBinaryCommissionBatch is the resolver for the runBinaryCommissionBatch field.
+// Lance le calcul binaire sur tout le réseau en arrière-plan et retourne l'ID de l'exécution
+func (r *mutationResolver) RunBinaryCommissionBatch(ctx context.Context) (string, error) {
+	if _, err := r.Resolver.requireAdmin(ctx); err != nil {
+		return "", err
+	}
+	run, err := r.Resolver.binaryBatchService.Start(ctx)
+	if err != nil {
+		return "", err
+	}
+	return run.ID.Hex(), nil
+}
+
+// CompPlanDraft is the resolver for the compPlanDraft field.
+// Crée un brouillon à partir de la version en vigueur et des règles fournies (nécessite authentification admin)
+func (r *mutationResolver) CompPlanDraft(ctx context.Context, input model.CompPlanDraftInput) (*model.CompPlanVersion, error) {
+	admin, err := r.Resolver.requireAdmin(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var effectiveFrom *time.Time
+	if input.EffectiveFrom != nil {
+		t, err := time.Parse(time.RFC3339, *input.EffectiveFrom)
+		if err != nil {
+			return nil, fmt.Errorf("date d'effet invalide (RFC3339 attendu): %w", err)
+		}
+		effectiveFrom = &t
+	}
+
+	current, err := r.Resolver.compPlanService.Effective(ctx, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	rules := applyBinaryPlanRulesInput(current.Binary, input.Binary)
+
+	notes := ""
+	if input.Notes != nil {
+		notes = *input.Notes
+	}
+
+	plan, err := r.Resolver.compPlanService.Draft(ctx, rules, effectiveFrom, notes, &admin.ID)
+	if err != nil {
+		return nil, err
+	}
+	return toCompPlanVersionModel(plan), nil
+}
+
+// CompPlanActivate is the resolver for the compPlanActivate field.
+// Active un brouillon à la date d'effet donnée (nécessite authentification admin)
+func (r *mutationResolver) CompPlanActivate(ctx context.Context, id string, effectiveFrom *string) (*model.CompPlanVersion, error) {
+	admin, err := r.Resolver.requireAdmin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := validation.ValidateObjectID(id); err != nil {
+		return nil, err
+	}
+
+	var from *time.Time
+	if effectiveFrom != nil {
+		t, err := time.Parse(time.RFC3339, *effectiveFrom)
+		if err != nil {
+			return nil, fmt.Errorf("date d'effet invalide (RFC3339 attendu): %w", err)
+		}
+		from = &t
+	}
+
+	plan, err := r.Resolver.compPlanService.Activate(ctx, id, from, &admin.ID)
+	if err != nil {
+		return nil, err
+	}
+	return toCompPlanVersionModel(plan), nil
+}
+
+// RankDefinitionSave is the resolver for the rankDefinitionSave field.
+// Crée ou met à jour un rang identifié par son code (nécessite authentification admin)
+func (r *mutationResolver) RankDefinitionSave(ctx context.Context, input model.RankDefinitionInput) (*model.RankDefinition, error) {
+	if _, err := r.Resolver.requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	rank := &models.RankDefinition{
+		Code:  input.Code,
+		Name:  input.Name,
+		Level: int(input.Level),
+	}
+	if input.MinPairedVolume != nil {
+		rank.MinPairedVolume = models.NewVolume(*input.MinPairedVolume)
+	}
+	if input.MinPersonalVolume != nil {
+		rank.MinPersonalVolume = models.NewVolume(*input.MinPersonalVolume)
+	}
+	if input.MinQualifiedLegs != nil {
+		rank.MinQualifiedLegs = int(*input.MinQualifiedLegs)
+	}
+
+	saved, err := r.Resolver.rankService.SaveDefinition(ctx, rank)
+	if err != nil {
+		return nil, err
+	}
+	return toRankDefinitionModel(saved), nil
+}
+
+// RankDefinitionDelete is the resolver for the rankDefinitionDelete field.
+func (r *mutationResolver) RankDefinitionDelete(ctx context.Context, code string) (bool, error) {
+	if _, err := r.Resolver.requireAdmin(ctx); err != nil {
+		return false, err
+	}
+	return r.Resolver.rankService.DeleteDefinition(ctx, code)
+}
+
+// RankEvaluate is the resolver for the rankEvaluate field.
+// Évalue le rang d'un membre sur la dernière période terminée sans attendre sa clôture (nécessite authentification admin)
+func (r *mutationResolver) RankEvaluate(ctx context.Context, clientID string) (*model.RankEvaluation, error) {
+	if _, err := r.Resolver.requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	if err := validation.ValidateObjectID(clientID); err != nil {
+		return nil, err
+	}
+
+	evaluation, err := r.Resolver.rankService.Evaluate(ctx, clientID, r.Resolver.payPeriodService.LastCompleted())
+	if err != nil {
+		return nil, err
+	}
+	return toRankEvaluationModel(evaluation), nil
+}
+
+// ClosePeriod is the resolver for the closePeriod field.
+// Clôture une période de paie terminée et produit les relevés des membres (nécessite authentification admin)
+func (r *mutationResolver) ClosePeriod(ctx context.Context, key *string) (*model.PayPeriod, error) {
+	admin, err := r.Resolver.requireAdmin(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	periodKey := ""
+	if key != nil {
+		periodKey = *key
+	}
+	period, err := r.Resolver.payPeriodService.ClosePeriod(ctx, periodKey, &admin.ID)
+	if err != nil {
+		return nil, err
+	}
+	return toPayPeriodModel(period), nil
+}
+
+// RequestWithdrawal is the resolver for the requestWithdrawal field.
+func (r *mutationResolver) RequestWithdrawal(ctx context.Context, input model.WithdrawalRequestInput) (*model.Withdrawal, error) {
+	_, selfID, err := r.Resolver.requireAdminOrClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if selfID == nil {
+		return nil, errors.New("seul un membre connecté peut demander un retrait")
+	}
+	if err := validation.ValidateAmountPositive(input.Amount); err != nil {
+		return nil, err
+	}
+	if err := validation.ValidatePaymentMethod(input.Method); err != nil {
+		return nil, err
+	}
+
+	withdrawal, err := r.Resolver.withdrawalService.Request(ctx, *selfID, input.Amount, input.Method, input.Destination)
+	if err != nil {
+		return nil, err
+	}
+	return toWithdrawalModel(withdrawal), nil
+}
+
+// ApproveWithdrawal is the resolver for the approveWithdrawal field.
+func (r *mutationResolver) ApproveWithdrawal(ctx context.Context, id string) (*model.Withdrawal, error) {
+	admin, err := r.Resolver.requireAdmin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := validation.ValidateObjectID(id); err != nil {
+		return nil, err
+	}
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	withdrawal, err := r.Resolver.withdrawalService.Approve(ctx, oid, &admin.ID)
+	if err != nil {
+		return nil, err
+	}
+	return toWithdrawalModel(withdrawal), nil
+}
+
+// RejectWithdrawal is the resolver for the rejectWithdrawal field.
+func (r *mutationResolver) RejectWithdrawal(ctx context.Context, id string, reason string) (*model.Withdrawal, error) {
+	admin, err := r.Resolver.requireAdmin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := validation.ValidateObjectID(id); err != nil {
+		return nil, err
+	}
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	withdrawal, err := r.Resolver.withdrawalService.Reject(ctx, oid, &admin.ID, reason)
+	if err != nil {
+		return nil, err
+	}
+	return toWithdrawalModel(withdrawal), nil
+}
+
+// SettleWithdrawal is the resolver for the settleWithdrawal field.
+func (r *mutationResolver) SettleWithdrawal(ctx context.Context, id string, completed bool, reason *string) (*model.Withdrawal, error) {
+	if _, err := r.Resolver.requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	if err := validation.ValidateObjectID(id); err != nil {
+		return nil, err
+	}
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	var failureReason string
+	if reason != nil {
+		failureReason = *reason
+	}
+	withdrawal, err := r.Resolver.withdrawalService.Settle(ctx, oid, completed, failureReason)
+	if err != nil {
+		return nil, err
+	}
+	return toWithdrawalModel(withdrawal), nil
+}
+
+// CreatePayoutBatch is the resolver for the createPayoutBatch field.
+func (r *mutationResolver) CreatePayoutBatch(ctx context.Context, input model.PayoutBatchInput) (*model.PayoutBatch, error) {
+	admin, err := r.Resolver.requireAdmin(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var minBalance models.Money
+	if input.MinBalance != nil {
+		minBalance = *input.MinBalance
+	}
+	batch, err := r.Resolver.payoutBatchService.Create(ctx, input.Provider, input.Source, minBalance, &admin.ID)
+	if err != nil {
+		return nil, err
+	}
+	return toPayoutBatchModel(batch), nil
+}
+
+// ImportPayoutResults is the resolver for the importPayoutResults field.
+func (r *mutationResolver) ImportPayoutResults(ctx context.Context, batchID string, content string) (*model.PayoutBatch, error) {
+	if _, err := r.Resolver.requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	if err := validation.ValidateObjectID(batchID); err != nil {
+		return nil, err
+	}
+	oid, err := primitive.ObjectIDFromHex(batchID)
+	if err != nil {
+		return nil, err
+	}
+
+	batch, err := r.Resolver.payoutBatchService.ImportResults(ctx, oid, content)
+	if err != nil {
+		return nil, err
+	}
+	return toPayoutBatchModel(batch), nil
+}
+
 // CaisseAddTransaction is the resolver for the caisseAddTransaction field.
 func (r *mutationResolver) CaisseAddTransaction(ctx context.Context, input model.CaisseTransactionInput) (*model.CaisseTransaction, error) {
 	// Validate input
@@ -976,7 +1293,7 @@ func (r *mutationResolver) CaisseAddTransaction(ctx context.Context, input model
 }
 
 // CaisseUpdateBalance is the resolver for the caisseUpdateBalance field.
-func (r *mutationResolver) CaisseUpdateBalance(ctx context.Context, balance float64) (*model.Caisse, error) {
+func (r *mutationResolver) CaisseUpdateBalance(ctx context.Context, balance models.Money) (*model.Caisse, error) {
 	// Validate input
 	if err := validation.ValidateAmount(balance); err != nil {
 		return nil, err
@@ -1055,7 +1372,7 @@ func (r *queryResolver) Products(ctx context.Context, filter *model.FilterInput,
 	out := make([]*model.Product, 0, len(list))
 	for _, p := range list {
 		out = append(out, &model.Product{
-			ID: p.ID.Hex(), Name: p.Name, Description: p.Description, Price: p.Price, Stock: int32(p.Stock), Points: p.Points, ImageURL: p.ImageURL,
+			ID: p.ID.Hex(), Name: p.Name, Description: p.Description, Price: p.Price, Stock: int32(p.Stock), Points: p.Points.Float64(), ImageURL: p.ImageURL,
 			CreatedAt: p.CreatedAt.Format(time.RFC3339), UpdatedAt: p.UpdatedAt.Format(time.RFC3339),
 		})
 	}
@@ -1071,7 +1388,7 @@ func (r *queryResolver) Product(ctx context.Context, id string) (*model.Product,
 	if err != nil {
 		return nil, err
 	}
-	return &model.Product{ID: p.ID.Hex(), Name: p.Name, Description: p.Description, Price: p.Price, Stock: int32(p.Stock), Points: p.Points, ImageURL: p.ImageURL, CreatedAt: p.CreatedAt.Format(time.RFC3339), UpdatedAt: p.UpdatedAt.Format(time.RFC3339)}, nil
+	return &model.Product{ID: p.ID.Hex(), Name: p.Name, Description: p.Description, Price: p.Price, Stock: int32(p.Stock), Points: p.Points.Float64(), ImageURL: p.ImageURL, CreatedAt: p.CreatedAt.Format(time.RFC3339), UpdatedAt: p.UpdatedAt.Format(time.RFC3339)}, nil
 }
 
 // Clients is the resolver for the clients field.
@@ -1134,15 +1451,30 @@ func (r *queryResolver) Clients(ctx context.Context, filter *model.FilterInput,
 			Position:           c.Position,
 			TotalEarnings:      c.TotalEarnings,
 			WalletBalance:      c.WalletBalance,
-			Points:             c.Points,
-			NetworkVolumeLeft:  c.NetworkVolumeLeft,
-			NetworkVolumeRight: c.NetworkVolumeRight,
+			Points:             c.Points.Float64(),
+			NetworkVolumeLeft:  c.NetworkVolumeLeft.Float64(),
+			NetworkVolumeRight: c.NetworkVolumeRight.Float64(),
+			PendingPoints:      c.PendingPoints.Float64(),
+			PendingVolumeLeft:  c.PendingVolumeLeft.Float64(),
+			PendingVolumeRight: c.PendingVolumeRight.Float64(),
 			BinaryPairs:        int32(c.BinaryPairs),
+			ActiveUntil:        formatTimePtr(c.ActiveUntil),
+			HoldingTankUntil:   formatTimePtr(c.HoldingTankUntil),
+			LeftMembers:        int32(c.LeftMembers),
+			RightMembers:       int32(c.RightMembers),
+			LeftActives:        int32(c.LeftActives),
+			RightActives:       int32(c.RightActives),
+			Rank:               optionalString(c.Rank),
+			HighestRank:        optionalString(c.HighestRank),
 		}
 		if c.SponsorID != nil {
 			sid := c.SponsorID.Hex()
 			mc.SponsorID = &sid
 		}
+		if c.PlacementParentID != nil {
+			pid := c.PlacementParentID.Hex()
+			mc.PlacementParentID = &pid
+		}
 		if c.LeftChildID != nil {
 			lid := c.LeftChildID.Hex()
 			mc.LeftChildID = &lid
@@ -1174,10 +1506,21 @@ func (r *queryResolver) Client(ctx context.Context, id string) (*model.Client, e
 		Position:           c.Position,
 		TotalEarnings:      c.TotalEarnings,
 		WalletBalance:      c.WalletBalance,
-		Points:             c.Points,
-		NetworkVolumeLeft:  c.NetworkVolumeLeft,
-		NetworkVolumeRight: c.NetworkVolumeRight,
+		Points:             c.Points.Float64(),
+		NetworkVolumeLeft:  c.NetworkVolumeLeft.Float64(),
+		NetworkVolumeRight: c.NetworkVolumeRight.Float64(),
+		PendingPoints:      c.PendingPoints.Float64(),
+		PendingVolumeLeft:  c.PendingVolumeLeft.Float64(),
+		PendingVolumeRight: c.PendingVolumeRight.Float64(),
 		BinaryPairs:        int32(c.BinaryPairs),
+		ActiveUntil:        formatTimePtr(c.ActiveUntil),
+		HoldingTankUntil:   formatTimePtr(c.HoldingTankUntil),
+		LeftMembers:        int32(c.LeftMembers),
+		RightMembers:       int32(c.RightMembers),
+		LeftActives:        int32(c.LeftActives),
+		RightActives:       int32(c.RightActives),
+		Rank:               optionalString(c.Rank),
+		HighestRank:        optionalString(c.HighestRank),
 	}
 	if c.SponsorID != nil {
 		sid := c.SponsorID.Hex()
@@ -1198,6 +1541,10 @@ func (r *queryResolver) Client(ctx context.Context, id string) (*model.Client, e
 			}
 		}
 	}
+	if c.PlacementParentID != nil {
+		pid := c.PlacementParentID.Hex()
+		mc.PlacementParentID = &pid
+	}
 	if c.LeftChildID != nil {
 		lid := c.LeftChildID.Hex()
 		mc.LeftChildID = &lid
@@ -1258,6 +1605,17 @@ func (r *queryResolver) Client(ctx context.Context, id string) (*model.Client, e
 		mc.Transactions = []*model.Payment{}
 	}
 
+	// Load rank history
+	history, err := r.Resolver.rankService.History(ctx, c.ID.Hex())
+	if err == nil {
+		mc.RankHistory = make([]*model.RankHistoryEntry, 0, len(history))
+		for _, entry := range history {
+			mc.RankHistory = append(mc.RankHistory, toRankHistoryEntryModel(entry))
+		}
+	} else {
+		mc.RankHistory = []*model.RankHistoryEntry{}
+	}
+
 	// Load purchases (sales)
 	sales, err := r.Resolver.saleService.GetByClientID(ctx, c.ID.Hex())
 	if err == nil {
@@ -1274,6 +1632,7 @@ func (r *queryResolver) Client(ctx context.Context, id string) (*model.Client, e
 				ProductID: prodIdStr,
 				Amount:    s.Amount,
 				Quantity:  int32(s.Quantity),
+				Points:    s.Points.Float64(),
 				Side:      s.Side,
 				Date:      s.Date.Format(time.RFC3339),
 				Status:    s.Status,
@@ -1323,24 +1682,7 @@ func (r *queryResolver) ClientTree(ctx context.Context, id string) (*model.Clien
 		return nil, fmt.Errorf("root client not found in subtree: %s", id)
 	}
 
-	// Cache pour les vérifications d'activité
-	activeCache := make(map[string]bool)
-	isClientActiveCached := func(clientID string) bool {
-		if active, found := activeCache[clientID]; found {
-			return active
-		}
-		if r.Resolver.binaryCommissionService == nil {
-			activeCache[clientID] = false
-			return false
-		}
-		active, err := r.Resolver.binaryCommissionService.IsClientActive(ctx, clientID)
-		if err != nil {
-			activeCache[clientID] = false
-			return false
-		}
-		activeCache[clientID] = active
-		return active
-	}
+	now := time.Now()
 
 	// Construire l'arbre en mémoire de manière optimisée (BFS)
 	nodeMap := make(map[string]*model.ClientTreeNode)
@@ -1381,41 +1723,28 @@ func (r *queryResolver) ClientTree(ctx context.Context, id string) (*model.Clien
 			ParentID:           item.parentID,
 			Level:              int32(item.level),
 			Position:           item.position,
-			NetworkVolumeLeft:  item.client.NetworkVolumeLeft,
-			NetworkVolumeRight: item.client.NetworkVolumeRight,
+			NetworkVolumeLeft:  item.client.NetworkVolumeLeft.Float64(),
+			NetworkVolumeRight: item.client.NetworkVolumeRight.Float64(),
 			BinaryPairs:        int32(item.client.BinaryPairs),
 			TotalEarnings:      item.client.TotalEarnings,
 			WalletBalance:      item.client.WalletBalance,
-			IsActive:           isClientActiveCached(clientIDStr),
-			LeftActives:        0,
-			RightActives:       0,
-			IsQualified:        false,
-		}
-
-		// Enrichir avec les informations binaires seulement pour les 3 premiers niveaux
-		if item.level < 3 {
-			leftActives, rightActives := r.countActivesInLegs(ctx, item.client, activeCache, 3-item.level)
-			node.LeftActives = int32(leftActives)
-			node.RightActives = int32(rightActives)
-			node.IsQualified = leftActives > 0 && rightActives > 0
-
-			if leftActives > 0 && rightActives > 0 {
-				if leftActives < rightActives {
-					cycles := int32(leftActives)
-					node.CyclesAvailable = &cycles
-				} else {
-					cycles := int32(rightActives)
-					node.CyclesAvailable = &cycles
-				}
-			} else {
-				zero := int32(0)
-				node.CyclesAvailable = &zero
-			}
-		} else {
-			zero := int32(0)
-			node.CyclesAvailable = &zero
+			IsActive:           service.IsActiveAt(item.client, now),
+			LeftMembers:        int32(item.client.LeftMembers),
+			RightMembers:       int32(item.client.RightMembers),
+			LeftActives:        int32(item.client.LeftActives),
+			RightActives:       int32(item.client.RightActives),
+			IsQualified:        item.client.LeftActives > 0 && item.client.RightActives > 0,
+		}
+
+		if item.client.SponsorID != nil {
+			sid := item.client.SponsorID.Hex()
+			node.SponsorID = &sid
 		}
 
+		// Les compteurs de jambes sont stockés sur chaque membre: lecture directe à tous les niveaux
+		cycles := int32(min(item.client.LeftActives, item.client.RightActives))
+		node.CyclesAvailable = &cycles
+
 		zero := int32(0)
 		node.CyclesPaidToday = &zero
 
@@ -1463,64 +1792,6 @@ func (r *queryResolver) ClientTree(ctx context.Context, id string) (*model.Clien
 	}, nil
 }
 
-// countActivesInLegs compte les actifs dans chaque jambe avec limite de profondeur
-func (r *queryResolver) countActivesInLegs(ctx context.Context, client *models.Client, activeCache map[string]bool, maxDepth int) (leftActives, rightActives int) {
-	if maxDepth <= 0 {
-		return 0, 0
-	}
-
-	if client.LeftChildID != nil {
-		leftActives = r.countActivesInLeg(ctx, client.LeftChildID.Hex(), activeCache, maxDepth-1, 0)
-	}
-	if client.RightChildID != nil {
-		rightActives = r.countActivesInLeg(ctx, client.RightChildID.Hex(), activeCache, maxDepth-1, 0)
-	}
-
-	return leftActives, rightActives
-}
-
-// countActivesInLeg compte récursivement les actifs dans une jambe
-func (r *queryResolver) countActivesInLeg(ctx context.Context, clientID string, activeCache map[string]bool, maxDepth, currentDepth int) int {
-	if maxDepth <= 0 {
-		return 0
-	}
-
-	// Vérifier si le client est actif
-	isActive := false
-	if active, found := activeCache[clientID]; found {
-		isActive = active
-	} else {
-		if r.Resolver.binaryCommissionService != nil {
-			active, err := r.Resolver.binaryCommissionService.IsClientActive(ctx, clientID)
-			if err == nil {
-				isActive = active
-			}
-		}
-		activeCache[clientID] = isActive
-	}
-
-	count := 0
-	if isActive {
-		count = 1
-	}
-
-	// Récupérer le client pour accéder aux enfants
-	client, err := r.Resolver.clientService.GetByID(ctx, clientID)
-	if err != nil {
-		return count
-	}
-
-	// Compter les actifs dans les sous-arbres
-	if client.LeftChildID != nil {
-		count += r.countActivesInLeg(ctx, client.LeftChildID.Hex(), activeCache, maxDepth-1, currentDepth+1)
-	}
-	if client.RightChildID != nil {
-		count += r.countActivesInLeg(ctx, client.RightChildID.Hex(), activeCache, maxDepth-1, currentDepth+1)
-	}
-
-	return count
-}
-
 // Sales is the resolver for the sales field.
 func (r *queryResolver) Sales(ctx context.Context, filter *model.FilterInput, paging *model.PagingInput) ([]*model.Sale, error) {
 	// Convert GraphQL model to internal model
@@ -1572,6 +1843,7 @@ func (r *queryResolver) Sales(ctx context.Context, filter *model.FilterInput, pa
 			Amount:     s.Amount,
 			PaidAmount: s.PaidAmount,
 			Quantity:   int32(s.Quantity),
+			Points:     s.Points.Float64(),
 			Side:       s.Side,
 			Date:       s.Date.Format(time.RFC3339),
 			Status:     s.Status,
@@ -1596,15 +1868,30 @@ func (r *queryResolver) Sales(ctx context.Context, filter *model.FilterInput, pa
 				JoinDate:           client.JoinDate.Format(time.RFC3339),
 				TotalEarnings:      client.TotalEarnings,
 				WalletBalance:      client.WalletBalance,
-				Points:             client.Points,
-				NetworkVolumeLeft:  client.NetworkVolumeLeft,
-				NetworkVolumeRight: client.NetworkVolumeRight,
+				Points:             client.Points.Float64(),
+				NetworkVolumeLeft:  client.NetworkVolumeLeft.Float64(),
+				NetworkVolumeRight: client.NetworkVolumeRight.Float64(),
+				PendingPoints:      client.PendingPoints.Float64(),
+				PendingVolumeLeft:  client.PendingVolumeLeft.Float64(),
+				PendingVolumeRight: client.PendingVolumeRight.Float64(),
 				BinaryPairs:        int32(client.BinaryPairs),
+				ActiveUntil:        formatTimePtr(client.ActiveUntil),
+				HoldingTankUntil:   formatTimePtr(client.HoldingTankUntil),
+				LeftMembers:        int32(client.LeftMembers),
+				RightMembers:       int32(client.RightMembers),
+				LeftActives:        int32(client.LeftActives),
+				RightActives:       int32(client.RightActives),
+				Rank:               optionalString(client.Rank),
+				HighestRank:        optionalString(client.HighestRank),
 			}
 			if client.SponsorID != nil {
 				sid := client.SponsorID.Hex()
 				sale.Client.SponsorID = &sid
 			}
+			if client.PlacementParentID != nil {
+				pid := client.PlacementParentID.Hex()
+				sale.Client.PlacementParentID = &pid
+			}
 			if client.LeftChildID != nil {
 				lid := client.LeftChildID.Hex()
 				sale.Client.LeftChildID = &lid
@@ -1625,7 +1912,7 @@ func (r *queryResolver) Sales(ctx context.Context, filter *model.FilterInput, pa
 					Description: product.Description,
 					Price:       product.Price,
 					Stock:       int32(product.Stock),
-					Points:      product.Points,
+					Points:      product.Points.Float64(),
 					ImageURL:    product.ImageURL,
 					CreatedAt:   product.CreatedAt.Format(time.RFC3339),
 					UpdatedAt:   product.UpdatedAt.Format(time.RFC3339),
@@ -1661,6 +1948,7 @@ func (r *queryResolver) Sale(ctx context.Context, id string) (*model.Sale, error
 		Amount:     s.Amount,
 		PaidAmount: s.PaidAmount,
 		Quantity:   int32(s.Quantity),
+		Points:     s.Points.Float64(),
 		Side:       s.Side,
 		Date:       s.Date.Format(time.RFC3339),
 		Status:     s.Status,
@@ -1685,15 +1973,30 @@ func (r *queryResolver) Sale(ctx context.Context, id string) (*model.Sale, error
 			JoinDate:           client.JoinDate.Format(time.RFC3339),
 			TotalEarnings:      client.TotalEarnings,
 			WalletBalance:      client.WalletBalance,
-			Points:             client.Points,
-			NetworkVolumeLeft:  client.NetworkVolumeLeft,
-			NetworkVolumeRight: client.NetworkVolumeRight,
+			Points:             client.Points.Float64(),
+			NetworkVolumeLeft:  client.NetworkVolumeLeft.Float64(),
+			NetworkVolumeRight: client.NetworkVolumeRight.Float64(),
+			PendingPoints:      client.PendingPoints.Float64(),
+			PendingVolumeLeft:  client.PendingVolumeLeft.Float64(),
+			PendingVolumeRight: client.PendingVolumeRight.Float64(),
 			BinaryPairs:        int32(client.BinaryPairs),
+			ActiveUntil:        formatTimePtr(client.ActiveUntil),
+			HoldingTankUntil:   formatTimePtr(client.HoldingTankUntil),
+			LeftMembers:        int32(client.LeftMembers),
+			RightMembers:       int32(client.RightMembers),
+			LeftActives:        int32(client.LeftActives),
+			RightActives:       int32(client.RightActives),
+			Rank:               optionalString(client.Rank),
+			HighestRank:        optionalString(client.HighestRank),
 		}
 		if client.SponsorID != nil {
 			sid := client.SponsorID.Hex()
 			sale.Client.SponsorID = &sid
 		}
+		if client.PlacementParentID != nil {
+			pid := client.PlacementParentID.Hex()
+			sale.Client.PlacementParentID = &pid
+		}
 		if client.LeftChildID != nil {
 			lid := client.LeftChildID.Hex()
 			sale.Client.LeftChildID = &lid
@@ -1714,7 +2017,7 @@ func (r *queryResolver) Sale(ctx context.Context, id string) (*model.Sale, error
 				Description: product.Description,
 				Price:       product.Price,
 				Stock:       int32(product.Stock),
-				Points:      product.Points,
+				Points:      product.Points.Float64(),
 				ImageURL:    product.ImageURL,
 				CreatedAt:   product.CreatedAt.Format(time.RFC3339),
 				UpdatedAt:   product.UpdatedAt.Format(time.RFC3339),
@@ -1758,7 +2061,7 @@ func (r *queryResolver) Commissions(ctx context.Context, filter *model.FilterInp
 	}
 	out := make([]*model.Commission, 0, len(list))
 	for _, c := range list {
-		out = append(out, &model.Commission{ID: c.ID.Hex(), ClientID: c.ClientID.Hex(), SourceClientID: c.SourceClientID.Hex(), Amount: c.Amount, Level: int32(c.Level), Type: c.Type, Date: c.Date.Format(time.RFC3339)})
+		out = append(out, &model.Commission{ID: c.ID.Hex(), ClientID: c.ClientID.Hex(), SourceClientID: c.SourceClientID.Hex(), Amount: c.Amount, Level: int32(c.Level), Type: c.Type, Date: c.Date.Format(time.RFC3339), PlanVersion: planVersionPtr(c.PlanVersion), SaleID: hexPtr(c.SaleID), SourceCommissionID: hexPtr(c.SourceCommissionID)})
 	}
 	return out, nil
 }
@@ -1772,19 +2075,181 @@ func (r *queryResolver) Commission(ctx context.Context, id string) (*model.Commi
 	if err != nil {
 		return nil, err
 	}
-	return &model.Commission{ID: c.ID.Hex(), ClientID: c.ClientID.Hex(), SourceClientID: c.SourceClientID.Hex(), Amount: c.Amount, Level: int32(c.Level), Type: c.Type, Date: c.Date.Format(time.RFC3339)}, nil
+	return &model.Commission{ID: c.ID.Hex(), ClientID: c.ClientID.Hex(), SourceClientID: c.SourceClientID.Hex(), Amount: c.Amount, Level: int32(c.Level), Type: c.Type, Date: c.Date.Format(time.RFC3339), PlanVersion: planVersionPtr(c.PlanVersion), SaleID: hexPtr(c.SaleID), SourceCommissionID: hexPtr(c.SourceCommissionID)}, nil
 }
 
-// DashboardStats is the resolver for the dashboardStats field.
-func (r *queryResolver) DashboardStats(ctx context.Context, rangeArg *string) (*model.DashboardStats, error) {
-	s, err := r.Resolver.adminService.GetDashboardStats(ctx, rangeArg)
-	if err != nil {
+// BinaryCycles is the resolver for the binaryCycles field.
+func (r *queryResolver) BinaryCycles(ctx context.Context, clientID string, filter *model.FilterInput, paging *model.PagingInput) ([]*model.BinaryCycle, error) {
+	if err := r.Resolver.requireAdminOrSelf(ctx, clientID); err != nil {
 		return nil, err
 	}
-	// Only load basic stats - heavy fields (monthlySales, networkGrowth, etc.) will be loaded via field resolvers if requested
-	return &model.DashboardStats{
-		TotalProducts:    int32(s.TotalProducts),
-		TotalClients:     int32(s.TotalClients),
+
+	var internalFilter *models.FilterInput
+	if filter != nil {
+		internalFilter = &models.FilterInput{
+			Search: filter.Search,
+			Status: filter.Status,
+		}
+		if filter.DateFrom != nil {
+			if t, err := time.Parse(time.RFC3339, *filter.DateFrom); err == nil {
+				internalFilter.DateFrom = &t
+			}
+		}
+		if filter.DateTo != nil {
+			if t, err := time.Parse(time.RFC3339, *filter.DateTo); err == nil {
+				internalFilter.DateTo = &t
+			}
+		}
+	}
+	var internalPaging *models.PagingInput
+	if paging != nil {
+		internalPaging = &models.PagingInput{}
+		if paging.Page != nil {
+			p := int(*paging.Page)
+			internalPaging.Page = &p
+		}
+		if paging.Limit != nil {
+			l := int(*paging.Limit)
+			internalPaging.Limit = &l
+		}
+	}
+
+	cycles, err := r.Resolver.binaryCommissionService.GetCycles(ctx, clientID, internalFilter, internalPaging)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]*model.BinaryCycle, 0, len(cycles))
+	for _, c := range cycles {
+		cycle := &model.BinaryCycle{
+			ID:                c.ID.Hex(),
+			ClientID:          c.ClientID.Hex(),
+			CyclesAvailable:   int32(c.CyclesAvailable),
+			Cycles:            int32(c.Cycles),
+			Amount:            c.Amount,
+			LeftVolumeBefore:  c.LeftVolumeBefore.Float64(),
+			RightVolumeBefore: c.RightVolumeBefore.Float64(),
+			LeftVolumeUsed:    c.LeftVolumeUsed.Float64(),
+			RightVolumeUsed:   c.RightVolumeUsed.Float64(),
+			Date:              c.Date.Format(time.RFC3339),
+			ProcessedAt:       c.ProcessedAt.Format(time.RFC3339),
+			PlanVersion:       planVersionPtr(c.PlanVersion),
+		}
+		if !c.CommissionID.IsZero() {
+			commissionID := c.CommissionID.Hex()
+			cycle.CommissionID = &commissionID
+		}
+		out = append(out, cycle)
+	}
+	return out, nil
+}
+
+// PreviewBinaryCommission is the resolver for the previewBinaryCommission field.
+// Simule le calcul binaire d'un client avec le plan en vigueur, sans rien payer
+func (r *queryResolver) PreviewBinaryCommission(ctx context.Context, clientID string) (*model.BinaryCommissionResult, error) {
+	if err := r.Resolver.requireAdminOrSelf(ctx, clientID); err != nil {
+		return nil, err
+	}
+
+	result, err := r.Resolver.binaryEngine.PreviewBinaryCommission(ctx, clientID)
+	if err != nil {
+		return nil, err
+	}
+	return toBinaryCommissionResultModel(result), nil
+}
+
+// BinaryCommissionRun is the resolver for the binaryCommissionRun field.
+func (r *queryResolver) BinaryCommissionRun(ctx context.Context, id string) (*model.BinaryCommissionRun, error) {
+	if _, err := r.Resolver.requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	if err := validation.ValidateObjectID(id); err != nil {
+		return nil, err
+	}
+	run, err := r.Resolver.binaryBatchService.GetRun(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return toBinaryCommissionRunModel(run), nil
+}
+
+// BinaryCommissionRuns is the resolver for the binaryCommissionRuns field.
+func (r *queryResolver) BinaryCommissionRuns(ctx context.Context, paging *model.PagingInput) ([]*model.BinaryCommissionRun, error) {
+	if _, err := r.Resolver.requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	var internalPaging *models.PagingInput
+	if paging != nil {
+		internalPaging = &models.PagingInput{}
+		if paging.Page != nil {
+			p := int(*paging.Page)
+			internalPaging.Page = &p
+		}
+		if paging.Limit != nil {
+			l := int(*paging.Limit)
+			internalPaging.Limit = &l
+		}
+	}
+
+	runs, err := r.Resolver.binaryBatchService.GetRuns(ctx, internalPaging)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*model.BinaryCommissionRun, 0, len(runs))
+	for _, run := range runs {
+		out = append(out, toBinaryCommissionRunModel(run))
+	}
+	return out, nil
+}
+
+// Clawback is the resolver for the clawback field.
+func (r *queryResolver) Clawback(ctx context.Context, saleID string) (*model.Clawback, error) {
+	if _, err := r.Resolver.requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	if err := validation.ValidateObjectID(saleID); err != nil {
+		return nil, err
+	}
+	saleOID, err := primitive.ObjectIDFromHex(saleID)
+	if err != nil {
+		return nil, err
+	}
+	clawback, err := r.Resolver.clawbackService.GetBySaleID(ctx, saleOID)
+	if err != nil {
+		return nil, err
+	}
+	if clawback == nil {
+		return nil, nil
+	}
+	return toClawbackModel(clawback), nil
+}
+
+// Clawbacks is the resolver for the clawbacks field.
+func (r *queryResolver) Clawbacks(ctx context.Context, paging *model.PagingInput) ([]*model.Clawback, error) {
+	if _, err := r.Resolver.requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	clawbacks, err := r.Resolver.clawbackService.GetAll(ctx, toPagingInput(paging))
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*model.Clawback, 0, len(clawbacks))
+	for _, clawback := range clawbacks {
+		out = append(out, toClawbackModel(clawback))
+	}
+	return out, nil
+}
+
+// DashboardStats is the resolver for the dashboardStats field.
+func (r *queryResolver) DashboardStats(ctx context.Context, rangeArg *string) (*model.DashboardStats, error) {
+	s, err := r.Resolver.adminService.GetDashboardStats(ctx, rangeArg)
+	if err != nil {
+		return nil, err
+	}
+	// Only load basic stats - heavy fields (monthlySales, networkGrowth, etc.) will be loaded via field resolvers if requested
+	return &model.DashboardStats{
+		TotalProducts:    int32(s.TotalProducts),
+		TotalClients:     int32(s.TotalClients),
 		TotalSales:       s.TotalSales,
 		TotalCommissions: s.TotalCommissions,
 		ActiveClients:    int32(s.ActiveClients),
@@ -1887,6 +2352,299 @@ func (r *queryResolver) CaisseTransactions(ctx context.Context, filter *model.Fi
 	return out, nil
 }
 
+// CaisseDailyReport is the resolver for the caisseDailyReport field.
+func (r *queryResolver) CaisseDailyReport(ctx context.Context, date *string) (*model.CaisseDailyReport, error) {
+	if _, err := r.Resolver.requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	day := ""
+	if date != nil {
+		day = *date
+	}
+	report, err := r.Resolver.caisseService.DailyReport(ctx, day)
+	if err != nil {
+		return nil, err
+	}
+	return toCaisseDailyReportModel(report), nil
+}
+
+// ScheduledJobs is the resolver for the scheduledJobs field.
+func (r *queryResolver) ScheduledJobs(ctx context.Context) ([]*model.ScheduledJob, error) {
+	if _, err := r.Resolver.requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	jobs, err := r.Resolver.jobScheduler.Jobs(ctx, 10)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]*model.ScheduledJob, 0, len(jobs))
+	for _, job := range jobs {
+		scheduled := &model.ScheduledJob{
+			Name:        job.Name,
+			Schedule:    job.Schedule,
+			Description: job.Description,
+			RecentRuns:  make([]*model.JobExecution, 0, len(job.RecentRuns)),
+		}
+		if !job.NextRunAt.IsZero() {
+			nextRunAt := job.NextRunAt.Format(time.RFC3339)
+			scheduled.NextRunAt = &nextRunAt
+		}
+		for _, execution := range job.RecentRuns {
+			scheduled.RecentRuns = append(scheduled.RecentRuns, toJobExecutionModel(execution))
+		}
+		if len(scheduled.RecentRuns) > 0 {
+			scheduled.LastRun = scheduled.RecentRuns[0]
+		}
+		out = append(out, scheduled)
+	}
+	return out, nil
+}
+
+// CompPlanVersions is the resolver for the compPlanVersions field.
+func (r *queryResolver) CompPlanVersions(ctx context.Context, paging *model.PagingInput) ([]*model.CompPlanVersion, error) {
+	if _, err := r.Resolver.requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	var internalPaging *models.PagingInput
+	if paging != nil {
+		internalPaging = &models.PagingInput{}
+		if paging.Page != nil {
+			p := int(*paging.Page)
+			internalPaging.Page = &p
+		}
+		if paging.Limit != nil {
+			l := int(*paging.Limit)
+			internalPaging.Limit = &l
+		}
+	}
+
+	plans, err := r.Resolver.compPlanService.GetVersions(ctx, internalPaging)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]*model.CompPlanVersion, 0, len(plans))
+	for _, plan := range plans {
+		out = append(out, toCompPlanVersionModel(plan))
+	}
+	return out, nil
+}
+
+// CurrentCompPlan is the resolver for the currentCompPlan field.
+// Retourne la version en vigueur (version 0 = configuration d'environnement)
+func (r *queryResolver) CurrentCompPlan(ctx context.Context) (*model.CompPlanVersion, error) {
+	plan, err := r.Resolver.compPlanService.Effective(ctx, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	return toCompPlanVersionModel(plan), nil
+}
+
+// RankDefinitions is the resolver for the rankDefinitions field.
+func (r *queryResolver) RankDefinitions(ctx context.Context) ([]*model.RankDefinition, error) {
+	ranks, err := r.Resolver.rankService.Definitions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]*model.RankDefinition, 0, len(ranks))
+	for _, rank := range ranks {
+		out = append(out, toRankDefinitionModel(rank))
+	}
+	return out, nil
+}
+
+// PayPeriods is the resolver for the payPeriods field.
+func (r *queryResolver) PayPeriods(ctx context.Context, paging *model.PagingInput) ([]*model.PayPeriod, error) {
+	if _, err := r.Resolver.requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	periods, err := r.Resolver.payPeriodService.GetAll(ctx, toPagingInput(paging))
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*model.PayPeriod, 0, len(periods))
+	for _, period := range periods {
+		out = append(out, toPayPeriodModel(period))
+	}
+	return out, nil
+}
+
+// PayPeriodStatements is the resolver for the payPeriodStatements field.
+func (r *queryResolver) PayPeriodStatements(ctx context.Context, key string, paging *model.PagingInput) ([]*model.MemberStatement, error) {
+	if _, err := r.Resolver.requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	statements, err := r.Resolver.payPeriodService.GetStatements(ctx, key, toPagingInput(paging))
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*model.MemberStatement, 0, len(statements))
+	for _, statement := range statements {
+		out = append(out, toMemberStatementModel(statement))
+	}
+	return out, nil
+}
+
+// MemberStatements is the resolver for the memberStatements field.
+// Un membre ne consulte que ses propres relevés
+func (r *queryResolver) MemberStatements(ctx context.Context, clientID string, paging *model.PagingInput) ([]*model.MemberStatement, error) {
+	_, selfID, err := r.Resolver.requireAdminOrClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := validation.ValidateObjectID(clientID); err != nil {
+		return nil, err
+	}
+	clientOID, err := primitive.ObjectIDFromHex(clientID)
+	if err != nil {
+		return nil, err
+	}
+	if selfID != nil && *selfID != clientOID {
+		return nil, errors.New("accès refusé aux relevés d'un autre membre")
+	}
+
+	statements, err := r.Resolver.payPeriodService.GetMemberStatements(ctx, clientOID, toPagingInput(paging))
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*model.MemberStatement, 0, len(statements))
+	for _, statement := range statements {
+		out = append(out, toMemberStatementModel(statement))
+	}
+	return out, nil
+}
+
+// WalletTransactions is the resolver for the walletTransactions field.
+func (r *queryResolver) WalletTransactions(ctx context.Context, clientID string, paging *model.PagingInput) ([]*model.WalletTransaction, error) {
+	_, selfID, err := r.Resolver.requireAdminOrClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := validation.ValidateObjectID(clientID); err != nil {
+		return nil, err
+	}
+	clientOID, err := primitive.ObjectIDFromHex(clientID)
+	if err != nil {
+		return nil, err
+	}
+	if selfID != nil && *selfID != clientOID {
+		return nil, errors.New("accès refusé au portefeuille d'un autre membre")
+	}
+
+	entries, err := r.Resolver.walletService.GetTransactions(ctx, clientOID, toPagingInput(paging))
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*model.WalletTransaction, 0, len(entries))
+	for _, entry := range entries {
+		out = append(out, toWalletTransactionModel(entry))
+	}
+	return out, nil
+}
+
+// Withdrawals is the resolver for the withdrawals field.
+func (r *queryResolver) Withdrawals(ctx context.Context, clientID *string, status *string, paging *model.PagingInput) ([]*model.Withdrawal, error) {
+	_, selfID, err := r.Resolver.requireAdminOrClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := validation.ValidateObjectIDPtr(clientID); err != nil {
+		return nil, err
+	}
+	if status != nil && *status != models.WithdrawalStatusPending && *status != models.WithdrawalStatusApproved && *status != models.WithdrawalStatusRejected && *status != models.WithdrawalStatusFailed {
+		return nil, errors.New("statut de retrait invalide")
+	}
+
+	var clientOID *primitive.ObjectID
+	if clientID != nil {
+		oid, err := primitive.ObjectIDFromHex(*clientID)
+		if err != nil {
+			return nil, err
+		}
+		clientOID = &oid
+	}
+	// Un membre ne voit que ses propres demandes
+	if selfID != nil {
+		if clientOID != nil && *clientOID != *selfID {
+			return nil, errors.New("accès refusé aux retraits d'un autre membre")
+		}
+		clientOID = selfID
+	}
+
+	withdrawals, err := r.Resolver.withdrawalService.GetAll(ctx, clientOID, status, toPagingInput(paging))
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*model.Withdrawal, 0, len(withdrawals))
+	for _, withdrawal := range withdrawals {
+		out = append(out, toWithdrawalModel(withdrawal))
+	}
+	return out, nil
+}
+
+// PayoutBatches is the resolver for the payoutBatches field.
+func (r *queryResolver) PayoutBatches(ctx context.Context, paging *model.PagingInput) ([]*model.PayoutBatch, error) {
+	if _, err := r.Resolver.requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	batches, err := r.Resolver.payoutBatchService.GetAll(ctx, toPagingInput(paging))
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*model.PayoutBatch, 0, len(batches))
+	for _, batch := range batches {
+		out = append(out, toPayoutBatchModel(batch))
+	}
+	return out, nil
+}
+
+// PayoutBatch is the resolver for the payoutBatch field.
+func (r *queryResolver) PayoutBatch(ctx context.Context, id string) (*model.PayoutBatch, error) {
+	if _, err := r.Resolver.requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	if err := validation.ValidateObjectID(id); err != nil {
+		return nil, err
+	}
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	batch, err := r.Resolver.payoutBatchService.GetByID(ctx, oid)
+	if err != nil {
+		return nil, err
+	}
+	if batch == nil {
+		return nil, nil
+	}
+	return toPayoutBatchModel(batch), nil
+}
+
+// PayoutBatchFile is the resolver for the payoutBatchFile field.
+func (r *queryResolver) PayoutBatchFile(ctx context.Context, id string) (*model.PayoutFile, error) {
+	if _, err := r.Resolver.requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	if err := validation.ValidateObjectID(id); err != nil {
+		return nil, err
+	}
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	filename, content, err := r.Resolver.payoutBatchService.File(ctx, oid)
+	if err != nil {
+		return nil, err
+	}
+	return &model.PayoutFile{Filename: filename, Content: content}, nil
+}
+
 // OnNewSale is the resolver for the onNewSale field.
 func (r *subscriptionResolver) OnNewSale(ctx context.Context) (<-chan *model.Sale, error) {
 	ch := make(chan *model.Sale, 1)
@@ -4,6 +4,7 @@ import (
 	"log"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
@@ -23,11 +24,47 @@ type Config struct {
 	BinaryThreshold      float64
 	BinaryCommissionRate float64
 	DefaultProductPrice  float64
+	// Stratégie de placement par défaut d'un nouveau membre sans position demandée
+	PlacementStrategy string
+	// Salle d'attente: délai de placement par le parrain, puis placement automatique
+	HoldingTankDays     int
+	HoldingTankStrategy string
+	HoldingTankSchedule string
 	// Nouveaux paramètres pour l'algorithme binaire amélioré
-	BinaryCycleValue      float64
-	BinaryDailyCycleLimit int
+	BinaryEngine           string
+	BinaryCycleValue       float64
+	BinaryDailyCycleLimit  int
 	BinaryWeeklyCycleLimit int
-	BinaryMinVolumePerLeg float64
+	BinaryWeekStartDay     time.Weekday
+	BinaryMinVolumePerLeg  float64
+	BinaryBatchWorkers     int
+	BinaryBatchSchedule    string
+	MatchingBonusRates     []float64
+	// Bonus de démarrage rapide (premier achat payé d'un filleul)
+	FastStartBonusRate   float64
+	FastStartBonusAmount float64
+	// Règle d'activité des membres
+	ActivityMinPoints       float64
+	ActivityWindow          string
+	ActivityWindowDays      int
+	ActivityRefreshSchedule string
+	LegCountExpireSchedule  string
+	// Périodes de paie
+	PayPeriodFrequency    string
+	PayPeriodWeekStartDay time.Weekday
+	// Fuseau du jour ouvré (nom IANA, ex: Africa/Kinshasa)
+	BusinessTimezone string
+	// Retraits du portefeuille
+	WithdrawalMinAmount  float64
+	WithdrawalFeeRate    float64
+	WithdrawalFeeAmount  float64
+	WithdrawalDailyLimit float64
+	// Lots de paiement mobile money
+	PayoutCurrency    string
+	PayoutCountryCode string
+	// Planificateur de tâches
+	SchedulerEnabled       bool
+	SchedulerLeaseDuration time.Duration
 }
 
 func Load() *Config {
@@ -50,11 +87,44 @@ func Load() *Config {
 		BinaryThreshold:      getFloatEnv("BINARY_THRESHOLD", 100.0),
 		BinaryCommissionRate: getFloatEnv("BINARY_COMMISSION_RATE", 0.1),
 		DefaultProductPrice:  getFloatEnv("DEFAULT_PRODUCT_PRICE", 50.0),
+		PlacementStrategy:    getEnv("PLACEMENT_STRATEGY", "balanced"),
+		HoldingTankDays:      getIntEnv("HOLDING_TANK_DAYS", 7),
+		HoldingTankStrategy:  getEnv("HOLDING_TANK_STRATEGY", "balanced"),
+		HoldingTankSchedule:  getEnv("HOLDING_TANK_SCHEDULE", "15 * * * *"),
 		// Nouveaux paramètres pour l'algorithme binaire amélioré
-		BinaryCycleValue:      getFloatEnv("BINARY_CYCLE_VALUE", 20.0),
-		BinaryDailyCycleLimit: getIntEnv("BINARY_DAILY_CYCLE_LIMIT", 4),
+		BinaryEngine:           getEnv("BINARY_ENGINE", "cycle"),
+		BinaryCycleValue:       getFloatEnv("BINARY_CYCLE_VALUE", 0),
+		BinaryDailyCycleLimit:  getIntEnv("BINARY_DAILY_CYCLE_LIMIT", 4),
 		BinaryWeeklyCycleLimit: getIntEnv("BINARY_WEEKLY_CYCLE_LIMIT", 0),
-		BinaryMinVolumePerLeg: getFloatEnv("BINARY_MIN_VOLUME_PER_LEG", 1.0),
+		BinaryWeekStartDay:     getWeekdayEnv("BINARY_WEEK_START_DAY", time.Monday),
+		BinaryMinVolumePerLeg:  getFloatEnv("BINARY_MIN_VOLUME_PER_LEG", 1.0),
+		BinaryBatchWorkers:     getIntEnv("BINARY_BATCH_WORKERS", 4),
+		BinaryBatchSchedule:    getEnv("BINARY_BATCH_SCHEDULE", "0 1 * * *"),
+		MatchingBonusRates:     getFloatListEnv("MATCHING_BONUS_RATES", nil),
+		FastStartBonusRate:     getFloatEnv("FAST_START_BONUS_RATE", 0),
+		FastStartBonusAmount:   getFloatEnv("FAST_START_BONUS_AMOUNT", 0),
+		// Règle d'activité des membres
+		ActivityMinPoints:       getFloatEnv("ACTIVITY_MIN_POINTS", 0),
+		ActivityWindow:          getEnv("ACTIVITY_WINDOW", "lifetime"),
+		ActivityWindowDays:      getIntEnv("ACTIVITY_WINDOW_DAYS", 30),
+		ActivityRefreshSchedule: getEnv("ACTIVITY_REFRESH_SCHEDULE", "30 0 * * *"),
+		LegCountExpireSchedule:  getEnv("LEG_COUNT_EXPIRE_SCHEDULE", "0 * * * *"),
+		// Périodes de paie
+		PayPeriodFrequency:    getEnv("PAY_PERIOD_FREQUENCY", "weekly"),
+		PayPeriodWeekStartDay: getWeekdayEnv("PAY_PERIOD_WEEK_START_DAY", time.Monday),
+		// Fuseau du jour ouvré
+		BusinessTimezone: getEnv("BUSINESS_TIMEZONE", "UTC"),
+		// Retraits du portefeuille
+		WithdrawalMinAmount:  getFloatEnv("WITHDRAWAL_MIN_AMOUNT", 10),
+		WithdrawalFeeRate:    getFloatEnv("WITHDRAWAL_FEE_RATE", 0),
+		WithdrawalFeeAmount:  getFloatEnv("WITHDRAWAL_FEE_AMOUNT", 0),
+		WithdrawalDailyLimit: getFloatEnv("WITHDRAWAL_DAILY_LIMIT", 0),
+		// Lots de paiement mobile money
+		PayoutCurrency:    getEnv("PAYOUT_CURRENCY", "USD"),
+		PayoutCountryCode: getEnv("PAYOUT_COUNTRY_CODE", ""),
+		// Planificateur de tâches
+		SchedulerEnabled:       getBoolEnv("SCHEDULER_ENABLED", true),
+		SchedulerLeaseDuration: getDurationEnv("SCHEDULER_LEASE_DURATION", 10*time.Minute),
 	}
 }
 
@@ -91,3 +161,55 @@ func getIntEnv(key string, defaultValue int) int {
 	}
 	return defaultValue
 }
+
+func getBoolEnv(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if boolValue, err := strconv.ParseBool(value); err == nil {
+			return boolValue
+		}
+	}
+	return defaultValue
+}
+
+// getFloatListEnv lit une liste de nombres séparés par des virgules ("0.10,0.05")
+// La valeur par défaut est retournée si un élément n'est pas un nombre
+func getFloatListEnv(key string, defaultValue []float64) []float64 {
+	value := strings.TrimSpace(os.Getenv(key))
+	if value == "" {
+		return defaultValue
+	}
+	var values []float64
+	for _, item := range strings.Split(value, ",") {
+		floatValue, err := strconv.ParseFloat(strings.TrimSpace(item), 64)
+		if err != nil {
+			return defaultValue
+		}
+		values = append(values, floatValue)
+	}
+	return values
+}
+
+// getWeekdayEnv accepte un nom de jour anglais ou français ("monday", "lundi")
+// ou un entier (0 = dimanche ... 6 = samedi)
+func getWeekdayEnv(key string, defaultValue time.Weekday) time.Weekday {
+	value := strings.ToLower(strings.TrimSpace(os.Getenv(key)))
+	if value == "" {
+		return defaultValue
+	}
+	if intValue, err := strconv.Atoi(value); err == nil && intValue >= 0 && intValue <= 6 {
+		return time.Weekday(intValue)
+	}
+	names := map[string]time.Weekday{
+		"sunday": time.Sunday, "dimanche": time.Sunday,
+		"monday": time.Monday, "lundi": time.Monday,
+		"tuesday": time.Tuesday, "mardi": time.Tuesday,
+		"wednesday": time.Wednesday, "mercredi": time.Wednesday,
+		"thursday": time.Thursday, "jeudi": time.Thursday,
+		"friday": time.Friday, "vendredi": time.Friday,
+		"saturday": time.Saturday, "samedi": time.Saturday,
+	}
+	if day, ok := names[value]; ok {
+		return day
+	}
+	return defaultValue
+}
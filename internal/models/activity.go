@@ -0,0 +1,17 @@
+package models
+
+// Fenêtres d'activité: période sur laquelle le volume personnel est cumulé
+const (
+	ActivityWindowLifetime = "lifetime" // Depuis l'inscription: actif pour toujours une fois le minimum atteint
+	ActivityWindowRolling  = "rolling"  // Les WindowDays derniers jours
+	ActivityWindowMonth    = "month"    // Le mois calendaire: actif jusqu'à la fin du mois où le minimum est atteint
+)
+
+// ActivityRule définit ce qu'est un membre actif: au moins MinPoints de volume personnel
+// confirmé (ventes payées) dans la fenêtre. Avec MinPoints = 0, une vente payée suffit.
+// La date jusqu'à laquelle un membre reste actif est stockée sur le client (ActiveUntil).
+type ActivityRule struct {
+	MinPoints  Volume `bson:"minPoints" json:"minPoints"`
+	Window     string `bson:"window" json:"window"`
+	WindowDays int    `bson:"windowDays" json:"windowDays"` // Longueur de la fenêtre glissante
+}
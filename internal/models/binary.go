@@ -6,23 +6,33 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// Moteurs de commission binaire disponibles
+const (
+	BinaryEngineCycle  = "cycle"  // Cycles plafonnés (binary-cycle)
+	BinaryEngineLegacy = "legacy" // Ancien appariement au seuil (binary-match), conservé jusqu'à la migration
+)
+
 // BinaryConfig représente la configuration du système binaire MLM
 type BinaryConfig struct {
-	CycleValue         float64 `bson:"cycleValue" json:"cycleValue"`                 // Valeur d'un cycle en $ (ex: 20$)
-	CommissionRate     float64 `bson:"commissionRate" json:"commissionRate"`         // Taux de commission (ex: 0.10)
-	DailyCycleLimit    int     `bson:"dailyCycleLimit" json:"dailyCycleLimit"`       // Limite de cycles par jour (ex: 4)
-	WeeklyCycleLimit   int     `bson:"weeklyCycleLimit" json:"weeklyCycleLimit"`     // Limite de cycles par semaine (optionnel)
-	MinVolumePerLeg    float64 `bson:"minVolumePerLeg" json:"minVolumePerLeg"`       // Volume minimum par jambe pour être payé
-	RequireDirectLeft  bool    `bson:"requireDirectLeft" json:"requireDirectLeft"`   // Requiert 1 direct actif à gauche
-	RequireDirectRight bool    `bson:"requireDirectRight" json:"requireDirectRight"` // Requiert 1 direct actif à droite
+	Engine             string       `bson:"engine" json:"engine"`                                   // Moteur utilisé: BinaryEngineCycle (défaut) ou BinaryEngineLegacy
+	Threshold          Volume       `bson:"threshold" json:"threshold"`                             // Seuil par jambe du moteur legacy (ex: 100)
+	CycleValue         Money        `bson:"cycleValue" json:"cycleValue"`                           // Montant payé par cycle en $ (0 = volume utilisé × CommissionRate)
+	CommissionRate     float64      `bson:"commissionRate" json:"commissionRate"`                   // Taux de commission (ex: 0.10)
+	DailyCycleLimit    int          `bson:"dailyCycleLimit" json:"dailyCycleLimit"`                 // Limite de cycles par jour (ex: 4)
+	WeeklyCycleLimit   int          `bson:"weeklyCycleLimit" json:"weeklyCycleLimit"`               // Limite de cycles par semaine (optionnel)
+	WeekStartDay       time.Weekday `bson:"weekStartDay" json:"weekStartDay"`                       // Premier jour de la semaine de capping (ex: time.Monday)
+	MinVolumePerLeg    Volume       `bson:"minVolumePerLeg" json:"minVolumePerLeg"`                 // Volume minimum par jambe pour être payé
+	RequireDirectLeft  bool         `bson:"requireDirectLeft" json:"requireDirectLeft"`             // Requiert 1 direct actif à gauche
+	RequireDirectRight bool         `bson:"requireDirectRight" json:"requireDirectRight"`           // Requiert 1 direct actif à droite
+	MatchingBonusRates []float64    `bson:"matchingBonusRates,omitempty" json:"matchingBonusRates"` // Bonus de matching par génération de parrainage (ex: [0.10, 0.05])
 }
 
 // BinaryLegs représente les jambes gauche et droite d'un membre
 type BinaryLegs struct {
-	LeftVolume   float64 `bson:"leftVolume" json:"leftVolume"`     // Volume total de la jambe gauche
-	RightVolume  float64 `bson:"rightVolume" json:"rightVolume"`   // Volume total de la jambe droite
-	LeftActives  int     `bson:"leftActives" json:"leftActives"`   // Nombre d'actifs à gauche
-	RightActives int     `bson:"rightActives" json:"rightActives"` // Nombre d'actifs à droite
+	LeftVolume   Volume `bson:"leftVolume" json:"leftVolume"`     // Volume total de la jambe gauche
+	RightVolume  Volume `bson:"rightVolume" json:"rightVolume"`   // Volume total de la jambe droite
+	LeftActives  int    `bson:"leftActives" json:"leftActives"`   // Nombre d'actifs à gauche
+	RightActives int    `bson:"rightActives" json:"rightActives"` // Nombre d'actifs à droite
 }
 
 // BinaryQualification représente la qualification d'un membre pour recevoir des commissions
@@ -36,14 +46,21 @@ type BinaryQualification struct {
 
 // BinaryCycle représente un cycle calculé et payé
 type BinaryCycle struct {
-	ID              primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-	ClientID        primitive.ObjectID `bson:"clientId" json:"clientId"`
-	Cycles          int                `bson:"cycles" json:"cycles"`                   // Nombre de cycles payés
-	Amount          float64            `bson:"amount" json:"amount"`                   // Montant gagné
-	LeftVolumeUsed  float64            `bson:"leftVolumeUsed" json:"leftVolumeUsed"`   // Volume gauche utilisé
-	RightVolumeUsed float64            `bson:"rightVolumeUsed" json:"rightVolumeUsed"` // Volume droite utilisé
-	Date            time.Time          `bson:"date" json:"date"`                       // Date du calcul
-	ProcessedAt     time.Time          `bson:"processedAt" json:"processedAt"`         // Date de traitement
+	ID                primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	ClientID          primitive.ObjectID `bson:"clientId" json:"clientId"`
+	CommissionID      primitive.ObjectID `bson:"commissionId" json:"commissionId"`           // Commission binary-cycle associée
+	CyclesAvailable   int                `bson:"cyclesAvailable" json:"cyclesAvailable"`     // Cycles possibles avant limite
+	Cycles            int                `bson:"cycles" json:"cycles"`                       // Nombre de cycles payés
+	Amount            Money              `bson:"amount" json:"amount"`                       // Montant gagné
+	LeftVolumeBefore  Volume             `bson:"leftVolumeBefore" json:"leftVolumeBefore"`   // Volume gauche avant paiement
+	RightVolumeBefore Volume             `bson:"rightVolumeBefore" json:"rightVolumeBefore"` // Volume droite avant paiement
+	LeftVolumeUsed    Volume             `bson:"leftVolumeUsed" json:"leftVolumeUsed"`       // Volume gauche utilisé
+	RightVolumeUsed   Volume             `bson:"rightVolumeUsed" json:"rightVolumeUsed"`     // Volume droite utilisé
+	Date              time.Time          `bson:"date" json:"date"`                           // Date du calcul
+	ProcessedAt       time.Time          `bson:"processedAt" json:"processedAt"`             // Date de traitement
+	PlanVersion       int                `bson:"planVersion,omitempty" json:"planVersion"`   // Version du plan de rémunération utilisée
+	// Volume apparié repris après l'annulation de ventes (voir ClawbackService)
+	ReversedVolume Volume `bson:"reversedVolume,omitempty" json:"reversedVolume"`
 }
 
 // BinaryCapping représente les limites journalières/hebdomadaires d'un membre
@@ -59,15 +76,22 @@ type BinaryCapping struct {
 
 // BinaryCommissionResult représente le résultat du calcul de commission binaire
 type BinaryCommissionResult struct {
-	Success              bool    `json:"success"`
-	Qualified            bool    `json:"qualified"`
-	CyclesAvailable      int     `json:"cyclesAvailable"`        // Cycles possibles avant limite
-	CyclesPaid           int     `json:"cyclesPaid"`             // Cycles effectivement payés (après limite)
-	Amount               float64 `json:"amount"`                 // Montant gagné
-	LeftVolumeRemaining  float64 `json:"leftVolumeRemaining"`    // Volume gauche restant
-	RightVolumeRemaining float64 `json:"rightVolumeRemaining"`   // Volume droite restant
-	Reason               string  `json:"reason"`                 // Raison si gain = 0
-	CommissionID         *string `json:"commissionId,omitempty"` // ID de la commission créée
+	Success              bool                 `json:"success"`
+	Qualified            bool                 `json:"qualified"`
+	DryRun               bool                 `json:"dryRun"`                  // Simulation: rien n'a été écrit
+	PlanVersion          int                  `json:"planVersion"`             // Version du plan de rémunération appliquée
+	Qualification        *BinaryQualification `json:"qualification,omitempty"` // Détail des directs actifs
+	Legs                 *BinaryLegs          `json:"legs,omitempty"`          // Volumes et actifs des jambes avant paiement
+	CyclesAvailable      int                  `json:"cyclesAvailable"`         // Cycles possibles avant limite
+	CyclesPaid           int                  `json:"cyclesPaid"`              // Cycles effectivement payés (après limite), ou qui le seraient en simulation
+	CyclesCapped         int                  `json:"cyclesCapped"`            // Cycles retenus par les limites journalière/hebdomadaire
+	CyclesPaidToday      int                  `json:"cyclesPaidToday"`         // Cycles déjà payés aujourd'hui avant ce calcul
+	CyclesPaidThisWeek   int                  `json:"cyclesPaidThisWeek"`      // Cycles déjà payés cette semaine avant ce calcul
+	Amount               Money                `json:"amount"`                  // Montant gagné
+	LeftVolumeRemaining  Volume               `json:"leftVolumeRemaining"`     // Volume gauche restant
+	RightVolumeRemaining Volume               `json:"rightVolumeRemaining"`    // Volume droite restant
+	Reason               string               `json:"reason"`                  // Raison si gain = 0
+	CommissionID         *string              `json:"commissionId,omitempty"`  // ID de la commission créée
 }
 
 // BinaryNode représente un nœud dans l'arbre binaire (pour calculs récursifs)
@@ -75,17 +99,50 @@ type BinaryNode struct {
 	ClientID     primitive.ObjectID  `bson:"clientId" json:"clientId"`
 	LeftChildID  *primitive.ObjectID `bson:"leftChildId,omitempty" json:"leftChildId,omitempty"`
 	RightChildID *primitive.ObjectID `bson:"rightChildId,omitempty" json:"rightChildId,omitempty"`
-	IsActive     bool                `bson:"isActive" json:"isActive"` // Un membre est actif s'il a fait au moins 1 vente
+	IsActive     bool                `bson:"isActive" json:"isActive"` // Période d'activité en cours (voir ActivityRule)
 }
 
+// Statuts d'une exécution batch des commissions binaires
+const (
+	BinaryRunStatusRunning   = "running"
+	BinaryRunStatusCompleted = "completed"
+	BinaryRunStatusFailed    = "failed"
+)
 
+// BinaryCommissionRun représente une exécution batch du calcul binaire sur tout le réseau
+type BinaryCommissionRun struct {
+	ID               primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Period           string             `bson:"period" json:"period"` // Période de capping couverte (jour, format 2006-01-02)
+	Status           string             `bson:"status" json:"status"` // running, completed, failed
+	StartedAt        time.Time          `bson:"startedAt" json:"startedAt"`
+	FinishedAt       *time.Time         `bson:"finishedAt,omitempty" json:"finishedAt,omitempty"`
+	ClientsTotal     int                `bson:"clientsTotal" json:"clientsTotal"`         // Clients à traiter
+	ClientsProcessed int                `bson:"clientsProcessed" json:"clientsProcessed"` // Clients traités (payés ou non)
+	ClientsSkipped   int                `bson:"clientsSkipped" json:"clientsSkipped"`     // Clients déjà payés pour la période
+	ClientsPaid      int                `bson:"clientsPaid" json:"clientsPaid"`           // Clients ayant reçu une commission
+	CyclesPaid       int                `bson:"cyclesPaid" json:"cyclesPaid"`
+	TotalPaid        Money              `bson:"totalPaid" json:"totalPaid"`
+	Errors           []BinaryRunError   `bson:"errors" json:"errors"`
+}
 
+// BinaryRunError représente l'erreur rencontrée pour un client lors d'une exécution batch
+type BinaryRunError struct {
+	ClientID primitive.ObjectID `bson:"clientId" json:"clientId"`
+	Error    string             `bson:"error" json:"error"`
+	Date     time.Time          `bson:"date" json:"date"`
+}
 
-
-
-
-
-
-
-
-
+// BinaryRunClaim garantit qu'un client n'est payé qu'une fois par période lors des exécutions batch
+// L'ID est dérivé de la période et du client pour que Mongo rejette un second paiement.
+// Tant qu'elle n'a pas de commission, la réservation n'est tenue que jusqu'à ExpiresAt: une
+// exécution interrompue entre la réservation et le paiement ne bloque pas le client pour la période.
+type BinaryRunClaim struct {
+	ID           string              `bson:"_id" json:"id"` // <période>:<clientId>
+	ClientID     primitive.ObjectID  `bson:"clientId" json:"clientId"`
+	Period       string              `bson:"period" json:"period"`
+	RunID        primitive.ObjectID  `bson:"runId" json:"runId"`
+	CommissionID *primitive.ObjectID `bson:"commissionId,omitempty" json:"commissionId,omitempty"`
+	Amount       Money               `bson:"amount" json:"amount"`
+	CreatedAt    time.Time           `bson:"createdAt" json:"createdAt"`
+	ExpiresAt    *time.Time          `bson:"expiresAt,omitempty" json:"expiresAt,omitempty"` // Fin du bail du calcul en cours (effacée au paiement)
+}
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Motifs de reprise d'une vente
+const (
+	ClawbackReasonCancelled = "cancelled" // Vente passée au statut annulé
+	ClawbackReasonDeleted   = "deleted"   // Vente supprimée
+)
+
+// Clawback enregistre la reprise de tous les effets d'une vente annulée ou supprimée.
+// Une vente n'est reprise qu'une fois (index unique sur saleId).
+type Clawback struct {
+	ID                  primitive.ObjectID   `bson:"_id,omitempty" json:"id"`
+	SaleID              primitive.ObjectID   `bson:"saleId" json:"saleId"`                                               // Vente d'origine
+	ClientID            primitive.ObjectID   `bson:"clientId" json:"clientId"`                                           // Acheteur
+	Reason              string               `bson:"reason" json:"reason"`                                               // ClawbackReasonCancelled ou ClawbackReasonDeleted
+	SaleStatus          string               `bson:"saleStatus" json:"saleStatus"`                                       // Statut de la vente avant la reprise
+	Volume              Volume               `bson:"volume" json:"volume"`                                               // Volume retiré de l'acheteur et de son upline
+	PairedVolume        Volume               `bson:"pairedVolume" json:"pairedVolume"`                                   // Part du volume déjà appariée par des cycles payés
+	StockRestored       int                  `bson:"stockRestored" json:"stockRestored"`                                 // Quantité remise en stock
+	CaisseAmount        Money                `bson:"caisseAmount" json:"caisseAmount"`                                   // Montant rendu par la caisse (sortie)
+	CaisseTransactionID *primitive.ObjectID  `bson:"caisseTransactionId,omitempty" json:"caisseTransactionId,omitempty"` // Sortie de caisse de la reprise
+	Commissions         []ClawbackCommission `bson:"commissions" json:"commissions"`                                     // Commissions reprises
+	TotalAmount         Money                `bson:"totalAmount" json:"totalAmount"`                                     // Total des commissions reprises
+	CreatedAt           time.Time            `bson:"createdAt" json:"createdAt"`
+}
+
+// ClawbackCommission décrit la reprise (totale ou partielle) d'une commission issue de la vente
+type ClawbackCommission struct {
+	CommissionID primitive.ObjectID `bson:"commissionId" json:"commissionId"` // Commission d'origine
+	ReversalID   primitive.ObjectID `bson:"reversalId" json:"reversalId"`     // Commission négative de type "clawback"
+	ClientID     primitive.ObjectID `bson:"clientId" json:"clientId"`         // Bénéficiaire débité
+	Type         string             `bson:"type" json:"type"`                 // Type de la commission d'origine
+	Amount       Money              `bson:"amount" json:"amount"`             // Montant repris (positif)
+}
@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Statuts d'une version du plan de rémunération
+const (
+	CompPlanStatusDraft  = "draft"  // Modifiable, jamais utilisée pour un calcul
+	CompPlanStatusActive = "active" // Utilisée à partir de EffectiveFrom
+)
+
+// CompPlanVersion est une version du plan de rémunération
+// Le moteur binaire utilise la version active la plus récente dont EffectiveFrom est passée;
+// chaque commission garde le numéro de version qui l'a produite.
+type CompPlanVersion struct {
+	ID            primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	Version       int                 `bson:"version" json:"version"` // Numéro croissant, 0 = configuration d'environnement
+	Status        string              `bson:"status" json:"status"`
+	Notes         string              `bson:"notes,omitempty" json:"notes,omitempty"`
+	Binary        BinaryConfig        `bson:"binary" json:"binary"`
+	EffectiveFrom *time.Time          `bson:"effectiveFrom,omitempty" json:"effectiveFrom,omitempty"` // Fixée à l'activation si absente
+	CreatedBy     *primitive.ObjectID `bson:"createdBy,omitempty" json:"createdBy,omitempty"`
+	CreatedAt     time.Time           `bson:"createdAt" json:"createdAt"`
+	ActivatedBy   *primitive.ObjectID `bson:"activatedBy,omitempty" json:"activatedBy,omitempty"`
+	ActivatedAt   *time.Time          `bson:"activatedAt,omitempty" json:"activatedAt,omitempty"`
+}
@@ -0,0 +1,13 @@
+package models
+
+// FastStartBonusRule définit le bonus de démarrage rapide versé au parrain lors du premier
+// achat payé d'un membre qu'il a inscrit
+type FastStartBonusRule struct {
+	Rate   float64 `bson:"rate" json:"rate"`     // Pourcentage du montant de la vente (ex: 0.20)
+	Amount Money   `bson:"amount" json:"amount"` // Montant fixe; prioritaire sur Rate s'il est positif
+}
+
+// Enabled indique si la règle verse un bonus
+func (r FastStartBonusRule) Enabled() bool {
+	return r.Rate > 0 || r.Amount > 0
+}
@@ -11,9 +11,9 @@ type Product struct {
 	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
 	Name        string             `bson:"name" json:"name"`
 	Description string             `bson:"description" json:"description"`
-	Price       float64            `bson:"price" json:"price"`
+	Price       Money              `bson:"price" json:"price"`
 	Stock       int                `bson:"stock" json:"stock"`
-	Points      float64            `bson:"points" json:"points"`
+	Points      Volume             `bson:"points" json:"points"`
 	ImageURL    string             `bson:"imageUrl" json:"imageUrl"`
 	CreatedAt   time.Time          `bson:"createdAt" json:"createdAt"`
 	UpdatedAt   time.Time          `bson:"updatedAt" json:"updatedAt"`
@@ -29,17 +29,42 @@ type Client struct {
 	NN                 *string             `bson:"nn,omitempty" json:"nn,omitempty"`
 	Address            *string             `bson:"address,omitempty" json:"address,omitempty"`
 	Avatar             *string             `bson:"avatar,omitempty" json:"avatar,omitempty"`
-	SponsorID          *primitive.ObjectID `bson:"sponsorId,omitempty" json:"sponsorId"`
-	Position           *string             `bson:"position,omitempty" json:"position"` // "left" or "right"
+	SponsorID          *primitive.ObjectID `bson:"sponsorId,omitempty" json:"sponsorId"` // Parrain: membre qui a inscrit le client (matching, démarrage rapide)
+	PlacementParentID  *primitive.ObjectID `bson:"placementParentId,omitempty" json:"placementParentId"` // Parent dans l'arbre binaire (commissions binaires)
+	Position           *string             `bson:"position,omitempty" json:"position"` // "left" or "right" sous le parent de placement
 	LeftChildID        *primitive.ObjectID `bson:"leftChildId,omitempty" json:"leftChildId"`
 	RightChildID       *primitive.ObjectID `bson:"rightChildId,omitempty" json:"rightChildId"`
 	JoinDate           time.Time           `bson:"joinDate" json:"joinDate"`
-	TotalEarnings      float64             `bson:"totalEarnings" json:"totalEarnings"`
-	WalletBalance      float64             `bson:"walletBalance" json:"walletBalance"`
-	Points             float64             `bson:"points" json:"points"`
-	NetworkVolumeLeft  float64             `bson:"networkVolumeLeft" json:"networkVolumeLeft"`
-	NetworkVolumeRight float64             `bson:"networkVolumeRight" json:"networkVolumeRight"`
+	TotalEarnings      Money               `bson:"totalEarnings" json:"totalEarnings"`
+	WalletBalance      Money               `bson:"walletBalance" json:"walletBalance"`
+	Points             Volume              `bson:"points" json:"points"`
+	NetworkVolumeLeft  Volume              `bson:"networkVolumeLeft" json:"networkVolumeLeft"`
+	NetworkVolumeRight Volume              `bson:"networkVolumeRight" json:"networkVolumeRight"`
 	BinaryPairs        int                 `bson:"binaryPairs" json:"binaryPairs"`
+	// Volume des ventes non encore payées: il devient confirmé (Points / NetworkVolume*) au paiement
+	PendingPoints      Volume `bson:"pendingPoints" json:"pendingPoints"`
+	PendingVolumeLeft  Volume `bson:"pendingVolumeLeft" json:"pendingVolumeLeft"`
+	PendingVolumeRight Volume `bson:"pendingVolumeRight" json:"pendingVolumeRight"`
+	// Fin de la période d'activité selon la règle d'activité (nil = jamais actif)
+	ActiveUntil *time.Time `bson:"activeUntil,omitempty" json:"activeUntil,omitempty"`
+	// Membres et membres actifs de chaque jambe, tenus à jour à l'inscription, au changement
+	// d'activité et à la suppression (recalculés par cmd/legcounts)
+	LeftMembers  int `bson:"leftMembers" json:"leftMembers"`
+	RightMembers int `bson:"rightMembers" json:"rightMembers"`
+	LeftActives  int `bson:"leftActives" json:"leftActives"`
+	RightActives int `bson:"rightActives" json:"rightActives"`
+	// Indique si le membre est compté dans les actifs de ses ancêtres
+	CountedActive bool `bson:"countedActive" json:"countedActive"`
+	// Code du rang obtenu à la dernière évaluation et du meilleur rang jamais atteint (vide = aucun)
+	Rank        string `bson:"rank,omitempty" json:"rank,omitempty"`
+	HighestRank string `bson:"highestRank,omitempty" json:"highestRank,omitempty"`
+	// Date du bonus de démarrage rapide versé au parrain pour le premier achat payé (nil = pas encore versé)
+	FastStartBonusPaidAt *time.Time `bson:"fastStartBonusPaidAt,omitempty" json:"fastStartBonusPaidAt,omitempty"`
+	// Salle d'attente: date limite de placement par le parrain (nil = membre placé ou racine)
+	HoldingTankUntil *time.Time `bson:"holdingTankUntil,omitempty" json:"holdingTankUntil,omitempty"`
+	// Volume confirmé et en attente mis en file pendant la salle d'attente, crédité à l'upline au placement
+	QueuedVolume        Volume `bson:"queuedVolume,omitempty" json:"queuedVolume,omitempty"`
+	QueuedPendingVolume Volume `bson:"queuedPendingVolume,omitempty" json:"queuedPendingVolume,omitempty"`
 }
 
 // Sale represents a sale in the MLM system
@@ -47,9 +72,10 @@ type Sale struct {
 	ID         primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
 	ClientID   primitive.ObjectID  `bson:"clientId" json:"clientId"`
 	ProductID  *primitive.ObjectID `bson:"productId,omitempty" json:"productId"`
-	Amount     float64             `bson:"amount" json:"amount"`
-	PaidAmount *float64            `bson:"paidAmount,omitempty" json:"paidAmount,omitempty"`
+	Amount     Money               `bson:"amount" json:"amount"`
+	PaidAmount *Money              `bson:"paidAmount,omitempty" json:"paidAmount,omitempty"`
 	Quantity   int                 `bson:"quantity" json:"quantity"`
+	Points     Volume              `bson:"points" json:"points"`       // Volume porté par la vente (points produit × quantité)
 	Side       *string             `bson:"side,omitempty" json:"side"` // "left" or "right"
 	Date       time.Time           `bson:"date" json:"date"`
 	Status     string              `bson:"status" json:"status"` // "paid", "pending", "partial", "cancelled"
@@ -60,7 +86,7 @@ type Sale struct {
 type Payment struct {
 	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
 	ClientID    primitive.ObjectID `bson:"clientId" json:"clientId"`
-	Amount      float64            `bson:"amount" json:"amount"`
+	Amount      Money              `bson:"amount" json:"amount"`
 	Date        time.Time          `bson:"date" json:"date"`
 	Method      string             `bson:"method" json:"method"` // 'mobile-money', 'cash', 'bank', etc.
 	Status      string             `bson:"status" json:"status"` // "completed", "pending", "failed"
@@ -72,10 +98,16 @@ type Commission struct {
 	ID             primitive.ObjectID `bson:"_id,omitempty" json:"id"`
 	ClientID       primitive.ObjectID `bson:"clientId" json:"clientId"`
 	SourceClientID primitive.ObjectID `bson:"sourceClientId" json:"sourceClientId"`
-	Amount         float64            `bson:"amount" json:"amount"`
+	Amount         Money              `bson:"amount" json:"amount"`
 	Level          int                `bson:"level" json:"level"`
 	Type           string             `bson:"type" json:"type"` // "binary-match", "override", etc.
 	Date           time.Time          `bson:"date" json:"date"`
+	PlanVersion    int                `bson:"planVersion,omitempty" json:"planVersion,omitempty"` // Version du plan de rémunération utilisée (0 = configuration d'environnement)
+	// Vente d'origine (bonus de démarrage rapide, reprise) et commission d'origine (matching, reprise)
+	SaleID             *primitive.ObjectID `bson:"saleId,omitempty" json:"saleId,omitempty"`
+	SourceCommissionID *primitive.ObjectID `bson:"sourceCommissionId,omitempty" json:"sourceCommissionId,omitempty"`
+	// Période de paie clôturée qui a figé la commission
+	PeriodID *primitive.ObjectID `bson:"periodId,omitempty" json:"periodId,omitempty"`
 }
 
 // Admin represents an admin user
@@ -91,8 +123,8 @@ type Admin struct {
 // DashboardStats represents dashboard statistics
 type DashboardStats struct {
 	TotalClients     int     `json:"totalClients"`
-	TotalSales       float64 `json:"totalSales"`
-	TotalCommissions float64 `json:"totalCommissions"`
+	TotalSales       Money   `json:"totalSales"`
+	TotalCommissions Money   `json:"totalCommissions"`
 	TotalProducts    int     `json:"totalProducts"`
 	ActiveClients    int     `json:"activeClients"`
 }
@@ -107,16 +139,16 @@ type AuthPayload struct {
 // CommissionResult represents the result of commission calculation
 type CommissionResult struct {
 	CommissionsCreated int     `json:"commissionsCreated"`
-	TotalAmount        float64 `json:"totalAmount"`
+	TotalAmount        Money   `json:"totalAmount"`
 	Message            string  `json:"message"`
 }
 
 // Caisse represents the company's cash register/treasury
 type Caisse struct {
 	ID           primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-	Balance      float64            `bson:"balance" json:"balance"`
-	TotalEntrees float64            `bson:"totalEntrees" json:"totalEntrees"`
-	TotalSorties float64            `bson:"totalSorties" json:"totalSorties"`
+	Balance      Money              `bson:"balance" json:"balance"`
+	TotalEntrees Money              `bson:"totalEntrees" json:"totalEntrees"`
+	TotalSorties Money              `bson:"totalSorties" json:"totalSorties"`
 	CreatedAt    time.Time          `bson:"createdAt" json:"createdAt"`
 	UpdatedAt    time.Time          `bson:"updatedAt" json:"updatedAt"`
 }
@@ -125,7 +157,7 @@ type Caisse struct {
 type CaisseTransaction struct {
 	ID            primitive.ObjectID `bson:"_id,omitempty" json:"id"`
 	Type          string             `bson:"type" json:"type"` // "entree" or "sortie"
-	Amount        float64            `bson:"amount" json:"amount"`
+	Amount        Money              `bson:"amount" json:"amount"`
 	Description   *string            `bson:"description,omitempty" json:"description,omitempty"`
 	Reference     *string            `bson:"reference,omitempty" json:"reference,omitempty"`         // ID of sale or payment
 	ReferenceType *string            `bson:"referenceType,omitempty" json:"referenceType,omitempty"` // "sale", "payment", "manual"
@@ -133,6 +165,18 @@ type CaisseTransaction struct {
 	CreatedBy     *string            `bson:"createdBy,omitempty" json:"createdBy,omitempty"`
 }
 
+// CaisseDailyReport summarizes the caisse transactions of one business day [Start, End)
+type CaisseDailyReport struct {
+	Date             string               `json:"date"` // "2006-01-02" in the business timezone
+	Start            time.Time            `json:"start"`
+	End              time.Time            `json:"end"`
+	TotalEntrees     Money                `json:"totalEntrees"`
+	TotalSorties     Money                `json:"totalSorties"`
+	Net              Money                `json:"net"`
+	TransactionCount int                  `json:"transactionCount"`
+	Transactions     []*CaisseTransaction `json:"transactions"`
+}
+
 // FilterInput represents filtering options for queries
 type FilterInput struct {
 	Search   *string    `json:"search,omitempty"`
@@ -151,9 +195,9 @@ type PagingInput struct {
 type ProductInput struct {
 	Name        string  `json:"name"`
 	Description string  `json:"description"`
-	Price       float64 `json:"price"`
+	Price       Money   `json:"price"`
 	Stock       int     `json:"stock"`
-	Points      float64 `json:"points"`
+	Points      Volume  `json:"points"`
 	ImageURL    string  `json:"imageUrl"`
 }
 
@@ -172,15 +216,15 @@ type ClientInput struct {
 type SaleInput struct {
 	ClientID   string   `json:"clientId"`
 	ProductID  *string  `json:"productId,omitempty"`
-	Amount     float64 `json:"amount"`
-	PaidAmount *float64 `json:"paidAmount,omitempty"`
+	Amount     Money   `json:"amount"`
+	PaidAmount *Money   `json:"paidAmount,omitempty"`
 	Note       *string  `json:"note,omitempty"`
 }
 
 // PaymentInput represents input for creating payments
 type PaymentInput struct {
 	ClientID    string  `json:"clientId"`
-	Amount      float64 `json:"amount"`
+	Amount      Money   `json:"amount"`
 	Method      string  `json:"method"`
 	Description *string `json:"description,omitempty"`
 }
@@ -189,7 +233,7 @@ type PaymentInput struct {
 type CommissionInput struct {
 	ClientID       string  `json:"clientId"`
 	SourceClientID string  `json:"sourceClientId"`
-	Amount         float64 `json:"amount"`
+	Amount         Money   `json:"amount"`
 	Level          int     `json:"level"`
 	Type           string  `json:"type"`
 }
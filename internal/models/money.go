@@ -0,0 +1,277 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/x/bsonx/bsoncore"
+)
+
+// Money est un montant exact en centimes. Les montants s'additionnent, se soustraient et se
+// comparent en entiers; seule la multiplication par un taux arrondit, une fois, au centime
+// le plus proche (demi-centime arrondi en s'éloignant de zéro).
+//
+// En base, un montant est enregistré en Decimal128 ("12.34") pour que les agrégations Mongo
+// restent exactes. Les documents antérieurs (double) sont lus et arrondis au centime;
+// cmd/moneymigrate les convertit.
+type Money int64
+
+// Volume est un volume de points exact en centièmes de point, enregistré comme Money
+type Volume int64
+
+// NewMoney convertit un montant décimal (configuration, ancien document) au centime le plus proche
+func NewMoney(amount float64) Money {
+	return Money(hundredthsFromFloat(amount))
+}
+
+// ParseMoney lit un montant décimal exact ("12.34", "-5", "1e3"); plus de deux décimales est une erreur
+func ParseMoney(s string) (Money, error) {
+	v, err := parseHundredths(s)
+	if err != nil {
+		return 0, fmt.Errorf("montant invalide %q: %w", s, err)
+	}
+	return Money(v), nil
+}
+
+// Cents retourne le montant en centimes
+func (m Money) Cents() int64 {
+	return int64(m)
+}
+
+// Float64 retourne le montant en unités, pour l'affichage et les calculs de ratio
+func (m Money) Float64() float64 {
+	return float64(m) / 100
+}
+
+// String formate le montant avec deux décimales ("-12.30")
+func (m Money) String() string {
+	return formatHundredths(int64(m))
+}
+
+// Mul retourne le montant multiplié par rate, arrondi au centime
+func (m Money) Mul(rate float64) Money {
+	return Money(mulHundredths(int64(m), rate))
+}
+
+// MarshalBSONValue enregistre le montant en Decimal128
+func (m Money) MarshalBSONValue() (bsontype.Type, []byte, error) {
+	return marshalHundredths(int64(m))
+}
+
+// UnmarshalBSONValue lit un Decimal128, ou un double ou entier d'un document antérieur
+func (m *Money) UnmarshalBSONValue(t bsontype.Type, data []byte) error {
+	v, err := unmarshalHundredths(t, data)
+	if err != nil {
+		return fmt.Errorf("montant: %w", err)
+	}
+	*m = Money(v)
+	return nil
+}
+
+// MarshalJSON écrit le montant comme un nombre à deux décimales
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// UnmarshalJSON lit un nombre ou une chaîne
+func (m *Money) UnmarshalJSON(data []byte) error {
+	parsed, err := ParseMoney(strings.Trim(string(data), `"`))
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// MarshalGQL écrit le scalaire GraphQL Money: un nombre JSON à deux décimales
+func (m Money) MarshalGQL(w io.Writer) {
+	_, _ = io.WriteString(w, m.String())
+}
+
+// UnmarshalGQL lit le scalaire GraphQL Money: un nombre ou une chaîne décimale, au centime
+func (m *Money) UnmarshalGQL(v any) error {
+	var s string
+	switch value := v.(type) {
+	case string:
+		s = value
+	case json.Number:
+		s = value.String()
+	case int:
+		s = strconv.Itoa(value)
+	case int32:
+		s = strconv.FormatInt(int64(value), 10)
+	case int64:
+		s = strconv.FormatInt(value, 10)
+	case float64:
+		s = strconv.FormatFloat(value, 'f', -1, 64)
+	default:
+		return fmt.Errorf("montant invalide: %T", v)
+	}
+	parsed, err := ParseMoney(s)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// NewVolume convertit un volume décimal au centième de point le plus proche
+func NewVolume(points float64) Volume {
+	return Volume(hundredthsFromFloat(points))
+}
+
+// Float64 retourne le volume en points
+func (v Volume) Float64() float64 {
+	return float64(v) / 100
+}
+
+// String formate le volume avec deux décimales
+func (v Volume) String() string {
+	return formatHundredths(int64(v))
+}
+
+// MoneyAt retourne la valeur du volume à rate, un point valant une unité monétaire
+func (v Volume) MoneyAt(rate float64) Money {
+	return Money(mulHundredths(int64(v), rate))
+}
+
+// MarshalBSONValue enregistre le volume en Decimal128
+func (v Volume) MarshalBSONValue() (bsontype.Type, []byte, error) {
+	return marshalHundredths(int64(v))
+}
+
+// UnmarshalBSONValue lit un Decimal128, ou un double ou entier d'un document antérieur
+func (v *Volume) UnmarshalBSONValue(t bsontype.Type, data []byte) error {
+	parsed, err := unmarshalHundredths(t, data)
+	if err != nil {
+		return fmt.Errorf("volume: %w", err)
+	}
+	*v = Volume(parsed)
+	return nil
+}
+
+// MarshalJSON écrit le volume comme un nombre à deux décimales
+func (v Volume) MarshalJSON() ([]byte, error) {
+	return []byte(v.String()), nil
+}
+
+// UnmarshalJSON lit un nombre ou une chaîne
+func (v *Volume) UnmarshalJSON(data []byte) error {
+	parsed, err := parseHundredths(strings.Trim(string(data), `"`))
+	if err != nil {
+		return fmt.Errorf("volume invalide: %w", err)
+	}
+	*v = Volume(parsed)
+	return nil
+}
+
+// Money et Volume partagent la même représentation: un entier de centièmes
+
+var hundred = big.NewRat(100, 1)
+
+// roundRat arrondit à l'entier le plus proche, la moitié en s'éloignant de zéro
+func roundRat(r *big.Rat) int64 {
+	num := new(big.Int).Abs(r.Num())
+	q, rem := new(big.Int).QuoRem(num, r.Denom(), new(big.Int))
+	if rem.Lsh(rem, 1).Cmp(r.Denom()) >= 0 {
+		q.Add(q, big.NewInt(1))
+	}
+	if r.Sign() < 0 {
+		q.Neg(q)
+	}
+	return q.Int64()
+}
+
+// decimalRat retourne la valeur décimale voulue d'un double (sa plus courte représentation:
+// 0.1 et non 0.1000000000000000055...)
+func decimalRat(f float64) *big.Rat {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return new(big.Rat)
+	}
+	r, _ := new(big.Rat).SetString(strconv.FormatFloat(f, 'g', -1, 64))
+	return r
+}
+
+func hundredthsFromFloat(f float64) int64 {
+	r := decimalRat(f)
+	return roundRat(r.Mul(r, hundred))
+}
+
+func mulHundredths(v int64, rate float64) int64 {
+	r := decimalRat(rate)
+	return roundRat(r.Mul(r, new(big.Rat).SetInt64(v)))
+}
+
+func parseHundredths(s string) (int64, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || strings.Contains(s, "/") {
+		return 0, errors.New("nombre décimal attendu")
+	}
+	r, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return 0, errors.New("nombre décimal attendu")
+	}
+	r.Mul(r, hundred)
+	if !r.IsInt() {
+		return 0, errors.New("deux décimales au plus")
+	}
+	if !r.Num().IsInt64() {
+		return 0, errors.New("valeur hors limites")
+	}
+	return r.Num().Int64(), nil
+}
+
+func formatHundredths(v int64) string {
+	sign := ""
+	u := uint64(v)
+	if v < 0 {
+		sign = "-"
+		u = uint64(-v)
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, u/100, u%100)
+}
+
+func marshalHundredths(v int64) (bsontype.Type, []byte, error) {
+	d, ok := primitive.ParseDecimal128FromBigInt(big.NewInt(v), -2)
+	if !ok {
+		return 0, nil, fmt.Errorf("valeur %d hors limites", v)
+	}
+	return bson.TypeDecimal128, bsoncore.AppendDecimal128(nil, d), nil
+}
+
+func unmarshalHundredths(t bsontype.Type, data []byte) (int64, error) {
+	raw := bson.RawValue{Type: t, Value: data}
+	switch t {
+	case bson.TypeDecimal128:
+		bi, exp, err := raw.Decimal128().BigInt()
+		if err != nil {
+			return 0, err
+		}
+		// Valeur = bi × 10^exp, soit bi × 10^(exp+2) centièmes
+		shift := exp + 2
+		r := new(big.Rat).SetInt(bi)
+		scale := new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(max(shift, -shift))), nil))
+		if shift >= 0 {
+			r.Mul(r, scale)
+		} else {
+			r.Quo(r, scale)
+		}
+		return roundRat(r), nil
+	case bson.TypeDouble:
+		return hundredthsFromFloat(raw.Double()), nil
+	case bson.TypeInt32, bson.TypeInt64:
+		return raw.AsInt64() * 100, nil
+	case bson.TypeNull, bson.TypeUndefined:
+		return 0, nil
+	}
+	return 0, fmt.Errorf("type BSON %s inattendu", t)
+}
@@ -0,0 +1,182 @@
+package models
+
+import (
+	"bytes"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    Money
+		wantErr bool
+	}{
+		{"12.34", 1234, false},
+		{"-5", -500, false},
+		{"0.1", 10, false},
+		{"1e3", 100000, false},
+		{" 7.50 ", 750, false},
+		{"1.005", 0, true},
+		{"abc", 0, true},
+		{"1/3", 0, true},
+		{"", 0, true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseMoney(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseMoney(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseMoney(%q) = %d, want %d", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestMoney_String(t *testing.T) {
+	tests := []struct {
+		amount Money
+		want   string
+	}{
+		{0, "0.00"},
+		{5, "0.05"},
+		{1230, "12.30"},
+		{-5, "-0.05"},
+		{-1234, "-12.34"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.amount.String(); got != tt.want {
+			t.Errorf("Money(%d).String() = %q, want %q", tt.amount, got, tt.want)
+		}
+	}
+}
+
+func TestNewMoney(t *testing.T) {
+	// 0.1 + 0.2 vaut 0.30000000000000004 en float64
+	if got := NewMoney(0.1 + 0.2); got != 30 {
+		t.Errorf("Expected 0.30, got %s", got)
+	}
+	// 1.005 est 1.00499999999999989... en float64: la valeur décimale voulue est arrondie
+	if got := NewMoney(1.005); got != 101 {
+		t.Errorf("Expected 1.01, got %s", got)
+	}
+	if got := NewMoney(-2.675); got != -268 {
+		t.Errorf("Expected -2.68, got %s", got)
+	}
+}
+
+func TestMoney_Exact(t *testing.T) {
+	var total Money
+	for i := 0; i < 10; i++ {
+		total += NewMoney(0.1)
+	}
+	if total != NewMoney(1) {
+		t.Errorf("Expected ten times 0.10 to be exactly 1.00, got %s", total)
+	}
+}
+
+func TestMoney_Mul(t *testing.T) {
+	tests := []struct {
+		amount Money
+		rate   float64
+		want   Money
+	}{
+		{NewMoney(100), 0.1, NewMoney(10)},
+		{NewMoney(33.33), 0.1, NewMoney(3.33)},
+		{NewMoney(0.05), 0.1, NewMoney(0.01)},   // 0.005 arrondi en s'éloignant de zéro
+		{NewMoney(-0.05), 0.1, NewMoney(-0.01)}, // idem pour une reprise
+		{NewMoney(19.99), 0.07, NewMoney(1.40)}, // 1.3993
+	}
+
+	for _, tt := range tests {
+		if got := tt.amount.Mul(tt.rate); got != tt.want {
+			t.Errorf("%s × %v = %s, want %s", tt.amount, tt.rate, got, tt.want)
+		}
+	}
+}
+
+func TestVolume_MoneyAt(t *testing.T) {
+	if got := NewVolume(150).MoneyAt(0.1); got != NewMoney(15) {
+		t.Errorf("Expected 15.00, got %s", got)
+	}
+	if got := NewVolume(0.15).MoneyAt(0.1); got != NewMoney(0.02) {
+		t.Errorf("Expected 0.02, got %s", got)
+	}
+}
+
+func TestMoney_BSON(t *testing.T) {
+	type doc struct {
+		Amount Money  `bson:"amount"`
+		Points Volume `bson:"points"`
+	}
+
+	data, err := bson.Marshal(doc{Amount: NewMoney(12.3), Points: NewVolume(50)})
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	raw := bson.Raw(data)
+	if typ := raw.Lookup("amount").Type; typ != bson.TypeDecimal128 {
+		t.Errorf("Expected amount stored as decimal128, got %s", typ)
+	}
+	if got := raw.Lookup("amount").Decimal128().String(); got != "12.30" {
+		t.Errorf("Expected 12.30, got %s", got)
+	}
+
+	var decoded doc
+	if err := bson.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if decoded.Amount != NewMoney(12.3) || decoded.Points != NewVolume(50) {
+		t.Errorf("Round trip changed values: %s, %s", decoded.Amount, decoded.Points)
+	}
+}
+
+func TestMoney_BSONLegacy(t *testing.T) {
+	// Documents enregistrés avant Money: double, entier ou champ nul
+	legacy, err := bson.Marshal(bson.M{"amount": 0.1 + 0.2, "points": int32(40), "paid": nil})
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+
+	var decoded struct {
+		Amount Money  `bson:"amount"`
+		Points Volume `bson:"points"`
+		Paid   Money  `bson:"paid"`
+	}
+	if err := bson.Unmarshal(legacy, &decoded); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if decoded.Amount != NewMoney(0.3) {
+		t.Errorf("Expected 0.30, got %s", decoded.Amount)
+	}
+	if decoded.Points != NewVolume(40) {
+		t.Errorf("Expected 40.00, got %s", decoded.Points)
+	}
+	if decoded.Paid != 0 {
+		t.Errorf("Expected 0.00, got %s", decoded.Paid)
+	}
+}
+
+func TestMoney_GQL(t *testing.T) {
+	var buf bytes.Buffer
+	NewMoney(40).MarshalGQL(&buf)
+	if buf.String() != "40.00" {
+		t.Errorf("Expected 40.00, got %s", buf.String())
+	}
+
+	for _, input := range []any{"12.5", 12.5, int64(12)} {
+		var m Money
+		if err := m.UnmarshalGQL(input); err != nil {
+			t.Errorf("UnmarshalGQL(%v) failed: %v", input, err)
+		}
+	}
+
+	var m Money
+	if err := m.UnmarshalGQL(12.345); err == nil {
+		t.Error("Expected an error for more than two decimals")
+	}
+}
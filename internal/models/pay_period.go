@@ -0,0 +1,78 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Fréquences des périodes de paie
+const (
+	PayPeriodWeekly  = "weekly"  // Semaine commençant au jour WeekStartDay
+	PayPeriodMonthly = "monthly" // Mois calendaire
+)
+
+// Statuts d'une période de paie
+const (
+	PayPeriodStatusOpen   = "open"
+	PayPeriodStatusClosed = "closed"
+)
+
+// PayPeriodRule définit le découpage des commissions en périodes de paie
+type PayPeriodRule struct {
+	Frequency    string       `bson:"frequency" json:"frequency"`       // PayPeriodWeekly ou PayPeriodMonthly
+	WeekStartDay time.Weekday `bson:"weekStartDay" json:"weekStartDay"` // Premier jour d'une période hebdomadaire
+}
+
+// IsPayPeriodFrequency indique si frequency est une fréquence de période connue
+func IsPayPeriodFrequency(frequency string) bool {
+	return frequency == PayPeriodWeekly || frequency == PayPeriodMonthly
+}
+
+// PayPeriod est une période de paie [Start, End). Une fois clôturée, ses commissions sont
+// figées (PeriodID) et aucune commission ne peut plus y être datée.
+type PayPeriod struct {
+	ID               primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	Key              string              `bson:"key" json:"key"` // "2006-01-02" (début de semaine) ou "2006-01" (mois)
+	Frequency        string              `bson:"frequency" json:"frequency"`
+	Start            time.Time           `bson:"start" json:"start"`
+	End              time.Time           `bson:"end" json:"end"` // Exclu
+	Status           string              `bson:"status" json:"status"`
+	ClosedAt         *time.Time          `bson:"closedAt,omitempty" json:"closedAt,omitempty"`
+	ClosedBy         *primitive.ObjectID `bson:"closedBy,omitempty" json:"closedBy,omitempty"` // Admin ayant clôturé
+	MemberCount      int                 `bson:"memberCount" json:"memberCount"`               // Relevés produits à la clôture
+	TotalCommissions Money               `bson:"totalCommissions" json:"totalCommissions"`
+	TotalAdjustments Money               `bson:"totalAdjustments" json:"totalAdjustments"`
+	CreatedAt        time.Time           `bson:"createdAt" json:"createdAt"`
+}
+
+// StatementLine est une commission ou un ajustement d'un relevé
+type StatementLine struct {
+	CommissionID       primitive.ObjectID  `bson:"commissionId" json:"commissionId"`
+	Type               string              `bson:"type" json:"type"`
+	Amount             Money               `bson:"amount" json:"amount"`
+	Level              int                 `bson:"level" json:"level"`
+	SourceClientID     primitive.ObjectID  `bson:"sourceClientId" json:"sourceClientId"`
+	SaleID             *primitive.ObjectID `bson:"saleId,omitempty" json:"saleId,omitempty"`
+	SourceCommissionID *primitive.ObjectID `bson:"sourceCommissionId,omitempty" json:"sourceCommissionId,omitempty"`
+	Date               time.Time           `bson:"date" json:"date"`
+}
+
+// MemberStatement est le relevé d'un membre pour une période clôturée
+type MemberStatement struct {
+	ID               primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	PeriodID         primitive.ObjectID `bson:"periodId" json:"periodId"`
+	PeriodKey        string             `bson:"periodKey" json:"periodKey"`
+	ClientID         primitive.ObjectID `bson:"clientId" json:"clientId"`
+	PersonalVolume   Volume             `bson:"personalVolume" json:"personalVolume"`     // Points des ventes payées de la période
+	PairedVolume     Volume             `bson:"pairedVolume" json:"pairedVolume"`         // Volume apparié par les cycles de la période (net des reprises)
+	Cycles           int                `bson:"cycles" json:"cycles"`                     // Cycles binaires payés
+	LeftVolumeCarry  Volume             `bson:"leftVolumeCarry" json:"leftVolumeCarry"`   // Report gauche à la clôture
+	RightVolumeCarry Volume             `bson:"rightVolumeCarry" json:"rightVolumeCarry"` // Report droit à la clôture
+	Commissions      []StatementLine    `bson:"commissions" json:"commissions"`
+	Adjustments      []StatementLine    `bson:"adjustments" json:"adjustments"` // Reprises et montants négatifs
+	TotalCommissions Money              `bson:"totalCommissions" json:"totalCommissions"`
+	TotalAdjustments Money              `bson:"totalAdjustments" json:"totalAdjustments"`
+	NetAmount        Money              `bson:"netAmount" json:"netAmount"`
+	CreatedAt        time.Time          `bson:"createdAt" json:"createdAt"`
+}
@@ -0,0 +1,77 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Formats des fichiers de paiement en masse
+const (
+	PayoutProviderMpesa   = "mpesa"
+	PayoutProviderAirtel  = "airtel"
+	PayoutProviderOrange  = "orange"
+	PayoutProviderGeneric = "generic"
+)
+
+// IsPayoutProvider indique si provider est un format de fichier connu
+func IsPayoutProvider(provider string) bool {
+	switch provider {
+	case PayoutProviderMpesa, PayoutProviderAirtel, PayoutProviderOrange, PayoutProviderGeneric:
+		return true
+	}
+	return false
+}
+
+// Sélection des paiements d'un lot
+const (
+	PayoutSourceWithdrawals = "withdrawals" // Retraits approuvés pas encore versés par lot
+	PayoutSourceBalances    = "balances"    // Portefeuilles dont le solde atteint un seuil
+)
+
+// Statuts d'un lot et de ses lignes
+const (
+	PayoutStatusPending   = "pending"   // Fichier produit, résultat de l'opérateur attendu
+	PayoutStatusCompleted = "completed" // Toutes les lignes ont un résultat
+	PayoutLineCompleted   = "completed"
+	PayoutLineFailed      = "failed"
+)
+
+// PayoutRule paramètre les fichiers de paiement en masse
+type PayoutRule struct {
+	Currency    string // Devise des montants du fichier
+	CountryCode string // Indicatif ajouté aux numéros nationaux (ex. "243"), vide pour les laisser tels quels
+}
+
+// PayoutBatch est un lot de paiements mobile money exporté en un fichier pour le portail de
+// l'opérateur. Chaque ligne est un retrait approuvé; le fichier de résultat de l'opérateur
+// marque chaque paiement versé ou échoué.
+type PayoutBatch struct {
+	ID             primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	Provider       string              `bson:"provider" json:"provider"` // PayoutProviderMpesa, Airtel, Orange ou Generic
+	Source         string              `bson:"source" json:"source"`     // PayoutSourceWithdrawals ou PayoutSourceBalances
+	MinBalance     Money               `bson:"minBalance,omitempty" json:"minBalance,omitempty"`
+	Currency       string              `bson:"currency" json:"currency"`
+	Status         string              `bson:"status" json:"status"`
+	Lines          []PayoutLine        `bson:"lines" json:"lines"`
+	TotalAmount    Money               `bson:"totalAmount" json:"totalAmount"` // Somme des montants nets
+	CompletedCount int                 `bson:"completedCount" json:"completedCount"`
+	FailedCount    int                 `bson:"failedCount" json:"failedCount"`
+	CreatedAt      time.Time           `bson:"createdAt" json:"createdAt"`
+	CreatedBy      *primitive.ObjectID `bson:"createdBy,omitempty" json:"createdBy,omitempty"` // Admin ayant créé le lot
+	ImportedAt     *time.Time          `bson:"importedAt,omitempty" json:"importedAt,omitempty"`
+}
+
+// PayoutLine est un paiement d'un lot
+type PayoutLine struct {
+	Reference         string             `bson:"reference" json:"reference"` // Référence envoyée à l'opérateur (ID du paiement)
+	WithdrawalID      primitive.ObjectID `bson:"withdrawalId" json:"withdrawalId"`
+	PaymentID         primitive.ObjectID `bson:"paymentId" json:"paymentId"`
+	ClientID          primitive.ObjectID `bson:"clientId" json:"clientId"`
+	Name              string             `bson:"name" json:"name"`
+	Phone             string             `bson:"phone" json:"phone"` // Numéro international sans "+"
+	Amount            Money              `bson:"amount" json:"amount"`
+	Status            string             `bson:"status" json:"status"`
+	ProviderReference *string            `bson:"providerReference,omitempty" json:"providerReference,omitempty"` // Reçu de l'opérateur
+	Error             *string            `bson:"error,omitempty" json:"error,omitempty"`
+}
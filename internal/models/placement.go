@@ -0,0 +1,38 @@
+package models
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// Stratégies de placement d'un nouveau membre sous son parent de placement
+const (
+	PlacementDirect       = "direct"        // Uniquement sous le parent de placement: erreur si ses deux positions sont prises
+	PlacementExtremeLeft  = "extreme-left"  // Au bout de la branche la plus à gauche
+	PlacementExtremeRight = "extreme-right" // Au bout de la branche la plus à droite
+	PlacementWeakerLeg    = "weaker-leg"    // Au bout de la jambe la plus faible en volume
+	PlacementBalanced     = "balanced"      // Première position libre en largeur (niveau par niveau, gauche puis droite)
+)
+
+// IsPlacementStrategy indique si strategy est une stratégie de placement connue
+func IsPlacementStrategy(strategy string) bool {
+	switch strategy {
+	case PlacementDirect, PlacementExtremeLeft, PlacementExtremeRight, PlacementWeakerLeg, PlacementBalanced:
+		return true
+	}
+	return false
+}
+
+// HoldingTankRule définit la salle d'attente des membres inscrits sans placement
+type HoldingTankRule struct {
+	Days     int    `bson:"days" json:"days"`         // Délai pendant lequel le parrain peut placer le membre
+	Strategy string `bson:"strategy" json:"strategy"` // Stratégie appliquée sous le parrain à l'échéance du délai
+}
+
+// LegCountDelta est la correction à ajouter aux compteurs de jambes d'un client, calculée par
+// rapport à une lecture de l'arbre: appliquée par incrément, elle conserve les mises à jour
+// faites par d'autres écritures depuis cette lecture
+type LegCountDelta struct {
+	ClientID     primitive.ObjectID
+	LeftMembers  int
+	RightMembers int
+	LeftActives  int
+	RightActives int
+}
@@ -0,0 +1,56 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Origine d'une évaluation de rang
+const (
+	RankSourcePeriodClose = "period-close" // Évaluation de tous les membres à la clôture de période
+	RankSourceOnDemand    = "on-demand"    // Évaluation d'un membre à la demande d'un admin
+)
+
+// RankDefinition définit un rang et ses conditions. Un membre obtient le rang de plus haut
+// niveau dont il remplit toutes les conditions; les volumes sont ceux de la période de paie évaluée.
+type RankDefinition struct {
+	ID                primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Code              string             `bson:"code" json:"code"`                           // Identifiant stable, stocké sur le client
+	Name              string             `bson:"name" json:"name"`                           // Libellé affiché
+	Level             int                `bson:"level" json:"level"`                         // Ordre des rangs: plus élevé = meilleur
+	MinPairedVolume   Volume             `bson:"minPairedVolume" json:"minPairedVolume"`     // Volume apparié de la période (cycles binaires payés)
+	MinPersonalVolume Volume             `bson:"minPersonalVolume" json:"minPersonalVolume"` // Points personnels confirmés de la période
+	MinQualifiedLegs  int                `bson:"minQualifiedLegs" json:"minQualifiedLegs"`   // Jambes (0 à 2) comptant au moins un membre actif
+	CreatedAt         time.Time          `bson:"createdAt" json:"createdAt"`
+	UpdatedAt         time.Time          `bson:"updatedAt" json:"updatedAt"`
+}
+
+// RankHistory enregistre un changement de rang d'un membre et les valeurs qui l'ont justifié
+type RankHistory struct {
+	ID             primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	ClientID       primitive.ObjectID `bson:"clientId" json:"clientId"`
+	Rank           string             `bson:"rank,omitempty" json:"rank"` // Vide = plus aucun rang
+	Level          int                `bson:"level" json:"level"`
+	PreviousRank   string             `bson:"previousRank,omitempty" json:"previousRank"`
+	PreviousLevel  int                `bson:"previousLevel" json:"previousLevel"`
+	PairedVolume   Volume             `bson:"pairedVolume" json:"pairedVolume"`
+	PersonalVolume Volume             `bson:"personalVolume" json:"personalVolume"`
+	QualifiedLegs  int                `bson:"qualifiedLegs" json:"qualifiedLegs"`
+	PeriodKey      string             `bson:"periodKey,omitempty" json:"periodKey"` // Période de paie dont les volumes ont été évalués
+	Source         string             `bson:"source" json:"source"`
+	AchievedAt     time.Time          `bson:"achievedAt" json:"achievedAt"`
+}
+
+// RankEvaluation est le résultat de l'évaluation du rang d'un membre
+type RankEvaluation struct {
+	ClientID       primitive.ObjectID `json:"clientId"`
+	Rank           string             `json:"rank"`
+	PreviousRank   string             `json:"previousRank"`
+	HighestRank    string             `json:"highestRank"`
+	Changed        bool               `json:"changed"`
+	PairedVolume   Volume             `json:"pairedVolume"`
+	PersonalVolume Volume             `json:"personalVolume"`
+	QualifiedLegs  int                `json:"qualifiedLegs"`
+	PeriodKey      string             `json:"periodKey"`
+}
@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Statuts d'exécution d'une tâche planifiée
+const (
+	JobStatusRunning   = "running"
+	JobStatusSucceeded = "succeeded"
+	JobStatusFailed    = "failed"
+)
+
+// JobLock est le bail détenu par l'instance qui exécute une tâche planifiée
+// Un seul document par tâche; l'ID est le nom de la tâche
+type JobLock struct {
+	ID          string    `bson:"_id" json:"id"`
+	Owner       string    `bson:"owner" json:"owner"`             // Instance détentrice du bail
+	LockedUntil time.Time `bson:"lockedUntil" json:"lockedUntil"` // Fin du bail
+	LastSlot    time.Time `bson:"lastSlot" json:"lastSlot"`       // Dernière occurrence planifiée prise en charge
+	AcquiredAt  time.Time `bson:"acquiredAt" json:"acquiredAt"`
+}
+
+// JobExecution représente une exécution d'une tâche planifiée
+type JobExecution struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	JobName     string             `bson:"jobName" json:"jobName"`
+	InstanceID  string             `bson:"instanceId" json:"instanceId"`
+	ScheduledAt time.Time          `bson:"scheduledAt" json:"scheduledAt"` // Occurrence planifiée
+	StartedAt   time.Time          `bson:"startedAt" json:"startedAt"`
+	FinishedAt  *time.Time         `bson:"finishedAt,omitempty" json:"finishedAt,omitempty"`
+	Status      string             `bson:"status" json:"status"` // running, succeeded, failed
+	Error       *string            `bson:"error,omitempty" json:"error,omitempty"`
+}
@@ -0,0 +1,77 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Sens d'une écriture sur le portefeuille du membre
+const (
+	WalletCredit = "credit"
+	WalletDebit  = "debit"
+)
+
+// Types d'écritures du portefeuille
+const (
+	WalletEntryCommission = "commission" // Gain versé (binary-cycle, matching, direct, binary-match)
+	WalletEntryClawback   = "clawback"   // Reprise d'une commission
+	WalletEntryOpening    = "opening"    // Solde antérieur au journal, repris à la migration
+	WalletEntryWithdrawal = "withdrawal" // Retrait approuvé
+	WalletEntryRefund     = "refund"     // Retrait dont le versement a échoué, rendu au portefeuille
+)
+
+// Comptes de contrepartie: chaque écriture débite ou crédite le portefeuille du membre
+// en contrepartie d'un compte de l'entreprise
+const (
+	WalletAccountCommissions = "commissions"     // Charge des commissions (gains et reprises)
+	WalletAccountOpening     = "opening-balance" // Soldes repris à l'ouverture du journal
+	WalletAccountWithdrawals = "withdrawals"     // Retraits versés aux membres
+)
+
+// Types de références d'une écriture
+const (
+	WalletReferenceCommission = "commission"
+	WalletReferenceClient     = "client" // Écriture d'ouverture
+	WalletReferenceWithdrawal = "withdrawal"
+)
+
+// WalletTransaction est une écriture du journal des portefeuilles. Le journal fait foi:
+// walletBalance et totalEarnings du client en sont une projection, reconstructible en
+// sommant les écritures. Une référence ne produit qu'une écriture de chaque type
+// (index unique referenceType, referenceId, type).
+type WalletTransaction struct {
+	ID             primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	ClientID       primitive.ObjectID `bson:"clientId" json:"clientId"`
+	Direction      string             `bson:"direction" json:"direction"` // WalletCredit ou WalletDebit
+	Type           string             `bson:"type" json:"type"`
+	Amount         Money              `bson:"amount" json:"amount"`                 // Toujours positif
+	Earnings       Money              `bson:"earnings" json:"earnings"`             // Effet signé sur totalEarnings
+	BalanceAfter   Money              `bson:"balanceAfter" json:"balanceAfter"`     // Solde courant après l'écriture
+	CounterAccount string             `bson:"counterAccount" json:"counterAccount"` // Compte de contrepartie
+	ReferenceType  string             `bson:"referenceType" json:"referenceType"`
+	ReferenceID    primitive.ObjectID `bson:"referenceId" json:"referenceId"`
+	Description    string             `bson:"description,omitempty" json:"description,omitempty"`
+	CreatedAt      time.Time          `bson:"createdAt" json:"createdAt"`
+}
+
+// SignedAmount retourne l'effet de l'écriture sur le solde du portefeuille
+func (t *WalletTransaction) SignedAmount() Money {
+	if t.Direction == WalletDebit {
+		return -t.Amount
+	}
+	return t.Amount
+}
+
+// WalletTotals est le solde et le total des gains d'un membre, selon le journal ou selon le client
+type WalletTotals struct {
+	Balance  Money `bson:"balance" json:"balance"`
+	Earnings Money `bson:"earnings" json:"earnings"`
+}
+
+// WalletDiscrepancy signale un membre dont la projection diffère du journal
+type WalletDiscrepancy struct {
+	ClientID   primitive.ObjectID `json:"clientId"`
+	Ledger     WalletTotals       `json:"ledger"`
+	Projection WalletTotals       `json:"projection"`
+}
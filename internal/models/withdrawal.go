@@ -0,0 +1,51 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Statuts d'une demande de retrait
+const (
+	WithdrawalStatusPending  = "pending"
+	WithdrawalStatusApproved = "approved"
+	WithdrawalStatusRejected = "rejected"
+	WithdrawalStatusFailed   = "failed" // Versement échoué chez l'opérateur: le montant est rendu au portefeuille
+)
+
+// WithdrawalRule définit les conditions d'un retrait du portefeuille
+type WithdrawalRule struct {
+	MinAmount  Money   `bson:"minAmount" json:"minAmount"`   // Montant minimum d'une demande
+	FeeRate    float64 `bson:"feeRate" json:"feeRate"`       // Frais proportionnels (ex: 0.02)
+	FeeAmount  Money   `bson:"feeAmount" json:"feeAmount"`   // Frais fixes, ajoutés aux frais proportionnels
+	DailyLimit Money   `bson:"dailyLimit" json:"dailyLimit"` // Total demandé par membre et par jour ouvré (0: illimité)
+}
+
+// Fee retourne les frais retenus sur un retrait de amount, arrondis au centime
+func (r WithdrawalRule) Fee(amount Money) Money {
+	return amount.Mul(r.FeeRate) + r.FeeAmount
+}
+
+// Withdrawal est une demande de retrait du portefeuille d'un membre. Le montant demandé est
+// débité du portefeuille à l'approbation; le membre reçoit le montant net des frais, versé
+// par un paiement en attente puis, à la confirmation du versement, par une sortie de caisse.
+type Withdrawal struct {
+	ID                  primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	ClientID            primitive.ObjectID  `bson:"clientId" json:"clientId"`
+	Amount              Money               `bson:"amount" json:"amount"`                     // Débité du portefeuille
+	Fee                 Money               `bson:"fee" json:"fee"`                           // Retenu par l'entreprise
+	NetAmount           Money               `bson:"netAmount" json:"netAmount"`               // Versé au membre
+	Method              string              `bson:"method" json:"method"`                     // Méthode de paiement
+	Destination         *string             `bson:"destination,omitempty" json:"destination"` // Numéro ou compte de réception
+	Status              string              `bson:"status" json:"status"`                     // WithdrawalStatusPending, Approved ou Rejected
+	RequestedAt         time.Time           `bson:"requestedAt" json:"requestedAt"`
+	ReviewedAt          *time.Time          `bson:"reviewedAt,omitempty" json:"reviewedAt"`
+	ReviewedBy          *primitive.ObjectID `bson:"reviewedBy,omitempty" json:"reviewedBy"` // Admin ayant traité la demande
+	RejectionReason     *string             `bson:"rejectionReason,omitempty" json:"rejectionReason"`
+	FailureReason       *string             `bson:"failureReason,omitempty" json:"failureReason"` // Motif de l'échec du versement
+	PaymentID           *primitive.ObjectID `bson:"paymentId,omitempty" json:"paymentId"`
+	CaisseTransactionID *primitive.ObjectID `bson:"caisseTransactionId,omitempty" json:"caisseTransactionId"`
+	WalletTransactionID *primitive.ObjectID `bson:"walletTransactionId,omitempty" json:"walletTransactionId"`
+	PayoutBatchID       *primitive.ObjectID `bson:"payoutBatchId,omitempty" json:"payoutBatchId"` // Lot de paiement mobile money
+}
@@ -0,0 +1,167 @@
+package scheduler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule est une expression cron à 5 champs: minute heure jour-du-mois mois jour-de-la-semaine
+// Chaque champ accepte *, des listes (1,15), des plages (1-5) et des pas (*/15, 0-30/10).
+// Les raccourcis @hourly, @daily, @weekly et @monthly sont aussi acceptés.
+type Schedule struct {
+	expr    string
+	minutes uint64
+	hours   uint64
+	doms    uint64
+	months  uint64
+	dows    uint64
+	domAny  bool
+	dowAny  bool
+}
+
+var cronShortcuts = map[string]string{
+	"@hourly":   "0 * * * *",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@weekly":   "0 0 * * 0",
+	"@monthly":  "0 0 1 * *",
+}
+
+// ParseSchedule analyse une expression cron
+func ParseSchedule(expr string) (*Schedule, error) {
+	spec := strings.TrimSpace(expr)
+	if shortcut, ok := cronShortcuts[spec]; ok {
+		spec = shortcut
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("expression cron invalide %q: 5 champs attendus", expr)
+	}
+
+	s := &Schedule{expr: expr}
+	var err error
+	if s.minutes, err = parseField(fields[0], 0, 59); err != nil {
+		return nil, fmt.Errorf("expression cron invalide %q (minute): %w", expr, err)
+	}
+	if s.hours, err = parseField(fields[1], 0, 23); err != nil {
+		return nil, fmt.Errorf("expression cron invalide %q (heure): %w", expr, err)
+	}
+	if s.doms, err = parseField(fields[2], 1, 31); err != nil {
+		return nil, fmt.Errorf("expression cron invalide %q (jour du mois): %w", expr, err)
+	}
+	if s.months, err = parseField(fields[3], 1, 12); err != nil {
+		return nil, fmt.Errorf("expression cron invalide %q (mois): %w", expr, err)
+	}
+	if s.dows, err = parseField(fields[4], 0, 7); err != nil {
+		return nil, fmt.Errorf("expression cron invalide %q (jour de la semaine): %w", expr, err)
+	}
+	// 7 et 0 désignent tous deux le dimanche
+	if s.dows&(1<<7) != 0 {
+		s.dows |= 1
+	}
+	s.domAny = fields[2] == "*"
+	s.dowAny = fields[4] == "*"
+
+	return s, nil
+}
+
+// String retourne l'expression d'origine
+func (s *Schedule) String() string {
+	return s.expr
+}
+
+// Next retourne la première occurrence strictement postérieure à t, à la minute près
+func (s *Schedule) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	// Borne de sécurité: une expression valide a forcément une occurrence dans les 5 ans
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		if s.months&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !s.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if s.hours&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+		if s.minutes&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+
+	return time.Time{}
+}
+
+// dayMatches applique la règle cron classique: si les deux champs jour sont restreints,
+// il suffit que l'un des deux corresponde
+func (s *Schedule) dayMatches(t time.Time) bool {
+	domMatch := s.doms&(1<<uint(t.Day())) != 0
+	dowMatch := s.dows&(1<<uint(t.Weekday())) != 0
+
+	switch {
+	case s.domAny && s.dowAny:
+		return true
+	case s.domAny:
+		return dowMatch
+	case s.dowAny:
+		return domMatch
+	default:
+		return domMatch || dowMatch
+	}
+}
+
+func parseField(field string, min, max int) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			n, err := strconv.Atoi(part[i+1:])
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("pas invalide %q", part)
+			}
+			step = n
+			part = part[:i]
+		}
+
+		lo, hi := min, max
+		switch {
+		case part == "*":
+		case strings.Contains(part, "-"):
+			bounds := strings.SplitN(part, "-", 2)
+			var err error
+			if lo, err = strconv.Atoi(bounds[0]); err != nil {
+				return 0, fmt.Errorf("valeur invalide %q", part)
+			}
+			if hi, err = strconv.Atoi(bounds[1]); err != nil {
+				return 0, fmt.Errorf("valeur invalide %q", part)
+			}
+		default:
+			n, err := strconv.Atoi(part)
+			if err != nil {
+				return 0, fmt.Errorf("valeur invalide %q", part)
+			}
+			lo = n
+			if step == 1 {
+				hi = n
+			}
+		}
+
+		if lo < min || hi > max || lo > hi {
+			return 0, fmt.Errorf("valeur hors limites %q (%d-%d)", part, min, max)
+		}
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
@@ -0,0 +1,47 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseSchedule_Invalid(t *testing.T) {
+	invalid := []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "*/0 * * * *", "a * * * *", "5-1 * * * *"}
+	for _, expr := range invalid {
+		if _, err := ParseSchedule(expr); err == nil {
+			t.Errorf("Expected error for %q", expr)
+		}
+	}
+}
+
+func TestSchedule_Next(t *testing.T) {
+	// Lundi 15 janvier 2024, 10:17
+	from := time.Date(2024, 1, 15, 10, 17, 30, 0, time.UTC)
+
+	tests := []struct {
+		expr string
+		want time.Time
+	}{
+		{"* * * * *", time.Date(2024, 1, 15, 10, 18, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)},
+		{"0 1 * * *", time.Date(2024, 1, 16, 1, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2024, 1, 16, 0, 0, 0, 0, time.UTC)},
+		{"30 9 * * 1-5", time.Date(2024, 1, 16, 9, 30, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2024, 1, 21, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1 * *", time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 12 29 2 *", time.Date(2024, 2, 29, 12, 0, 0, 0, time.UTC)},
+		{"0,45 10 * * *", time.Date(2024, 1, 15, 10, 45, 0, 0, time.UTC)},
+		// Jour du mois et jour de semaine restreints: l'un ou l'autre suffit
+		{"0 0 20 * 3", time.Date(2024, 1, 17, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		schedule, err := ParseSchedule(tt.expr)
+		if err != nil {
+			t.Fatalf("Unexpected error for %q: %v", tt.expr, err)
+		}
+		if got := schedule.Next(from); !got.Equal(tt.want) {
+			t.Errorf("%q: expected %s, got %s", tt.expr, tt.want, got)
+		}
+	}
+}
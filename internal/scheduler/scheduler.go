@@ -0,0 +1,267 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"bureau/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+// jobStore persiste les baux et l'historique des exécutions (voir store.JobRepository)
+type jobStore interface {
+	AcquireLease(ctx context.Context, jobName, owner string, slot time.Time, lease time.Duration) (bool, error)
+	RenewLease(ctx context.Context, jobName, owner string, lease time.Duration) error
+	ReleaseLease(ctx context.Context, jobName, owner string) error
+	CreateExecution(ctx context.Context, execution *models.JobExecution) (*models.JobExecution, error)
+	FinishExecution(ctx context.Context, id primitive.ObjectID, status string, errMsg *string) error
+	GetExecutions(ctx context.Context, jobName string, limit int) ([]*models.JobExecution, error)
+}
+
+// Job décrit une tâche planifiée
+type Job struct {
+	Name        string
+	Schedule    string // Expression cron, voir ParseSchedule
+	Description string
+	Run         func(ctx context.Context) error
+}
+
+// JobStatus décrit une tâche enregistrée et ses dernières exécutions
+type JobStatus struct {
+	Name        string
+	Schedule    string
+	Description string
+	NextRunAt   time.Time
+	RecentRuns  []*models.JobExecution
+}
+
+type registeredJob struct {
+	Job
+	schedule *Schedule
+	next     time.Time
+}
+
+// Scheduler exécute des tâches cron dans le processus
+// Plusieurs instances peuvent tourner en parallèle: un bail stocké dans Mongo garantit
+// qu'une seule instance exécute chaque occurrence d'une tâche.
+type Scheduler struct {
+	store      jobStore
+	logger     *zap.Logger
+	instanceID string
+	lease      time.Duration
+	location   *time.Location
+
+	mu      sync.Mutex
+	jobs    map[string]*registeredJob
+	running sync.WaitGroup
+}
+
+// New crée un nouveau planificateur
+// Les expressions cron sont évaluées dans le fuseau location (UTC si nil)
+func New(store jobStore, logger *zap.Logger, instanceID string, lease time.Duration, location *time.Location) *Scheduler {
+	if lease <= 0 {
+		lease = 10 * time.Minute
+	}
+	if location == nil {
+		location = time.UTC
+	}
+	return &Scheduler{
+		store:      store,
+		logger:     logger,
+		instanceID: instanceID,
+		lease:      lease,
+		location:   location,
+		jobs:       make(map[string]*registeredJob),
+	}
+}
+
+// Register ajoute une tâche au planificateur
+func (s *Scheduler) Register(job Job) error {
+	if job.Name == "" || job.Run == nil {
+		return fmt.Errorf("tâche invalide: nom et fonction requis")
+	}
+	schedule, err := ParseSchedule(job.Schedule)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, exists := s.jobs[job.Name]; exists {
+		return fmt.Errorf("tâche %q déjà enregistrée", job.Name)
+	}
+	s.jobs[job.Name] = &registeredJob{
+		Job:      job,
+		schedule: schedule,
+		next:     schedule.Next(time.Now().In(s.location)),
+	}
+	return nil
+}
+
+// Start lance la boucle du planificateur jusqu'à l'annulation du contexte
+func (s *Scheduler) Start(ctx context.Context) {
+	go s.loop(ctx)
+	s.logger.Info("Scheduler started", zap.String("instanceID", s.instanceID), zap.Int("jobs", len(s.jobs)))
+}
+
+// Wait attend la fin des tâches en cours d'exécution
+func (s *Scheduler) Wait() {
+	s.running.Wait()
+}
+
+func (s *Scheduler) loop(ctx context.Context) {
+	for {
+		wait := time.Until(s.nextWakeUp())
+		if wait < 0 {
+			wait = 0
+		}
+		timer := time.NewTimer(wait)
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		now := time.Now().In(s.location)
+		s.mu.Lock()
+		for _, job := range s.jobs {
+			if job.next.IsZero() || job.next.After(now) {
+				continue
+			}
+			slot := job.next
+			job.next = job.schedule.Next(now)
+
+			s.running.Add(1)
+			go func(job Job, slot time.Time) {
+				defer s.running.Done()
+				s.runJob(ctx, job, slot)
+			}(job.Job, slot)
+		}
+		s.mu.Unlock()
+	}
+}
+
+// nextWakeUp retourne la prochaine occurrence parmi toutes les tâches
+func (s *Scheduler) nextWakeUp() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	next := time.Now().Add(time.Minute)
+	for _, job := range s.jobs {
+		if !job.next.IsZero() && job.next.Before(next) {
+			next = job.next
+		}
+	}
+	return next
+}
+
+// runJob exécute une occurrence si cette instance obtient le bail
+func (s *Scheduler) runJob(ctx context.Context, job Job, slot time.Time) {
+	acquired, err := s.store.AcquireLease(ctx, job.Name, s.instanceID, slot, s.lease)
+	if err != nil {
+		s.logger.Error("Failed to acquire job lease", zap.String("job", job.Name), zap.Error(err))
+		return
+	}
+	if !acquired {
+		s.logger.Debug("Job already handled by another instance", zap.String("job", job.Name), zap.Time("slot", slot))
+		return
+	}
+	defer func() {
+		if err := s.store.ReleaseLease(context.Background(), job.Name, s.instanceID); err != nil {
+			s.logger.Error("Failed to release job lease", zap.String("job", job.Name), zap.Error(err))
+		}
+	}()
+
+	execution, err := s.store.CreateExecution(ctx, &models.JobExecution{
+		JobName:     job.Name,
+		InstanceID:  s.instanceID,
+		ScheduledAt: slot,
+		StartedAt:   time.Now(),
+		Status:      models.JobStatusRunning,
+	})
+	if err != nil {
+		s.logger.Error("Failed to record job execution", zap.String("job", job.Name), zap.Error(err))
+		return
+	}
+
+	// Prolonger le bail tant que la tâche tourne
+	jobCtx, cancel := context.WithCancel(ctx)
+	go s.renewLease(jobCtx, job.Name)
+
+	s.logger.Info("Running scheduled job", zap.String("job", job.Name), zap.Time("slot", slot))
+	runErr := s.safeRun(jobCtx, job)
+	cancel()
+
+	status := models.JobStatusSucceeded
+	var errMsg *string
+	if runErr != nil {
+		status = models.JobStatusFailed
+		msg := runErr.Error()
+		errMsg = &msg
+		s.logger.Error("Scheduled job failed", zap.String("job", job.Name), zap.Error(runErr))
+	}
+
+	if err := s.store.FinishExecution(context.Background(), execution.ID, status, errMsg); err != nil {
+		s.logger.Error("Failed to finish job execution", zap.String("job", job.Name), zap.Error(err))
+	}
+}
+
+// safeRun protège le planificateur d'une panique dans une tâche
+func (s *Scheduler) safeRun(ctx context.Context, job Job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return job.Run(ctx)
+}
+
+func (s *Scheduler) renewLease(ctx context.Context, jobName string) {
+	ticker := time.NewTicker(s.lease / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.store.RenewLease(ctx, jobName, s.instanceID, s.lease); err != nil {
+				s.logger.Warn("Failed to renew job lease", zap.String("job", jobName), zap.Error(err))
+			}
+		}
+	}
+}
+
+// Jobs retourne les tâches enregistrées avec leurs dernières exécutions
+func (s *Scheduler) Jobs(ctx context.Context, historyLimit int) ([]*JobStatus, error) {
+	s.mu.Lock()
+	statuses := make([]*JobStatus, 0, len(s.jobs))
+	for _, job := range s.jobs {
+		statuses = append(statuses, &JobStatus{
+			Name:        job.Name,
+			Schedule:    job.schedule.String(),
+			Description: job.Description,
+			NextRunAt:   job.next,
+		})
+	}
+	s.mu.Unlock()
+
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Name < statuses[j].Name })
+
+	for _, status := range statuses {
+		runs, err := s.store.GetExecutions(ctx, status.Name, historyLimit)
+		if err != nil {
+			return nil, err
+		}
+		status.RecentRuns = runs
+	}
+
+	return statuses, nil
+}
@@ -0,0 +1,212 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"bureau/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+// Mock du store partagé entre plusieurs instances, même sémantique que store.JobRepository
+type mockJobStore struct {
+	mu         sync.Mutex
+	locks      map[string]*models.JobLock
+	executions []*models.JobExecution
+}
+
+func newMockJobStore() *mockJobStore {
+	return &mockJobStore{locks: make(map[string]*models.JobLock)}
+}
+
+func (m *mockJobStore) AcquireLease(ctx context.Context, jobName, owner string, slot time.Time, lease time.Duration) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now()
+	if lock, ok := m.locks[jobName]; ok {
+		if lock.LockedUntil.After(now) || !lock.LastSlot.Before(slot) {
+			return false, nil
+		}
+	}
+	m.locks[jobName] = &models.JobLock{ID: jobName, Owner: owner, LockedUntil: now.Add(lease), LastSlot: slot, AcquiredAt: now}
+	return true, nil
+}
+
+func (m *mockJobStore) RenewLease(ctx context.Context, jobName, owner string, lease time.Duration) error {
+	return nil
+}
+
+func (m *mockJobStore) ReleaseLease(ctx context.Context, jobName, owner string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if lock, ok := m.locks[jobName]; ok && lock.Owner == owner {
+		lock.LockedUntil = time.Now()
+	}
+	return nil
+}
+
+func (m *mockJobStore) CreateExecution(ctx context.Context, execution *models.JobExecution) (*models.JobExecution, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	execution.ID = primitive.NewObjectID()
+	m.executions = append(m.executions, execution)
+	return execution, nil
+}
+
+func (m *mockJobStore) FinishExecution(ctx context.Context, id primitive.ObjectID, status string, errMsg *string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, execution := range m.executions {
+		if execution.ID == id {
+			now := time.Now()
+			execution.Status = status
+			execution.Error = errMsg
+			execution.FinishedAt = &now
+		}
+	}
+	return nil
+}
+
+func (m *mockJobStore) GetExecutions(ctx context.Context, jobName string, limit int) ([]*models.JobExecution, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var out []*models.JobExecution
+	for i := len(m.executions) - 1; i >= 0; i-- {
+		if m.executions[i].JobName == jobName {
+			out = append(out, m.executions[i])
+		}
+	}
+	return out, nil
+}
+
+// Test: deux instances qui se réveillent pour la même occurrence ne l'exécutent qu'une fois
+func TestScheduler_OneInstancePerSlot(t *testing.T) {
+	store := newMockJobStore()
+	var mu sync.Mutex
+	runs := 0
+	job := Job{
+		Name:     "test-job",
+		Schedule: "* * * * *",
+		Run: func(ctx context.Context) error {
+			mu.Lock()
+			runs++
+			mu.Unlock()
+			return nil
+		},
+	}
+
+	a := New(store, zap.NewNop(), "instance-a", time.Minute, nil)
+	b := New(store, zap.NewNop(), "instance-b", time.Minute, nil)
+	slot := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+
+	var wg sync.WaitGroup
+	for _, s := range []*Scheduler{a, b} {
+		wg.Add(1)
+		go func(s *Scheduler) {
+			defer wg.Done()
+			s.runJob(context.Background(), job, slot)
+		}(s)
+	}
+	wg.Wait()
+
+	// Une instance en retard ne rejoue pas une occurrence déjà traitée, même bail libéré
+	b.runJob(context.Background(), job, slot)
+
+	if runs != 1 {
+		t.Fatalf("Expected job to run once, got %d", runs)
+	}
+
+	// L'occurrence suivante peut être prise par n'importe quelle instance
+	b.runJob(context.Background(), job, slot.Add(time.Minute))
+	if runs != 2 {
+		t.Fatalf("Expected next slot to run, got %d runs", runs)
+	}
+}
+
+// Test: l'historique enregistre le statut et l'erreur, y compris en cas de panique
+func TestScheduler_ExecutionHistory(t *testing.T) {
+	store := newMockJobStore()
+	s := New(store, zap.NewNop(), "instance-a", time.Minute, nil)
+
+	calls := 0
+	if err := s.Register(Job{
+		Name:     "flaky",
+		Schedule: "@hourly",
+		Run: func(ctx context.Context) error {
+			calls++
+			switch calls {
+			case 1:
+				return errors.New("boom")
+			case 2:
+				panic("crash")
+			}
+			return nil
+		},
+	}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	job := s.jobs["flaky"].Job
+	slot := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		s.runJob(context.Background(), job, slot.Add(time.Duration(i)*time.Hour))
+	}
+
+	statuses, err := s.Jobs(context.Background(), 10)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(statuses) != 1 || len(statuses[0].RecentRuns) != 3 {
+		t.Fatalf("Expected 3 recorded runs, got %+v", statuses)
+	}
+
+	runs := statuses[0].RecentRuns
+	if runs[0].Status != models.JobStatusSucceeded {
+		t.Errorf("Expected last run succeeded, got %s", runs[0].Status)
+	}
+	if runs[1].Status != models.JobStatusFailed || runs[1].Error == nil || *runs[1].Error != "panic: crash" {
+		t.Errorf("Expected panic to be recorded, got %+v", runs[1])
+	}
+	if runs[2].Status != models.JobStatusFailed || runs[2].Error == nil || *runs[2].Error != "boom" {
+		t.Errorf("Expected error to be recorded, got %+v", runs[2])
+	}
+	if statuses[0].NextRunAt.IsZero() {
+		t.Errorf("Expected next run to be scheduled")
+	}
+
+	if err := s.Register(Job{Name: "flaky", Schedule: "@hourly", Run: job.Run}); err == nil {
+		t.Errorf("Expected duplicate registration to fail")
+	}
+	if err := s.Register(Job{Name: "bad", Schedule: "nope", Run: job.Run}); err == nil {
+		t.Errorf("Expected invalid schedule to fail")
+	}
+}
+
+// Test: les occurrences sont calculées dans le fuseau du planificateur
+func TestScheduler_Location(t *testing.T) {
+	kinshasa := time.FixedZone("WAT", 3600)
+	s := New(newMockJobStore(), zap.NewNop(), "instance-a", time.Minute, kinshasa)
+	if err := s.Register(Job{
+		Name:     "daily",
+		Schedule: "0 1 * * *",
+		Run:      func(ctx context.Context) error { return nil },
+	}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	next := s.jobs["daily"].next
+	if next.Location() != kinshasa {
+		t.Errorf("Expected next run in %s, got %s", kinshasa, next.Location())
+	}
+	if next.Hour() != 1 || next.Minute() != 0 {
+		t.Errorf("Expected 01:00 local time, got %s", next)
+	}
+	if next.UTC().Hour() != 0 {
+		t.Errorf("Expected 00:00 UTC, got %s", next.UTC())
+	}
+}
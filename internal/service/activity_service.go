@@ -0,0 +1,171 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"bureau/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+type saleRepository interface {
+	GetByClientID(ctx context.Context, clientID string) ([]*models.Sale, error)
+}
+
+type activityClientRepository interface {
+	GetAllIDs(ctx context.Context) ([]primitive.ObjectID, error)
+	UpdateActiveUntil(ctx context.Context, id string, activeUntil *time.Time) error
+}
+
+// defaultActivityWindowDays est la longueur de la fenêtre glissante si elle n'est pas configurée
+const defaultActivityWindowDays = 30
+
+// activeForever est la date de fin d'activité de la fenêtre ActivityWindowLifetime
+var activeForever = time.Date(9999, 12, 31, 0, 0, 0, 0, time.UTC)
+
+// ActivityService tient à jour la date de fin d'activité (ActiveUntil) des membres
+// à partir de leur volume personnel confirmé et de la règle d'activité configurée
+type ActivityService struct {
+	clientRepo activityClientRepository
+	saleRepo   saleRepository
+	logger     *zap.Logger
+	rule       models.ActivityRule
+	location   *time.Location // Fuseau du jour ouvré (bornes des mois calendaires)
+}
+
+// NewActivityService crée un nouveau service d'activité
+func NewActivityService(clientRepo activityClientRepository, saleRepo saleRepository, logger *zap.Logger, rule models.ActivityRule, location *time.Location) *ActivityService {
+	switch rule.Window {
+	case models.ActivityWindowLifetime, models.ActivityWindowRolling, models.ActivityWindowMonth:
+	case "":
+		rule.Window = models.ActivityWindowLifetime
+	default:
+		logger.Warn("Unknown activity window, using lifetime", zap.String("window", rule.Window))
+		rule.Window = models.ActivityWindowLifetime
+	}
+	if rule.WindowDays <= 0 {
+		rule.WindowDays = defaultActivityWindowDays
+	}
+
+	return &ActivityService{
+		clientRepo: clientRepo,
+		saleRepo:   saleRepo,
+		logger:     logger,
+		rule:       rule,
+		location:   locationOrUTC(location),
+	}
+}
+
+// Rule retourne la règle d'activité appliquée
+func (s *ActivityService) Rule() models.ActivityRule {
+	return s.rule
+}
+
+// Refresh recalcule et enregistre la date de fin d'activité d'un membre
+// À appeler dès que son volume personnel confirmé change (vente payée ou annulée)
+func (s *ActivityService) Refresh(ctx context.Context, clientID string) (*time.Time, error) {
+	sales, err := s.saleRepo.GetByClientID(ctx, clientID)
+	if err != nil {
+		return nil, fmt.Errorf("échec de la lecture des ventes: %w", err)
+	}
+
+	activeUntil := ActiveUntil(s.rule, sales, s.location)
+	if err := s.clientRepo.UpdateActiveUntil(ctx, clientID, activeUntil); err != nil {
+		return nil, fmt.Errorf("échec de l'enregistrement de l'activité: %w", err)
+	}
+	return activeUntil, nil
+}
+
+// RefreshAll recalcule la date de fin d'activité de tous les membres
+// (reprise des données existantes ou changement de règle) et retourne le nombre de membres actifs
+func (s *ActivityService) RefreshAll(ctx context.Context) (int, error) {
+	ids, err := s.clientRepo.GetAllIDs(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("échec de la lecture des membres: %w", err)
+	}
+
+	now := time.Now()
+	active := 0
+	for _, id := range ids {
+		if err := ctx.Err(); err != nil {
+			return active, err
+		}
+		activeUntil, err := s.Refresh(ctx, id.Hex())
+		if err != nil {
+			s.logger.Error("Failed to refresh activity", zap.String("clientID", id.Hex()), zap.Error(err))
+			continue
+		}
+		if activeUntil != nil && now.Before(*activeUntil) {
+			active++
+		}
+	}
+
+	s.logger.Info("Activity refreshed", zap.Int("members", len(ids)), zap.Int("active", active))
+	return active, nil
+}
+
+// IsActiveAt indique si un membre est actif à une date d'après sa date de fin d'activité
+func IsActiveAt(client *models.Client, at time.Time) bool {
+	return client != nil && client.ActiveUntil != nil && at.Before(*client.ActiveUntil)
+}
+
+// ActiveUntil calcule la date de fin d'activité d'un membre à partir de ses ventes:
+// seules les ventes payées comptent, et au moins une est nécessaire. Retourne nil si
+// aucune fenêtre n'atteint le minimum de points. Les mois calendaires commencent à minuit
+// dans location, comme les plafonds et les périodes de paie.
+func ActiveUntil(rule models.ActivityRule, sales []*models.Sale, location *time.Location) *time.Time {
+	var paid []*models.Sale
+	var total models.Volume
+	for _, sale := range sales {
+		if sale.Status == "paid" {
+			paid = append(paid, sale)
+			total += sale.Points
+		}
+	}
+	if len(paid) == 0 {
+		return nil
+	}
+	sort.Slice(paid, func(i, j int) bool { return paid[i].Date.Before(paid[j].Date) })
+
+	var until time.Time
+	switch rule.Window {
+	case models.ActivityWindowRolling:
+		// Actif tant que la fenêtre glissante contient assez de points: la fenêtre la plus
+		// tardive qui atteint le minimum commence à une vente et dure WindowDays jours
+		window := time.Duration(rule.WindowDays) * 24 * time.Hour
+		for i := len(paid) - 1; i >= 0 && until.IsZero(); i-- {
+			end := paid[i].Date.Add(window)
+			var points models.Volume
+			for j := i; j < len(paid) && paid[j].Date.Before(end); j++ {
+				points += paid[j].Points
+			}
+			if points >= rule.MinPoints {
+				until = end
+			}
+		}
+	case models.ActivityWindowMonth:
+		// Actif jusqu'à la fin du dernier mois calendaire qui atteint le minimum
+		points := make(map[time.Time]models.Volume)
+		for _, sale := range paid {
+			points[startOfMonth(sale.Date, locationOrUTC(location))] += sale.Points
+		}
+		for month, sum := range points {
+			if sum >= rule.MinPoints && month.AddDate(0, 1, 0).After(until) {
+				until = month.AddDate(0, 1, 0)
+			}
+		}
+	default:
+		if total >= rule.MinPoints {
+			until = activeForever
+		}
+	}
+
+	if until.IsZero() {
+		return nil
+	}
+	return &until
+}
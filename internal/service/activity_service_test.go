@@ -0,0 +1,161 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"bureau/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+type mockActivityClientRepo struct {
+	activeUntil map[string]*time.Time
+}
+
+func (m *mockActivityClientRepo) GetAllIDs(ctx context.Context) ([]primitive.ObjectID, error) {
+	return nil, nil
+}
+
+func (m *mockActivityClientRepo) UpdateActiveUntil(ctx context.Context, id string, activeUntil *time.Time) error {
+	m.activeUntil[id] = activeUntil
+	return nil
+}
+
+func paidSale(date time.Time, points models.Volume) *models.Sale {
+	return &models.Sale{ID: primitive.NewObjectID(), Date: date, Points: points, Status: "paid"}
+}
+
+func TestActiveUntil_Lifetime(t *testing.T) {
+	rule := models.ActivityRule{Window: models.ActivityWindowLifetime, MinPoints: models.NewVolume(100)}
+	day := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	if until := ActiveUntil(rule, nil, time.UTC); until != nil {
+		t.Errorf("Expected no activity without sales, got %v", until)
+	}
+	if until := ActiveUntil(rule, []*models.Sale{paidSale(day, models.NewVolume(60))}, time.UTC); until != nil {
+		t.Errorf("Expected no activity below the minimum, got %v", until)
+	}
+
+	until := ActiveUntil(rule, []*models.Sale{paidSale(day, models.NewVolume(60)), paidSale(day.AddDate(1, 0, 0), models.NewVolume(40))}, time.UTC)
+	if until == nil || !until.Equal(activeForever) {
+		t.Errorf("Expected lifetime activity, got %v", until)
+	}
+
+	// Une vente payée suffit lorsque le minimum est nul
+	rule.MinPoints = 0
+	if until := ActiveUntil(rule, []*models.Sale{paidSale(day, 0)}, time.UTC); until == nil {
+		t.Error("Expected one paid sale to be enough with no minimum")
+	}
+}
+
+func TestActiveUntil_Rolling(t *testing.T) {
+	rule := models.ActivityRule{Window: models.ActivityWindowRolling, WindowDays: 30, MinPoints: models.NewVolume(100)}
+	day := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	// 60 + 50 sur 20 jours: actif 30 jours après la première vente de la fenêtre
+	until := ActiveUntil(rule, []*models.Sale{paidSale(day, models.NewVolume(60)), paidSale(day.AddDate(0, 0, 20), models.NewVolume(50))}, time.UTC)
+	if until == nil || !until.Equal(day.AddDate(0, 0, 30)) {
+		t.Errorf("Expected active until %v, got %v", day.AddDate(0, 0, 30), until)
+	}
+
+	// Une vente tardive qui atteint seule le minimum prolonge l'activité
+	until = ActiveUntil(rule, []*models.Sale{paidSale(day, models.NewVolume(60)), paidSale(day.AddDate(0, 0, 20), models.NewVolume(50)), paidSale(day.AddDate(0, 0, 25), models.NewVolume(100))}, time.UTC)
+	if until == nil || !until.Equal(day.AddDate(0, 0, 55)) {
+		t.Errorf("Expected active until %v, got %v", day.AddDate(0, 0, 55), until)
+	}
+
+	// Deux ventes trop éloignées ne se cumulent pas
+	if until := ActiveUntil(rule, []*models.Sale{paidSale(day, models.NewVolume(60)), paidSale(day.AddDate(0, 0, 45), models.NewVolume(50))}, time.UTC); until != nil {
+		t.Errorf("Expected no activity for sales 45 days apart, got %v", until)
+	}
+}
+
+func TestActiveUntil_CalendarMonth(t *testing.T) {
+	rule := models.ActivityRule{Window: models.ActivityWindowMonth, MinPoints: models.NewVolume(100)}
+
+	sales := []*models.Sale{
+		paidSale(time.Date(2025, 1, 5, 0, 0, 0, 0, time.UTC), models.NewVolume(100)),
+		paidSale(time.Date(2025, 2, 27, 0, 0, 0, 0, time.UTC), models.NewVolume(50)),
+		paidSale(time.Date(2025, 3, 2, 0, 0, 0, 0, time.UTC), models.NewVolume(50)), // Février et mars n'atteignent pas le minimum
+	}
+	until := ActiveUntil(rule, sales, time.UTC)
+	if expected := time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC); until == nil || !until.Equal(expected) {
+		t.Errorf("Expected active until %v, got %v", expected, until)
+	}
+
+	sales = append(sales, paidSale(time.Date(2025, 3, 20, 0, 0, 0, 0, time.UTC), models.NewVolume(50)))
+	until = ActiveUntil(rule, sales, time.UTC)
+	if expected := time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC); until == nil || !until.Equal(expected) {
+		t.Errorf("Expected active until %v, got %v", expected, until)
+	}
+}
+
+// Test: le mois calendaire commence à minuit dans le fuseau du jour ouvré
+func TestActiveUntil_CalendarMonthBusinessTimezone(t *testing.T) {
+	rule := models.ActivityRule{Window: models.ActivityWindowMonth, MinPoints: models.NewVolume(100)}
+	kinshasa := time.FixedZone("WAT", 3600)
+
+	// 31 janvier 23h30 UTC: déjà le 1er février à Kinshasa
+	sales := []*models.Sale{paidSale(time.Date(2025, 1, 31, 23, 30, 0, 0, time.UTC), models.NewVolume(100))}
+	until := ActiveUntil(rule, sales, kinshasa)
+	if expected := time.Date(2025, 3, 1, 0, 0, 0, 0, kinshasa); until == nil || !until.Equal(expected) {
+		t.Errorf("Expected active until %v, got %v", expected, until)
+	}
+}
+
+func TestActivityService_RefreshIgnoresUnpaidSales(t *testing.T) {
+	logger, _ := zap.NewDevelopment()
+	clientID := primitive.NewObjectID()
+	clientRepo := &mockActivityClientRepo{activeUntil: make(map[string]*time.Time)}
+	saleRepo := &mockSaleRepo{sales: map[string][]*models.Sale{
+		clientID.Hex(): {
+			{ID: primitive.NewObjectID(), Date: time.Now(), Points: models.NewVolume(500), Status: "pending"},
+			{ID: primitive.NewObjectID(), Date: time.Now(), Points: models.NewVolume(500), Status: "cancelled"},
+		},
+	}}
+	service := NewActivityService(clientRepo, saleRepo, logger, models.ActivityRule{Window: models.ActivityWindowRolling, MinPoints: models.NewVolume(100)}, time.UTC)
+
+	if service.Rule().WindowDays != defaultActivityWindowDays {
+		t.Errorf("Expected default window of %d days, got %d", defaultActivityWindowDays, service.Rule().WindowDays)
+	}
+
+	until, err := service.Refresh(context.Background(), clientID.Hex())
+	if err != nil {
+		t.Fatalf("Erreur inattendue: %v", err)
+	}
+	if until != nil || clientRepo.activeUntil[clientID.Hex()] != nil {
+		t.Errorf("Expected unpaid sales not to count, got %v", until)
+	}
+
+	saleRepo.sales[clientID.Hex()] = append(saleRepo.sales[clientID.Hex()], paidSale(time.Now(), models.NewVolume(100)))
+	until, err = service.Refresh(context.Background(), clientID.Hex())
+	if err != nil {
+		t.Fatalf("Erreur inattendue: %v", err)
+	}
+	if until == nil || clientRepo.activeUntil[clientID.Hex()] != until {
+		t.Errorf("Expected the active-until date to be stored, got %v", until)
+	}
+}
+
+// Test: un direct dont la période d'activité est échue ne qualifie plus son parent
+func TestCheckQualification_ExpiredActivity(t *testing.T) {
+	service, clientRepo, _, _ := createTestBinaryService()
+	client := setupQualifiedClient(clientRepo, models.NewVolume(10), models.NewVolume(10))
+
+	expired := time.Now().Add(-time.Hour)
+	clientRepo.clients[client.LeftChildID.Hex()].ActiveUntil = &expired
+
+	qualification, err := service.checkQualification(context.Background(), client)
+	if err != nil {
+		t.Fatalf("Erreur inattendue: %v", err)
+	}
+	if qualification.HasDirectLeft || qualification.IsQualified {
+		t.Errorf("Expected the expired left direct not to qualify, got %+v", qualification)
+	}
+	if !qualification.HasDirectRight {
+		t.Error("Expected the right direct to stay active")
+	}
+}
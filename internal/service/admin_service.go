@@ -18,6 +18,8 @@ type AdminService struct {
 	saleRepo       *store.SaleRepository
 	commissionRepo *store.CommissionRepository
 	logger         *zap.Logger
+	location       *time.Location // Fuseau du jour ouvré (plages du tableau de bord)
+	now            func() time.Time
 }
 
 func NewAdminService(
@@ -27,6 +29,7 @@ func NewAdminService(
 	saleRepo *store.SaleRepository,
 	commissionRepo *store.CommissionRepository,
 	logger *zap.Logger,
+	location *time.Location,
 ) *AdminService {
 	return &AdminService{
 		adminRepo:      adminRepo,
@@ -35,6 +38,8 @@ func NewAdminService(
 		saleRepo:       saleRepo,
 		commissionRepo: commissionRepo,
 		logger:         logger,
+		location:       locationOrUTC(location),
+		now:            time.Now,
 	}
 }
 
@@ -46,23 +51,10 @@ func (s *AdminService) GetDashboardStats(ctx context.Context, rangeArg *string)
 	}
 
 	// Calculate date range
-	var dateFrom *time.Time
-	now := time.Now()
-
-	switch *rangeArg {
-	case "7d":
-		from := now.AddDate(0, 0, -7)
-		dateFrom = &from
-	case "30d":
-		from := now.AddDate(0, 0, -30)
-		dateFrom = &from
-	case "90d":
-		from := now.AddDate(0, 0, -90)
-		dateFrom = &from
-	case "1y":
-		from := now.AddDate(-1, 0, 0)
-		dateFrom = &from
-	}
+	// Les plages couvrent des jours ouvrés entiers: aujourd'hui (depuis minuit dans le
+	// fuseau de l'entreprise) et les jours précédents
+	now := s.now()
+	dateFrom := dashboardRangeStart(*rangeArg, now, s.location)
 
 	// Create filter for date range
 	filter := &models.FilterInput{
@@ -149,28 +141,24 @@ func (s *AdminService) GetDashboardStats(ctx context.Context, rangeArg *string)
 	return stats, nil
 }
 
+// dashboardRangeStart retourne le début d'une plage du tableau de bord ("7d", "30d", "90d", "1y"):
+// minuit du premier jour ouvré de la plage, aujourd'hui compris. nil pour une plage inconnue (tout l'historique)
+func dashboardRangeStart(rangeArg string, now time.Time, location *time.Location) *time.Time {
+	today := startOfDay(now, location)
 
+	var from time.Time
+	switch rangeArg {
+	case "7d":
+		from = today.AddDate(0, 0, -6)
+	case "30d":
+		from = today.AddDate(0, 0, -29)
+	case "90d":
+		from = today.AddDate(0, 0, -89)
+	case "1y":
+		from = today.AddDate(-1, 0, 1)
+	default:
+		return nil
+	}
 
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
+	return &from
+}
@@ -0,0 +1,248 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"bureau/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+// Interfaces pour permettre l'utilisation de mocks dans les tests
+type clientLister interface {
+	GetAllIDs(ctx context.Context) ([]primitive.ObjectID, error)
+}
+
+type binaryRunRepository interface {
+	Create(ctx context.Context, run *models.BinaryCommissionRun) (*models.BinaryCommissionRun, error)
+	GetByID(ctx context.Context, id string) (*models.BinaryCommissionRun, error)
+	GetAll(ctx context.Context, paging *models.PagingInput) ([]*models.BinaryCommissionRun, error)
+	SetTotal(ctx context.Context, runID primitive.ObjectID, total int) error
+	RecordProcessed(ctx context.Context, runID primitive.ObjectID, cycles int, amount models.Money) error
+	RecordSkipped(ctx context.Context, runID primitive.ObjectID) error
+	RecordError(ctx context.Context, runID primitive.ObjectID, runErr models.BinaryRunError) error
+	Finish(ctx context.Context, runID primitive.ObjectID, status string) error
+	Claim(ctx context.Context, clientID primitive.ObjectID, period string, runID primitive.ObjectID, lease time.Duration) (bool, error)
+	CompleteClaim(ctx context.Context, clientID primitive.ObjectID, period string, commissionID primitive.ObjectID, amount models.Money) error
+	ReleaseClaim(ctx context.Context, clientID primitive.ObjectID, period string) error
+}
+
+// binaryClaimLease est la durée pendant laquelle une exécution tient la réservation d'un client
+// en cours de calcul. Passé ce délai sans paiement (exécution interrompue), une autre exécution
+// de la période reprend le client; le moteur déduisant le volume utilisé à chaque paiement,
+// reprendre un client payé dont la réservation n'a pas été complétée ne le repaie pas.
+const binaryClaimLease = 15 * time.Minute
+
+// ErrBinaryRunInProgress est renvoyée quand une exécution batch tourne déjà dans ce processus
+var ErrBinaryRunInProgress = errors.New("une exécution batch des commissions binaires est déjà en cours")
+
+// BinaryBatchService exécute le calcul binaire sur tout le réseau
+// Chaque client est réservé pour la période avant d'être payé, ce qui rend une exécution rejouable
+type BinaryBatchService struct {
+	engine     BinaryEngine
+	clientRepo clientLister
+	runRepo    binaryRunRepository
+	logger     *zap.Logger
+	workers    int
+	running    sync.Mutex
+}
+
+// NewBinaryBatchService crée un nouveau service d'exécution batch
+func NewBinaryBatchService(
+	engine BinaryEngine,
+	clientRepo clientLister,
+	runRepo binaryRunRepository,
+	logger *zap.Logger,
+	workers int,
+) *BinaryBatchService {
+	if workers <= 0 {
+		workers = 1
+	}
+	return &BinaryBatchService{
+		engine:     engine,
+		clientRepo: clientRepo,
+		runRepo:    runRepo,
+		logger:     logger,
+		workers:    workers,
+	}
+}
+
+// Start démarre une exécution en arrière-plan et retourne immédiatement l'enregistrement créé
+func (s *BinaryBatchService) Start(ctx context.Context) (*models.BinaryCommissionRun, error) {
+	if !s.running.TryLock() {
+		return nil, ErrBinaryRunInProgress
+	}
+
+	run, err := s.createRun(ctx)
+	if err != nil {
+		s.running.Unlock()
+		return nil, err
+	}
+
+	go func() {
+		defer s.running.Unlock()
+		// Détaché de la requête: l'exécution continue après la réponse GraphQL
+		s.execute(context.Background(), run)
+	}()
+
+	return run, nil
+}
+
+// Run exécute un batch de manière synchrone et retourne l'enregistrement final
+func (s *BinaryBatchService) Run(ctx context.Context) (*models.BinaryCommissionRun, error) {
+	if !s.running.TryLock() {
+		return nil, ErrBinaryRunInProgress
+	}
+	defer s.running.Unlock()
+
+	run, err := s.createRun(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	s.execute(ctx, run)
+
+	return s.runRepo.GetByID(ctx, run.ID.Hex())
+}
+
+// GetRun récupère une exécution par son ID
+func (s *BinaryBatchService) GetRun(ctx context.Context, id string) (*models.BinaryCommissionRun, error) {
+	return s.runRepo.GetByID(ctx, id)
+}
+
+// GetRuns récupère l'historique des exécutions
+func (s *BinaryBatchService) GetRuns(ctx context.Context, paging *models.PagingInput) ([]*models.BinaryCommissionRun, error) {
+	return s.runRepo.GetAll(ctx, paging)
+}
+
+func (s *BinaryBatchService) createRun(ctx context.Context) (*models.BinaryCommissionRun, error) {
+	now := time.Now()
+	run := &models.BinaryCommissionRun{
+		Period:    s.engine.PeriodKey(now),
+		Status:    models.BinaryRunStatusRunning,
+		StartedAt: now,
+	}
+
+	created, err := s.runRepo.Create(ctx, run)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create binary run: %w", err)
+	}
+	return created, nil
+}
+
+// execute distribue les clients à un pool de workers borné
+func (s *BinaryBatchService) execute(ctx context.Context, run *models.BinaryCommissionRun) {
+	status := models.BinaryRunStatusCompleted
+	defer func() {
+		if err := s.runRepo.Finish(ctx, run.ID, status); err != nil {
+			s.logger.Error("Failed to finish binary run", zap.String("runID", run.ID.Hex()), zap.Error(err))
+		}
+	}()
+
+	clientIDs, err := s.clientRepo.GetAllIDs(ctx)
+	if err != nil {
+		s.logger.Error("Failed to list clients for binary run", zap.String("runID", run.ID.Hex()), zap.Error(err))
+		status = models.BinaryRunStatusFailed
+		return
+	}
+
+	if err := s.runRepo.SetTotal(ctx, run.ID, len(clientIDs)); err != nil {
+		s.logger.Error("Failed to set binary run total", zap.String("runID", run.ID.Hex()), zap.Error(err))
+	}
+
+	jobs := make(chan primitive.ObjectID)
+	var wg sync.WaitGroup
+	for i := 0; i < s.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for clientID := range jobs {
+				s.processClient(ctx, run, clientID)
+			}
+		}()
+	}
+
+	for _, clientID := range clientIDs {
+		if ctx.Err() != nil {
+			status = models.BinaryRunStatusFailed
+			break
+		}
+		jobs <- clientID
+	}
+	close(jobs)
+	wg.Wait()
+
+	s.logger.Info("Binary commission run finished",
+		zap.String("runID", run.ID.Hex()),
+		zap.String("period", run.Period),
+		zap.Int("clients", len(clientIDs)),
+		zap.String("status", status),
+	)
+}
+
+// processClient réserve le client pour la période, calcule sa commission et enregistre le résultat
+func (s *BinaryBatchService) processClient(ctx context.Context, run *models.BinaryCommissionRun, clientID primitive.ObjectID) {
+	claimed, err := s.runRepo.Claim(ctx, clientID, run.Period, run.ID, binaryClaimLease)
+	if err != nil {
+		s.recordError(ctx, run, clientID, fmt.Errorf("réservation impossible: %w", err))
+		return
+	}
+	if !claimed {
+		if err := s.runRepo.RecordSkipped(ctx, run.ID); err != nil {
+			s.logger.Error("Failed to record skipped client", zap.String("runID", run.ID.Hex()), zap.Error(err))
+		}
+		return
+	}
+
+	result, err := s.engine.ComputeBinaryCommission(ctx, clientID.Hex())
+	if err == nil && result != nil && !result.Success {
+		err = errors.New(result.Reason)
+	}
+	if err != nil {
+		s.release(ctx, run, clientID)
+		s.recordError(ctx, run, clientID, err)
+		return
+	}
+
+	if result.CyclesPaid == 0 || result.CommissionID == nil {
+		// Rien payé: le client reste éligible pour une exécution ultérieure de la même période
+		s.release(ctx, run, clientID)
+	} else {
+		commissionID, _ := primitive.ObjectIDFromHex(*result.CommissionID)
+		if err := s.runRepo.CompleteClaim(ctx, clientID, run.Period, commissionID, result.Amount); err != nil {
+			s.logger.Error("Failed to complete binary claim", zap.String("clientID", clientID.Hex()), zap.Error(err))
+		}
+	}
+
+	if err := s.runRepo.RecordProcessed(ctx, run.ID, result.CyclesPaid, result.Amount); err != nil {
+		s.logger.Error("Failed to record processed client", zap.String("runID", run.ID.Hex()), zap.Error(err))
+	}
+}
+
+func (s *BinaryBatchService) release(ctx context.Context, run *models.BinaryCommissionRun, clientID primitive.ObjectID) {
+	if err := s.runRepo.ReleaseClaim(ctx, clientID, run.Period); err != nil {
+		s.logger.Error("Failed to release binary claim", zap.String("clientID", clientID.Hex()), zap.Error(err))
+	}
+}
+
+func (s *BinaryBatchService) recordError(ctx context.Context, run *models.BinaryCommissionRun, clientID primitive.ObjectID, err error) {
+	s.logger.Warn("Binary commission failed for client",
+		zap.String("runID", run.ID.Hex()),
+		zap.String("clientID", clientID.Hex()),
+		zap.Error(err),
+	)
+
+	runErr := models.BinaryRunError{
+		ClientID: clientID,
+		Error:    err.Error(),
+		Date:     time.Now(),
+	}
+	if err := s.runRepo.RecordError(ctx, run.ID, runErr); err != nil {
+		s.logger.Error("Failed to record binary run error", zap.String("runID", run.ID.Hex()), zap.Error(err))
+	}
+}
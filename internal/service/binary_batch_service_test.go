@@ -0,0 +1,247 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"bureau/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+// Mock du moteur binaire: paie un cycle à chaque appel pour les clients listés dans paid
+type mockBinaryEngine struct {
+	mu     sync.Mutex
+	paid   map[primitive.ObjectID]bool
+	failed map[primitive.ObjectID]bool
+	calls  map[primitive.ObjectID]int
+}
+
+func (m *mockBinaryEngine) ComputeBinaryCommission(ctx context.Context, clientID string) (*models.BinaryCommissionResult, error) {
+	id, _ := primitive.ObjectIDFromHex(clientID)
+	m.mu.Lock()
+	m.calls[id]++
+	m.mu.Unlock()
+
+	if m.failed[id] {
+		return &models.BinaryCommissionResult{Success: false, Reason: "Erreur"}, errors.New("boom")
+	}
+	if !m.paid[id] {
+		return &models.BinaryCommissionResult{Success: true, Qualified: true, Reason: "Aucun cycle disponible"}, nil
+	}
+	commissionID := primitive.NewObjectID().Hex()
+	return &models.BinaryCommissionResult{Success: true, Qualified: true, CyclesPaid: 1, Amount: models.NewMoney(2), CommissionID: &commissionID}, nil
+}
+
+func (m *mockBinaryEngine) PreviewBinaryCommission(ctx context.Context, clientID string) (*models.BinaryCommissionResult, error) {
+	return &models.BinaryCommissionResult{Success: true, DryRun: true}, nil
+}
+
+func (m *mockBinaryEngine) PeriodKey(date time.Time) string {
+	return "2024-01-15"
+}
+
+type mockClientLister struct {
+	ids []primitive.ObjectID
+}
+
+func (m *mockClientLister) GetAllIDs(ctx context.Context) ([]primitive.ObjectID, error) {
+	return m.ids, nil
+}
+
+type mockRunRepo struct {
+	mu     sync.Mutex
+	runs   map[primitive.ObjectID]*models.BinaryCommissionRun
+	claims map[string]*models.BinaryRunClaim
+}
+
+func (m *mockRunRepo) Create(ctx context.Context, run *models.BinaryCommissionRun) (*models.BinaryCommissionRun, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	run.ID = primitive.NewObjectID()
+	m.runs[run.ID] = run
+	return run, nil
+}
+
+func (m *mockRunRepo) GetByID(ctx context.Context, id string) (*models.BinaryCommissionRun, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	objectID, _ := primitive.ObjectIDFromHex(id)
+	run, ok := m.runs[objectID]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	return run, nil
+}
+
+func (m *mockRunRepo) GetAll(ctx context.Context, paging *models.PagingInput) ([]*models.BinaryCommissionRun, error) {
+	return nil, nil
+}
+
+func (m *mockRunRepo) SetTotal(ctx context.Context, runID primitive.ObjectID, total int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.runs[runID].ClientsTotal = total
+	return nil
+}
+
+func (m *mockRunRepo) RecordProcessed(ctx context.Context, runID primitive.ObjectID, cycles int, amount models.Money) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	run := m.runs[runID]
+	run.ClientsProcessed++
+	if cycles > 0 {
+		run.ClientsPaid++
+		run.CyclesPaid += cycles
+		run.TotalPaid += amount
+	}
+	return nil
+}
+
+func (m *mockRunRepo) RecordSkipped(ctx context.Context, runID primitive.ObjectID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.runs[runID].ClientsProcessed++
+	m.runs[runID].ClientsSkipped++
+	return nil
+}
+
+func (m *mockRunRepo) RecordError(ctx context.Context, runID primitive.ObjectID, runErr models.BinaryRunError) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.runs[runID].ClientsProcessed++
+	m.runs[runID].Errors = append(m.runs[runID].Errors, runErr)
+	return nil
+}
+
+func (m *mockRunRepo) Finish(ctx context.Context, runID primitive.ObjectID, status string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now()
+	m.runs[runID].Status = status
+	m.runs[runID].FinishedAt = &now
+	return nil
+}
+
+func (m *mockRunRepo) Claim(ctx context.Context, clientID primitive.ObjectID, period string, runID primitive.ObjectID, lease time.Duration) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key := period + ":" + clientID.Hex()
+	now := time.Now()
+	if claim, ok := m.claims[key]; ok && (claim.CommissionID != nil || claim.ExpiresAt.After(now)) {
+		return false, nil
+	}
+	expiresAt := now.Add(lease)
+	m.claims[key] = &models.BinaryRunClaim{ID: key, ClientID: clientID, Period: period, RunID: runID, ExpiresAt: &expiresAt}
+	return true, nil
+}
+
+func (m *mockRunRepo) CompleteClaim(ctx context.Context, clientID primitive.ObjectID, period string, commissionID primitive.ObjectID, amount models.Money) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	claim := m.claims[period+":"+clientID.Hex()]
+	claim.CommissionID = &commissionID
+	claim.ExpiresAt = nil
+	return nil
+}
+
+func (m *mockRunRepo) ReleaseClaim(ctx context.Context, clientID primitive.ObjectID, period string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.claims, period+":"+clientID.Hex())
+	return nil
+}
+
+// Test: une exécution rejouée sur la même période ne repaie pas les clients déjà payés
+func TestBinaryBatch_RerunIsIdempotent(t *testing.T) {
+	paidClient := primitive.NewObjectID()
+	unpaidClient := primitive.NewObjectID()
+	failingClient := primitive.NewObjectID()
+
+	engine := &mockBinaryEngine{
+		paid:   map[primitive.ObjectID]bool{paidClient: true},
+		failed: map[primitive.ObjectID]bool{failingClient: true},
+		calls:  make(map[primitive.ObjectID]int),
+	}
+	runRepo := &mockRunRepo{
+		runs:   make(map[primitive.ObjectID]*models.BinaryCommissionRun),
+		claims: make(map[string]*models.BinaryRunClaim),
+	}
+	lister := &mockClientLister{ids: []primitive.ObjectID{paidClient, unpaidClient, failingClient}}
+	service := NewBinaryBatchService(engine, lister, runRepo, zap.NewNop(), 2)
+	ctx := context.Background()
+
+	first, err := service.Run(ctx)
+	if err != nil {
+		t.Fatalf("Erreur inattendue: %v", err)
+	}
+	if first.Status != models.BinaryRunStatusCompleted {
+		t.Errorf("Expected status completed, got %s", first.Status)
+	}
+	if first.ClientsTotal != 3 || first.ClientsProcessed != 3 {
+		t.Errorf("Expected 3 clients processed, got %d/%d", first.ClientsProcessed, first.ClientsTotal)
+	}
+	if first.ClientsPaid != 1 || first.TotalPaid != models.NewMoney(2) {
+		t.Errorf("Expected 1 client paid for 2, got %d for %s", first.ClientsPaid, first.TotalPaid)
+	}
+	if len(first.Errors) != 1 || first.Errors[0].ClientID != failingClient {
+		t.Errorf("Expected one error for the failing client, got %+v", first.Errors)
+	}
+
+	second, err := service.Run(ctx)
+	if err != nil {
+		t.Fatalf("Erreur inattendue: %v", err)
+	}
+	if second.ClientsPaid != 0 || second.ClientsSkipped != 1 {
+		t.Errorf("Expected paid client to be skipped on re-run, got paid=%d skipped=%d", second.ClientsPaid, second.ClientsSkipped)
+	}
+	if engine.calls[paidClient] != 1 {
+		t.Errorf("Expected paid client to be computed once, got %d", engine.calls[paidClient])
+	}
+	// Les clients non payés ou en erreur sont retentés
+	if engine.calls[unpaidClient] != 2 || engine.calls[failingClient] != 2 {
+		t.Errorf("Expected unpaid/failing clients to be retried, got %d/%d", engine.calls[unpaidClient], engine.calls[failingClient])
+	}
+}
+
+// Test: la réservation d'une exécution interrompue est reprise à son expiration, pas avant
+func TestBinaryBatch_RetakesExpiredClaims(t *testing.T) {
+	abandoned := primitive.NewObjectID()
+	inProgress := primitive.NewObjectID()
+
+	engine := &mockBinaryEngine{
+		paid:  map[primitive.ObjectID]bool{abandoned: true, inProgress: true},
+		calls: make(map[primitive.ObjectID]int),
+	}
+	expired := time.Now().Add(-time.Minute)
+	running := time.Now().Add(time.Minute)
+	deadRun := primitive.NewObjectID()
+	runRepo := &mockRunRepo{
+		runs: make(map[primitive.ObjectID]*models.BinaryCommissionRun),
+		claims: map[string]*models.BinaryRunClaim{
+			"2024-01-15:" + abandoned.Hex():  {ClientID: abandoned, RunID: deadRun, ExpiresAt: &expired},
+			"2024-01-15:" + inProgress.Hex(): {ClientID: inProgress, RunID: primitive.NewObjectID(), ExpiresAt: &running},
+		},
+	}
+	lister := &mockClientLister{ids: []primitive.ObjectID{abandoned, inProgress}}
+	service := NewBinaryBatchService(engine, lister, runRepo, zap.NewNop(), 1)
+
+	run, err := service.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Erreur inattendue: %v", err)
+	}
+	if run.ClientsPaid != 1 || run.ClientsSkipped != 1 {
+		t.Errorf("Expected the expired claim to be retaken and the live one skipped, got paid=%d skipped=%d", run.ClientsPaid, run.ClientsSkipped)
+	}
+	if engine.calls[abandoned] != 1 || engine.calls[inProgress] != 0 {
+		t.Errorf("Unexpected engine calls: abandoned=%d inProgress=%d", engine.calls[abandoned], engine.calls[inProgress])
+	}
+	claim := runRepo.claims["2024-01-15:"+abandoned.Hex()]
+	if claim.RunID != run.ID || claim.CommissionID == nil || claim.ExpiresAt != nil {
+		t.Errorf("Expected the retaken claim to be completed by the new run, got %+v", claim)
+	}
+}
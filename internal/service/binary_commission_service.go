@@ -2,8 +2,8 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"math"
 	"sync"
 	"time"
 
@@ -16,34 +16,46 @@ import (
 // Interfaces pour permettre l'utilisation de mocks dans les tests
 type clientRepository interface {
 	GetByID(ctx context.Context, id string) (*models.Client, error)
-	UpdateNetworkVolumes(ctx context.Context, id string, left, right float64) error
-	UpdateEarnings(ctx context.Context, id string, totalEarnings, walletBalance float64) error
+	UpdateNetworkVolumes(ctx context.Context, id string, left, right models.Volume) error
+	DeductLegVolumes(ctx context.Context, id string, volume models.Volume) (*models.Client, error)
 }
 
 type commissionRepository interface {
 	Create(ctx context.Context, commission *models.Commission) (*models.Commission, error)
 }
 
-type saleRepository interface {
-	GetByClientID(ctx context.Context, clientID string) ([]*models.Sale, error)
-}
-
 type binaryCappingRepository interface {
-	GetByClientIDAndDate(ctx context.Context, clientID primitive.ObjectID, date time.Time) (*models.BinaryCapping, error)
+	GetByClientIDAndDate(ctx context.Context, clientID primitive.ObjectID, date time.Time, weekStart time.Time) (*models.BinaryCapping, error)
+	Peek(ctx context.Context, clientID primitive.ObjectID, date time.Time, weekStart time.Time) (*models.BinaryCapping, error)
 	Update(ctx context.Context, capping *models.BinaryCapping) error
-	IncrementCycles(ctx context.Context, clientID primitive.ObjectID, date time.Time, cycles int) error
+	IncrementCycles(ctx context.Context, clientID primitive.ObjectID, date time.Time, weekStart time.Time, cycles int) error
+}
+
+type binaryCycleRepository interface {
+	Create(ctx context.Context, cycle *models.BinaryCycle) (*models.BinaryCycle, error)
+	GetByClientID(ctx context.Context, clientID string, filter *models.FilterInput, paging *models.PagingInput) ([]*models.BinaryCycle, error)
 }
 
+// Raisons renvoyées lorsque le plafond de cycles limite le paiement
+const (
+	reasonDailyCapReached  = "Limite journalière atteinte"
+	reasonWeeklyCapReached = "Limite hebdomadaire atteinte"
+)
+
 // BinaryCommissionService gère le calcul et le paiement des commissions binaires MLM
 type BinaryCommissionService struct {
 	clientRepo     clientRepository
 	commissionRepo commissionRepository
-	saleRepo       saleRepository
 	cappingRepo    binaryCappingRepository
+	cycleRepo      binaryCycleRepository
+	wallet         walletLedger
 	logger         *zap.Logger
 	config         models.BinaryConfig
 	mu             sync.Mutex        // Pour éviter les doubles paiements (fallback si transactions non disponibles)
 	txHelper       transactionHelper // Helper pour les transactions atomiques
+	now            func() time.Time  // Horloge (remplacée par le simulateur de plan)
+	matching       *matchingBonusCalculator
+	location       *time.Location // Fuseau du jour ouvré (bornes des plafonds)
 }
 
 // transactionHelper interface pour les transactions
@@ -55,123 +67,78 @@ type transactionHelper interface {
 func NewBinaryCommissionService(
 	clientRepo clientRepository,
 	commissionRepo commissionRepository,
-	saleRepo saleRepository,
 	cappingRepo binaryCappingRepository,
+	cycleRepo binaryCycleRepository,
+	wallet walletLedger,
 	logger *zap.Logger,
 	config models.BinaryConfig,
 	txHelper transactionHelper,
+	location *time.Location,
 ) *BinaryCommissionService {
 	return &BinaryCommissionService{
 		clientRepo:     clientRepo,
 		commissionRepo: commissionRepo,
-		saleRepo:       saleRepo,
 		cappingRepo:    cappingRepo,
+		cycleRepo:      cycleRepo,
+		wallet:         wallet,
 		logger:         logger,
 		config:         config,
 		txHelper:       txHelper,
+		now:            time.Now,
+		location:       locationOrUTC(location),
+		matching: &matchingBonusCalculator{
+			clientRepo:     clientRepo,
+			commissionRepo: commissionRepo,
+			wallet:         wallet,
+			logger:         logger,
+		},
 	}
 }
 
-// ComputeBinaryCommission calcule et paie la commission binaire pour un membre
-// C'est la fonction principale qui orchestre tout le processus
-func (s *BinaryCommissionService) ComputeBinaryCommission(ctx context.Context, clientID string) (*models.BinaryCommissionResult, error) {
-	// 1. Vérifier que le client existe
-	client, err := s.clientRepo.GetByID(ctx, clientID)
-	if err != nil {
-		return &models.BinaryCommissionResult{
-			Success: false,
-			Reason:  "Client introuvable",
-		}, err
-	}
-
-	// 2. Vérifier la qualification
-	qualification, err := s.checkQualification(ctx, client)
-	if err != nil {
-		return &models.BinaryCommissionResult{
-			Success: false,
-			Reason:  fmt.Sprintf("Erreur lors de la vérification de qualification: %v", err),
-		}, err
-	}
-
-	if !qualification.IsQualified {
-		return &models.BinaryCommissionResult{
-			Success:   true,
-			Qualified: false,
-			Reason:    "Membre non qualifié: doit avoir au moins 1 direct actif à gauche ET 1 direct actif à droite",
-		}, nil
-	}
-
-	// 3. Lire les volumes des jambes
-	legs, err := s.getLegsVolumes(ctx, client)
-	if err != nil {
-		return &models.BinaryCommissionResult{
-			Success: false,
-			Reason:  fmt.Sprintf("Erreur lors de la lecture des volumes: %v", err),
-		}, err
-	}
+// SetClock remplace l'horloge utilisée pour le capping et la date des paiements
+// Utilisé par le simulateur de plan pour rejouer des ventes sur plusieurs jours
+func (s *BinaryCommissionService) SetClock(now func() time.Time) {
+	s.now = now
+}
 
-	// 4. Vérifier les conditions de base (au moins 1 actif de chaque côté)
-	if legs.LeftActives == 0 || legs.RightActives == 0 {
-		return &models.BinaryCommissionResult{
-			Success:              true,
-			Qualified:            true,
-			Reason:               "Jambe gauche ou droite vide - aucun cycle possible",
-			LeftVolumeRemaining:  legs.LeftVolume,
-			RightVolumeRemaining: legs.RightVolume,
-		}, nil
-	}
+// ComputeBinaryCommission calcule et paie la commission binaire pour un membre avec la configuration du service
+// En production, PlanBinaryEngine appelle computeWithPlan avec la version du plan en vigueur
+func (s *BinaryCommissionService) ComputeBinaryCommission(ctx context.Context, clientID string) (*models.BinaryCommissionResult, error) {
+	return s.computeWithPlan(ctx, clientID, &models.CompPlanVersion{Binary: s.config}, false)
+}
 
-	// 5. Calculer les cycles possibles à partir des volumes
-	cyclesAvailable := s.calculateCycles(legs)
-
-	if cyclesAvailable == 0 {
-		return &models.BinaryCommissionResult{
-			Success:              true,
-			Qualified:            true,
-			Reason:               "Aucun cycle disponible - volumes insuffisants",
-			LeftVolumeRemaining:  legs.LeftVolume,
-			RightVolumeRemaining: legs.RightVolume,
-		}, nil
-	}
+// PreviewBinaryCommission simule le calcul sans rien écrire (capping, commissions, volumes, gains)
+func (s *BinaryCommissionService) PreviewBinaryCommission(ctx context.Context, clientID string) (*models.BinaryCommissionResult, error) {
+	return s.computeWithPlan(ctx, clientID, &models.CompPlanVersion{Binary: s.config}, true)
+}
 
-	// 6. Appliquer la limite journalière
-	cyclesToPay, err := s.applyDailyLimit(ctx, client.ID, cyclesAvailable)
-	if err != nil {
-		return &models.BinaryCommissionResult{
-			Success: false,
-			Reason:  fmt.Sprintf("Erreur lors de l'application de la limite: %v", err),
-		}, err
-	}
+// computeWithPlan est la fonction principale qui orchestre tout le processus
+// avec les règles de la version du plan fournie. En mode simulation, le résultat de
+// l'évaluation est retourné tel quel: rien n'est écrit.
+func (s *BinaryCommissionService) computeWithPlan(ctx context.Context, clientID string, plan *models.CompPlanVersion, simulate bool) (*models.BinaryCommissionResult, error) {
+	cfg := plan.Binary
 
-	if cyclesToPay == 0 {
-		return &models.BinaryCommissionResult{
-			Success:              true,
-			Qualified:            true,
-			CyclesAvailable:      cyclesAvailable,
-			CyclesPaid:           0,
-			Reason:               "Limite journalière atteinte",
-			LeftVolumeRemaining:  legs.LeftVolume,
-			RightVolumeRemaining: legs.RightVolume,
-		}, nil
+	// 1 à 7. Évaluer ce qui serait payé
+	client, legs, result, err := s.evaluate(ctx, clientID, plan)
+	result.DryRun = simulate
+	if err != nil || simulate || result.CyclesPaid == 0 {
+		return result, err
 	}
-
-	// 7. Calculer le montant basé sur le volume faible
-	minVolumePerLeg := s.getMinVolumePerLeg()
-	volumeUsed := float64(cyclesToPay) * minVolumePerLeg
-	amount := s.calculateAmount(volumeUsed)
+	cyclesAvailable := result.CyclesAvailable
 
 	// 8. Enregistrer le paiement avec transaction atomique
 	var commission *models.Commission
 	var cyclesToPayFinal int
-	var leftRemaining, rightRemaining float64
-	var commissionID string
+	var capReason string
+	var volumeUsed, leftRemaining, rightRemaining models.Volume
+	var amount models.Money
 
 	// Utiliser une transaction atomique pour toutes les opérations critiques
 	if s.txHelper != nil {
 		err = s.txHelper.ExecuteTransaction(ctx, func(txCtx context.Context) error {
-			// Double vérification de la limite journalière dans la transaction
+			// Double vérification des limites dans la transaction
 			var err error
-			cyclesToPayFinal, err = s.applyDailyLimit(txCtx, client.ID, cyclesAvailable)
+			cyclesToPayFinal, capReason, err = s.applyCycleLimits(txCtx, cfg, client.ID, cyclesAvailable)
 			if err != nil {
 				return fmt.Errorf("erreur lors de la vérification de la limite: %w", err)
 			}
@@ -180,24 +147,28 @@ func (s *BinaryCommissionService) ComputeBinaryCommission(ctx context.Context, c
 				return nil // Pas d'erreur, juste pas de cycles à payer
 			}
 
-			minVolumePerLeg := s.getMinVolumePerLeg()
-			volumeUsed = float64(cyclesToPayFinal) * minVolumePerLeg
-			amount = s.calculateAmount(volumeUsed)
+			// Réserver les cycles sur les compteurs journalier et hebdomadaire
+			if err := s.reserveCycles(txCtx, cfg, client.ID, cyclesToPayFinal); err != nil {
+				return fmt.Errorf("erreur lors de la mise à jour du capping: %w", err)
+			}
+
+			volumeUsed = models.Volume(cyclesToPayFinal) * s.getMinVolumePerLeg(cfg)
+			amount = s.calculateAmount(cfg, cyclesToPayFinal, volumeUsed)
 
 			// Créer la commission
-			commission, err = s.recordPayment(txCtx, client.ID, cyclesToPayFinal, amount)
+			commission, err = s.recordPayment(txCtx, plan, client.ID, legs, cyclesAvailable, cyclesToPayFinal, volumeUsed, amount)
 			if err != nil {
 				return fmt.Errorf("erreur lors de l'enregistrement du paiement: %w", err)
 			}
 
 			// Déduire les volumes utilisés
-			leftRemaining, rightRemaining, err = s.deductVolume(txCtx, client.ID, legs, volumeUsed)
+			leftRemaining, rightRemaining, err = s.deductVolume(txCtx, client.ID, volumeUsed)
 			if err != nil {
 				return fmt.Errorf("erreur lors de la déduction des volumes: %w", err)
 			}
 
-			// Mettre à jour les gains du client
-			err = s.updateClientEarnings(txCtx, client.ID.Hex(), amount)
+			// Créditer le portefeuille du client
+			_, err = s.wallet.Post(txCtx, commissionEntry(commission))
 			if err != nil {
 				return fmt.Errorf("erreur lors de la mise à jour des gains: %w", err)
 			}
@@ -211,27 +182,13 @@ func (s *BinaryCommissionService) ComputeBinaryCommission(ctx context.Context, c
 				Reason:  fmt.Sprintf("Erreur lors de la transaction atomique: %v", err),
 			}, err
 		}
-
-		if cyclesToPayFinal == 0 {
-			return &models.BinaryCommissionResult{
-				Success:              true,
-				Qualified:            true,
-				CyclesAvailable:      cyclesAvailable,
-				CyclesPaid:           0,
-				Reason:               "Limite journalière atteinte",
-				LeftVolumeRemaining:  legs.LeftVolume,
-				RightVolumeRemaining: legs.RightVolume,
-			}, nil
-		}
-
-		commissionID = commission.ID.Hex()
 	} else {
 		// Fallback: utiliser mutex si transactions non disponibles
 		s.mu.Lock()
 		defer s.mu.Unlock()
 
 		// Double vérification après verrouillage
-		cyclesToPayFinal, err = s.applyDailyLimit(ctx, client.ID, cyclesAvailable)
+		cyclesToPayFinal, capReason, err = s.applyCycleLimits(ctx, cfg, client.ID, cyclesAvailable)
 		if err != nil {
 			return &models.BinaryCommissionResult{
 				Success: false,
@@ -239,60 +196,143 @@ func (s *BinaryCommissionService) ComputeBinaryCommission(ctx context.Context, c
 			}, err
 		}
 
-		if cyclesToPayFinal == 0 {
-			return &models.BinaryCommissionResult{
-				Success:              true,
-				Qualified:            true,
-				CyclesAvailable:      cyclesAvailable,
-				CyclesPaid:           0,
-				Reason:               "Limite journalière atteinte (double vérification)",
-				LeftVolumeRemaining:  legs.LeftVolume,
-				RightVolumeRemaining: legs.RightVolume,
-			}, nil
-		}
+		if cyclesToPayFinal > 0 {
+			if err := s.reserveCycles(ctx, cfg, client.ID, cyclesToPayFinal); err != nil {
+				return &models.BinaryCommissionResult{
+					Success: false,
+					Reason:  fmt.Sprintf("Erreur lors de la mise à jour du capping: %v", err),
+				}, err
+			}
 
-		minVolumePerLeg := s.getMinVolumePerLeg()
-		volumeUsed = float64(cyclesToPayFinal) * minVolumePerLeg
-		amount = s.calculateAmount(volumeUsed)
+			volumeUsed = models.Volume(cyclesToPayFinal) * s.getMinVolumePerLeg(cfg)
+			amount = s.calculateAmount(cfg, cyclesToPayFinal, volumeUsed)
 
-		// Créer la commission
-		commission, err = s.recordPayment(ctx, client.ID, cyclesToPayFinal, amount)
-		if err != nil {
-			return &models.BinaryCommissionResult{
-				Success: false,
-				Reason:  fmt.Sprintf("Erreur lors de l'enregistrement du paiement: %v", err),
-			}, err
-		}
+			// Créer la commission
+			commission, err = s.recordPayment(ctx, plan, client.ID, legs, cyclesAvailable, cyclesToPayFinal, volumeUsed, amount)
+			if err != nil {
+				return &models.BinaryCommissionResult{
+					Success: false,
+					Reason:  fmt.Sprintf("Erreur lors de l'enregistrement du paiement: %v", err),
+				}, err
+			}
 
-		// Déduire les volumes utilisés
-		leftRemaining, rightRemaining, err = s.deductVolume(ctx, client.ID, legs, volumeUsed)
-		if err != nil {
-			return &models.BinaryCommissionResult{
-				Success: false,
-				Reason:  fmt.Sprintf("Erreur lors de la déduction des volumes: %v", err),
-			}, err
-		}
+			// Déduire les volumes utilisés
+			leftRemaining, rightRemaining, err = s.deductVolume(ctx, client.ID, volumeUsed)
+			if err != nil {
+				return &models.BinaryCommissionResult{
+					Success: false,
+					Reason:  fmt.Sprintf("Erreur lors de la déduction des volumes: %v", err),
+				}, err
+			}
 
-		// Mettre à jour les gains du client
-		err = s.updateClientEarnings(ctx, client.ID.Hex(), amount)
-		if err != nil {
-			s.logger.Error("Failed to update client earnings", zap.Error(err))
-			// Ne pas échouer complètement si c'est juste la mise à jour des gains
+			// Créditer le portefeuille du client
+			_, err = s.wallet.Post(ctx, commissionEntry(commission))
+			if err != nil {
+				return &models.BinaryCommissionResult{
+					Success: false,
+					Reason:  fmt.Sprintf("Erreur lors de la mise à jour des gains: %v", err),
+				}, err
+			}
 		}
+	}
 
-		commissionID = commission.ID.Hex()
+	// Un paiement concurrent a pu consommer le plafond entre l'évaluation et le paiement
+	result.CyclesPaid = cyclesToPayFinal
+	result.CyclesCapped = cyclesAvailable - cyclesToPayFinal
+	result.Reason = capReason // Renseignée si un plafond a réduit le paiement
+	if cyclesToPayFinal == 0 {
+		result.Amount = 0
+		result.LeftVolumeRemaining = legs.LeftVolume
+		result.RightVolumeRemaining = legs.RightVolume
+		return result, nil
 	}
 
-	return &models.BinaryCommissionResult{
-		Success:              true,
-		Qualified:            true,
-		CyclesAvailable:      cyclesAvailable,
-		CyclesPaid:           cyclesToPayFinal,
-		Amount:               amount,
-		LeftVolumeRemaining:  leftRemaining,
-		RightVolumeRemaining: rightRemaining,
-		CommissionID:         &commissionID,
-	}, nil
+	commissionID := commission.ID.Hex()
+	result.Amount = amount
+	result.LeftVolumeRemaining = leftRemaining
+	result.RightVolumeRemaining = rightRemaining
+	result.CommissionID = &commissionID
+	return result, nil
+}
+
+// evaluate vérifie la qualification, lit les jambes et applique les plafonds sans rien écrire
+// Le résultat décrit ce qui serait payé; client et legs sont nil si l'évaluation s'arrête avant
+func (s *BinaryCommissionService) evaluate(ctx context.Context, clientID string, plan *models.CompPlanVersion) (*models.Client, *models.BinaryLegs, *models.BinaryCommissionResult, error) {
+	cfg := plan.Binary
+	result := &models.BinaryCommissionResult{PlanVersion: plan.Version}
+
+	// 1. Vérifier que le client existe
+	client, err := s.clientRepo.GetByID(ctx, clientID)
+	if err != nil {
+		result.Reason = "Client introuvable"
+		return nil, nil, result, err
+	}
+
+	// 2. Vérifier la qualification
+	qualification, err := s.checkQualification(ctx, client)
+	if err != nil {
+		result.Reason = fmt.Sprintf("Erreur lors de la vérification de qualification: %v", err)
+		return nil, nil, result, err
+	}
+	result.Qualification = qualification
+
+	if !qualification.IsQualified {
+		result.Success = true
+		result.Reason = "Membre non qualifié: doit avoir au moins 1 direct actif à gauche ET 1 direct actif à droite"
+		return client, nil, result, nil
+	}
+
+	// 3. Lire les volumes des jambes
+	legs, err := s.getLegsVolumes(ctx, client)
+	if err != nil {
+		result.Reason = fmt.Sprintf("Erreur lors de la lecture des volumes: %v", err)
+		return nil, nil, result, err
+	}
+	result.Qualified = true
+	result.Legs = legs
+	result.LeftVolumeRemaining = legs.LeftVolume
+	result.RightVolumeRemaining = legs.RightVolume
+
+	// 4. Vérifier les conditions de base (au moins 1 actif de chaque côté)
+	if legs.LeftActives == 0 || legs.RightActives == 0 {
+		result.Success = true
+		result.Reason = "Jambe gauche ou droite vide - aucun cycle possible"
+		return client, legs, result, nil
+	}
+
+	// 5. Calculer les cycles possibles à partir des volumes
+	result.CyclesAvailable = s.calculateCycles(cfg, legs)
+	if result.CyclesAvailable == 0 {
+		result.Success = true
+		result.Reason = "Aucun cycle disponible - volumes insuffisants"
+		return client, legs, result, nil
+	}
+
+	// 6. Appliquer les limites journalière et hebdomadaire (lecture seule des compteurs)
+	usage, err := s.peekCapping(ctx, cfg, client.ID)
+	if err != nil {
+		result.Reason = fmt.Sprintf("Erreur lors de l'application de la limite: %v", err)
+		return nil, nil, result, err
+	}
+	result.Success = true
+	result.CyclesPaidToday = usage.CyclesPaidToday
+	result.CyclesPaidThisWeek = usage.CyclesPaidThisWeek
+
+	cyclesToPay, capReason := s.limitCycles(cfg, usage, result.CyclesAvailable)
+	result.CyclesCapped = result.CyclesAvailable - cyclesToPay
+	result.Reason = capReason
+	if cyclesToPay == 0 {
+		return client, legs, result, nil
+	}
+
+	// 7. Calculer le montant des cycles payés
+	volumeUsed := models.Volume(cyclesToPay) * s.getMinVolumePerLeg(cfg)
+	result.CyclesPaid = cyclesToPay
+	result.Amount = s.calculateAmount(cfg, cyclesToPay, volumeUsed)
+	result.LeftVolumeRemaining = max(legs.LeftVolume-volumeUsed, 0)
+	result.RightVolumeRemaining = max(legs.RightVolume-volumeUsed, 0)
+
+	return client, legs, result, nil
 }
 
 // checkQualification vérifie si un membre est qualifié pour recevoir des commissions
@@ -304,9 +344,8 @@ func (s *BinaryCommissionService) checkQualification(ctx context.Context, client
 	if client.LeftChildID != nil {
 		leftChild, err := s.clientRepo.GetByID(ctx, client.LeftChildID.Hex())
 		if err == nil && leftChild != nil {
-			// Un direct est actif s'il a fait au moins 1 vente
-			isActive, err := s.isClientActive(ctx, leftChild.ID.Hex())
-			if err == nil && isActive {
+			// Un direct est actif tant que sa période d'activité n'est pas échue
+			if IsActiveAt(leftChild, s.now()) {
 				qualification.HasDirectLeft = true
 				qualification.DirectLeftCount = 1
 			}
@@ -317,8 +356,7 @@ func (s *BinaryCommissionService) checkQualification(ctx context.Context, client
 	if client.RightChildID != nil {
 		rightChild, err := s.clientRepo.GetByID(ctx, client.RightChildID.Hex())
 		if err == nil && rightChild != nil {
-			isActive, err := s.isClientActive(ctx, rightChild.ID.Hex())
-			if err == nil && isActive {
+			if IsActiveAt(rightChild, s.now()) {
 				qualification.HasDirectRight = true
 				qualification.DirectRightCount = 1
 			}
@@ -331,127 +369,121 @@ func (s *BinaryCommissionService) checkQualification(ctx context.Context, client
 	return qualification, nil
 }
 
-// isClientActive vérifie si un client est actif (a fait au moins 1 vente)
+// isClientActive vérifie si un client est actif d'après sa date de fin d'activité (voir ActivityService)
 func (s *BinaryCommissionService) isClientActive(ctx context.Context, clientID string) (bool, error) {
-	sales, err := s.saleRepo.GetByClientID(ctx, clientID)
+	client, err := s.clientRepo.GetByID(ctx, clientID)
 	if err != nil {
 		return false, err
 	}
-	return len(sales) > 0, nil
+	return IsActiveAt(client, s.now()), nil
 }
 
 // getLegsVolumes récupère les volumes et actifs des jambes gauche et droite
+// Seul le volume confirmé (ventes payées) est apparié; le volume en attente est ignoré
 func (s *BinaryCommissionService) getLegsVolumes(ctx context.Context, client *models.Client) (*models.BinaryLegs, error) {
-	legs := &models.BinaryLegs{
-		LeftVolume:  client.NetworkVolumeLeft,
-		RightVolume: client.NetworkVolumeRight,
-	}
+	// Les actifs de chaque jambe sont comptés sur le membre (LegCountService): lecture O(1)
+	return &models.BinaryLegs{
+		LeftVolume:   client.NetworkVolumeLeft,
+		RightVolume:  client.NetworkVolumeRight,
+		LeftActives:  client.LeftActives,
+		RightActives: client.RightActives,
+	}, nil
+}
 
-	// Compter les actifs dans chaque jambe
-	leftActives, err := s.countActivesInLeg(ctx, client.LeftChildID, "left")
-	if err != nil {
-		return nil, err
+// calculateCycles calcule le nombre de cycles possibles
+// cycles = floor(min(leftVolume, rightVolume) / minVolumePerLeg)
+func (s *BinaryCommissionService) calculateCycles(cfg models.BinaryConfig, legs *models.BinaryLegs) int {
+	if legs.LeftVolume <= 0 || legs.RightVolume <= 0 {
+		return 0
 	}
-	legs.LeftActives = leftActives
 
-	rightActives, err := s.countActivesInLeg(ctx, client.RightChildID, "right")
-	if err != nil {
-		return nil, err
-	}
-	legs.RightActives = rightActives
+	minVolumePerLeg := s.getMinVolumePerLeg(cfg)
+	weakVolume := min(legs.LeftVolume, legs.RightVolume)
+	return int(weakVolume / minVolumePerLeg)
+}
 
-	return legs, nil
+// hasCycleLimits indique si au moins une limite (journalière ou hebdomadaire) est configurée
+func (s *BinaryCommissionService) hasCycleLimits(cfg models.BinaryConfig) bool {
+	return cfg.DailyCycleLimit > 0 || cfg.WeeklyCycleLimit > 0
 }
 
-// countActivesInLeg compte récursivement les membres actifs dans une jambe
-func (s *BinaryCommissionService) countActivesInLeg(ctx context.Context, rootID *primitive.ObjectID, side string) (int, error) {
-	if rootID == nil {
-		return 0, nil
+// applyCycleLimits calcule le nombre de cycles payables compte tenu des limites
+// journalière et hebdomadaire, sans incrémenter les compteurs. La raison retournée
+// indique le plafond qui a réduit le paiement (vide si aucun)
+func (s *BinaryCommissionService) applyCycleLimits(ctx context.Context, cfg models.BinaryConfig, clientID primitive.ObjectID, cyclesAvailable int) (int, string, error) {
+	if !s.hasCycleLimits(cfg) {
+		return cyclesAvailable, "", nil // Pas de limite
 	}
 
-	count := 0
-	visited := make(map[string]bool) // Pour éviter de compter deux fois
-	queue := []*primitive.ObjectID{rootID}
+	// Récupérer ou créer le capping pour aujourd'hui
+	capping, err := s.getOrCreateCapping(ctx, cfg, clientID, s.now())
+	if err != nil {
+		return 0, "", err
+	}
 
-	for len(queue) > 0 {
-		currentID := queue[0]
-		queue = queue[1:]
+	cyclesToPay, reason := s.limitCycles(cfg, capping, cyclesAvailable)
+	return cyclesToPay, reason, nil
+}
 
-		// Éviter les doublons
-		if visited[currentID.Hex()] {
-			continue
-		}
-		visited[currentID.Hex()] = true
+// peekCapping lit les compteurs de capping du jour sans créer ni modifier de document
+func (s *BinaryCommissionService) peekCapping(ctx context.Context, cfg models.BinaryConfig, clientID primitive.ObjectID) (*models.BinaryCapping, error) {
+	if !s.hasCycleLimits(cfg) {
+		return &models.BinaryCapping{ClientID: clientID}, nil
+	}
+	day, weekStart := s.cappingPeriod(cfg, s.now())
+	return s.cappingRepo.Peek(ctx, clientID, day, weekStart)
+}
 
-		client, err := s.clientRepo.GetByID(ctx, currentID.Hex())
-		if err != nil {
-			continue // Ignorer les erreurs et continuer
-		}
+// limitCycles applique les limites journalière et hebdomadaire aux compteurs fournis
+func (s *BinaryCommissionService) limitCycles(cfg models.BinaryConfig, capping *models.BinaryCapping, cyclesAvailable int) (int, string) {
+	cyclesToPay := cyclesAvailable
+	reason := ""
 
-		// Vérifier si ce client est actif
-		isActive, err := s.isClientActive(ctx, currentID.Hex())
-		if err == nil && isActive {
-			count++
+	if cfg.DailyCycleLimit > 0 {
+		remaining := cfg.DailyCycleLimit - capping.CyclesPaidToday
+		if remaining < cyclesToPay {
+			cyclesToPay = max(remaining, 0)
+			reason = reasonDailyCapReached
 		}
+	}
 
-		// Ajouter tous les enfants à la queue (pour compter récursivement)
-		if client.LeftChildID != nil {
-			queue = append(queue, client.LeftChildID)
-		}
-		if client.RightChildID != nil {
-			queue = append(queue, client.RightChildID)
+	if cfg.WeeklyCycleLimit > 0 {
+		remaining := cfg.WeeklyCycleLimit - capping.CyclesPaidThisWeek
+		if remaining < cyclesToPay {
+			cyclesToPay = max(remaining, 0)
+			reason = reasonWeeklyCapReached
 		}
 	}
 
-	return count, nil
+	return cyclesToPay, reason
 }
 
-// calculateCycles calcule le nombre de cycles possibles
-// cycles = floor(min(leftVolume, rightVolume) / minVolumePerLeg)
-func (s *BinaryCommissionService) calculateCycles(legs *models.BinaryLegs) int {
-	if legs.LeftVolume <= 0 || legs.RightVolume <= 0 {
-		return 0
+// reserveCycles incrémente les compteurs de capping pour les cycles payés
+func (s *BinaryCommissionService) reserveCycles(ctx context.Context, cfg models.BinaryConfig, clientID primitive.ObjectID, cycles int) error {
+	if !s.hasCycleLimits(cfg) || cycles <= 0 {
+		return nil
 	}
 
-	minVolumePerLeg := s.getMinVolumePerLeg()
-	weakVolume := math.Min(legs.LeftVolume, legs.RightVolume)
-	return int(math.Floor(weakVolume / minVolumePerLeg))
+	today, weekStart := s.cappingPeriod(cfg, s.now())
+	return s.cappingRepo.IncrementCycles(ctx, clientID, today, weekStart, cycles)
 }
 
-// applyDailyLimit applique la limite journalière de cycles
-func (s *BinaryCommissionService) applyDailyLimit(ctx context.Context, clientID primitive.ObjectID, cyclesAvailable int) (int, error) {
-	if s.config.DailyCycleLimit <= 0 {
-		return cyclesAvailable, nil // Pas de limite
-	}
-
-	// Récupérer ou créer le capping pour aujourd'hui
-	today := time.Now().Truncate(24 * time.Hour)
-	capping, err := s.getOrCreateCapping(ctx, clientID, today)
-	if err != nil {
-		return 0, err
-	}
-
-	// Vérifier si la limite est atteinte
-	if capping.CyclesPaidToday >= s.config.DailyCycleLimit {
-		return 0, nil
-	}
-
-	// Calculer combien de cycles on peut encore payer
-	remainingLimit := s.config.DailyCycleLimit - capping.CyclesPaidToday
-	cyclesToPay := int(math.Min(float64(cyclesAvailable), float64(remainingLimit)))
-
-	// Mettre à jour le capping (incrémenter les cycles payés)
-	err = s.cappingRepo.IncrementCycles(ctx, clientID, today, cyclesToPay)
-	if err != nil {
-		return 0, err
-	}
+// cappingPeriod retourne le début du jour et le début de la semaine de capping pour une date,
+// à minuit dans le fuseau du jour ouvré
+func (s *BinaryCommissionService) cappingPeriod(cfg models.BinaryConfig, date time.Time) (time.Time, time.Time) {
+	return startOfDay(date, s.location), startOfWeek(date, s.location, cfg.WeekStartDay)
+}
 
-	return cyclesToPay, nil
+// PeriodKey retourne l'identifiant de la période de paiement (jour de capping) d'une date
+func (s *BinaryCommissionService) PeriodKey(date time.Time) string {
+	day, _ := s.cappingPeriod(s.config, date)
+	return day.Format("2006-01-02")
 }
 
 // getOrCreateCapping récupère ou crée un enregistrement de capping
-func (s *BinaryCommissionService) getOrCreateCapping(ctx context.Context, clientID primitive.ObjectID, date time.Time) (*models.BinaryCapping, error) {
-	return s.cappingRepo.GetByClientIDAndDate(ctx, clientID, date)
+func (s *BinaryCommissionService) getOrCreateCapping(ctx context.Context, cfg models.BinaryConfig, clientID primitive.ObjectID, date time.Time) (*models.BinaryCapping, error) {
+	day, weekStart := s.cappingPeriod(cfg, date)
+	return s.cappingRepo.GetByClientIDAndDate(ctx, clientID, day, weekStart)
 }
 
 // updateCapping met à jour le capping dans la DB
@@ -459,8 +491,10 @@ func (s *BinaryCommissionService) updateCapping(ctx context.Context, capping *mo
 	return s.cappingRepo.Update(ctx, capping)
 }
 
-// recordPayment enregistre le paiement de commission
-func (s *BinaryCommissionService) recordPayment(ctx context.Context, clientID primitive.ObjectID, cycles int, amount float64) (*models.Commission, error) {
+// recordPayment enregistre le paiement de commission, l'historique du cycle et le bonus
+// de matching des parrains. Les écritures partagent le contexte (et donc la transaction) de l'appelant
+func (s *BinaryCommissionService) recordPayment(ctx context.Context, plan *models.CompPlanVersion, clientID primitive.ObjectID, legs *models.BinaryLegs, cyclesAvailable, cycles int, volumeUsed models.Volume, amount models.Money) (*models.Commission, error) {
+	now := s.now()
 	commission := &models.Commission{
 		ID:             primitive.NewObjectID(),
 		ClientID:       clientID,
@@ -468,7 +502,8 @@ func (s *BinaryCommissionService) recordPayment(ctx context.Context, clientID pr
 		Amount:         amount,
 		Level:          0,
 		Type:           "binary-cycle",
-		Date:           time.Now(),
+		Date:           now,
+		PlanVersion:    plan.Version,
 	}
 
 	created, err := s.commissionRepo.Create(ctx, commission)
@@ -477,56 +512,70 @@ func (s *BinaryCommissionService) recordPayment(ctx context.Context, clientID pr
 	}
 
 	// Enregistrer aussi dans BinaryCycle pour l'historique
-	// TODO: Créer un repository pour BinaryCycle si nécessaire
+	if s.cycleRepo != nil {
+		cycle := &models.BinaryCycle{
+			ClientID:          clientID,
+			CommissionID:      created.ID,
+			CyclesAvailable:   cyclesAvailable,
+			Cycles:            cycles,
+			Amount:            amount,
+			LeftVolumeBefore:  legs.LeftVolume,
+			RightVolumeBefore: legs.RightVolume,
+			LeftVolumeUsed:    volumeUsed,
+			RightVolumeUsed:   volumeUsed,
+			Date:              now,
+			ProcessedAt:       now,
+			PlanVersion:       plan.Version,
+		}
+		if _, err := s.cycleRepo.Create(ctx, cycle); err != nil {
+			return nil, fmt.Errorf("failed to record binary cycle: %w", err)
+		}
+	}
+
+	if _, err := s.matching.pay(ctx, plan.Binary.MatchingBonusRates, created, now); err != nil {
+		return nil, fmt.Errorf("failed to pay matching bonus: %w", err)
+	}
 
 	return created, nil
 }
 
-// deductVolume déduit les volumes utilisés des jambes
-func (s *BinaryCommissionService) deductVolume(ctx context.Context, clientID primitive.ObjectID, legs *models.BinaryLegs, volumeUsed float64) (float64, float64, error) {
-	leftRemaining := legs.LeftVolume - volumeUsed
-	rightRemaining := legs.RightVolume - volumeUsed
-
-	// S'assurer que les volumes ne deviennent pas négatifs
-	if leftRemaining < 0 {
-		leftRemaining = 0
-	}
-	if rightRemaining < 0 {
-		rightRemaining = 0
+// GetCycles récupère l'historique des cycles binaires payés à un client
+func (s *BinaryCommissionService) GetCycles(ctx context.Context, clientID string, filter *models.FilterInput, paging *models.PagingInput) ([]*models.BinaryCycle, error) {
+	if s.cycleRepo == nil {
+		return []*models.BinaryCycle{}, nil
 	}
+	return s.cycleRepo.GetByClientID(ctx, clientID, filter, paging)
+}
 
-	// Mettre à jour dans la DB
-	err := s.clientRepo.UpdateNetworkVolumes(ctx, clientID.Hex(), leftRemaining, rightRemaining)
+// deductVolume déduit le volume apparié des deux jambes par décrément: les jambes lues par
+// evaluate ont pu recevoir du volume depuis, qu'une écriture absolue effacerait. Le paiement
+// est refusé si une jambe ne couvre plus ce volume (reprise concurrente d'une vente).
+func (s *BinaryCommissionService) deductVolume(ctx context.Context, clientID primitive.ObjectID, volumeUsed models.Volume) (models.Volume, models.Volume, error) {
+	updated, err := s.clientRepo.DeductLegVolumes(ctx, clientID.Hex(), volumeUsed)
 	if err != nil {
 		return 0, 0, fmt.Errorf("failed to update network volumes: %w", err)
 	}
-
-	return leftRemaining, rightRemaining, nil
-}
-
-func (s *BinaryCommissionService) getMinVolumePerLeg() float64 {
-	if s.config.MinVolumePerLeg <= 0 {
-		return 1.0
+	if updated == nil {
+		return 0, 0, errors.New("le volume des jambes ne couvre plus les cycles à payer")
 	}
-	return s.config.MinVolumePerLeg
+	return updated.NetworkVolumeLeft, updated.NetworkVolumeRight, nil
 }
 
-func (s *BinaryCommissionService) calculateAmount(volumeUsed float64) float64 {
-	amount := volumeUsed * s.config.CommissionRate
-	return math.Round(amount*100) / 100
+func (s *BinaryCommissionService) getMinVolumePerLeg(cfg models.BinaryConfig) models.Volume {
+	if cfg.MinVolumePerLeg <= 0 {
+		return models.NewVolume(1)
+	}
+	return cfg.MinVolumePerLeg
 }
 
-// updateClientEarnings met à jour les gains totaux et le wallet du client
-func (s *BinaryCommissionService) updateClientEarnings(ctx context.Context, clientID string, amount float64) error {
-	client, err := s.clientRepo.GetByID(ctx, clientID)
-	if err != nil {
-		return err
+// calculateAmount calcule le montant des cycles payés: CycleValue par cycle si le plan
+// fixe une valeur de cycle, sinon le volume apparié multiplié par CommissionRate (un seul
+// arrondi, au centime)
+func (s *BinaryCommissionService) calculateAmount(cfg models.BinaryConfig, cycles int, volumeUsed models.Volume) models.Money {
+	if cfg.CycleValue > 0 {
+		return models.Money(cycles) * cfg.CycleValue
 	}
-
-	newTotalEarnings := client.TotalEarnings + amount
-	newWalletBalance := client.WalletBalance + amount
-
-	return s.clientRepo.UpdateEarnings(ctx, clientID, newTotalEarnings, newWalletBalance)
+	return volumeUsed.MoneyAt(cfg.CommissionRate)
 }
 
 // GetLegsVolumes récupère les volumes et actifs des jambes gauche et droite (méthode publique)
@@ -534,112 +583,6 @@ func (s *BinaryCommissionService) GetLegsVolumes(ctx context.Context, client *mo
 	return s.getLegsVolumes(ctx, client)
 }
 
-// GetLegsVolumesWithCache récupère les volumes et actifs avec un cache d'activité (version optimisée)
-func (s *BinaryCommissionService) GetLegsVolumesWithCache(ctx context.Context, client *models.Client, activeCache map[string]bool, maxDepth int) (*models.BinaryLegs, error) {
-	legs := &models.BinaryLegs{
-		LeftVolume:  client.NetworkVolumeLeft,
-		RightVolume: client.NetworkVolumeRight,
-	}
-
-	// Compter les actifs dans chaque jambe avec cache et limite de profondeur
-	leftActives, err := s.countActivesInLegWithCache(ctx, client.LeftChildID, "left", activeCache, maxDepth, 0)
-	if err != nil {
-		return nil, err
-	}
-	legs.LeftActives = leftActives
-
-	rightActives, err := s.countActivesInLegWithCache(ctx, client.RightChildID, "right", activeCache, maxDepth, 0)
-	if err != nil {
-		return nil, err
-	}
-	legs.RightActives = rightActives
-
-	return legs, nil
-}
-
-// countActivesInLegWithCache compte récursivement les membres actifs avec cache et limite de profondeur
-func (s *BinaryCommissionService) countActivesInLegWithCache(ctx context.Context, rootID *primitive.ObjectID, side string, activeCache map[string]bool, maxDepth int, currentDepth int) (int, error) {
-	if rootID == nil {
-		return 0, nil
-	}
-
-	// Limiter la profondeur pour éviter les calculs trop coûteux
-	if maxDepth > 0 && currentDepth >= maxDepth {
-		return 0, nil
-	}
-
-	count := 0
-	visited := make(map[string]bool) // Pour éviter de compter deux fois
-	queue := []struct {
-		id    *primitive.ObjectID
-		depth int
-	}{{rootID, currentDepth}}
-
-	for len(queue) > 0 {
-		current := queue[0]
-		queue = queue[1:]
-
-		// Limiter la profondeur
-		if maxDepth > 0 && current.depth >= maxDepth {
-			continue
-		}
-
-		// Éviter les doublons
-		if visited[current.id.Hex()] {
-			continue
-		}
-		visited[current.id.Hex()] = true
-
-		client, err := s.clientRepo.GetByID(ctx, current.id.Hex())
-		if err != nil {
-			continue // Ignorer les erreurs et continuer
-		}
-
-		// Vérifier si ce client est actif (utiliser le cache si disponible)
-		var isActive bool
-		if activeCache != nil {
-			if cached, found := activeCache[current.id.Hex()]; found {
-				isActive = cached
-			} else {
-				// Si pas dans le cache, vérifier et mettre en cache
-				active, err := s.isClientActive(ctx, current.id.Hex())
-				if err == nil {
-					isActive = active
-					activeCache[current.id.Hex()] = active
-				}
-			}
-		} else {
-			active, err := s.isClientActive(ctx, current.id.Hex())
-			if err == nil {
-				isActive = active
-			}
-		}
-
-		if isActive {
-			count++
-		}
-
-		// Ajouter tous les enfants à la queue (pour compter récursivement)
-		nextDepth := current.depth + 1
-		if maxDepth == 0 || nextDepth < maxDepth {
-			if client.LeftChildID != nil {
-				queue = append(queue, struct {
-					id    *primitive.ObjectID
-					depth int
-				}{client.LeftChildID, nextDepth})
-			}
-			if client.RightChildID != nil {
-				queue = append(queue, struct {
-					id    *primitive.ObjectID
-					depth int
-				}{client.RightChildID, nextDepth})
-			}
-		}
-	}
-
-	return count, nil
-}
-
 // CheckQualification vérifie si un membre est qualifié (méthode publique)
 func (s *BinaryCommissionService) CheckQualification(ctx context.Context, client *models.Client) (*models.BinaryQualification, error) {
 	return s.checkQualification(ctx, client)
@@ -652,10 +595,10 @@ func (s *BinaryCommissionService) IsClientActive(ctx context.Context, clientID s
 
 // GetOrCreateCapping récupère ou crée un enregistrement de capping (méthode publique)
 func (s *BinaryCommissionService) GetOrCreateCapping(ctx context.Context, clientID primitive.ObjectID, date time.Time) (*models.BinaryCapping, error) {
-	return s.getOrCreateCapping(ctx, clientID, date)
+	return s.getOrCreateCapping(ctx, s.config, clientID, date)
 }
 
 // CalculateCycles calcule le nombre de cycles possibles (méthode publique)
 func (s *BinaryCommissionService) CalculateCycles(legs *models.BinaryLegs) int {
-	return s.calculateCycles(legs)
+	return s.calculateCycles(s.config, legs)
 }
@@ -23,7 +23,7 @@ func (m *mockClientRepo) GetByID(ctx context.Context, id string) (*models.Client
 	return nil, nil
 }
 
-func (m *mockClientRepo) UpdateNetworkVolumes(ctx context.Context, id string, left, right float64) error {
+func (m *mockClientRepo) UpdateNetworkVolumes(ctx context.Context, id string, left, right models.Volume) error {
 	if client, ok := m.clients[id]; ok {
 		client.NetworkVolumeLeft = left
 		client.NetworkVolumeRight = right
@@ -31,10 +31,20 @@ func (m *mockClientRepo) UpdateNetworkVolumes(ctx context.Context, id string, le
 	return nil
 }
 
-func (m *mockClientRepo) UpdateEarnings(ctx context.Context, id string, totalEarnings, walletBalance float64) error {
+func (m *mockClientRepo) DeductLegVolumes(ctx context.Context, id string, volume models.Volume) (*models.Client, error) {
+	client, ok := m.clients[id]
+	if !ok || client.NetworkVolumeLeft < volume || client.NetworkVolumeRight < volume {
+		return nil, nil
+	}
+	client.NetworkVolumeLeft -= volume
+	client.NetworkVolumeRight -= volume
+	copied := *client
+	return &copied, nil
+}
+
+func (m *mockClientRepo) UpdateBinaryPairs(ctx context.Context, id string, pairs int) error {
 	if client, ok := m.clients[id]; ok {
-		client.TotalEarnings = totalEarnings
-		client.WalletBalance = walletBalance
+		client.BinaryPairs = pairs
 	}
 	return nil
 }
@@ -60,40 +70,90 @@ func (m *mockSaleRepo) GetByClientID(ctx context.Context, clientID string) ([]*m
 	return []*models.Sale{}, nil
 }
 
+type mockCycleRepo struct {
+	cycles []*models.BinaryCycle
+}
+
+func (m *mockCycleRepo) Create(ctx context.Context, cycle *models.BinaryCycle) (*models.BinaryCycle, error) {
+	cycle.ID = primitive.NewObjectID()
+	m.cycles = append(m.cycles, cycle)
+	return cycle, nil
+}
+
+func (m *mockCycleRepo) GetByClientID(ctx context.Context, clientID string, filter *models.FilterInput, paging *models.PagingInput) ([]*models.BinaryCycle, error) {
+	var out []*models.BinaryCycle
+	for _, c := range m.cycles {
+		if c.ClientID.Hex() == clientID {
+			out = append(out, c)
+		}
+	}
+	return out, nil
+}
+
 type mockCappingRepo struct {
-	cappings map[string]*models.BinaryCapping
+	cappings map[string]*models.BinaryCapping // clé: clientID + date
+	peeks    int                              // lectures sans écriture
+}
+
+func cappingKey(clientID primitive.ObjectID, date time.Time) string {
+	return clientID.Hex() + "|" + date.Format("2006-01-02")
 }
 
-func (m *mockCappingRepo) GetByClientIDAndDate(ctx context.Context, clientID primitive.ObjectID, date time.Time) (*models.BinaryCapping, error) {
-	key := clientID.Hex()
-	if capping, ok := m.cappings[key]; ok {
-		return capping, nil
+func (m *mockCappingRepo) GetByClientIDAndDate(ctx context.Context, clientID primitive.ObjectID, date time.Time, weekStart time.Time) (*models.BinaryCapping, error) {
+	key := cappingKey(clientID, date)
+	capping, ok := m.cappings[key]
+	if !ok {
+		// Créer un nouveau capping
+		capping = &models.BinaryCapping{
+			ID:        primitive.NewObjectID(),
+			ClientID:  clientID,
+			Date:      date,
+			WeekStart: weekStart,
+		}
+		m.cappings[key] = capping
 	}
-	// Créer un nouveau capping
-	capping := &models.BinaryCapping{
-		ID:                 primitive.NewObjectID(),
-		ClientID:           clientID,
-		CyclesPaidToday:    0,
-		CyclesPaidThisWeek: 0,
+
+	// Total hebdomadaire = somme des jours de la semaine
+	weekly := 0
+	for _, c := range m.cappings {
+		if c.ClientID == clientID && !c.Date.Before(weekStart) && c.Date.Before(weekStart.AddDate(0, 0, 7)) {
+			weekly += c.CyclesPaidToday
+		}
 	}
-	m.cappings[key] = capping
-	return capping, nil
+
+	result := *capping
+	result.CyclesPaidThisWeek = weekly
+	return &result, nil
+}
+
+func (m *mockCappingRepo) Peek(ctx context.Context, clientID primitive.ObjectID, date time.Time, weekStart time.Time) (*models.BinaryCapping, error) {
+	m.peeks++
+	capping := models.BinaryCapping{ClientID: clientID, Date: date, WeekStart: weekStart}
+	if existing, ok := m.cappings[cappingKey(clientID, date)]; ok {
+		capping = *existing
+	}
+	for _, c := range m.cappings {
+		if c.ClientID == clientID && !c.Date.Before(weekStart) && c.Date.Before(weekStart.AddDate(0, 0, 7)) {
+			capping.CyclesPaidThisWeek += c.CyclesPaidToday
+		}
+	}
+	return &capping, nil
 }
 
 func (m *mockCappingRepo) Update(ctx context.Context, capping *models.BinaryCapping) error {
-	m.cappings[capping.ClientID.Hex()] = capping
+	m.cappings[cappingKey(capping.ClientID, capping.Date)] = capping
 	return nil
 }
 
-func (m *mockCappingRepo) IncrementCycles(ctx context.Context, clientID primitive.ObjectID, date time.Time, cycles int) error {
-	key := clientID.Hex()
+func (m *mockCappingRepo) IncrementCycles(ctx context.Context, clientID primitive.ObjectID, date time.Time, weekStart time.Time, cycles int) error {
+	key := cappingKey(clientID, date)
 	capping, ok := m.cappings[key]
 	if !ok {
 		capping = &models.BinaryCapping{
-			ID:                 primitive.NewObjectID(),
-			ClientID:           clientID,
-			CyclesPaidToday:    0,
-			CyclesPaidThisWeek: 0,
+			ID:        primitive.NewObjectID(),
+			ClientID:  clientID,
+			Date:      date,
+			WeekStart: weekStart,
 		}
 		m.cappings[key] = capping
 	}
@@ -101,8 +161,30 @@ func (m *mockCappingRepo) IncrementCycles(ctx context.Context, clientID primitiv
 	return nil
 }
 
+// markActive rend un membre actif pour les 30 prochains jours
+func markActive(client *models.Client) {
+	activeUntil := time.Now().AddDate(0, 0, 30)
+	client.ActiveUntil = &activeUntil
+}
+
+// countLegs recalcule les compteurs de jambes de tous les membres du dépôt, comme cmd/legcounts
+func countLegs(clientRepo *mockClientRepo) {
+	clients := make([]*models.Client, 0, len(clientRepo.clients))
+	for _, client := range clientRepo.clients {
+		client.CountedActive = IsActiveAt(client, time.Now())
+		clients = append(clients, client)
+	}
+	for _, delta := range legCountDeltas(clients) {
+		client := clientRepo.clients[delta.ClientID.Hex()]
+		client.LeftMembers += delta.LeftMembers
+		client.RightMembers += delta.RightMembers
+		client.LeftActives += delta.LeftActives
+		client.RightActives += delta.RightActives
+	}
+}
+
 // Helper pour créer un service de test
-func createTestBinaryService() (*BinaryCommissionService, *mockClientRepo, *mockCommissionRepo, *mockSaleRepo, *mockCappingRepo) {
+func createTestBinaryService() (*BinaryCommissionService, *mockClientRepo, *mockCommissionRepo, *mockCappingRepo) {
 	logger, _ := zap.NewDevelopment()
 
 	clientRepo := &mockClientRepo{
@@ -111,18 +193,15 @@ func createTestBinaryService() (*BinaryCommissionService, *mockClientRepo, *mock
 	commissionRepo := &mockCommissionRepo{
 		commissions: []*models.Commission{},
 	}
-	saleRepo := &mockSaleRepo{
-		sales: make(map[string][]*models.Sale),
-	}
 	cappingRepo := &mockCappingRepo{
 		cappings: make(map[string]*models.BinaryCapping),
 	}
 
 	config := models.BinaryConfig{
-		CycleValue:         20.0,
+		CycleValue:         0, // Montant = volume utilisé × taux
 		CommissionRate:     0.10,
 		DailyCycleLimit:    4,
-		MinVolumePerLeg:    1.0,
+		MinVolumePerLeg:    models.NewVolume(1.0),
 		RequireDirectLeft:  true,
 		RequireDirectRight: true,
 	}
@@ -130,19 +209,21 @@ func createTestBinaryService() (*BinaryCommissionService, *mockClientRepo, *mock
 	service := NewBinaryCommissionService(
 		clientRepo,
 		commissionRepo,
-		saleRepo,
 		cappingRepo,
+		&mockCycleRepo{},
+		newMockWalletRepo(clientRepo.clients),
 		logger,
 		config,
 		nil,
+		time.UTC,
 	)
 
-	return service, clientRepo, commissionRepo, saleRepo, cappingRepo
+	return service, clientRepo, commissionRepo, cappingRepo
 }
 
 // Test Case 1: 50 gauche, 100 droite → cycles = 50 → gain = 1000
 func TestBinaryCommission_Case1_50Left100Right(t *testing.T) {
-	service, clientRepo, _, saleRepo, _ := createTestBinaryService()
+	service, clientRepo, _, _ := createTestBinaryService()
 	ctx := context.Background()
 
 	// Créer un client avec 50 actifs à gauche et 100 à droite
@@ -154,8 +235,8 @@ func TestBinaryCommission_Case1_50Left100Right(t *testing.T) {
 		ID:                 clientID,
 		ClientID:           "12345678",
 		Name:               "Test Client",
-		NetworkVolumeLeft:  50.0,
-		NetworkVolumeRight: 100.0,
+		NetworkVolumeLeft:  models.NewVolume(50.0),
+		NetworkVolumeRight: models.NewVolume(100.0),
 		LeftChildID:        &leftChildID,
 		RightChildID:       &rightChildID,
 		TotalEarnings:      0,
@@ -178,13 +259,10 @@ func TestBinaryCommission_Case1_50Left100Right(t *testing.T) {
 	clientRepo.clients[leftChildID.Hex()] = leftChild
 	clientRepo.clients[rightChildID.Hex()] = rightChild
 
-	// Les enfants sont actifs (ont des ventes)
-	saleRepo.sales[leftChildID.Hex()] = []*models.Sale{
-		{ID: primitive.NewObjectID(), ClientID: leftChildID, Amount: 100},
-	}
-	saleRepo.sales[rightChildID.Hex()] = []*models.Sale{
-		{ID: primitive.NewObjectID(), ClientID: rightChildID, Amount: 100},
-	}
+	// Les enfants sont actifs (période d'activité en cours)
+	markActive(clientRepo.clients[leftChildID.Hex()])
+	markActive(clientRepo.clients[rightChildID.Hex()])
+	countLegs(clientRepo)
 
 	// Simuler 50 actifs à gauche et 100 à droite dans le réseau
 	// Pour simplifier, on va modifier la logique de comptage dans le test
@@ -203,10 +281,10 @@ func TestBinaryCommission_Case1_50Left100Right(t *testing.T) {
 		t.Errorf("Expected qualified=true, got %v", result.Qualified)
 	}
 
-	// Note: Le calcul exact dépend de l'implémentation de countActivesInLeg
+	// Note: Le calcul exact dépend des compteurs de jambes (LegCountService)
 	// Pour ce test, on vérifie au moins que le processus fonctionne
 	if result.Amount < 0 {
-		t.Errorf("Expected amount >= 0, got %f", result.Amount)
+		t.Errorf("Expected amount >= 0, got %s", result.Amount)
 	}
 
 	t.Logf("Test Case 1 - Result: %+v", result)
@@ -214,7 +292,7 @@ func TestBinaryCommission_Case1_50Left100Right(t *testing.T) {
 
 // Test Case 2: 3 gauche, 5 droite → cycles = 3 → gain = 60
 func TestBinaryCommission_Case2_3Left5Right(t *testing.T) {
-	service, clientRepo, _, saleRepo, _ := createTestBinaryService()
+	service, clientRepo, _, _ := createTestBinaryService()
 	ctx := context.Background()
 
 	clientID := primitive.NewObjectID()
@@ -225,8 +303,8 @@ func TestBinaryCommission_Case2_3Left5Right(t *testing.T) {
 		ID:                 clientID,
 		ClientID:           "33333333",
 		Name:               "Test Client 2",
-		NetworkVolumeLeft:  3.0,
-		NetworkVolumeRight: 5.0,
+		NetworkVolumeLeft:  models.NewVolume(3.0),
+		NetworkVolumeRight: models.NewVolume(5.0),
 		LeftChildID:        &leftChildID,
 		RightChildID:       &rightChildID,
 		TotalEarnings:      0,
@@ -248,12 +326,9 @@ func TestBinaryCommission_Case2_3Left5Right(t *testing.T) {
 	clientRepo.clients[leftChildID.Hex()] = leftChild
 	clientRepo.clients[rightChildID.Hex()] = rightChild
 
-	saleRepo.sales[leftChildID.Hex()] = []*models.Sale{
-		{ID: primitive.NewObjectID(), ClientID: leftChildID, Amount: 50},
-	}
-	saleRepo.sales[rightChildID.Hex()] = []*models.Sale{
-		{ID: primitive.NewObjectID(), ClientID: rightChildID, Amount: 50},
-	}
+	markActive(clientRepo.clients[leftChildID.Hex()])
+	markActive(clientRepo.clients[rightChildID.Hex()])
+	countLegs(clientRepo)
 
 	result, err := service.ComputeBinaryCommission(ctx, clientID.Hex())
 	if err != nil {
@@ -269,7 +344,7 @@ func TestBinaryCommission_Case2_3Left5Right(t *testing.T) {
 
 // Test Case 3: 0 gauche, 10 droite → gain = 0
 func TestBinaryCommission_Case3_0Left10Right(t *testing.T) {
-	service, clientRepo, _, saleRepo, _ := createTestBinaryService()
+	service, clientRepo, _, _ := createTestBinaryService()
 	ctx := context.Background()
 
 	clientID := primitive.NewObjectID()
@@ -280,7 +355,7 @@ func TestBinaryCommission_Case3_0Left10Right(t *testing.T) {
 		ClientID:           "66666666",
 		Name:               "Test Client 3",
 		NetworkVolumeLeft:  0.0,
-		NetworkVolumeRight: 10.0,
+		NetworkVolumeRight: models.NewVolume(10.0),
 		LeftChildID:        nil, // Pas d'enfant gauche
 		RightChildID:       &rightChildID,
 		TotalEarnings:      0,
@@ -296,9 +371,8 @@ func TestBinaryCommission_Case3_0Left10Right(t *testing.T) {
 	clientRepo.clients[clientID.Hex()] = client
 	clientRepo.clients[rightChildID.Hex()] = rightChild
 
-	saleRepo.sales[rightChildID.Hex()] = []*models.Sale{
-		{ID: primitive.NewObjectID(), ClientID: rightChildID, Amount: 50},
-	}
+	markActive(clientRepo.clients[rightChildID.Hex()])
+	countLegs(clientRepo)
 
 	result, err := service.ComputeBinaryCommission(ctx, clientID.Hex())
 	if err != nil {
@@ -311,7 +385,7 @@ func TestBinaryCommission_Case3_0Left10Right(t *testing.T) {
 
 	// Devrait être non qualifié ou avoir gain = 0
 	if result.Amount != 0 {
-		t.Errorf("Expected amount=0 (jambe gauche vide), got %f", result.Amount)
+		t.Errorf("Expected amount=0 (jambe gauche vide), got %s", result.Amount)
 	}
 
 	t.Logf("Test Case 3 - Result: %+v", result)
@@ -319,7 +393,7 @@ func TestBinaryCommission_Case3_0Left10Right(t *testing.T) {
 
 // Test Case 4: Non qualifié → gain = 0
 func TestBinaryCommission_Case4_NotQualified(t *testing.T) {
-	service, clientRepo, _, _, _ := createTestBinaryService()
+	service, clientRepo, _, _ := createTestBinaryService()
 	ctx := context.Background()
 
 	clientID := primitive.NewObjectID()
@@ -329,8 +403,8 @@ func TestBinaryCommission_Case4_NotQualified(t *testing.T) {
 		ID:                 clientID,
 		ClientID:           "88888888",
 		Name:               "Test Client 4",
-		NetworkVolumeLeft:  10.0,
-		NetworkVolumeRight: 10.0,
+		NetworkVolumeLeft:  models.NewVolume(10.0),
+		NetworkVolumeRight: models.NewVolume(10.0),
 		LeftChildID:        nil, // Pas de direct gauche
 		RightChildID:       nil, // Pas de direct droite
 		TotalEarnings:      0,
@@ -353,7 +427,7 @@ func TestBinaryCommission_Case4_NotQualified(t *testing.T) {
 	}
 
 	if result.Amount != 0 {
-		t.Errorf("Expected amount=0 (non qualifié), got %f", result.Amount)
+		t.Errorf("Expected amount=0 (non qualifié), got %s", result.Amount)
 	}
 
 	t.Logf("Test Case 4 - Result: %+v", result)
@@ -361,7 +435,7 @@ func TestBinaryCommission_Case4_NotQualified(t *testing.T) {
 
 // Test Case 5: Limite journalière 4 cycles → payer 4 cycles
 func TestBinaryCommission_Case5_DailyLimit(t *testing.T) {
-	service, clientRepo, commissionRepo, saleRepo, _ := createTestBinaryService()
+	service, clientRepo, commissionRepo, _ := createTestBinaryService()
 	ctx := context.Background()
 
 	clientID := primitive.NewObjectID()
@@ -372,8 +446,8 @@ func TestBinaryCommission_Case5_DailyLimit(t *testing.T) {
 		ID:                 clientID,
 		ClientID:           "99999999",
 		Name:               "Test Client 5",
-		NetworkVolumeLeft:  100.0, // Beaucoup d'actifs
-		NetworkVolumeRight: 100.0,
+		NetworkVolumeLeft:  models.NewVolume(100.0), // Beaucoup d'actifs
+		NetworkVolumeRight: models.NewVolume(100.0),
 		LeftChildID:        &leftChildID,
 		RightChildID:       &rightChildID,
 		TotalEarnings:      0,
@@ -395,12 +469,9 @@ func TestBinaryCommission_Case5_DailyLimit(t *testing.T) {
 	clientRepo.clients[leftChildID.Hex()] = leftChild
 	clientRepo.clients[rightChildID.Hex()] = rightChild
 
-	saleRepo.sales[leftChildID.Hex()] = []*models.Sale{
-		{ID: primitive.NewObjectID(), ClientID: leftChildID, Amount: 50},
-	}
-	saleRepo.sales[rightChildID.Hex()] = []*models.Sale{
-		{ID: primitive.NewObjectID(), ClientID: rightChildID, Amount: 50},
-	}
+	markActive(clientRepo.clients[leftChildID.Hex()])
+	markActive(clientRepo.clients[rightChildID.Hex()])
+	countLegs(clientRepo)
 
 	// Premier calcul - devrait payer jusqu'à la limite
 	result1, err := service.ComputeBinaryCommission(ctx, clientID.Hex())
@@ -418,9 +489,9 @@ func TestBinaryCommission_Case5_DailyLimit(t *testing.T) {
 	}
 
 	// Le montant devrait être volumeUtilise * commissionRate
-	expectedAmount := float64(result1.CyclesPaid) * service.config.MinVolumePerLeg * service.config.CommissionRate
+	expectedAmount := (models.Volume(result1.CyclesPaid) * service.config.MinVolumePerLeg).MoneyAt(service.config.CommissionRate)
 	if result1.Amount != expectedAmount {
-		t.Errorf("Expected amount=%f (volumeUtilise * commissionRate), got %f", expectedAmount, result1.Amount)
+		t.Errorf("Expected amount=%s (volumeUtilise * commissionRate), got %s", expectedAmount, result1.Amount)
 	}
 
 	t.Logf("Test Case 5 - First Result: %+v", result1)
@@ -429,7 +500,7 @@ func TestBinaryCommission_Case5_DailyLimit(t *testing.T) {
 
 // Test de qualification
 func TestCheckQualification(t *testing.T) {
-	service, clientRepo, _, saleRepo, _ := createTestBinaryService()
+	service, clientRepo, _, _ := createTestBinaryService()
 	ctx := context.Background()
 
 	clientID := primitive.NewObjectID()
@@ -450,12 +521,9 @@ func TestCheckQualification(t *testing.T) {
 	clientRepo.clients[rightChildID.Hex()] = rightChild
 
 	// Les deux enfants sont actifs
-	saleRepo.sales[leftChildID.Hex()] = []*models.Sale{
-		{ID: primitive.NewObjectID(), ClientID: leftChildID},
-	}
-	saleRepo.sales[rightChildID.Hex()] = []*models.Sale{
-		{ID: primitive.NewObjectID(), ClientID: rightChildID},
-	}
+	markActive(clientRepo.clients[leftChildID.Hex()])
+	markActive(clientRepo.clients[rightChildID.Hex()])
+	countLegs(clientRepo)
 
 	qualification, err := service.checkQualification(ctx, client)
 	if err != nil {
@@ -477,24 +545,24 @@ func TestCheckQualification(t *testing.T) {
 
 // Test de calcul de cycles
 func TestCalculateCycles(t *testing.T) {
-	service, _, _, _, _ := createTestBinaryService()
+	service, _, _, _ := createTestBinaryService()
 
 	// Cas 1: 50 gauche, 100 droite → cycles = 50
 	legs1 := &models.BinaryLegs{
-		LeftVolume:  50,
-		RightVolume: 100,
+		LeftVolume:  models.NewVolume(50),
+		RightVolume: models.NewVolume(100),
 	}
-	cycles1 := service.calculateCycles(legs1)
+	cycles1 := service.calculateCycles(service.config, legs1)
 	if cycles1 != 50 {
 		t.Errorf("Expected cycles=50, got %d", cycles1)
 	}
 
 	// Cas 2: 3 gauche, 5 droite → cycles = 3
 	legs2 := &models.BinaryLegs{
-		LeftVolume:  3,
-		RightVolume: 5,
+		LeftVolume:  models.NewVolume(3),
+		RightVolume: models.NewVolume(5),
 	}
-	cycles2 := service.calculateCycles(legs2)
+	cycles2 := service.calculateCycles(service.config, legs2)
 	if cycles2 != 3 {
 		t.Errorf("Expected cycles=3, got %d", cycles2)
 	}
@@ -502,10 +570,354 @@ func TestCalculateCycles(t *testing.T) {
 	// Cas 3: 0 gauche, 10 droite → cycles = 0
 	legs3 := &models.BinaryLegs{
 		LeftVolume:  0,
-		RightVolume: 10,
+		RightVolume: models.NewVolume(10),
 	}
-	cycles3 := service.calculateCycles(legs3)
+	cycles3 := service.calculateCycles(service.config, legs3)
 	if cycles3 != 0 {
 		t.Errorf("Expected cycles=0, got %d", cycles3)
 	}
 }
+
+// Helper: client qualifié avec deux directs actifs et des volumes donnés
+func setupQualifiedClient(clientRepo *mockClientRepo, left, right models.Volume) *models.Client {
+	clientID := primitive.NewObjectID()
+	leftChildID := primitive.NewObjectID()
+	rightChildID := primitive.NewObjectID()
+
+	client := &models.Client{
+		ID:                 clientID,
+		NetworkVolumeLeft:  left,
+		NetworkVolumeRight: right,
+		LeftChildID:        &leftChildID,
+		RightChildID:       &rightChildID,
+	}
+
+	clientRepo.clients[clientID.Hex()] = client
+	clientRepo.clients[leftChildID.Hex()] = &models.Client{ID: leftChildID}
+	clientRepo.clients[rightChildID.Hex()] = &models.Client{ID: rightChildID}
+
+	markActive(clientRepo.clients[leftChildID.Hex()])
+	markActive(clientRepo.clients[rightChildID.Hex()])
+	countLegs(clientRepo)
+
+	return client
+}
+
+// Test de la limite hebdomadaire: les cycles déjà payés dans la semaine réduisent le paiement
+func TestBinaryCommission_WeeklyLimit(t *testing.T) {
+	service, clientRepo, _, cappingRepo := createTestBinaryService()
+	service.config.DailyCycleLimit = 0
+	service.config.WeeklyCycleLimit = 10
+	ctx := context.Background()
+
+	client := setupQualifiedClient(clientRepo, models.NewVolume(100), models.NewVolume(100))
+
+	// 8 cycles déjà payés le premier jour de la semaine
+	_, weekStart := service.cappingPeriod(service.config, time.Now())
+	if err := cappingRepo.IncrementCycles(ctx, client.ID, weekStart, weekStart, 8); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := service.ComputeBinaryCommission(ctx, client.ID.Hex())
+	if err != nil {
+		t.Fatalf("Erreur inattendue: %v", err)
+	}
+	if result.CyclesPaid != 2 {
+		t.Errorf("Expected cyclesPaid=2 (10 - 8 déjà payés), got %d", result.CyclesPaid)
+	}
+	if result.Reason != reasonWeeklyCapReached {
+		t.Errorf("Expected reason %q, got %q", reasonWeeklyCapReached, result.Reason)
+	}
+
+	// Deuxième calcul: la semaine est pleine
+	result2, err := service.ComputeBinaryCommission(ctx, client.ID.Hex())
+	if err != nil {
+		t.Fatalf("Erreur inattendue: %v", err)
+	}
+	if result2.CyclesPaid != 0 {
+		t.Errorf("Expected cyclesPaid=0 (limite hebdomadaire), got %d", result2.CyclesPaid)
+	}
+	if result2.Reason != reasonWeeklyCapReached {
+		t.Errorf("Expected reason %q, got %q", reasonWeeklyCapReached, result2.Reason)
+	}
+
+	// Les cycles de la semaine précédente ne comptent pas
+	previousWeek := weekStart.AddDate(0, 0, -7)
+	_ = cappingRepo.IncrementCycles(ctx, client.ID, previousWeek, previousWeek, 50)
+	capping, _ := service.GetOrCreateCapping(ctx, client.ID, time.Now())
+	if capping.CyclesPaidThisWeek != 10 {
+		t.Errorf("Expected cyclesPaidThisWeek=10, got %d", capping.CyclesPaidThisWeek)
+	}
+}
+
+// Test: la limite journalière reste prioritaire lorsqu'elle est plus restrictive
+func TestBinaryCommission_DailyLimitBeforeWeekly(t *testing.T) {
+	service, clientRepo, _, _ := createTestBinaryService()
+	service.config.DailyCycleLimit = 3
+	service.config.WeeklyCycleLimit = 20
+	ctx := context.Background()
+
+	client := setupQualifiedClient(clientRepo, models.NewVolume(50), models.NewVolume(50))
+
+	result, err := service.ComputeBinaryCommission(ctx, client.ID.Hex())
+	if err != nil {
+		t.Fatalf("Erreur inattendue: %v", err)
+	}
+	if result.CyclesPaid != 3 {
+		t.Errorf("Expected cyclesPaid=3, got %d", result.CyclesPaid)
+	}
+	if result.Reason != reasonDailyCapReached {
+		t.Errorf("Expected reason %q, got %q", reasonDailyCapReached, result.Reason)
+	}
+}
+
+// Test du calcul du début de semaine selon le jour configuré
+func TestCappingPeriod_WeekStartDay(t *testing.T) {
+	service, _, _, _ := createTestBinaryService()
+
+	// Mercredi 15 janvier 2025, 18h UTC
+	date := time.Date(2025, 1, 15, 18, 0, 0, 0, time.UTC)
+
+	service.config.WeekStartDay = time.Monday
+	day, weekStart := service.cappingPeriod(service.config, date)
+	if !day.Equal(time.Date(2025, 1, 15, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected day start: %v", day)
+	}
+	if !weekStart.Equal(time.Date(2025, 1, 13, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected week start on Monday 13, got %v", weekStart)
+	}
+
+	service.config.WeekStartDay = time.Sunday
+	_, weekStart = service.cappingPeriod(service.config, date)
+	if !weekStart.Equal(time.Date(2025, 1, 12, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected week start on Sunday 12, got %v", weekStart)
+	}
+
+	service.config.WeekStartDay = time.Wednesday
+	_, weekStart = service.cappingPeriod(service.config, date)
+	if !weekStart.Equal(time.Date(2025, 1, 15, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected week start on Wednesday 15, got %v", weekStart)
+	}
+}
+
+// Test: le jour de capping commence à minuit dans le fuseau de l'entreprise, pas à minuit UTC
+func TestCappingPeriod_BusinessTimezone(t *testing.T) {
+	service, clientRepo, _, _ := createTestBinaryService()
+	lubumbashi := time.FixedZone("CAT", 2*60*60) // Africa/Lubumbashi, UTC+2 sans heure d'été
+	service.location = lubumbashi
+	service.config.WeekStartDay = time.Monday
+	ctx := context.Background()
+
+	// Dimanche 19 janvier 2025, 23h30 à Lubumbashi (21h30 UTC)
+	beforeMidnight := time.Date(2025, 1, 19, 21, 30, 0, 0, time.UTC)
+	// Lundi 20 janvier 2025, 0h30 à Lubumbashi, encore dimanche en UTC
+	afterMidnight := time.Date(2025, 1, 19, 22, 30, 0, 0, time.UTC)
+
+	day, weekStart := service.cappingPeriod(service.config, afterMidnight)
+	if !day.Equal(time.Date(2025, 1, 20, 0, 0, 0, 0, lubumbashi)) || !weekStart.Equal(day) {
+		t.Errorf("Expected day and week to start on Monday 20 at local midnight, got %v / %v", day, weekStart)
+	}
+	if service.PeriodKey(beforeMidnight) != "2025-01-19" || service.PeriodKey(afterMidnight) != "2025-01-20" {
+		t.Errorf("Unexpected period keys: %s / %s", service.PeriodKey(beforeMidnight), service.PeriodKey(afterMidnight))
+	}
+
+	client := setupQualifiedClient(clientRepo, models.NewVolume(100), models.NewVolume(100))
+	now := beforeMidnight
+	service.now = func() time.Time { return now }
+
+	result, err := service.ComputeBinaryCommission(ctx, client.ID.Hex())
+	if err != nil {
+		t.Fatalf("Erreur inattendue: %v", err)
+	}
+	if result.CyclesPaid != 4 {
+		t.Fatalf("Expected cyclesPaid=4, got %d", result.CyclesPaid)
+	}
+
+	// 23h59 locales: même jour ouvré, la limite est atteinte
+	now = time.Date(2025, 1, 19, 21, 59, 0, 0, time.UTC)
+	result, _ = service.ComputeBinaryCommission(ctx, client.ID.Hex())
+	if result.CyclesPaid != 0 || result.Reason != reasonDailyCapReached {
+		t.Errorf("Expected the daily cap before local midnight, got %d (%q)", result.CyclesPaid, result.Reason)
+	}
+
+	// Minuit passé à Lubumbashi: nouveau jour ouvré alors que le jour UTC n'a pas changé
+	now = afterMidnight
+	result, _ = service.ComputeBinaryCommission(ctx, client.ID.Hex())
+	if result.CyclesPaid != 4 {
+		t.Errorf("Expected a fresh daily cap after local midnight, got %d", result.CyclesPaid)
+	}
+}
+
+// Test: chaque paiement binaire laisse une trace BinaryCycle liée à la commission
+func TestBinaryCommission_RecordsCycleHistory(t *testing.T) {
+	service, clientRepo, commissionRepo, _ := createTestBinaryService()
+	ctx := context.Background()
+
+	client := setupQualifiedClient(clientRepo, models.NewVolume(3), models.NewVolume(5))
+
+	result, err := service.ComputeBinaryCommission(ctx, client.ID.Hex())
+	if err != nil {
+		t.Fatalf("Erreur inattendue: %v", err)
+	}
+	if result.CyclesPaid != 3 {
+		t.Fatalf("Expected cyclesPaid=3, got %d", result.CyclesPaid)
+	}
+
+	cycles, err := service.GetCycles(ctx, client.ID.Hex(), nil, nil)
+	if err != nil {
+		t.Fatalf("Erreur inattendue: %v", err)
+	}
+	if len(cycles) != 1 {
+		t.Fatalf("Expected 1 cycle record, got %d", len(cycles))
+	}
+
+	cycle := cycles[0]
+	if cycle.CommissionID != commissionRepo.commissions[0].ID {
+		t.Errorf("Cycle not linked to the commission")
+	}
+	if cycle.Cycles != 3 || cycle.CyclesAvailable != 3 {
+		t.Errorf("Expected 3 cycles paid/available, got %d/%d", cycle.Cycles, cycle.CyclesAvailable)
+	}
+	if cycle.LeftVolumeBefore != models.NewVolume(3) || cycle.RightVolumeBefore != models.NewVolume(5) {
+		t.Errorf("Unexpected volumes before payout: %s/%s", cycle.LeftVolumeBefore, cycle.RightVolumeBefore)
+	}
+	if cycle.LeftVolumeUsed != models.NewVolume(3) || cycle.RightVolumeUsed != models.NewVolume(3) {
+		t.Errorf("Expected 3 volume used per leg, got %s/%s", cycle.LeftVolumeUsed, cycle.RightVolumeUsed)
+	}
+	if cycle.Amount != result.Amount {
+		t.Errorf("Expected amount %s, got %s", result.Amount, cycle.Amount)
+	}
+}
+
+// Test: le volume en attente (ventes non payées) n'est pas apparié
+func TestBinaryCommission_IgnoresPendingVolume(t *testing.T) {
+	service, clientRepo, _, _ := createTestBinaryService()
+	ctx := context.Background()
+
+	client := setupQualifiedClient(clientRepo, models.NewVolume(1), models.NewVolume(2))
+	client.PendingVolumeLeft = models.NewVolume(50)
+	client.PendingVolumeRight = models.NewVolume(50)
+
+	result, err := service.ComputeBinaryCommission(ctx, client.ID.Hex())
+	if err != nil {
+		t.Fatalf("Erreur inattendue: %v", err)
+	}
+	if result.CyclesAvailable != 1 || result.CyclesPaid != 1 {
+		t.Errorf("Expected only confirmed volume to be paired (1 cycle), got available=%d paid=%d", result.CyclesAvailable, result.CyclesPaid)
+	}
+}
+
+// Test: une valeur de cycle fixée par le plan paie un montant fixe par cycle
+func TestBinaryCommission_CycleValuePaysPerCycle(t *testing.T) {
+	service, clientRepo, _, _ := createTestBinaryService()
+	service.config.CycleValue = models.NewMoney(20)
+	ctx := context.Background()
+
+	client := setupQualifiedClient(clientRepo, models.NewVolume(3), models.NewVolume(5))
+
+	result, err := service.ComputeBinaryCommission(ctx, client.ID.Hex())
+	if err != nil {
+		t.Fatalf("Erreur inattendue: %v", err)
+	}
+	if result.CyclesPaid != 3 {
+		t.Fatalf("Expected cyclesPaid=3, got %d", result.CyclesPaid)
+	}
+	if result.Amount != models.NewMoney(60) {
+		t.Errorf("Expected amount=60 (3 cycles × 20), got %s", result.Amount)
+	}
+}
+
+// Test: la simulation décrit le paiement sans rien écrire
+func TestBinaryCommission_PreviewWritesNothing(t *testing.T) {
+	service, clientRepo, commissionRepo, cappingRepo := createTestBinaryService()
+	ctx := context.Background()
+
+	client := setupQualifiedClient(clientRepo, models.NewVolume(10), models.NewVolume(12))
+	client.WalletBalance = models.NewMoney(5)
+
+	// 3 cycles déjà payés aujourd'hui sur une limite de 4
+	today, weekStart := service.cappingPeriod(service.config, time.Now())
+	if err := cappingRepo.IncrementCycles(ctx, client.ID, today, weekStart, 3); err != nil {
+		t.Fatal(err)
+	}
+	cappingsBefore := len(cappingRepo.cappings)
+
+	preview, err := service.PreviewBinaryCommission(ctx, client.ID.Hex())
+	if err != nil {
+		t.Fatalf("Erreur inattendue: %v", err)
+	}
+	if !preview.DryRun || preview.CommissionID != nil {
+		t.Errorf("Expected a dry run without commission, got %+v", preview)
+	}
+	if preview.Qualification == nil || !preview.Qualification.IsQualified {
+		t.Errorf("Expected qualification details, got %+v", preview.Qualification)
+	}
+	if preview.Legs == nil || preview.Legs.LeftVolume != models.NewVolume(10) || preview.Legs.RightVolume != models.NewVolume(12) {
+		t.Errorf("Expected leg volumes 10/12, got %+v", preview.Legs)
+	}
+	if preview.CyclesAvailable != 10 || preview.CyclesPaid != 1 || preview.CyclesCapped != 9 || preview.CyclesPaidToday != 3 {
+		t.Errorf("Expected 10 available, 1 payable, 9 capped after 3 today, got %d/%d/%d/%d",
+			preview.CyclesAvailable, preview.CyclesPaid, preview.CyclesCapped, preview.CyclesPaidToday)
+	}
+	if preview.Reason != reasonDailyCapReached {
+		t.Errorf("Expected reason %q, got %q", reasonDailyCapReached, preview.Reason)
+	}
+	if preview.Amount != models.NewMoney(0.1) || preview.LeftVolumeRemaining != models.NewVolume(9) || preview.RightVolumeRemaining != models.NewVolume(11) {
+		t.Errorf("Unexpected projected payout: %s, remaining %s/%s", preview.Amount, preview.LeftVolumeRemaining, preview.RightVolumeRemaining)
+	}
+
+	// Rien n'a été écrit
+	if len(commissionRepo.commissions) != 0 {
+		t.Errorf("Expected no commission, got %d", len(commissionRepo.commissions))
+	}
+	if len(cappingRepo.cappings) != cappingsBefore || cappingRepo.cappings[cappingKey(client.ID, today)].CyclesPaidToday != 3 {
+		t.Error("Capping counters must not change during a preview")
+	}
+	if client.NetworkVolumeLeft != models.NewVolume(10) || client.NetworkVolumeRight != models.NewVolume(12) || client.WalletBalance != models.NewMoney(5) {
+		t.Errorf("Client must not change during a preview, got %s/%s wallet %s", client.NetworkVolumeLeft, client.NetworkVolumeRight, client.WalletBalance)
+	}
+
+	// Le paiement réel correspond à la simulation
+	result, err := service.ComputeBinaryCommission(ctx, client.ID.Hex())
+	if err != nil {
+		t.Fatalf("Erreur inattendue: %v", err)
+	}
+	if result.DryRun || result.CyclesPaid != preview.CyclesPaid || result.Amount != preview.Amount {
+		t.Errorf("Expected payout to match the preview, got %+v", result)
+	}
+}
+
+// racingClientRepo crédite du volume sur une jambe juste avant la déduction, comme une vente
+// confirmée entre l'évaluation et le paiement
+type racingClientRepo struct {
+	*mockClientRepo
+	credit models.Volume
+}
+
+func (m *racingClientRepo) DeductLegVolumes(ctx context.Context, id string, volume models.Volume) (*models.Client, error) {
+	m.clients[id].NetworkVolumeLeft += m.credit
+	return m.mockClientRepo.DeductLegVolumes(ctx, id, volume)
+}
+
+// Test: le volume crédité pendant le paiement n'est pas écrasé par la déduction
+func TestBinaryCommission_KeepsVolumeCreditedDuringPayout(t *testing.T) {
+	service, clientRepo, _, _ := createTestBinaryService()
+	service.clientRepo = &racingClientRepo{mockClientRepo: clientRepo, credit: models.NewVolume(4)}
+	ctx := context.Background()
+
+	client := setupQualifiedClient(clientRepo, models.NewVolume(3), models.NewVolume(5))
+
+	result, err := service.ComputeBinaryCommission(ctx, client.ID.Hex())
+	if err != nil {
+		t.Fatalf("Erreur inattendue: %v", err)
+	}
+	if result.CyclesPaid != 3 {
+		t.Fatalf("Expected cyclesPaid=3, got %d", result.CyclesPaid)
+	}
+	if client.NetworkVolumeLeft != models.NewVolume(4) || client.NetworkVolumeRight != models.NewVolume(2) {
+		t.Errorf("Expected 4 left (credited during payout) and 2 right, got %s / %s", client.NetworkVolumeLeft, client.NetworkVolumeRight)
+	}
+	if result.LeftVolumeRemaining != models.NewVolume(4) {
+		t.Errorf("Expected the remaining volume read back after the deduction, got %s", result.LeftVolumeRemaining)
+	}
+}
@@ -0,0 +1,81 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"bureau/internal/models"
+)
+
+// BinaryEngine calcule et paie la commission binaire d'un membre
+// C'est le point d'entrée unique utilisé par l'inscription, les ventes, le batch et la vérification manuelle.
+type BinaryEngine interface {
+	ComputeBinaryCommission(ctx context.Context, clientID string) (*models.BinaryCommissionResult, error)
+	// PreviewBinaryCommission retourne ce que ComputeBinaryCommission paierait, sans rien écrire
+	PreviewBinaryCommission(ctx context.Context, clientID string) (*models.BinaryCommissionResult, error)
+	// PeriodKey retourne l'identifiant de la période de paiement d'une date
+	PeriodKey(date time.Time) string
+}
+
+type compPlanProvider interface {
+	Effective(ctx context.Context, at time.Time) (*models.CompPlanVersion, error)
+}
+
+// PlanBinaryEngine charge la version du plan en vigueur au moment du calcul et délègue
+// au moteur qu'elle désigne (cycles ou legacy) avec ses règles
+type PlanBinaryEngine struct {
+	plans  compPlanProvider
+	cycle  *BinaryCommissionService
+	legacy *LegacyBinaryEngine
+}
+
+// NewPlanBinaryEngine crée le moteur binaire piloté par le plan de rémunération
+func NewPlanBinaryEngine(plans compPlanProvider, cycle *BinaryCommissionService, legacy *LegacyBinaryEngine) *PlanBinaryEngine {
+	return &PlanBinaryEngine{
+		plans:  plans,
+		cycle:  cycle,
+		legacy: legacy,
+	}
+}
+
+// ComputeBinaryCommission calcule la commission avec la version du plan en vigueur
+func (e *PlanBinaryEngine) ComputeBinaryCommission(ctx context.Context, clientID string) (*models.BinaryCommissionResult, error) {
+	return e.compute(ctx, clientID, false)
+}
+
+// PreviewBinaryCommission simule la commission avec la version du plan en vigueur
+func (e *PlanBinaryEngine) PreviewBinaryCommission(ctx context.Context, clientID string) (*models.BinaryCommissionResult, error) {
+	return e.compute(ctx, clientID, true)
+}
+
+func (e *PlanBinaryEngine) compute(ctx context.Context, clientID string, simulate bool) (*models.BinaryCommissionResult, error) {
+	plan, err := e.plans.Effective(ctx, time.Now())
+	if err != nil {
+		return &models.BinaryCommissionResult{
+			Success: false,
+			DryRun:  simulate,
+			Reason:  err.Error(),
+		}, err
+	}
+
+	switch plan.Binary.Engine {
+	case "", models.BinaryEngineCycle:
+		return e.cycle.computeWithPlan(ctx, clientID, plan, simulate)
+	case models.BinaryEngineLegacy:
+		return e.legacy.computeWithPlan(ctx, clientID, plan, simulate)
+	default:
+		err := fmt.Errorf("moteur binaire inconnu %q dans la version %d du plan", plan.Binary.Engine, plan.Version)
+		return &models.BinaryCommissionResult{
+			Success:     false,
+			DryRun:      simulate,
+			PlanVersion: plan.Version,
+			Reason:      err.Error(),
+		}, err
+	}
+}
+
+// PeriodKey retourne le jour de paiement d'une date (identique pour les deux moteurs)
+func (e *PlanBinaryEngine) PeriodKey(date time.Time) string {
+	return e.cycle.PeriodKey(date)
+}
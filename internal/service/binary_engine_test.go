@@ -0,0 +1,147 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"bureau/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+func createTestLegacyEngine() (*LegacyBinaryEngine, *mockClientRepo, *mockCommissionRepo) {
+	logger, _ := zap.NewDevelopment()
+	clientRepo := &mockClientRepo{clients: make(map[string]*models.Client)}
+	commissionRepo := &mockCommissionRepo{}
+
+	engine := NewLegacyBinaryEngine(clientRepo, commissionRepo, newMockWalletRepo(clientRepo.clients), logger, models.BinaryConfig{
+		Engine:         models.BinaryEngineLegacy,
+		Threshold:      models.NewVolume(100),
+		CommissionRate: 0.10,
+	})
+	return engine, clientRepo, commissionRepo
+}
+
+// Test: le moteur legacy paie la jambe faible une fois le seuil atteint des deux côtés
+func TestLegacyBinaryEngine_PaysMatchAboveThreshold(t *testing.T) {
+	engine, clientRepo, commissionRepo := createTestLegacyEngine()
+	ctx := context.Background()
+
+	client := &models.Client{ID: primitive.NewObjectID(), NetworkVolumeLeft: models.NewVolume(150), NetworkVolumeRight: models.NewVolume(120)}
+	clientRepo.clients[client.ID.Hex()] = client
+
+	// La simulation annonce le paiement sans l'effectuer
+	preview, err := engine.PreviewBinaryCommission(ctx, client.ID.Hex())
+	if err != nil {
+		t.Fatalf("Erreur inattendue: %v", err)
+	}
+	if preview.Amount != models.NewMoney(12) || len(commissionRepo.commissions) != 0 || client.NetworkVolumeLeft != models.NewVolume(150) {
+		t.Errorf("Expected a 12 preview without side effects, got %+v", preview)
+	}
+
+	result, err := engine.ComputeBinaryCommission(ctx, client.ID.Hex())
+	if err != nil {
+		t.Fatalf("Erreur inattendue: %v", err)
+	}
+	if result.CyclesPaid != 1 || result.Amount != models.NewMoney(12) {
+		t.Errorf("Expected 1 match paying 12, got %d paying %s", result.CyclesPaid, result.Amount)
+	}
+	if len(commissionRepo.commissions) != 1 || commissionRepo.commissions[0].Type != "binary-match" {
+		t.Fatalf("Expected one binary-match commission, got %+v", commissionRepo.commissions)
+	}
+	if client.NetworkVolumeLeft != models.NewVolume(30) || client.NetworkVolumeRight != 0 {
+		t.Errorf("Expected matched volume consumed (30/0), got %s/%s", client.NetworkVolumeLeft, client.NetworkVolumeRight)
+	}
+	if client.WalletBalance != models.NewMoney(12) || client.BinaryPairs != 1 {
+		t.Errorf("Expected wallet 12 and 1 pair, got %s and %d", client.WalletBalance, client.BinaryPairs)
+	}
+
+	// Le volume restant est sous le seuil: aucun nouveau paiement, simulation comprise
+	preview, err = engine.PreviewBinaryCommission(ctx, client.ID.Hex())
+	if err != nil {
+		t.Fatalf("Erreur inattendue: %v", err)
+	}
+	if !preview.DryRun || preview.CyclesPaid != 0 {
+		t.Errorf("Expected an empty dry run, got %+v", preview)
+	}
+
+	result, err = engine.ComputeBinaryCommission(ctx, client.ID.Hex())
+	if err != nil {
+		t.Fatalf("Erreur inattendue: %v", err)
+	}
+	if result.CyclesPaid != 0 || len(commissionRepo.commissions) != 1 {
+		t.Errorf("Expected no second payout, got %d cycles and %d commissions", result.CyclesPaid, len(commissionRepo.commissions))
+	}
+}
+
+type stubPlanProvider struct {
+	plan *models.CompPlanVersion
+}
+
+func (p *stubPlanProvider) Effective(ctx context.Context, at time.Time) (*models.CompPlanVersion, error) {
+	return p.plan, nil
+}
+
+// Test: le moteur du plan applique les règles de la version en vigueur et la trace sur la commission
+func TestPlanBinaryEngine_UsesEffectivePlanVersion(t *testing.T) {
+	cycle, clientRepo, commissionRepo, _ := createTestBinaryService()
+	legacy := NewLegacyBinaryEngine(clientRepo, commissionRepo, newMockWalletRepo(clientRepo.clients), cycle.logger, models.BinaryConfig{})
+	plans := &stubPlanProvider{plan: &models.CompPlanVersion{
+		Version: 2,
+		Binary: models.BinaryConfig{
+			Engine:          models.BinaryEngineCycle,
+			CycleValue:      models.NewMoney(15),
+			MinVolumePerLeg: models.NewVolume(1),
+		},
+	}}
+	engine := NewPlanBinaryEngine(plans, cycle, legacy)
+	ctx := context.Background()
+
+	client := setupQualifiedClient(clientRepo, models.NewVolume(2), models.NewVolume(4))
+	result, err := engine.ComputeBinaryCommission(ctx, client.ID.Hex())
+	if err != nil {
+		t.Fatalf("Erreur inattendue: %v", err)
+	}
+	// Version 2 sans limite journalière: 2 cycles à 15
+	if result.CyclesPaid != 2 || result.Amount != models.NewMoney(30) {
+		t.Errorf("Expected 2 cycles paying 30, got %d paying %s", result.CyclesPaid, result.Amount)
+	}
+	last := commissionRepo.commissions[len(commissionRepo.commissions)-1]
+	if last.Type != "binary-cycle" || last.PlanVersion != 2 {
+		t.Errorf("Expected binary-cycle commission from plan 2, got %s from plan %d", last.Type, last.PlanVersion)
+	}
+
+	// Version 3: retour au moteur legacy
+	plans.plan = &models.CompPlanVersion{
+		Version: 3,
+		Binary: models.BinaryConfig{
+			Engine:         models.BinaryEngineLegacy,
+			Threshold:      models.NewVolume(1),
+			CommissionRate: 0.5,
+		},
+	}
+	client.NetworkVolumeLeft, client.NetworkVolumeRight = models.NewVolume(10), models.NewVolume(6)
+	result, err = engine.ComputeBinaryCommission(ctx, client.ID.Hex())
+	if err != nil {
+		t.Fatalf("Erreur inattendue: %v", err)
+	}
+	if result.Amount != models.NewMoney(3) {
+		t.Errorf("Expected legacy match paying 3, got %s", result.Amount)
+	}
+	last = commissionRepo.commissions[len(commissionRepo.commissions)-1]
+	if last.Type != "binary-match" || last.PlanVersion != 3 {
+		t.Errorf("Expected binary-match commission from plan 3, got %s from plan %d", last.Type, last.PlanVersion)
+	}
+
+	// Moteur inconnu: erreur sans paiement
+	plans.plan = &models.CompPlanVersion{Version: 4, Binary: models.BinaryConfig{Engine: "unknown"}}
+	count := len(commissionRepo.commissions)
+	if _, err := engine.ComputeBinaryCommission(ctx, client.ID.Hex()); err == nil {
+		t.Error("Expected an error for an unknown engine")
+	}
+	if len(commissionRepo.commissions) != count {
+		t.Error("No commission should be created for an unknown engine")
+	}
+}
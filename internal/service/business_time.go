@@ -0,0 +1,49 @@
+package service
+
+import (
+	"fmt"
+	"time"
+)
+
+// Le jour ouvré commence à minuit dans le fuseau de l'entreprise (BUSINESS_TIMEZONE),
+// pas à minuit UTC: plafonds journaliers et hebdomadaires, périodes de paie, plages du
+// tableau de bord et rapports de caisse calculent leurs bornes avec les fonctions ci-dessous.
+
+// locationOrUTC retourne le fuseau fourni, UTC s'il n'est pas renseigné
+func locationOrUTC(location *time.Location) *time.Location {
+	if location == nil {
+		return time.UTC
+	}
+	return location
+}
+
+// startOfDay retourne minuit (heure locale de location) du jour contenant date
+func startOfDay(date time.Time, location *time.Location) time.Time {
+	year, month, day := date.In(location).Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, location)
+}
+
+// startOfWeek retourne minuit du premier jour (weekStart) de la semaine contenant date
+func startOfWeek(date time.Time, location *time.Location, weekStart time.Weekday) time.Time {
+	day := startOfDay(date, location)
+	offset := (int(day.Weekday()) - int(weekStart) + 7) % 7
+	return day.AddDate(0, 0, -offset)
+}
+
+// startOfMonth retourne minuit du premier jour du mois contenant date
+func startOfMonth(date time.Time, location *time.Location) time.Time {
+	year, month, _ := date.In(location).Date()
+	return time.Date(year, month, 1, 0, 0, 0, 0, location)
+}
+
+// parseBusinessDay retourne minuit du jour désigné par key ("2006-01-02"), aujourd'hui si key est vide
+func parseBusinessDay(key string, now time.Time, location *time.Location) (time.Time, error) {
+	if key == "" {
+		return startOfDay(now, location), nil
+	}
+	day, err := time.ParseInLocation("2006-01-02", key, location)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("date invalide (format attendu 2006-01-02): %s", key)
+	}
+	return day, nil
+}
@@ -0,0 +1,82 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"bureau/internal/models"
+)
+
+func TestStartOfDay_AroundLocalMidnight(t *testing.T) {
+	kinshasa := time.FixedZone("WAT", 60*60)
+
+	tests := []struct {
+		date     time.Time
+		expected time.Time
+	}{
+		// 23h59 à Kinshasa: encore le 14
+		{time.Date(2025, 3, 14, 22, 59, 0, 0, time.UTC), time.Date(2025, 3, 14, 0, 0, 0, 0, kinshasa)},
+		// Minuit pile à Kinshasa: le 15 commence
+		{time.Date(2025, 3, 14, 23, 0, 0, 0, time.UTC), time.Date(2025, 3, 15, 0, 0, 0, 0, kinshasa)},
+		// 0h30 UTC: déjà le 15 dans les deux fuseaux
+		{time.Date(2025, 3, 15, 0, 30, 0, 0, time.UTC), time.Date(2025, 3, 15, 0, 0, 0, 0, kinshasa)},
+	}
+	for _, tt := range tests {
+		if got := startOfDay(tt.date, kinshasa); !got.Equal(tt.expected) {
+			t.Errorf("startOfDay(%s) = %s, expected %s", tt.date, got, tt.expected)
+		}
+	}
+
+	// Le 1er du mois commence à 23h UTC la veille
+	if got := startOfMonth(time.Date(2025, 2, 28, 23, 30, 0, 0, time.UTC), kinshasa); !got.Equal(time.Date(2025, 3, 1, 0, 0, 0, 0, kinshasa)) {
+		t.Errorf("Expected March to start at local midnight, got %s", got)
+	}
+}
+
+func TestDashboardRangeStart_BusinessDays(t *testing.T) {
+	lubumbashi := time.FixedZone("CAT", 2*60*60)
+	// Mardi 11 mars 2025, 0h30 à Lubumbashi (lundi 22h30 UTC)
+	now := time.Date(2025, 3, 10, 22, 30, 0, 0, time.UTC)
+
+	from := dashboardRangeStart("7d", now, lubumbashi)
+	if from == nil || !from.Equal(time.Date(2025, 3, 5, 0, 0, 0, 0, lubumbashi)) {
+		t.Errorf("Expected 7d to start on Wednesday 5 at local midnight, got %v", from)
+	}
+	from = dashboardRangeStart("1y", now, lubumbashi)
+	if from == nil || !from.Equal(time.Date(2024, 3, 12, 0, 0, 0, 0, lubumbashi)) {
+		t.Errorf("Unexpected 1y start: %v", from)
+	}
+	if from := dashboardRangeStart("all", now, lubumbashi); from != nil {
+		t.Errorf("An unknown range should not filter by date, got %v", from)
+	}
+}
+
+func TestCaisseDailyReport_LocalDayBounds(t *testing.T) {
+	kinshasa := time.FixedZone("WAT", 60*60)
+	// 23h30 UTC le 14 mars: le jour ouvré courant est déjà le 15
+	now := time.Date(2025, 3, 14, 23, 30, 0, 0, time.UTC)
+
+	today, err := parseBusinessDay("", now, kinshasa)
+	if err != nil || today.Format("2006-01-02") != "2025-03-15" {
+		t.Fatalf("Expected today to be 2025-03-15 in Kinshasa, got %s (%v)", today, err)
+	}
+	day, err := parseBusinessDay("2025-03-15", now, kinshasa)
+	if err != nil || !day.Equal(time.Date(2025, 3, 14, 23, 0, 0, 0, time.UTC)) {
+		t.Fatalf("Expected the day to start at 23h UTC the day before, got %s (%v)", day, err)
+	}
+	if _, err := parseBusinessDay("15/03/2025", now, kinshasa); err == nil {
+		t.Error("An invalid date should be rejected")
+	}
+
+	report := summarizeCaisseDay(day, day.AddDate(0, 0, 1), []*models.CaisseTransaction{
+		{Type: "entree", Amount: models.NewMoney(120), Date: now},
+		{Type: "sortie", Amount: models.NewMoney(45), Date: now},
+		{Type: "entree", Amount: models.NewMoney(30), Date: now},
+	})
+	if report.Date != "2025-03-15" || report.TransactionCount != 3 {
+		t.Errorf("Unexpected report: %+v", report)
+	}
+	if report.TotalEntrees != models.NewMoney(150) || report.TotalSorties != models.NewMoney(45) || report.Net != models.NewMoney(105) {
+		t.Errorf("Unexpected report totals: %s / %s / %s", report.TotalEntrees, report.TotalSorties, report.Net)
+	}
+}
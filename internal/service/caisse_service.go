@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 	"errors"
+	"time"
 
 	"bureau/internal/models"
 	"bureau/internal/store"
@@ -13,12 +14,16 @@ import (
 type CaisseService struct {
 	caisseRepo *store.CaisseRepository
 	logger     *zap.Logger
+	location   *time.Location // Business timezone (daily report boundaries)
+	now        func() time.Time
 }
 
-func NewCaisseService(caisseRepo *store.CaisseRepository, logger *zap.Logger) *CaisseService {
+func NewCaisseService(caisseRepo *store.CaisseRepository, logger *zap.Logger, location *time.Location) *CaisseService {
 	return &CaisseService{
 		caisseRepo: caisseRepo,
 		logger:     logger,
+		location:   locationOrUTC(location),
+		now:        time.Now,
 	}
 }
 
@@ -51,7 +56,7 @@ func (s *CaisseService) AddTransaction(ctx context.Context, transaction *models.
 	}
 
 	// Update caisse balance
-	var newBalance, newTotalEntrees, newTotalSorties float64
+	var newBalance, newTotalEntrees, newTotalSorties models.Money
 	if transaction.Type == "entree" {
 		newBalance = caisse.Balance + transaction.Amount
 		newTotalEntrees = caisse.TotalEntrees + transaction.Amount
@@ -71,18 +76,61 @@ func (s *CaisseService) AddTransaction(ctx context.Context, transaction *models.
 	return createdTransaction, nil
 }
 
+// GetTransactionsByReference gets the transactions linked to a sale or payment
+func (s *CaisseService) GetTransactionsByReference(ctx context.Context, reference string) ([]*models.CaisseTransaction, error) {
+	return s.caisseRepo.GetTransactionsByReference(ctx, reference)
+}
+
 // GetTransactions gets all transactions
 func (s *CaisseService) GetTransactions(ctx context.Context, filter *models.FilterInput, paging *models.PagingInput) ([]*models.CaisseTransaction, error) {
 	return s.caisseRepo.GetTransactions(ctx, filter, paging)
 }
 
+// DailyReport summarizes the transactions of a business day ("2006-01-02", today if empty)
+// The day runs from midnight to midnight in the business timezone
+func (s *CaisseService) DailyReport(ctx context.Context, date string) (*models.CaisseDailyReport, error) {
+	start, err := parseBusinessDay(date, s.now(), s.location)
+	if err != nil {
+		return nil, err
+	}
+	end := start.AddDate(0, 0, 1)
+
+	transactions, err := s.caisseRepo.GetTransactionsByDateRange(ctx, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	return summarizeCaisseDay(start, end, transactions), nil
+}
+
+// summarizeCaisseDay totals the entries and exits of the day [start, end)
+func summarizeCaisseDay(start, end time.Time, transactions []*models.CaisseTransaction) *models.CaisseDailyReport {
+	report := &models.CaisseDailyReport{
+		Date:             start.Format("2006-01-02"),
+		Start:            start,
+		End:              end,
+		TransactionCount: len(transactions),
+		Transactions:     transactions,
+	}
+	for _, transaction := range transactions {
+		if transaction.Type == "entree" {
+			report.TotalEntrees += transaction.Amount
+		} else {
+			report.TotalSorties += transaction.Amount
+		}
+	}
+	report.Net = report.TotalEntrees - report.TotalSorties
+
+	return report
+}
+
 // GetTransactionByID gets a transaction by ID
 func (s *CaisseService) GetTransactionByID(ctx context.Context, id string) (*models.CaisseTransaction, error) {
 	return s.caisseRepo.GetTransactionByID(ctx, id)
 }
 
 // UpdateBalance manually updates the caisse balance (admin only)
-func (s *CaisseService) UpdateBalance(ctx context.Context, balance float64) (*models.Caisse, error) {
+func (s *CaisseService) UpdateBalance(ctx context.Context, balance models.Money) (*models.Caisse, error) {
 	// Get current caisse to preserve totalEntrees and totalSorties
 	caisse, err := s.caisseRepo.GetOrCreate(ctx)
 	if err != nil {
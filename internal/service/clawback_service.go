@@ -0,0 +1,345 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"bureau/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+type clawbackRepository interface {
+	Create(ctx context.Context, clawback *models.Clawback) (*models.Clawback, error)
+	GetBySaleID(ctx context.Context, saleID primitive.ObjectID) (*models.Clawback, error)
+	GetAll(ctx context.Context, paging *models.PagingInput) ([]*models.Clawback, error)
+}
+
+type clawbackCommissionRepository interface {
+	Create(ctx context.Context, commission *models.Commission) (*models.Commission, error)
+	GetBySaleID(ctx context.Context, saleID primitive.ObjectID) ([]*models.Commission, error)
+	GetBySourceCommissionID(ctx context.Context, commissionID primitive.ObjectID) ([]*models.Commission, error)
+}
+
+type clawbackCycleRepository interface {
+	GetByClientID(ctx context.Context, clientID string, filter *models.FilterInput, paging *models.PagingInput) ([]*models.BinaryCycle, error)
+	AddReversedVolume(ctx context.Context, id primitive.ObjectID, volume models.Volume) error
+}
+
+type clawbackCaisse interface {
+	GetTransactionsByReference(ctx context.Context, reference string) ([]*models.CaisseTransaction, error)
+	AddTransaction(ctx context.Context, transaction *models.CaisseTransaction) (*models.CaisseTransaction, error)
+}
+
+type stockRepository interface {
+	IncrementStock(ctx context.Context, id primitive.ObjectID, quantity int) error
+}
+
+// saleVolumeWithdrawer retire le volume d'une vente de l'acheteur et de son upline (ClientService)
+type saleVolumeWithdrawer interface {
+	WithdrawSaleVolume(ctx context.Context, buyer *models.Client, volume models.Volume, status string) ([]VolumeShortfall, error)
+}
+
+// ClawbackService reprend les effets d'une vente annulée ou supprimée: points et volumes de
+// l'upline, commissions binary-cycle et matching payées sur le volume apparié, bonus de
+// démarrage rapide, entrée de caisse et stock. Chaque commission reprise donne lieu à une
+// commission négative de type "clawback" débitée du portefeuille, qui peut devenir négatif
+// si le montant a déjà été retiré. Les commissions du moteur legacy (binary-match) ne sont
+// pas reprises: elles ne conservent pas le volume apparié.
+type ClawbackService struct {
+	clawbackRepo   clawbackRepository
+	clientRepo     clientRepository
+	commissionRepo clawbackCommissionRepository
+	cycleRepo      clawbackCycleRepository
+	caisse         clawbackCaisse
+	stock          stockRepository
+	volume         saleVolumeWithdrawer
+	wallet         walletLedger
+	logger         *zap.Logger
+	now            func() time.Time
+}
+
+// NewClawbackService crée un nouveau service de reprise des ventes
+func NewClawbackService(
+	clawbackRepo clawbackRepository,
+	clientRepo clientRepository,
+	commissionRepo clawbackCommissionRepository,
+	cycleRepo clawbackCycleRepository,
+	caisse clawbackCaisse,
+	stock stockRepository,
+	volume saleVolumeWithdrawer,
+	wallet walletLedger,
+	logger *zap.Logger,
+) *ClawbackService {
+	return &ClawbackService{
+		clawbackRepo:   clawbackRepo,
+		clientRepo:     clientRepo,
+		commissionRepo: commissionRepo,
+		cycleRepo:      cycleRepo,
+		caisse:         caisse,
+		stock:          stock,
+		volume:         volume,
+		wallet:         wallet,
+		logger:         logger,
+		now:            time.Now,
+	}
+}
+
+// GetBySaleID récupère la reprise d'une vente (nil si elle n'a pas été reprise)
+func (s *ClawbackService) GetBySaleID(ctx context.Context, saleID primitive.ObjectID) (*models.Clawback, error) {
+	return s.clawbackRepo.GetBySaleID(ctx, saleID)
+}
+
+// GetAll récupère les reprises, de la plus récente à la plus ancienne
+func (s *ClawbackService) GetAll(ctx context.Context, paging *models.PagingInput) ([]*models.Clawback, error) {
+	return s.clawbackRepo.GetAll(ctx, paging)
+}
+
+// ReverseSale reprend les effets de sale, dont le statut est celui d'avant l'annulation ou la
+// suppression, et enregistre la reprise. Les écritures partagent le contexte, et donc la
+// transaction, de l'appelant: une reprise qui échoue annule la modification de la vente.
+func (s *ClawbackService) ReverseSale(ctx context.Context, sale *models.Sale, reason string) (*models.Clawback, error) {
+	if sale.Status == "cancelled" {
+		return nil, errors.New("une vente annulée n'a plus d'effet à reprendre")
+	}
+	existing, err := s.clawbackRepo.GetBySaleID(ctx, sale.ID)
+	if err != nil {
+		return nil, fmt.Errorf("échec de la lecture des reprises: %w", err)
+	}
+	if existing != nil {
+		return nil, errors.New("cette vente a déjà été reprise")
+	}
+
+	now := s.now()
+	clawback := &models.Clawback{
+		ID:          primitive.NewObjectID(),
+		SaleID:      sale.ID,
+		ClientID:    sale.ClientID,
+		Reason:      reason,
+		SaleStatus:  sale.Status,
+		Volume:      sale.Points,
+		Commissions: []models.ClawbackCommission{},
+		CreatedAt:   now,
+	}
+
+	if err := s.reverseVolume(ctx, clawback, sale, now); err != nil {
+		return nil, err
+	}
+	if err := s.reverseSaleCommissions(ctx, clawback, sale, now); err != nil {
+		return nil, err
+	}
+	if err := s.refundCaisse(ctx, clawback, sale); err != nil {
+		return nil, err
+	}
+	if sale.ProductID != nil && sale.Quantity > 0 {
+		if err := s.stock.IncrementStock(ctx, *sale.ProductID, sale.Quantity); err != nil {
+			return nil, fmt.Errorf("échec de la remise en stock: %w", err)
+		}
+		clawback.StockRestored = sale.Quantity
+	}
+
+	created, err := s.clawbackRepo.Create(ctx, clawback)
+	if err != nil {
+		return nil, fmt.Errorf("échec de l'enregistrement de la reprise: %w", err)
+	}
+
+	s.logger.Info("Sale reversed",
+		zap.String("saleID", sale.ID.Hex()),
+		zap.String("reason", reason),
+		zap.Float64("volume", created.Volume.Float64()),
+		zap.Float64("pairedVolume", created.PairedVolume.Float64()),
+		zap.Int("commissions", len(created.Commissions)),
+		zap.Float64("totalAmount", created.TotalAmount.Float64()))
+	return created, nil
+}
+
+// reverseVolume retire le volume de la vente et reprend, pour chaque ancêtre dont il avait
+// déjà été apparié, la part correspondante de ses derniers cycles et des matchings associés
+func (s *ClawbackService) reverseVolume(ctx context.Context, clawback *models.Clawback, sale *models.Sale, now time.Time) error {
+	buyer, err := s.clientRepo.GetByID(ctx, sale.ClientID.Hex())
+	if err != nil || buyer == nil {
+		// Acheteur supprimé: son volume a déjà quitté l'arbre avec lui
+		s.logger.Warn("Buyer not found, sale volume not withdrawn", zap.String("saleID", sale.ID.Hex()), zap.Error(err))
+		return nil
+	}
+
+	shortfalls, err := s.volume.WithdrawSaleVolume(ctx, buyer, sale.Points, sale.Status)
+	if err != nil {
+		return fmt.Errorf("échec du retrait du volume: %w", err)
+	}
+
+	for _, shortfall := range shortfalls {
+		reversed, err := s.reverseCycles(ctx, clawback, shortfall, sale.Date, now)
+		if err != nil {
+			return err
+		}
+		clawback.PairedVolume += reversed
+		if reversed < shortfall.Volume {
+			s.logger.Warn("Paired volume without matching binary cycles",
+				zap.String("saleID", sale.ID.Hex()),
+				zap.String("clientID", shortfall.ClientID.Hex()),
+				zap.Float64("volume", (shortfall.Volume-reversed).Float64()))
+		}
+	}
+	return nil
+}
+
+// reverseCycles reprend shortfall.Volume sur les cycles de l'ancêtre payés depuis la vente,
+// du plus récent au plus ancien, et retourne le volume effectivement repris
+func (s *ClawbackService) reverseCycles(ctx context.Context, clawback *models.Clawback, shortfall VolumeShortfall, since, now time.Time) (models.Volume, error) {
+	cycles, err := s.cycleRepo.GetByClientID(ctx, shortfall.ClientID.Hex(), &models.FilterInput{DateFrom: &since}, nil)
+	if err != nil {
+		return 0, fmt.Errorf("échec de la lecture des cycles: %w", err)
+	}
+
+	rest := shortfall.Volume
+	for _, cycle := range cycles {
+		if rest <= 0 {
+			break
+		}
+		available := cycle.LeftVolumeUsed - cycle.ReversedVolume
+		if available <= 0 {
+			continue
+		}
+		portion := min(rest, available)
+		if err := s.cycleRepo.AddReversedVolume(ctx, cycle.ID, portion); err != nil {
+			return 0, fmt.Errorf("échec de la mise à jour du cycle: %w", err)
+		}
+		rest -= portion
+
+		fraction := float64(portion) / float64(cycle.LeftVolumeUsed)
+		source := &models.Commission{
+			ID:          cycle.CommissionID,
+			ClientID:    cycle.ClientID,
+			Type:        "binary-cycle",
+			Amount:      cycle.Amount,
+			PlanVersion: cycle.PlanVersion,
+		}
+		if err := s.reverseCommission(ctx, clawback, source, fraction, now); err != nil {
+			return 0, err
+		}
+
+		// Les matchings ont été calculés sur la commission du cycle: même proportion
+		derived, err := s.commissionRepo.GetBySourceCommissionID(ctx, cycle.CommissionID)
+		if err != nil {
+			return 0, fmt.Errorf("échec de la lecture des bonus de matching: %w", err)
+		}
+		for _, matching := range derived {
+			if matching.Type != "matching" {
+				continue
+			}
+			if err := s.reverseCommission(ctx, clawback, matching, fraction, now); err != nil {
+				return 0, err
+			}
+		}
+	}
+	return shortfall.Volume - rest, nil
+}
+
+// reverseSaleCommissions reprend entièrement les commissions versées sur la vente elle-même
+// (bonus de démarrage rapide). Le bonus reste marqué comme versé pour le membre.
+func (s *ClawbackService) reverseSaleCommissions(ctx context.Context, clawback *models.Clawback, sale *models.Sale, now time.Time) error {
+	commissions, err := s.commissionRepo.GetBySaleID(ctx, sale.ID)
+	if err != nil {
+		return fmt.Errorf("échec de la lecture des commissions de la vente: %w", err)
+	}
+	for _, commission := range commissions {
+		if commission.Type != "direct" {
+			continue
+		}
+		if err := s.reverseCommission(ctx, clawback, commission, 1, now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reverseCommission enregistre une commission négative de fraction × source.Amount et la
+// débite des gains et du portefeuille du bénéficiaire
+func (s *ClawbackService) reverseCommission(ctx context.Context, clawback *models.Clawback, source *models.Commission, fraction float64, now time.Time) error {
+	amount := source.Amount.Mul(fraction)
+	if amount <= 0 {
+		return nil
+	}
+
+	earner, err := s.clientRepo.GetByID(ctx, source.ClientID.Hex())
+	if err != nil || earner == nil {
+		s.logger.Warn("Commission earner not found, clawback skipped",
+			zap.String("commissionID", source.ID.Hex()),
+			zap.Float64("amount", amount.Float64()),
+			zap.Error(err))
+		return nil
+	}
+
+	reversal, err := s.commissionRepo.Create(ctx, &models.Commission{
+		ID:                 primitive.NewObjectID(),
+		ClientID:           earner.ID,
+		SourceClientID:     clawback.ClientID,
+		Amount:             -amount,
+		Level:              source.Level,
+		Type:               "clawback",
+		Date:               now,
+		PlanVersion:        source.PlanVersion,
+		SaleID:             &clawback.SaleID,
+		SourceCommissionID: &source.ID,
+	})
+	if err != nil {
+		return fmt.Errorf("échec de l'enregistrement de la reprise de commission: %w", err)
+	}
+	if _, err := s.wallet.Post(ctx, commissionEntry(reversal)); err != nil {
+		return fmt.Errorf("échec du débit du portefeuille: %w", err)
+	}
+
+	clawback.Commissions = append(clawback.Commissions, models.ClawbackCommission{
+		CommissionID: source.ID,
+		ReversalID:   reversal.ID,
+		ClientID:     earner.ID,
+		Type:         source.Type,
+		Amount:       amount,
+	})
+	clawback.TotalAmount += amount
+	return nil
+}
+
+// refundCaisse enregistre une sortie de caisse du montant net encaissé pour la vente
+func (s *ClawbackService) refundCaisse(ctx context.Context, clawback *models.Clawback, sale *models.Sale) error {
+	if s.caisse == nil {
+		return nil
+	}
+	saleRef := sale.ID.Hex()
+	transactions, err := s.caisse.GetTransactionsByReference(ctx, saleRef)
+	if err != nil {
+		return fmt.Errorf("échec de la lecture des entrées de caisse: %w", err)
+	}
+
+	var net models.Money
+	for _, transaction := range transactions {
+		if transaction.Type == "entree" {
+			net += transaction.Amount
+		} else {
+			net -= transaction.Amount
+		}
+	}
+	if net <= 0 {
+		return nil
+	}
+
+	refType := "sale"
+	desc := fmt.Sprintf("Reprise de la vente %s (%s)", saleRef, clawback.Reason)
+	refund, err := s.caisse.AddTransaction(ctx, &models.CaisseTransaction{
+		Type:          "sortie",
+		Amount:        net,
+		Description:   &desc,
+		Reference:     &saleRef,
+		ReferenceType: &refType,
+	})
+	if err != nil {
+		return fmt.Errorf("échec de la sortie de caisse: %w", err)
+	}
+	clawback.CaisseAmount = net
+	clawback.CaisseTransactionID = &refund.ID
+	return nil
+}
@@ -0,0 +1,225 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"bureau/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+type mockClawbackRepo struct {
+	clawbacks []*models.Clawback
+}
+
+func (m *mockClawbackRepo) Create(ctx context.Context, clawback *models.Clawback) (*models.Clawback, error) {
+	m.clawbacks = append(m.clawbacks, clawback)
+	return clawback, nil
+}
+
+func (m *mockClawbackRepo) GetBySaleID(ctx context.Context, saleID primitive.ObjectID) (*models.Clawback, error) {
+	for _, clawback := range m.clawbacks {
+		if clawback.SaleID == saleID {
+			return clawback, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *mockClawbackRepo) GetAll(ctx context.Context, paging *models.PagingInput) ([]*models.Clawback, error) {
+	return m.clawbacks, nil
+}
+
+type mockClawbackCommissionRepo struct {
+	*mockCommissionRepo
+}
+
+func (m *mockClawbackCommissionRepo) GetBySaleID(ctx context.Context, saleID primitive.ObjectID) ([]*models.Commission, error) {
+	var out []*models.Commission
+	for _, c := range m.commissions {
+		if c.SaleID != nil && *c.SaleID == saleID {
+			out = append(out, c)
+		}
+	}
+	return out, nil
+}
+
+func (m *mockClawbackCommissionRepo) GetBySourceCommissionID(ctx context.Context, commissionID primitive.ObjectID) ([]*models.Commission, error) {
+	var out []*models.Commission
+	for _, c := range m.commissions {
+		if c.SourceCommissionID != nil && *c.SourceCommissionID == commissionID {
+			out = append(out, c)
+		}
+	}
+	return out, nil
+}
+
+type mockClawbackCycleRepo struct {
+	*mockCycleRepo
+}
+
+func (m *mockClawbackCycleRepo) AddReversedVolume(ctx context.Context, id primitive.ObjectID, volume models.Volume) error {
+	for _, c := range m.cycles {
+		if c.ID == id {
+			c.ReversedVolume += volume
+		}
+	}
+	return nil
+}
+
+type mockCaisse struct {
+	transactions []*models.CaisseTransaction
+}
+
+func (m *mockCaisse) GetTransactionsByReference(ctx context.Context, reference string) ([]*models.CaisseTransaction, error) {
+	var out []*models.CaisseTransaction
+	for _, t := range m.transactions {
+		if t.Reference != nil && *t.Reference == reference {
+			out = append(out, t)
+		}
+	}
+	return out, nil
+}
+
+func (m *mockCaisse) AddTransaction(ctx context.Context, transaction *models.CaisseTransaction) (*models.CaisseTransaction, error) {
+	transaction.ID = primitive.NewObjectID()
+	m.transactions = append(m.transactions, transaction)
+	return transaction, nil
+}
+
+type mockStockRepo struct {
+	stock map[primitive.ObjectID]int
+}
+
+func (m *mockStockRepo) IncrementStock(ctx context.Context, id primitive.ObjectID, quantity int) error {
+	m.stock[id] += quantity
+	return nil
+}
+
+// mockVolumeWithdrawer retourne les manques configurés, comme ClientService.WithdrawSaleVolume
+type mockVolumeWithdrawer struct {
+	shortfalls []VolumeShortfall
+	withdrawn  models.Volume
+}
+
+func (m *mockVolumeWithdrawer) WithdrawSaleVolume(ctx context.Context, buyer *models.Client, volume models.Volume, status string) ([]VolumeShortfall, error) {
+	m.withdrawn += volume
+	return m.shortfalls, nil
+}
+
+type clawbackTestEnv struct {
+	service        *ClawbackService
+	clawbackRepo   *mockClawbackRepo
+	clientRepo     *mockClientRepo
+	commissionRepo *mockClawbackCommissionRepo
+	cycleRepo      *mockClawbackCycleRepo
+	caisse         *mockCaisse
+	stock          *mockStockRepo
+	volume         *mockVolumeWithdrawer
+}
+
+func createTestClawbackService() *clawbackTestEnv {
+	env := &clawbackTestEnv{
+		clawbackRepo:   &mockClawbackRepo{},
+		clientRepo:     &mockClientRepo{clients: make(map[string]*models.Client)},
+		commissionRepo: &mockClawbackCommissionRepo{&mockCommissionRepo{}},
+		cycleRepo:      &mockClawbackCycleRepo{&mockCycleRepo{}},
+		caisse:         &mockCaisse{},
+		stock:          &mockStockRepo{stock: make(map[primitive.ObjectID]int)},
+		volume:         &mockVolumeWithdrawer{},
+	}
+	env.service = NewClawbackService(env.clawbackRepo, env.clientRepo, env.commissionRepo, env.cycleRepo, env.caisse, env.stock, env.volume, newMockWalletRepo(env.clientRepo.clients), zap.NewNop())
+	return env
+}
+
+func (env *clawbackTestEnv) addClient(wallet models.Money) *models.Client {
+	client := &models.Client{ID: primitive.NewObjectID(), TotalEarnings: wallet, WalletBalance: wallet}
+	env.clientRepo.clients[client.ID.Hex()] = client
+	return client
+}
+
+func TestClawback_ReversesPairedCyclesMatchingCaisseAndStock(t *testing.T) {
+	env := createTestClawbackService()
+	ctx := context.Background()
+
+	buyer := env.addClient(0)
+	ancestor := env.addClient(models.NewMoney(50))
+	sponsor := env.addClient(models.NewMoney(10))
+
+	// Cycle de 100 de volume apparié payé 20, dont 5 de matching au parrain
+	cycle, _ := env.cycleRepo.Create(ctx, &models.BinaryCycle{ClientID: ancestor.ID, CommissionID: primitive.NewObjectID(), Amount: models.NewMoney(20), LeftVolumeUsed: models.NewVolume(100), RightVolumeUsed: models.NewVolume(100)})
+	env.commissionRepo.Create(ctx, &models.Commission{ClientID: sponsor.ID, Amount: models.NewMoney(5), Level: 1, Type: "matching", SourceCommissionID: &cycle.CommissionID})
+	env.volume.shortfalls = []VolumeShortfall{{ClientID: ancestor.ID, Side: "left", Volume: models.NewVolume(40)}}
+
+	productID := primitive.NewObjectID()
+	sale := &models.Sale{ID: primitive.NewObjectID(), ClientID: buyer.ID, ProductID: &productID, Quantity: 2, Amount: models.NewMoney(120), Points: models.NewVolume(60), Status: "paid"}
+	saleRef, entree := sale.ID.Hex(), "entree"
+	env.caisse.transactions = append(env.caisse.transactions, &models.CaisseTransaction{Type: entree, Amount: models.NewMoney(120), Reference: &saleRef})
+
+	clawback, err := env.service.ReverseSale(ctx, sale, models.ClawbackReasonCancelled)
+	if err != nil {
+		t.Fatalf("Erreur inattendue: %v", err)
+	}
+
+	if env.volume.withdrawn != models.NewVolume(60) {
+		t.Errorf("Expected 60 volume withdrawn, got %s", env.volume.withdrawn)
+	}
+	if cycle.ReversedVolume != models.NewVolume(40) || clawback.PairedVolume != models.NewVolume(40) {
+		t.Errorf("Expected 40 paired volume reversed, got cycle %s / clawback %s", cycle.ReversedVolume, clawback.PairedVolume)
+	}
+	// 40% du cycle (8) et du matching (2)
+	if ancestor.WalletBalance != models.NewMoney(42) || ancestor.TotalEarnings != models.NewMoney(42) || sponsor.WalletBalance != models.NewMoney(8) {
+		t.Errorf("Unexpected wallets after clawback: ancestor %s, sponsor %s", ancestor.WalletBalance, sponsor.WalletBalance)
+	}
+	if len(clawback.Commissions) != 2 || clawback.TotalAmount != models.NewMoney(10) {
+		t.Fatalf("Expected 2 reversed commissions totalling 10, got %+v", clawback)
+	}
+	for _, c := range env.commissionRepo.commissions {
+		if c.Type == "clawback" && (c.Amount >= 0 || c.SaleID == nil || *c.SaleID != sale.ID || c.SourceClientID != buyer.ID) {
+			t.Errorf("Unexpected clawback commission: %+v", c)
+		}
+	}
+	if clawback.CaisseAmount != models.NewMoney(120) || clawback.CaisseTransactionID == nil {
+		t.Errorf("Expected a 120 caisse refund, got %+v", clawback)
+	}
+	if last := env.caisse.transactions[len(env.caisse.transactions)-1]; last.Type != "sortie" || last.Amount != models.NewMoney(120) {
+		t.Errorf("Expected a sortie of 120, got %+v", last)
+	}
+	if env.stock.stock[productID] != 2 || clawback.StockRestored != 2 {
+		t.Errorf("Expected 2 units back in stock, got %d", env.stock.stock[productID])
+	}
+	if clawback.SaleID != sale.ID || clawback.SaleStatus != "paid" || clawback.Reason != models.ClawbackReasonCancelled {
+		t.Errorf("Clawback not linked to the sale: %+v", clawback)
+	}
+}
+
+func TestClawback_FastStartBonusReversedOnceEvenIfWithdrawn(t *testing.T) {
+	env := createTestClawbackService()
+	ctx := context.Background()
+
+	buyer := env.addClient(0)
+	sponsor := env.addClient(0) // Bonus déjà retiré du portefeuille
+	sponsor.TotalEarnings = models.NewMoney(15)
+	sale := &models.Sale{ID: primitive.NewObjectID(), ClientID: buyer.ID, Amount: models.NewMoney(75), Status: "paid"}
+	env.commissionRepo.Create(ctx, &models.Commission{ClientID: sponsor.ID, Amount: models.NewMoney(15), Type: "direct", SaleID: &sale.ID})
+
+	clawback, err := env.service.ReverseSale(ctx, sale, models.ClawbackReasonDeleted)
+	if err != nil {
+		t.Fatalf("Erreur inattendue: %v", err)
+	}
+	if clawback.TotalAmount != models.NewMoney(15) || sponsor.WalletBalance != models.NewMoney(-15) || sponsor.TotalEarnings != 0 {
+		t.Errorf("Expected the 15 bonus to be taken back (negative wallet), got total %s wallet %s", clawback.TotalAmount, sponsor.WalletBalance)
+	}
+	if clawback.CaisseTransactionID != nil || clawback.StockRestored != 0 {
+		t.Errorf("Nothing to refund from caisse or stock, got %+v", clawback)
+	}
+
+	if _, err := env.service.ReverseSale(ctx, sale, models.ClawbackReasonDeleted); err == nil {
+		t.Error("Expected an error when reversing the same sale twice")
+	}
+	if sponsor.WalletBalance != models.NewMoney(-15) {
+		t.Errorf("Second reversal must not debit again, got %s", sponsor.WalletBalance)
+	}
+}
@@ -16,30 +16,38 @@ import (
 )
 
 type ClientService struct {
-	clientRepo           *store.ClientRepository
-	saleRepo             *store.SaleRepository
-	commissionRepo       *store.CommissionRepository
-	logger               *zap.Logger
-	binaryThreshold      float64
-	binaryCommissionRate float64
-	defaultProductPrice  float64
+	clientRepo        *store.ClientRepository
+	saleRepo          *store.SaleRepository
+	binaryEngine      BinaryEngine
+	activityService   *ActivityService
+	legCountService   *LegCountService
+	logger            *zap.Logger
+	placementStrategy string
+	holdingTank       models.HoldingTankRule
+	txHelper          transactionHelper
 }
 
 func NewClientService(
 	clientRepo *store.ClientRepository,
 	saleRepo *store.SaleRepository,
-	commissionRepo *store.CommissionRepository,
+	binaryEngine BinaryEngine,
+	activityService *ActivityService,
+	legCountService *LegCountService,
 	logger *zap.Logger,
-	binaryThreshold, binaryCommissionRate, defaultProductPrice float64,
+	placementStrategy string,
+	holdingTank models.HoldingTankRule,
+	txHelper transactionHelper,
 ) *ClientService {
 	return &ClientService{
-		clientRepo:           clientRepo,
-		saleRepo:             saleRepo,
-		commissionRepo:       commissionRepo,
-		logger:               logger,
-		binaryThreshold:      binaryThreshold,
-		binaryCommissionRate: binaryCommissionRate,
-		defaultProductPrice:  defaultProductPrice,
+		clientRepo:        clientRepo,
+		saleRepo:          saleRepo,
+		binaryEngine:      binaryEngine,
+		activityService:   activityService,
+		legCountService:   legCountService,
+		logger:            logger,
+		placementStrategy: placementStrategy,
+		holdingTank:       holdingTank,
+		txHelper:          txHelper,
 	}
 }
 
@@ -66,33 +74,47 @@ func (s *ClientService) Update(ctx context.Context, id string, client *models.Cl
 }
 
 func (s *ClientService) Delete(ctx context.Context, id string) (bool, error) {
+	// Retirer le membre et son sous-arbre des compteurs de jambes de l'upline
+	if s.legCountService != nil {
+		if member, err := s.clientRepo.GetByID(ctx, id); err == nil {
+			if err := s.legCountService.OnDelete(ctx, member); err != nil {
+				s.logger.Error("Failed to update leg counts", zap.String("clientID", id), zap.Error(err))
+			}
+		}
+	}
+
 	err := s.clientRepo.Delete(ctx, id)
 	return err == nil, err
 }
 
 // CreateWithBinaryPlacement creates a new client and places them in the binary tree
+// The sponsor (enroller) and the placement parent are distinct: the member is placed under
+// placementParentID, which must be the sponsor or one of its placement descendants.
+// If placementParentID is nil, the member is placed directly under the sponsor.
 // If requestedPosition is provided ("left" or "right"), it will try to place the client at that position.
 // If the requested position is not available, it returns an error.
-// If requestedPosition is nil, it uses the first available position (left then right).
-// If both positions are taken, it returns an error asking user to choose another sponsor.
-func (s *ClientService) CreateWithBinaryPlacement(ctx context.Context, client *models.Client, sponsorID *primitive.ObjectID, requestedPosition *string) (*models.Client, error) {
-	// Generate unique client ID
-	clientID, err := s.generateUniqueClientID(ctx)
-	if err != nil {
-		return nil, fmt.Errorf("failed to generate client ID: %w", err)
+// If requestedPosition is nil, the placement strategy (the system default when strategy is nil)
+// searches the placement parent's downline for an open position: see findPlacement.
+func (s *ClientService) CreateWithBinaryPlacement(ctx context.Context, client *models.Client, sponsorID, placementParentID *primitive.ObjectID, requestedPosition, strategy *string) (*models.Client, error) {
+	placementStrategy := s.placementStrategy
+	if strategy != nil && *strategy != "" {
+		placementStrategy = *strategy
+	}
+	if requestedPosition == nil && !models.IsPlacementStrategy(placementStrategy) {
+		return nil, fmt.Errorf("stratégie de placement inconnue %q", placementStrategy)
 	}
-	client.ClientID = clientID
 
-	// Hash the password
-	hashedPassword, err := s.HashPassword(client.PasswordHash)
-	if err != nil {
-		return nil, fmt.Errorf("failed to hash password: %w", err)
+	if err := s.prepareNewClient(ctx, client); err != nil {
+		return nil, err
 	}
-	client.PasswordHash = hashedPassword
 
 	// If no sponsor provided, this is the root client
 	if sponsorID == nil {
+		if placementParentID != nil {
+			return nil, errors.New("un parent de placement ne peut être choisi qu'avec un sponsor")
+		}
 		client.SponsorID = nil
+		client.PlacementParentID = nil
 		client.Position = nil
 		client.LeftChildID = nil
 		client.RightChildID = nil
@@ -116,44 +138,54 @@ func (s *ClientService) CreateWithBinaryPlacement(ctx context.Context, client *m
 		return nil, errors.New("sponsor introuvable")
 	}
 
-	// Check if sponsor has available positions
-	hasLeftChild := sponsor.LeftChildID != nil
-	hasRightChild := sponsor.RightChildID != nil
-
-	// If both positions are taken, return error
-	if hasLeftChild && hasRightChild {
-		return nil, errors.New("ce sponsor a déjà 2 enfants (les positions gauche et droite sont prises). Veuillez choisir un autre sponsor qui a une position disponible")
+	// Find the placement parent (the sponsor itself by default)
+	parent := sponsor
+	if placementParentID != nil && *placementParentID != sponsor.ID {
+		parent, err = s.clientRepo.GetByID(ctx, placementParentID.Hex())
+		if err != nil {
+			return nil, errors.New("parent de placement introuvable")
+		}
+		inDownline, err := s.isInPlacementDownline(ctx, parent, sponsor.ID)
+		if err != nil {
+			return nil, err
+		}
+		if !inDownline {
+			return nil, errors.New("le parent de placement doit faire partie de l'arbre de placement du sponsor")
+		}
+	}
+	if parent.HoldingTankUntil != nil {
+		return nil, errors.New("le parent de placement est en salle d'attente: il doit d'abord être placé")
 	}
 
 	// Determine position
 	var position string
 	if requestedPosition != nil {
-		// User specified a position
+		// User specified a position: direct placement under the placement parent
 		requestedPos := *requestedPosition
 		if requestedPos != "left" && requestedPos != "right" {
 			return nil, errors.New("la position doit être 'left' ou 'right'")
 		}
 
 		// Check if requested position is available
-		if requestedPos == "left" && hasLeftChild {
-			return nil, errors.New("la position gauche est déjà prise sur ce sponsor. Veuillez choisir 'right' ou sélectionner un autre sponsor")
+		if requestedPos == "left" && parent.LeftChildID != nil {
+			return nil, errors.New("la position gauche est déjà prise sur ce parent de placement. Veuillez choisir 'right' ou sélectionner un autre parent de placement")
 		}
-		if requestedPos == "right" && hasRightChild {
-			return nil, errors.New("la position droite est déjà prise sur ce sponsor. Veuillez choisir 'left' ou sélectionner un autre sponsor")
+		if requestedPos == "right" && parent.RightChildID != nil {
+			return nil, errors.New("la position droite est déjà prise sur ce parent de placement. Veuillez choisir 'left' ou sélectionner un autre parent de placement")
 		}
 
 		position = requestedPos
 	} else {
-		// No position specified, use first available
-		if !hasLeftChild {
-			position = "left"
-		} else {
-			position = "right"
+		// No position specified: the strategy finds the next open position in the downline
+		parent, position, err = findPlacement(ctx, s.clientRepo, parent, placementStrategy)
+		if err != nil {
+			return nil, err
 		}
 	}
 
 	// Set client properties
-	client.SponsorID = sponsorID
+	client.SponsorID = &sponsor.ID
+	client.PlacementParentID = &parent.ID
 	client.Position = &position
 	client.LeftChildID = nil
 	client.RightChildID = nil
@@ -170,142 +202,283 @@ func (s *ClientService) CreateWithBinaryPlacement(ctx context.Context, client *m
 		return nil, err
 	}
 
-	// Update sponsor's binary tree
-	err = s.updateSponsorBinaryTree(ctx, *sponsorID, createdClient.ID, position)
+	// Update the placement parent's binary tree
+	err = s.updateParentBinaryTree(ctx, parent.ID, createdClient.ID, position)
 	if err != nil {
-		s.logger.Error("Failed to update sponsor binary tree", zap.Error(err))
+		s.logger.Error("Failed to update placement parent binary tree", zap.Error(err))
 		// Continue anyway, the client is created
+	} else if s.legCountService != nil {
+		if err := s.legCountService.OnEnrollment(ctx, createdClient); err != nil {
+			s.logger.Error("Failed to update leg counts", zap.Error(err))
+		}
 	}
 
-	// Update network volumes and check for binary commissions
-	err = s.updateNetworkVolumesAndCommissions(ctx, *sponsorID, s.defaultProductPrice, position)
+	// L'inscription n'apporte aucun volume: seules les ventes payées alimentent les jambes
+	return createdClient, nil
+}
+
+// prepareNewClient attribue un identifiant unique au nouveau client et hache son mot de passe
+func (s *ClientService) prepareNewClient(ctx context.Context, client *models.Client) error {
+	// Generate unique client ID
+	clientID, err := s.generateUniqueClientID(ctx)
 	if err != nil {
-		s.logger.Error("Failed to update network volumes and commissions", zap.Error(err))
+		return fmt.Errorf("failed to generate client ID: %w", err)
 	}
+	client.ClientID = clientID
 
-	return createdClient, nil
+	// Hash the password
+	hashedPassword, err := s.HashPassword(client.PasswordHash)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+	client.PasswordHash = hashedPassword
+	return nil
 }
 
-// updateSponsorBinaryTree updates the sponsor's binary tree with the new client
-func (s *ClientService) updateSponsorBinaryTree(ctx context.Context, sponsorID primitive.ObjectID, clientID primitive.ObjectID, position string) error {
-	// Verify sponsor exists and check binary tree constraint
-	sponsor, err := s.clientRepo.GetByID(ctx, sponsorID.Hex())
+// isInPlacementDownline indique si member est ancestorID ou se trouve sous lui dans l'arbre de placement
+func (s *ClientService) isInPlacementDownline(ctx context.Context, member *models.Client, ancestorID primitive.ObjectID) (bool, error) {
+	if member.ID == ancestorID {
+		return true, nil
+	}
+	found := false
+	err := s.walkUpline(ctx, member, func(ancestor *models.Client, side string) error {
+		if ancestor.ID == ancestorID {
+			found = true
+			return errStopWalk
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errStopWalk) {
+		return false, fmt.Errorf("échec du parcours de l'arbre de placement: %w", err)
+	}
+	return found, nil
+}
+
+// errStopWalk interrompt un parcours de l'upline dès que le résultat est connu
+var errStopWalk = errors.New("parcours interrompu")
+
+// updateParentBinaryTree updates the placement parent's binary tree with the new client
+func (s *ClientService) updateParentBinaryTree(ctx context.Context, parentID primitive.ObjectID, clientID primitive.ObjectID, position string) error {
+	// Verify parent exists and check binary tree constraint
+	parent, err := s.clientRepo.GetByID(ctx, parentID.Hex())
 	if err != nil {
-		return fmt.Errorf("sponsor introuvable: %w", err)
+		return fmt.Errorf("parent de placement introuvable: %w", err)
 	}
 
-	// Enforce binary tree constraint: a sponsor can only have 2 children
+	// Enforce binary tree constraint: a parent can only have 2 children
+	// The parent keeps its own position: only the child pointer is set
 	if position == "left" {
-		if sponsor.LeftChildID != nil {
-			return errors.New("le sponsor a déjà un enfant à gauche - contrainte d'arbre binaire violée")
+		if parent.LeftChildID != nil {
+			return errors.New("le parent de placement a déjà un enfant à gauche - contrainte d'arbre binaire violée")
 		}
-		return s.clientRepo.UpdateBinaryFields(ctx, sponsorID.Hex(), &clientID, nil, &position)
+		return s.clientRepo.UpdateBinaryFields(ctx, parentID.Hex(), &clientID, nil, nil)
 	} else {
-		if sponsor.RightChildID != nil {
-			return errors.New("le sponsor a déjà un enfant à droite - contrainte d'arbre binaire violée")
+		if parent.RightChildID != nil {
+			return errors.New("le parent de placement a déjà un enfant à droite - contrainte d'arbre binaire violée")
 		}
-		return s.clientRepo.UpdateBinaryFields(ctx, sponsorID.Hex(), nil, &clientID, &position)
+		return s.clientRepo.UpdateBinaryFields(ctx, parentID.Hex(), nil, &clientID, nil)
 	}
 }
 
-// updateNetworkVolumesAndCommissions updates network volumes and calculates binary commissions
-func (s *ClientService) updateNetworkVolumesAndCommissions(ctx context.Context, sponsorID primitive.ObjectID, amount float64, side string) error {
-	// Traverse up the sponsor chain and update volumes
-	currentID := sponsorID
+// EvaluateBinary fait évaluer par le moteur binaire les ancêtres dont le volume confirmé a augmenté.
+// Le moteur écrit dans sa propre transaction: l'appelant l'appelle une fois la sienne validée.
+func (s *ClientService) EvaluateBinary(ctx context.Context, ancestors []*models.Client) {
+	for _, ancestor := range ancestors {
+		s.computeBinaryCommission(ctx, ancestor)
+	}
+}
 
-	for currentID != primitive.NilObjectID {
-		client, err := s.clientRepo.GetByID(ctx, currentID.Hex())
-		if err != nil {
-			break
-		}
+// computeBinaryCommission fait évaluer un ancêtre par le moteur binaire du plan
+// Un échec est journalisé sans bloquer la vente ou le placement qui l'a déclenché
+func (s *ClientService) computeBinaryCommission(ctx context.Context, ancestor *models.Client) {
+	if s.binaryEngine == nil {
+		return
+	}
+	if _, err := s.binaryEngine.ComputeBinaryCommission(ctx, ancestor.ID.Hex()); err != nil {
+		s.logger.Error("Failed to compute binary commission", zap.String("clientID", ancestor.ID.Hex()), zap.Error(err))
+	}
+}
 
-		// Update network volumes
-		if side == "left" {
-			client.NetworkVolumeLeft += amount
-		} else {
-			client.NetworkVolumeRight += amount
-		}
+// Compartiments du volume d'une vente selon son statut
+const (
+	saleVolumeNone      = ""
+	saleVolumePending   = "pending"
+	saleVolumeConfirmed = "confirmed"
+)
 
-		// Update in database
-		err = s.clientRepo.UpdateNetworkVolumes(ctx, currentID.Hex(), client.NetworkVolumeLeft, client.NetworkVolumeRight)
-		if err != nil {
-			s.logger.Error("Failed to update network volumes", zap.Error(err))
-		}
+// saleVolumeBucket retourne le compartiment du volume d'une vente: confirmé une fois payée,
+// en attente tant qu'elle est pending/partial, aucun si elle est annulée
+func saleVolumeBucket(status string) string {
+	switch status {
+	case "paid":
+		return saleVolumeConfirmed
+	case "pending", "partial":
+		return saleVolumePending
+	default:
+		return saleVolumeNone
+	}
+}
 
-		// Check for binary commission eligibility
-		if client.NetworkVolumeLeft >= s.binaryThreshold && client.NetworkVolumeRight >= s.binaryThreshold {
-			err = s.calculateBinaryCommission(ctx, client)
-			if err != nil {
-				s.logger.Error("Failed to calculate binary commission", zap.Error(err))
-			}
-		}
+// ApplySaleVolume enregistre le volume d'une nouvelle vente sur l'acheteur et sur la bonne jambe de chaque ancêtre.
+// Retourne les ancêtres dont le volume confirmé a augmenté, à faire évaluer par EvaluateBinary.
+func (s *ClientService) ApplySaleVolume(ctx context.Context, buyer *models.Client, volume models.Volume, status string) ([]*models.Client, error) {
+	return s.moveSaleVolume(ctx, buyer, volume, saleVolumeNone, saleVolumeBucket(status), nil)
+}
 
-		// Move to parent sponsor
-		if client.SponsorID != nil {
-			currentID = *client.SponsorID
-		} else {
-			break
-		}
-	}
+// TransitionSaleVolume déplace le volume d'une vente lors d'un changement de statut
+// (pending -> paid: confirmé). Retourne les ancêtres à faire évaluer par EvaluateBinary.
+// Le volume confirmé n'est retiré que par WithdrawSaleVolume, qui reprend les cycles payés.
+func (s *ClientService) TransitionSaleVolume(ctx context.Context, buyer *models.Client, volume models.Volume, oldStatus, newStatus string) ([]*models.Client, error) {
+	return s.moveSaleVolume(ctx, buyer, volume, saleVolumeBucket(oldStatus), saleVolumeBucket(newStatus), nil)
+}
 
-	return nil
+// VolumeShortfall est la part du volume retiré d'une jambe qui avait déjà été appariée
+// par des cycles binaires: elle ne figurait plus dans le report de l'ancêtre
+type VolumeShortfall struct {
+	ClientID primitive.ObjectID
+	Side     string
+	Volume   models.Volume
+}
+
+// WithdrawSaleVolume retire le volume d'une vente annulée ou supprimée (statut status avant
+// la reprise) de l'acheteur et de son upline. Le report d'une jambe ne devient jamais négatif:
+// la part déjà appariée est rendue à la jambe opposée et retournée pour reprendre les cycles payés.
+func (s *ClientService) WithdrawSaleVolume(ctx context.Context, buyer *models.Client, volume models.Volume, status string) ([]VolumeShortfall, error) {
+	var shortfalls []VolumeShortfall
+	_, err := s.moveSaleVolume(ctx, buyer, volume, saleVolumeBucket(status), saleVolumeNone, &shortfalls)
+	return shortfalls, err
 }
 
-// calculateBinaryCommission calculates and creates binary commission
-func (s *ClientService) calculateBinaryCommission(ctx context.Context, client *models.Client) error {
-	// Calculate commission amount (minimum of left and right volumes)
-	commissionAmount := client.NetworkVolumeLeft
-	if client.NetworkVolumeRight < client.NetworkVolumeLeft {
-		commissionAmount = client.NetworkVolumeRight
+// moveSaleVolume déplace le volume d'une vente entre deux compartiments et retourne les ancêtres
+// dont le volume confirmé a augmenté. Si shortfalls est fourni, le volume confirmé retiré
+// au-delà du report d'un ancêtre y est enregistré (voir WithdrawSaleVolume)
+func (s *ClientService) moveSaleVolume(ctx context.Context, buyer *models.Client, volume models.Volume, from, to string, shortfalls *[]VolumeShortfall) ([]*models.Client, error) {
+	if from == to {
+		return nil, nil
 	}
-	commissionAmount *= s.binaryCommissionRate
 
-	// Create commission record
-	commission := &models.Commission{
-		ClientID:       client.ID,
-		SourceClientID: client.ID, // Self-commission for binary match
-		Amount:         commissionAmount,
-		Level:          0, // Direct binary commission
-		Type:           "binary-match",
+	// Le volume personnel confirmé change: recalculer l'activité de l'acheteur avant
+	// d'évaluer ses ancêtres, dont la qualification dépend de leurs directs actifs
+	if from == saleVolumeConfirmed || to == saleVolumeConfirmed {
+		s.refreshActivity(ctx, buyer)
+	}
+	if volume <= 0 {
+		return nil, nil
 	}
 
-	_, err := s.commissionRepo.Create(ctx, commission)
-	if err != nil {
-		return err
+	var confirmed, pending models.Volume
+	switch from {
+	case saleVolumeConfirmed:
+		confirmed -= volume
+	case saleVolumePending:
+		pending -= volume
+	}
+	switch to {
+	case saleVolumeConfirmed:
+		confirmed += volume
+	case saleVolumePending:
+		pending += volume
 	}
 
-	// Update client earnings and wallet
-	newTotalEarnings := client.TotalEarnings + commissionAmount
-	newWalletBalance := client.WalletBalance + commissionAmount
-	newBinaryPairs := client.BinaryPairs + 1
+	if err := s.clientRepo.IncrementPoints(ctx, buyer.ID.Hex(), confirmed, pending); err != nil {
+		return nil, fmt.Errorf("échec de la mise à jour des points de %s: %w", buyer.ClientID, err)
+	}
 
-	err = s.clientRepo.UpdateEarnings(ctx, client.ID.Hex(), newTotalEarnings, newWalletBalance)
-	if err != nil {
-		return err
+	if buyer.HoldingTankUntil != nil {
+		// Membre en salle d'attente: son volume est mis en file jusqu'à son placement
+		queued, err := s.clientRepo.IncrementQueuedVolume(ctx, buyer.ID.Hex(), confirmed, pending)
+		if err != nil {
+			return nil, fmt.Errorf("échec de la mise en file du volume de %s: %w", buyer.ClientID, err)
+		}
+		if queued {
+			return nil, nil
+		}
+		// Placé entre-temps: le volume revient à sa nouvelle upline
+		if buyer, err = s.clientRepo.GetByID(ctx, buyer.ID.Hex()); err != nil {
+			return nil, fmt.Errorf("acheteur introuvable: %w", err)
+		}
 	}
 
-	err = s.clientRepo.UpdateBinaryPairs(ctx, client.ID.Hex(), newBinaryPairs)
+	var credited []*models.Client
+	err := s.walkUpline(ctx, buyer, func(ancestor *models.Client, side string) error {
+		if confirmed < 0 && shortfalls != nil {
+			if shortfall := withdrawalShortfall(ancestor, side, -confirmed); shortfall > 0 {
+				*shortfalls = append(*shortfalls, VolumeShortfall{ClientID: ancestor.ID, Side: side, Volume: shortfall})
+				return s.withdrawPairedVolume(ctx, ancestor, side, -confirmed, shortfall, pending)
+			}
+		}
+		if err := s.clientRepo.IncrementLegVolumes(ctx, ancestor.ID.Hex(), side, confirmed, pending); err != nil {
+			return fmt.Errorf("échec de la mise à jour du volume de %s: %w", ancestor.ClientID, err)
+		}
+		// Seul le volume nouvellement confirmé peut déclencher un paiement
+		if confirmed > 0 {
+			credited = append(credited, ancestor)
+		}
+		return nil
+	})
 	if err != nil {
-		return err
+		return nil, err
+	}
+	return credited, nil
+}
+
+// withdrawalShortfall retourne la part de volume retiré de la jambe side qui dépasse son report
+func withdrawalShortfall(ancestor *models.Client, side string, volume models.Volume) models.Volume {
+	carry := ancestor.NetworkVolumeRight
+	if side == "left" {
+		carry = ancestor.NetworkVolumeLeft
 	}
+	return volume - max(carry, 0)
+}
 
-	// Reduce network volumes (consume the matched volume)
-	consumedVolume := commissionAmount / s.binaryCommissionRate
-	client.NetworkVolumeLeft -= consumedVolume
-	client.NetworkVolumeRight -= consumedVolume
+// withdrawPairedVolume vide le report de la jambe side et rend à la jambe opposée le volume
+// qui avait été apparié avec la part déjà consommée par les cycles
+func (s *ClientService) withdrawPairedVolume(ctx context.Context, ancestor *models.Client, side string, volume, shortfall, pending models.Volume) error {
+	other := "left"
+	if side == "left" {
+		other = "right"
+	}
+	if err := s.clientRepo.IncrementLegVolumes(ctx, ancestor.ID.Hex(), side, shortfall-volume, pending); err != nil {
+		return fmt.Errorf("échec de la mise à jour du volume de %s: %w", ancestor.ClientID, err)
+	}
+	if err := s.clientRepo.IncrementLegVolumes(ctx, ancestor.ID.Hex(), other, shortfall, 0); err != nil {
+		return fmt.Errorf("échec de la mise à jour du volume de %s: %w", ancestor.ClientID, err)
+	}
+	return nil
+}
 
-	err = s.clientRepo.UpdateNetworkVolumes(ctx, client.ID.Hex(), client.NetworkVolumeLeft, client.NetworkVolumeRight)
+// refreshActivity recalcule la date de fin d'activité d'un membre et ses compteurs d'actifs dans l'upline
+// Un échec est journalisé sans bloquer la vente qui l'a déclenché
+func (s *ClientService) refreshActivity(ctx context.Context, member *models.Client) {
+	if s.activityService == nil {
+		return
+	}
+	activeUntil, err := s.activityService.Refresh(ctx, member.ID.Hex())
 	if err != nil {
-		return err
+		s.logger.Error("Failed to refresh activity", zap.String("clientID", member.ID.Hex()), zap.Error(err))
+		return
 	}
+	member.ActiveUntil = activeUntil
 
-	s.logger.Info("Binary commission calculated",
-		zap.String("clientID", client.ID.Hex()),
-		zap.Float64("amount", commissionAmount),
-		zap.Int("binaryPairs", newBinaryPairs),
-	)
+	if s.legCountService != nil {
+		if err := s.legCountService.SyncActive(ctx, member); err != nil {
+			s.logger.Error("Failed to update leg counts", zap.String("clientID", member.ID.Hex()), zap.Error(err))
+		}
+	}
+}
 
-	return nil
+// inTransaction exécute fn dans une transaction si le helper est disponible
+func (s *ClientService) inTransaction(ctx context.Context, fn func(context.Context) error) error {
+	if s.txHelper == nil {
+		return fn(ctx)
+	}
+	return s.txHelper.ExecuteTransaction(ctx, fn)
+}
+
+// walkUpline remonte l'arbre de placement depuis member et appelle fn pour chaque ancêtre,
+// avec le côté ("left" ou "right") du sous-arbre qui contient member
+func (s *ClientService) walkUpline(ctx context.Context, member *models.Client, fn func(ancestor *models.Client, side string) error) error {
+	return walkPlacementUpline(ctx, s.clientRepo.GetByID, member, fn)
 }
 
 // generateUniqueClientID generates a unique 8-digit client ID
@@ -353,7 +526,7 @@ func (s *ClientService) HashPassword(password string) (string, error) {
 }
 
 // AddPoints adds points to a client
-func (s *ClientService) AddPoints(ctx context.Context, clientID string, pointsToAdd float64) error {
+func (s *ClientService) AddPoints(ctx context.Context, clientID string, pointsToAdd models.Volume) error {
 	return s.clientRepo.AddPoints(ctx, clientID, pointsToAdd)
 }
 
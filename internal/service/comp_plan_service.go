@@ -0,0 +1,146 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"bureau/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+type compPlanRepository interface {
+	Create(ctx context.Context, plan *models.CompPlanVersion) (*models.CompPlanVersion, error)
+	GetByID(ctx context.Context, id string) (*models.CompPlanVersion, error)
+	GetAll(ctx context.Context, paging *models.PagingInput) ([]*models.CompPlanVersion, error)
+	Activate(ctx context.Context, id primitive.ObjectID, effectiveFrom time.Time, activatedBy *primitive.ObjectID) (*models.CompPlanVersion, error)
+	GetEffective(ctx context.Context, at time.Time) (*models.CompPlanVersion, error)
+}
+
+// CompPlanService gère les versions du plan de rémunération
+// Tant qu'aucune version n'est active, la configuration d'environnement sert de version 0.
+type CompPlanService struct {
+	repo     compPlanRepository
+	logger   *zap.Logger
+	defaults models.BinaryConfig
+}
+
+// NewCompPlanService crée un nouveau service de plan de rémunération
+func NewCompPlanService(repo compPlanRepository, logger *zap.Logger, defaults models.BinaryConfig) *CompPlanService {
+	return &CompPlanService{
+		repo:     repo,
+		logger:   logger,
+		defaults: defaults,
+	}
+}
+
+// Draft enregistre un brouillon de nouvelle version
+func (s *CompPlanService) Draft(ctx context.Context, binary models.BinaryConfig, effectiveFrom *time.Time, notes string, createdBy *primitive.ObjectID) (*models.CompPlanVersion, error) {
+	if err := validateBinaryConfig(binary); err != nil {
+		return nil, err
+	}
+
+	plan, err := s.repo.Create(ctx, &models.CompPlanVersion{
+		Binary:        binary,
+		EffectiveFrom: effectiveFrom,
+		Notes:         notes,
+		CreatedBy:     createdBy,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("échec de l'enregistrement du brouillon: %w", err)
+	}
+
+	s.logger.Info("Comp plan draft created", zap.Int("version", plan.Version))
+	return plan, nil
+}
+
+// Activate rend un brouillon actif
+// La date d'effet est celle fournie, sinon celle du brouillon, sinon maintenant. Elle ne peut pas
+// être dans le passé: les commissions déjà calculées doivent rester expliquées par leur version.
+func (s *CompPlanService) Activate(ctx context.Context, id string, effectiveFrom *time.Time, activatedBy *primitive.ObjectID) (*models.CompPlanVersion, error) {
+	plan, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, errors.New("version du plan introuvable")
+	}
+	if plan.Status != models.CompPlanStatusDraft {
+		return nil, fmt.Errorf("la version %d est déjà active", plan.Version)
+	}
+
+	now := time.Now()
+	from := now
+	if effectiveFrom != nil {
+		from = *effectiveFrom
+	} else if plan.EffectiveFrom != nil {
+		from = *plan.EffectiveFrom
+	}
+	if from.Before(now.Add(-time.Minute)) {
+		return nil, errors.New("la date d'effet ne peut pas être dans le passé")
+	}
+
+	activated, err := s.repo.Activate(ctx, plan.ID, from, activatedBy)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Comp plan activated", zap.Int("version", activated.Version), zap.Time("effectiveFrom", from))
+	return activated, nil
+}
+
+// GetVersion récupère une version
+func (s *CompPlanService) GetVersion(ctx context.Context, id string) (*models.CompPlanVersion, error) {
+	return s.repo.GetByID(ctx, id)
+}
+
+// GetVersions récupère les versions, de la plus récente à la plus ancienne
+func (s *CompPlanService) GetVersions(ctx context.Context, paging *models.PagingInput) ([]*models.CompPlanVersion, error) {
+	return s.repo.GetAll(ctx, paging)
+}
+
+// Effective retourne la version en vigueur à une date
+func (s *CompPlanService) Effective(ctx context.Context, at time.Time) (*models.CompPlanVersion, error) {
+	plan, err := s.repo.GetEffective(ctx, at)
+	if err != nil {
+		return nil, fmt.Errorf("échec du chargement du plan de rémunération: %w", err)
+	}
+	if plan == nil {
+		return &models.CompPlanVersion{
+			Version: 0,
+			Status:  models.CompPlanStatusActive,
+			Binary:  s.defaults,
+		}, nil
+	}
+	return plan, nil
+}
+
+// validateBinaryConfig vérifie la cohérence des règles binaires d'une version
+func validateBinaryConfig(cfg models.BinaryConfig) error {
+	switch cfg.Engine {
+	case models.BinaryEngineCycle, models.BinaryEngineLegacy:
+	default:
+		return fmt.Errorf("moteur binaire inconnu %q (attendu: %s ou %s)", cfg.Engine, models.BinaryEngineCycle, models.BinaryEngineLegacy)
+	}
+	if cfg.CommissionRate < 0 || cfg.CommissionRate > 1 {
+		return errors.New("le taux de commission doit être compris entre 0 et 1")
+	}
+	if cfg.CycleValue < 0 || cfg.MinVolumePerLeg < 0 || cfg.Threshold < 0 {
+		return errors.New("la valeur de cycle, le volume minimum par jambe et le seuil ne peuvent pas être négatifs")
+	}
+	if cfg.DailyCycleLimit < 0 || cfg.WeeklyCycleLimit < 0 {
+		return errors.New("les limites de cycles ne peuvent pas être négatives")
+	}
+	if cfg.WeekStartDay < time.Sunday || cfg.WeekStartDay > time.Saturday {
+		return errors.New("jour de début de semaine invalide")
+	}
+	if cfg.Engine == models.BinaryEngineLegacy && cfg.Threshold <= 0 {
+		return errors.New("le moteur legacy requiert un seuil positif")
+	}
+	for i, rate := range cfg.MatchingBonusRates {
+		if rate < 0 || rate > 1 {
+			return fmt.Errorf("le taux de matching de la génération %d doit être compris entre 0 et 1", i+1)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,159 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"testing"
+	"time"
+
+	"bureau/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+type mockCompPlanRepo struct {
+	plans []*models.CompPlanVersion
+}
+
+func (m *mockCompPlanRepo) Create(ctx context.Context, plan *models.CompPlanVersion) (*models.CompPlanVersion, error) {
+	plan.ID = primitive.NewObjectID()
+	plan.Version = len(m.plans) + 1
+	plan.Status = models.CompPlanStatusDraft
+	plan.CreatedAt = time.Now()
+	m.plans = append(m.plans, plan)
+	return plan, nil
+}
+
+func (m *mockCompPlanRepo) GetByID(ctx context.Context, id string) (*models.CompPlanVersion, error) {
+	for _, plan := range m.plans {
+		if plan.ID.Hex() == id {
+			return plan, nil
+		}
+	}
+	return nil, errors.New("not found")
+}
+
+func (m *mockCompPlanRepo) GetAll(ctx context.Context, paging *models.PagingInput) ([]*models.CompPlanVersion, error) {
+	return m.plans, nil
+}
+
+func (m *mockCompPlanRepo) Activate(ctx context.Context, id primitive.ObjectID, effectiveFrom time.Time, activatedBy *primitive.ObjectID) (*models.CompPlanVersion, error) {
+	for _, plan := range m.plans {
+		if plan.ID == id {
+			now := time.Now()
+			plan.Status = models.CompPlanStatusActive
+			plan.EffectiveFrom = &effectiveFrom
+			plan.ActivatedAt = &now
+			plan.ActivatedBy = activatedBy
+			return plan, nil
+		}
+	}
+	return nil, errors.New("not found")
+}
+
+func (m *mockCompPlanRepo) GetEffective(ctx context.Context, at time.Time) (*models.CompPlanVersion, error) {
+	var candidates []*models.CompPlanVersion
+	for _, plan := range m.plans {
+		if plan.Status == models.CompPlanStatusActive && !plan.EffectiveFrom.After(at) {
+			candidates = append(candidates, plan)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if !candidates[i].EffectiveFrom.Equal(*candidates[j].EffectiveFrom) {
+			return candidates[i].EffectiveFrom.After(*candidates[j].EffectiveFrom)
+		}
+		return candidates[i].Version > candidates[j].Version
+	})
+	return candidates[0], nil
+}
+
+func createTestCompPlanService() (*CompPlanService, *mockCompPlanRepo) {
+	logger, _ := zap.NewDevelopment()
+	repo := &mockCompPlanRepo{}
+	defaults := models.BinaryConfig{Engine: models.BinaryEngineCycle, CommissionRate: 0.10, DailyCycleLimit: 4}
+	return NewCompPlanService(repo, logger, defaults), repo
+}
+
+// Test: sans version active, la configuration d'environnement sert de version 0
+func TestCompPlanService_DefaultsToEnvironmentConfig(t *testing.T) {
+	service, _ := createTestCompPlanService()
+
+	plan, err := service.Effective(context.Background(), time.Now())
+	if err != nil {
+		t.Fatalf("Erreur inattendue: %v", err)
+	}
+	if plan.Version != 0 || plan.Binary.DailyCycleLimit != 4 {
+		t.Errorf("Expected version 0 with env config, got version %d (%+v)", plan.Version, plan.Binary)
+	}
+}
+
+// Test: un brouillon n'est utilisé qu'une fois activé, à partir de sa date d'effet
+func TestCompPlanService_DraftThenActivate(t *testing.T) {
+	service, _ := createTestCompPlanService()
+	ctx := context.Background()
+
+	draft, err := service.Draft(ctx, models.BinaryConfig{Engine: models.BinaryEngineCycle, CycleValue: models.NewMoney(20), DailyCycleLimit: 6}, nil, "cycle à 20$", nil)
+	if err != nil {
+		t.Fatalf("Erreur inattendue: %v", err)
+	}
+	if draft.Version != 1 || draft.Status != models.CompPlanStatusDraft {
+		t.Fatalf("Expected draft version 1, got version %d (%s)", draft.Version, draft.Status)
+	}
+
+	current, _ := service.Effective(ctx, time.Now())
+	if current.Version != 0 {
+		t.Errorf("A draft must not be used, got version %d", current.Version)
+	}
+
+	effectiveFrom := time.Now().Add(24 * time.Hour)
+	if _, err := service.Activate(ctx, draft.ID.Hex(), &effectiveFrom, nil); err != nil {
+		t.Fatalf("Erreur inattendue: %v", err)
+	}
+
+	current, _ = service.Effective(ctx, time.Now())
+	if current.Version != 0 {
+		t.Errorf("Plan must not apply before its effective date, got version %d", current.Version)
+	}
+	current, _ = service.Effective(ctx, effectiveFrom.Add(time.Minute))
+	if current.Version != 1 || current.Binary.CycleValue != models.NewMoney(20) {
+		t.Errorf("Expected version 1 after its effective date, got version %d", current.Version)
+	}
+
+	// Une version active ne peut pas être réactivée
+	if _, err := service.Activate(ctx, draft.ID.Hex(), nil, nil); err == nil {
+		t.Error("Expected an error when activating an active version")
+	}
+}
+
+// Test: les règles invalides et les dates d'effet passées sont refusées
+func TestCompPlanService_Validation(t *testing.T) {
+	service, _ := createTestCompPlanService()
+	ctx := context.Background()
+
+	invalid := []models.BinaryConfig{
+		{Engine: "unknown"},
+		{Engine: models.BinaryEngineCycle, CommissionRate: 1.5},
+		{Engine: models.BinaryEngineCycle, DailyCycleLimit: -1},
+		{Engine: models.BinaryEngineLegacy, Threshold: 0},
+		{Engine: models.BinaryEngineCycle, MatchingBonusRates: []float64{0.1, -0.05}},
+	}
+	for _, cfg := range invalid {
+		if _, err := service.Draft(ctx, cfg, nil, "", nil); err == nil {
+			t.Errorf("Expected validation error for %+v", cfg)
+		}
+	}
+
+	draft, err := service.Draft(ctx, models.BinaryConfig{Engine: models.BinaryEngineCycle}, nil, "", nil)
+	if err != nil {
+		t.Fatalf("Erreur inattendue: %v", err)
+	}
+	past := time.Now().Add(-time.Hour)
+	if _, err := service.Activate(ctx, draft.ID.Hex(), &past, nil); err == nil {
+		t.Error("Expected an error for an effective date in the past")
+	}
+}
@@ -0,0 +1,123 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"bureau/internal/models"
+
+	"go.uber.org/zap"
+)
+
+type fastStartClientRepository interface {
+	GetByID(ctx context.Context, id string) (*models.Client, error)
+	MarkFastStartBonusPaid(ctx context.Context, id string, paidAt time.Time) (bool, error)
+}
+
+type fastStartSaleRepository interface {
+	GetByClientID(ctx context.Context, clientID string) ([]*models.Sale, error)
+}
+
+// FastStartBonusService verse au parrain un bonus de démarrage rapide (commission "direct")
+// lorsque un membre qu'il a inscrit effectue son premier achat payé
+type FastStartBonusService struct {
+	clientRepo     fastStartClientRepository
+	saleRepo       fastStartSaleRepository
+	commissionRepo commissionRepository
+	wallet         walletLedger
+	rule           models.FastStartBonusRule
+	logger         *zap.Logger
+	now            func() time.Time
+}
+
+// NewFastStartBonusService crée un nouveau service de bonus de démarrage rapide
+func NewFastStartBonusService(clientRepo fastStartClientRepository, saleRepo fastStartSaleRepository, commissionRepo commissionRepository, wallet walletLedger, rule models.FastStartBonusRule, logger *zap.Logger) *FastStartBonusService {
+	return &FastStartBonusService{
+		clientRepo:     clientRepo,
+		saleRepo:       saleRepo,
+		commissionRepo: commissionRepo,
+		wallet:         wallet,
+		rule:           rule,
+		logger:         logger,
+		now:            time.Now,
+	}
+}
+
+// OnSalePaid verse le bonus si sale est le premier achat payé de l'acheteur et retourne la
+// commission créée (nil si rien n'est dû). Le versement est unique par membre: les écritures
+// partagent le contexte, et donc la transaction, de l'enregistrement de la vente.
+func (s *FastStartBonusService) OnSalePaid(ctx context.Context, sale *models.Sale) (*models.Commission, error) {
+	if sale.Status != "paid" || !s.rule.Enabled() {
+		return nil, nil
+	}
+
+	buyer, err := s.clientRepo.GetByID(ctx, sale.ClientID.Hex())
+	if err != nil {
+		return nil, fmt.Errorf("acheteur introuvable: %w", err)
+	}
+	if buyer.SponsorID == nil || buyer.FastStartBonusPaidAt != nil {
+		return nil, nil
+	}
+
+	// Seul le premier achat payé compte: un membre déjà acheteur avant l'activation
+	// de la règle ne la déclenche pas
+	sales, err := s.saleRepo.GetByClientID(ctx, buyer.ID.Hex())
+	if err != nil {
+		return nil, fmt.Errorf("échec de la lecture des ventes de l'acheteur: %w", err)
+	}
+	for _, other := range sales {
+		if other.ID != sale.ID && other.Status == "paid" {
+			return nil, nil
+		}
+	}
+
+	amount := s.bonusAmount(sale)
+	if amount <= 0 {
+		return nil, nil
+	}
+
+	now := s.now()
+	claimed, err := s.clientRepo.MarkFastStartBonusPaid(ctx, buyer.ID.Hex(), now)
+	if err != nil {
+		return nil, fmt.Errorf("échec de l'enregistrement du bonus de démarrage rapide: %w", err)
+	}
+	if !claimed {
+		return nil, nil // Déjà versé par un appel concurrent
+	}
+
+	sponsor, err := s.clientRepo.GetByID(ctx, buyer.SponsorID.Hex())
+	if err != nil {
+		return nil, fmt.Errorf("parrain introuvable: %w", err)
+	}
+	commission, err := s.commissionRepo.Create(ctx, &models.Commission{
+		ClientID:       sponsor.ID,
+		SourceClientID: buyer.ID,
+		Amount:         amount,
+		Level:          1,
+		Type:           "direct",
+		Date:           now,
+		SaleID:         &sale.ID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("échec de la création de la commission directe: %w", err)
+	}
+	if _, err := s.wallet.Post(ctx, commissionEntry(commission)); err != nil {
+		return nil, fmt.Errorf("échec du crédit du portefeuille du parrain: %w", err)
+	}
+
+	s.logger.Info("Fast start bonus paid",
+		zap.String("sponsorID", sponsor.ID.Hex()),
+		zap.String("clientID", buyer.ID.Hex()),
+		zap.String("saleID", sale.ID.Hex()),
+		zap.Float64("amount", amount.Float64()))
+	return commission, nil
+}
+
+// bonusAmount calcule le bonus: montant fixe s'il est défini, sinon pourcentage de la vente
+func (s *FastStartBonusService) bonusAmount(sale *models.Sale) models.Money {
+	if s.rule.Amount > 0 {
+		return s.rule.Amount
+	}
+	return sale.Amount.Mul(s.rule.Rate)
+}
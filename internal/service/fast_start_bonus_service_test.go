@@ -0,0 +1,120 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"bureau/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+type mockFastStartClientRepo struct {
+	clients map[string]*models.Client
+}
+
+func (m *mockFastStartClientRepo) GetByID(ctx context.Context, id string) (*models.Client, error) {
+	client, ok := m.clients[id]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	copied := *client
+	return &copied, nil
+}
+
+func (m *mockFastStartClientRepo) MarkFastStartBonusPaid(ctx context.Context, id string, paidAt time.Time) (bool, error) {
+	client := m.clients[id]
+	if client.FastStartBonusPaidAt != nil {
+		return false, nil
+	}
+	client.FastStartBonusPaidAt = &paidAt
+	return true, nil
+}
+
+func createTestFastStartService(rule models.FastStartBonusRule) (*FastStartBonusService, *mockFastStartClientRepo, *mockSaleRepo, *mockCommissionRepo) {
+	clientRepo := &mockFastStartClientRepo{clients: make(map[string]*models.Client)}
+	saleRepo := &mockSaleRepo{sales: make(map[string][]*models.Sale)}
+	commissionRepo := &mockCommissionRepo{}
+	return NewFastStartBonusService(clientRepo, saleRepo, commissionRepo, newMockWalletRepo(clientRepo.clients), rule, zap.NewNop()), clientRepo, saleRepo, commissionRepo
+}
+
+// recordSale ajoute la vente aux ventes de l'acheteur, comme l'insertion dans la transaction
+func recordSale(saleRepo *mockSaleRepo, buyer *models.Client, amount models.Money, status string) *models.Sale {
+	sale := &models.Sale{ID: primitive.NewObjectID(), ClientID: buyer.ID, Amount: amount, Status: status}
+	saleRepo.sales[buyer.ID.Hex()] = append(saleRepo.sales[buyer.ID.Hex()], sale)
+	return sale
+}
+
+func TestFastStartBonus_PaidOnceOnFirstPaidPurchase(t *testing.T) {
+	ctx := context.Background()
+	service, clientRepo, saleRepo, commissionRepo := createTestFastStartService(models.FastStartBonusRule{Rate: 0.20})
+
+	sponsor := &models.Client{ID: primitive.NewObjectID(), TotalEarnings: models.NewMoney(5), WalletBalance: models.NewMoney(5)}
+	enrollee := &models.Client{ID: primitive.NewObjectID(), SponsorID: &sponsor.ID}
+	clientRepo.clients[sponsor.ID.Hex()] = sponsor
+	clientRepo.clients[enrollee.ID.Hex()] = enrollee
+
+	// Une vente en attente ne déclenche rien
+	pending := recordSale(saleRepo, enrollee, models.NewMoney(100), "pending")
+	if commission, err := service.OnSalePaid(ctx, pending); err != nil || commission != nil {
+		t.Fatalf("Expected no bonus for a pending sale, got %+v (%v)", commission, err)
+	}
+
+	// Elle passe à payée: premier achat payé
+	pending.Status = "paid"
+	commission, err := service.OnSalePaid(ctx, pending)
+	if err != nil {
+		t.Fatalf("Erreur inattendue: %v", err)
+	}
+	if commission == nil || commission.Type != "direct" || commission.Amount != models.NewMoney(20) ||
+		commission.ClientID != sponsor.ID || commission.SourceClientID != enrollee.ID {
+		t.Fatalf("Unexpected fast start commission: %+v", commission)
+	}
+	if sponsor.TotalEarnings != models.NewMoney(25) || sponsor.WalletBalance != models.NewMoney(25) {
+		t.Errorf("Expected sponsor earnings 25, got %s/%s", sponsor.TotalEarnings, sponsor.WalletBalance)
+	}
+
+	// Rejouer la même vente ou une vente suivante ne paie plus rien
+	second := recordSale(saleRepo, enrollee, models.NewMoney(300), "paid")
+	for _, sale := range []*models.Sale{pending, second} {
+		if commission, err := service.OnSalePaid(ctx, sale); err != nil || commission != nil {
+			t.Errorf("Expected the bonus to be paid only once, got %+v (%v)", commission, err)
+		}
+	}
+	if len(commissionRepo.commissions) != 1 {
+		t.Errorf("Expected 1 commission, got %d", len(commissionRepo.commissions))
+	}
+}
+
+func TestFastStartBonus_FixedAmountAndPriorPurchases(t *testing.T) {
+	ctx := context.Background()
+	service, clientRepo, saleRepo, _ := createTestFastStartService(models.FastStartBonusRule{Rate: 0.20, Amount: models.NewMoney(15)})
+
+	sponsor := &models.Client{ID: primitive.NewObjectID()}
+	newcomer := &models.Client{ID: primitive.NewObjectID(), SponsorID: &sponsor.ID}
+	veteran := &models.Client{ID: primitive.NewObjectID(), SponsorID: &sponsor.ID}
+	root := &models.Client{ID: primitive.NewObjectID()}
+	for _, client := range []*models.Client{sponsor, newcomer, veteran, root} {
+		clientRepo.clients[client.ID.Hex()] = client
+	}
+
+	// Le montant fixe est prioritaire sur le pourcentage
+	commission, err := service.OnSalePaid(ctx, recordSale(saleRepo, newcomer, models.NewMoney(500), "paid"))
+	if err != nil || commission == nil || commission.Amount != models.NewMoney(15) {
+		t.Fatalf("Expected a fixed bonus of 15, got %+v (%v)", commission, err)
+	}
+
+	// Un membre qui avait déjà acheté avant l'activation de la règle ne déclenche rien
+	recordSale(saleRepo, veteran, models.NewMoney(100), "paid")
+	if commission, err := service.OnSalePaid(ctx, recordSale(saleRepo, veteran, models.NewMoney(100), "paid")); err != nil || commission != nil {
+		t.Errorf("Expected no bonus for a member with a prior purchase, got %+v (%v)", commission, err)
+	}
+
+	// Un membre sans parrain ne déclenche rien
+	if commission, err := service.OnSalePaid(ctx, recordSale(saleRepo, root, models.NewMoney(100), "paid")); err != nil || commission != nil {
+		t.Errorf("Expected no bonus without a sponsor, got %+v (%v)", commission, err)
+	}
+}
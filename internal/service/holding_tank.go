@@ -0,0 +1,221 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"bureau/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+// CreateInHoldingTank inscrit un membre sous son parrain sans le placer dans l'arbre binaire.
+// Le parrain (ou un admin) le place avec PlaceFromHoldingTank pendant le délai de la règle;
+// à l'échéance, AutoPlaceExpired le place sous le parrain selon la stratégie de la règle.
+// Le volume de ses achats est mis en file et crédité à l'upline au placement.
+func (s *ClientService) CreateInHoldingTank(ctx context.Context, client *models.Client, sponsorID primitive.ObjectID) (*models.Client, error) {
+	sponsor, err := s.clientRepo.GetByID(ctx, sponsorID.Hex())
+	if err != nil {
+		return nil, errors.New("sponsor introuvable")
+	}
+	if err := s.prepareNewClient(ctx, client); err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().AddDate(0, 0, s.holdingTank.Days)
+	client.SponsorID = &sponsor.ID
+	client.PlacementParentID = nil
+	client.Position = nil
+	client.LeftChildID = nil
+	client.RightChildID = nil
+	client.NetworkVolumeLeft = 0
+	client.NetworkVolumeRight = 0
+	client.BinaryPairs = 0
+	client.TotalEarnings = 0
+	client.WalletBalance = 0
+	client.Points = 0
+	client.HoldingTankUntil = &deadline
+	client.QueuedVolume = 0
+	client.QueuedPendingVolume = 0
+
+	createdClient, err := s.clientRepo.Create(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Client enrolled in holding tank",
+		zap.String("clientID", createdClient.ID.Hex()),
+		zap.String("sponsorID", sponsor.ID.Hex()),
+		zap.Time("placementDeadline", deadline))
+	return createdClient, nil
+}
+
+// PlaceFromHoldingTank place un membre de la salle d'attente sous parentID, à la position donnée.
+// Si sponsorID est renseigné (appel par un membre), il doit être le parrain du membre et le
+// délai de placement ne doit pas être échu; un admin (sponsorID nil) peut placer à tout moment.
+func (s *ClientService) PlaceFromHoldingTank(ctx context.Context, memberID, parentID, position string, sponsorID *primitive.ObjectID) (*models.Client, error) {
+	if position != "left" && position != "right" {
+		return nil, errors.New("la position doit être 'left' ou 'right'")
+	}
+
+	member, err := s.clientRepo.GetByID(ctx, memberID)
+	if err != nil {
+		return nil, errors.New("membre introuvable")
+	}
+	if member.HoldingTankUntil == nil {
+		return nil, errors.New("ce membre n'est pas en salle d'attente")
+	}
+	if sponsorID != nil {
+		if member.SponsorID == nil || *member.SponsorID != *sponsorID {
+			return nil, errors.New("seul le parrain du membre peut le placer")
+		}
+		if time.Now().After(*member.HoldingTankUntil) {
+			return nil, errors.New("le délai de placement est dépassé: le membre sera placé automatiquement")
+		}
+	}
+
+	parent, err := s.clientRepo.GetByID(ctx, parentID)
+	if err != nil {
+		return nil, errors.New("parent de placement introuvable")
+	}
+	return s.placeHeldMember(ctx, member, parent, position)
+}
+
+// AutoPlaceExpired place sous leur parrain, selon la stratégie de la règle, les membres dont le
+// délai de placement est échu et retourne leur nombre. Un membre dont le placement échoue
+// (parrain lui-même en attente, par exemple) reste en salle d'attente jusqu'au passage suivant.
+func (s *ClientService) AutoPlaceExpired(ctx context.Context) (int, error) {
+	members, err := s.clientRepo.GetExpiredHoldingTank(ctx, time.Now())
+	if err != nil {
+		return 0, fmt.Errorf("échec de la lecture de la salle d'attente: %w", err)
+	}
+
+	placed := 0
+	for _, member := range members {
+		if err := ctx.Err(); err != nil {
+			return placed, err
+		}
+		if err := s.autoPlace(ctx, member); err != nil {
+			s.logger.Error("Failed to auto-place holding tank member", zap.String("clientID", member.ID.Hex()), zap.Error(err))
+			continue
+		}
+		placed++
+	}
+
+	s.logger.Info("Holding tank auto-placement done", zap.Int("expired", len(members)), zap.Int("placed", placed))
+	return placed, nil
+}
+
+func (s *ClientService) autoPlace(ctx context.Context, member *models.Client) error {
+	if member.SponsorID == nil {
+		return errors.New("membre en salle d'attente sans parrain")
+	}
+	sponsor, err := s.clientRepo.GetByID(ctx, member.SponsorID.Hex())
+	if err != nil {
+		return fmt.Errorf("parrain introuvable: %w", err)
+	}
+	if sponsor.HoldingTankUntil != nil {
+		return errors.New("le parrain est lui-même en salle d'attente")
+	}
+
+	parent, position, err := findPlacement(ctx, s.clientRepo, sponsor, s.holdingTank.Strategy)
+	if err != nil {
+		return err
+	}
+	_, err = s.placeHeldMember(ctx, member, parent, position)
+	return err
+}
+
+// placeHeldMember sort un membre de la salle d'attente: il est rattaché à parent, compté dans
+// les jambes de sa nouvelle upline, qui reçoit le volume mis en file. Ces écritures partagent
+// une transaction: un placement qui échoue laisse le membre en salle d'attente.
+func (s *ClientService) placeHeldMember(ctx context.Context, member, parent *models.Client, position string) (*models.Client, error) {
+	if member.SponsorID == nil {
+		return nil, errors.New("membre en salle d'attente sans parrain")
+	}
+	if parent.HoldingTankUntil != nil {
+		return nil, errors.New("le parent de placement est en salle d'attente: il doit d'abord être placé")
+	}
+	inDownline, err := s.isInPlacementDownline(ctx, parent, *member.SponsorID)
+	if err != nil {
+		return nil, err
+	}
+	if !inDownline {
+		return nil, errors.New("le parent de placement doit faire partie de l'arbre de placement du sponsor")
+	}
+	if (position == "left" && parent.LeftChildID != nil) || (position == "right" && parent.RightChildID != nil) {
+		return nil, errors.New("cette position est déjà prise sur ce parent de placement. Veuillez choisir une autre position ou un autre parent de placement")
+	}
+
+	var placed, released *models.Client
+	var credited []*models.Client
+	err = s.inTransaction(ctx, func(txCtx context.Context) error {
+		var err error
+		released, err = s.clientRepo.ReleaseFromHoldingTank(txCtx, member.ID.Hex(), parent.ID, position)
+		if err != nil {
+			return fmt.Errorf("échec du placement du membre: %w", err)
+		}
+		if released == nil {
+			return errors.New("ce membre n'est plus en salle d'attente")
+		}
+
+		placed = new(models.Client)
+		*placed = *released
+		placed.PlacementParentID = &parent.ID
+		placed.Position = &position
+		placed.HoldingTankUntil = nil
+		placed.QueuedVolume = 0
+		placed.QueuedPendingVolume = 0
+
+		if err := s.updateParentBinaryTree(txCtx, parent.ID, placed.ID, position); err != nil {
+			return fmt.Errorf("échec du rattachement au parent de placement: %w", err)
+		}
+		if s.legCountService != nil {
+			if err := s.legCountService.OnPlacement(txCtx, placed); err != nil {
+				return fmt.Errorf("échec de la mise à jour des compteurs de jambes: %w", err)
+			}
+		}
+
+		credited, err = s.creditQueuedVolume(txCtx, placed, released.QueuedVolume, released.QueuedPendingVolume)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Le moteur binaire écrit dans sa propre transaction, après celle du placement
+	s.EvaluateBinary(ctx, credited)
+
+	s.logger.Info("Holding tank member placed",
+		zap.String("clientID", placed.ID.Hex()),
+		zap.String("parentID", parent.ID.Hex()),
+		zap.String("position", position),
+		zap.Float64("queuedVolume", released.QueuedVolume.Float64()),
+		zap.Float64("queuedPendingVolume", released.QueuedPendingVolume.Float64()))
+	return placed, nil
+}
+
+// creditQueuedVolume crédite à la bonne jambe de chaque ancêtre le volume mis en file pendant la
+// salle d'attente et retourne les ancêtres dont le volume confirmé a augmenté (voir EvaluateBinary)
+func (s *ClientService) creditQueuedVolume(ctx context.Context, member *models.Client, confirmed, pending models.Volume) ([]*models.Client, error) {
+	if confirmed == 0 && pending == 0 {
+		return nil, nil
+	}
+	var credited []*models.Client
+	err := s.walkUpline(ctx, member, func(ancestor *models.Client, side string) error {
+		if err := s.clientRepo.IncrementLegVolumes(ctx, ancestor.ID.Hex(), side, confirmed, pending); err != nil {
+			return fmt.Errorf("échec de la mise à jour du volume de %s: %w", ancestor.ClientID, err)
+		}
+		if confirmed > 0 {
+			credited = append(credited, ancestor)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return credited, nil
+}
@@ -0,0 +1,270 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"bureau/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+type legCountRepository interface {
+	GetByID(ctx context.Context, id string) (*models.Client, error)
+	IncrementLegCounts(ctx context.Context, id string, side string, members, actives int) error
+	SetCountedActive(ctx context.Context, id string, active bool) (bool, error)
+	GetExpiredCountedActives(ctx context.Context, now time.Time) ([]*models.Client, error)
+	GetPlacementTree(ctx context.Context) ([]*models.Client, error)
+	ApplyLegCountDeltas(ctx context.Context, deltas []models.LegCountDelta) error
+}
+
+// LegCountService tient à jour les compteurs de membres et d'actifs de chaque jambe
+// (LeftMembers, RightMembers, LeftActives, RightActives) stockés sur les clients.
+// Chaque événement ne touche que l'upline du membre concerné; Rebuild recalcule tout l'arbre.
+type LegCountService struct {
+	clientRepo legCountRepository
+	logger     *zap.Logger
+	now        func() time.Time
+}
+
+// NewLegCountService crée un nouveau service de compteurs de jambes
+func NewLegCountService(clientRepo legCountRepository, logger *zap.Logger) *LegCountService {
+	return &LegCountService{
+		clientRepo: clientRepo,
+		logger:     logger,
+		now:        time.Now,
+	}
+}
+
+// SetClock remplace l'horloge utilisée pour évaluer l'activité (simulation)
+func (s *LegCountService) SetClock(now func() time.Time) {
+	s.now = now
+}
+
+// OnEnrollment compte un nouveau membre dans la jambe de chacun de ses ancêtres
+// Un nouveau membre n'a pas encore de vente payée: il n'est pas compté comme actif
+func (s *LegCountService) OnEnrollment(ctx context.Context, member *models.Client) error {
+	return s.incrementUpline(ctx, member, 1, 0)
+}
+
+// OnPlacement compte dans l'upline un membre placé à sa sortie de la salle d'attente,
+// avec l'activité qu'il a pu acquérir entre-temps
+func (s *LegCountService) OnPlacement(ctx context.Context, member *models.Client) error {
+	actives := 0
+	if member.CountedActive {
+		actives = 1
+	}
+	return s.incrementUpline(ctx, member, 1, actives)
+}
+
+// SyncActive répercute sur l'upline un changement d'activité du membre: il est ajouté aux
+// actifs de ses ancêtres lorsqu'il devient actif et retiré lorsque sa période est échue
+func (s *LegCountService) SyncActive(ctx context.Context, member *models.Client) error {
+	active := IsActiveAt(member, s.now())
+	changed, err := s.clientRepo.SetCountedActive(ctx, member.ID.Hex(), active)
+	if err != nil {
+		return fmt.Errorf("échec de la mise à jour de l'activité comptée: %w", err)
+	}
+	member.CountedActive = active
+	if !changed {
+		return nil
+	}
+
+	delta := 1
+	if !active {
+		delta = -1
+	}
+	return s.incrementUpline(ctx, member, 0, delta)
+}
+
+// SyncExpired retire des compteurs les membres dont la période d'activité est échue
+// depuis leur dernière vente et retourne leur nombre
+func (s *LegCountService) SyncExpired(ctx context.Context) (int, error) {
+	expired, err := s.clientRepo.GetExpiredCountedActives(ctx, s.now())
+	if err != nil {
+		return 0, fmt.Errorf("échec de la lecture des membres expirés: %w", err)
+	}
+
+	for _, member := range expired {
+		if err := ctx.Err(); err != nil {
+			return 0, err
+		}
+		if err := s.SyncActive(ctx, member); err != nil {
+			s.logger.Error("Failed to sync expired member", zap.String("clientID", member.ID.Hex()), zap.Error(err))
+		}
+	}
+	return len(expired), nil
+}
+
+// OnDelete retire de l'upline un membre supprimé avec tout son sous-arbre, qui n'est plus
+// rattaché à l'arbre. À appeler avant la suppression, avec le membre tel qu'enregistré.
+func (s *LegCountService) OnDelete(ctx context.Context, member *models.Client) error {
+	members := 1 + member.LeftMembers + member.RightMembers
+	actives := member.LeftActives + member.RightActives
+	if member.CountedActive {
+		actives++
+	}
+	return s.incrementUpline(ctx, member, -members, -actives)
+}
+
+func (s *LegCountService) incrementUpline(ctx context.Context, member *models.Client, members, actives int) error {
+	return walkPlacementUpline(ctx, s.clientRepo.GetByID, member, func(ancestor *models.Client, side string) error {
+		if err := s.clientRepo.IncrementLegCounts(ctx, ancestor.ID.Hex(), side, members, actives); err != nil {
+			return fmt.Errorf("échec de la mise à jour des compteurs de %s: %w", ancestor.ClientID, err)
+		}
+		return nil
+	})
+}
+
+// Rebuild recalcule les compteurs de tous les membres à partir de l'arbre de placement
+// et de leur activité, et retourne le nombre de membres dont les compteurs ont été corrigés.
+// Les membres dont l'activité comptée n'est plus à jour sont d'abord basculés comme par
+// SyncActive; les compteurs reçoivent ensuite l'écart entre les totaux recalculés et ceux
+// lus dans l'arbre, par incrément: les événements enregistrés pendant le recalcul ne sont
+// pas écrasés.
+func (s *LegCountService) Rebuild(ctx context.Context) (int, error) {
+	clients, err := s.clientRepo.GetPlacementTree(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("échec de la lecture de l'arbre: %w", err)
+	}
+
+	corrected := make(map[primitive.ObjectID]bool)
+	now := s.now()
+	for _, client := range clients {
+		if err := ctx.Err(); err != nil {
+			return 0, err
+		}
+		if client.CountedActive == IsActiveAt(client, now) {
+			continue
+		}
+		if err := s.SyncActive(ctx, client); err != nil {
+			return 0, err
+		}
+		corrected[client.ID] = true
+	}
+	if len(corrected) > 0 {
+		// Les bascules ont modifié les compteurs de l'upline: l'écart se calcule sur une nouvelle lecture
+		if clients, err = s.clientRepo.GetPlacementTree(ctx); err != nil {
+			return 0, fmt.Errorf("échec de la lecture de l'arbre: %w", err)
+		}
+	}
+
+	deltas := legCountDeltas(clients)
+	if err := s.clientRepo.ApplyLegCountDeltas(ctx, deltas); err != nil {
+		return 0, fmt.Errorf("échec de l'enregistrement des compteurs: %w", err)
+	}
+	for _, delta := range deltas {
+		corrected[delta.ClientID] = true
+	}
+
+	s.logger.Info("Leg counts rebuilt", zap.Int("members", len(clients)), zap.Int("corrected", len(corrected)))
+	return len(corrected), nil
+}
+
+// legCountDeltas calcule en mémoire les compteurs de jambes de chaque client (parcours
+// postfixe des liens enfants, en comptant les membres comptés actifs) et retourne l'écart
+// avec les compteurs lus, pour les clients dont les compteurs diffèrent
+func legCountDeltas(clients []*models.Client) []models.LegCountDelta {
+	type subtree struct {
+		members int
+		actives int
+	}
+
+	byID := make(map[primitive.ObjectID]*models.Client, len(clients))
+	for _, client := range clients {
+		byID[client.ID] = client
+	}
+	child := func(id *primitive.ObjectID) *models.Client {
+		if id == nil {
+			return nil
+		}
+		return byID[*id]
+	}
+
+	const (
+		pending = iota
+		visiting
+		done
+	)
+	state := make(map[primitive.ObjectID]int, len(clients))
+	totals := make(map[primitive.ObjectID]subtree, len(clients))
+	var deltas []models.LegCountDelta
+
+	for _, start := range clients {
+		stack := []*models.Client{start}
+		for len(stack) > 0 {
+			node := stack[len(stack)-1]
+			switch state[node.ID] {
+			case pending:
+				// Traiter les enfants avant le nœud; un lien qui reboucle est ignoré
+				state[node.ID] = visiting
+				for _, c := range []*models.Client{child(node.LeftChildID), child(node.RightChildID)} {
+					if c != nil && state[c.ID] == pending {
+						stack = append(stack, c)
+					}
+				}
+				continue
+			case done:
+				stack = stack[:len(stack)-1]
+				continue
+			}
+			stack = stack[:len(stack)-1]
+			state[node.ID] = done
+
+			var left, right subtree
+			if c := child(node.LeftChildID); c != nil {
+				left = totals[c.ID]
+			}
+			if c := child(node.RightChildID); c != nil {
+				right = totals[c.ID]
+			}
+
+			total := subtree{members: 1 + left.members + right.members, actives: left.actives + right.actives}
+			if node.CountedActive {
+				total.actives++
+			}
+			totals[node.ID] = total
+
+			delta := models.LegCountDelta{
+				ClientID:     node.ID,
+				LeftMembers:  left.members - node.LeftMembers,
+				RightMembers: right.members - node.RightMembers,
+				LeftActives:  left.actives - node.LeftActives,
+				RightActives: right.actives - node.RightActives,
+			}
+			if delta != (models.LegCountDelta{ClientID: node.ID}) {
+				deltas = append(deltas, delta)
+			}
+		}
+	}
+	return deltas
+}
+
+// walkPlacementUpline remonte l'arbre de placement depuis member et appelle fn pour chaque
+// ancêtre, avec le côté ("left" ou "right") du sous-arbre qui contient member
+func walkPlacementUpline(ctx context.Context, getByID func(ctx context.Context, id string) (*models.Client, error), member *models.Client, fn func(ancestor *models.Client, side string) error) error {
+	visited := map[primitive.ObjectID]bool{member.ID: true}
+	current := member
+
+	for current.PlacementParentID != nil && current.Position != nil {
+		if visited[*current.PlacementParentID] {
+			return fmt.Errorf("cycle détecté dans l'arbre de placement au niveau de %s", current.PlacementParentID.Hex())
+		}
+		visited[*current.PlacementParentID] = true
+
+		ancestor, err := getByID(ctx, current.PlacementParentID.Hex())
+		if err != nil {
+			return fmt.Errorf("ancêtre introuvable %s: %w", current.PlacementParentID.Hex(), err)
+		}
+
+		if err := fn(ancestor, *current.Position); err != nil {
+			return err
+		}
+
+		current = ancestor
+	}
+
+	return nil
+}
@@ -0,0 +1,258 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"bureau/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+type mockLegCountRepo struct {
+	clients map[string]*models.Client
+	// afterTreeRead simule une écriture concurrente juste après la lecture de l'arbre
+	afterTreeRead func()
+}
+
+func (m *mockLegCountRepo) GetByID(ctx context.Context, id string) (*models.Client, error) {
+	client, ok := m.clients[id]
+	if !ok {
+		return nil, fmt.Errorf("client not found")
+	}
+	copied := *client
+	return &copied, nil
+}
+
+func (m *mockLegCountRepo) IncrementLegCounts(ctx context.Context, id string, side string, members, actives int) error {
+	client := m.clients[id]
+	if side == "left" {
+		client.LeftMembers += members
+		client.LeftActives += actives
+	} else {
+		client.RightMembers += members
+		client.RightActives += actives
+	}
+	return nil
+}
+
+func (m *mockLegCountRepo) SetCountedActive(ctx context.Context, id string, active bool) (bool, error) {
+	client := m.clients[id]
+	changed := client.CountedActive != active
+	client.CountedActive = active
+	return changed, nil
+}
+
+func (m *mockLegCountRepo) GetExpiredCountedActives(ctx context.Context, now time.Time) ([]*models.Client, error) {
+	var expired []*models.Client
+	for _, client := range m.clients {
+		if client.CountedActive && !IsActiveAt(client, now) {
+			copied := *client
+			expired = append(expired, &copied)
+		}
+	}
+	return expired, nil
+}
+
+func (m *mockLegCountRepo) GetPlacementTree(ctx context.Context) ([]*models.Client, error) {
+	var clients []*models.Client
+	for _, client := range m.clients {
+		copied := *client
+		clients = append(clients, &copied)
+	}
+	if m.afterTreeRead != nil {
+		m.afterTreeRead()
+		m.afterTreeRead = nil
+	}
+	return clients, nil
+}
+
+func (m *mockLegCountRepo) ApplyLegCountDeltas(ctx context.Context, deltas []models.LegCountDelta) error {
+	for _, delta := range deltas {
+		client := m.clients[delta.ClientID.Hex()]
+		client.LeftMembers += delta.LeftMembers
+		client.RightMembers += delta.RightMembers
+		client.LeftActives += delta.LeftActives
+		client.RightActives += delta.RightActives
+	}
+	return nil
+}
+
+// enroll place un nouveau membre sous parent, comme CreateWithBinaryPlacement
+func (m *mockLegCountRepo) enroll(parent *models.Client, position string) *models.Client {
+	member := &models.Client{ID: primitive.NewObjectID()}
+	if parent != nil {
+		member.SponsorID = &parent.ID
+		member.PlacementParentID = &parent.ID
+		member.Position = &position
+		if position == "left" {
+			parent.LeftChildID = &member.ID
+		} else {
+			parent.RightChildID = &member.ID
+		}
+	}
+	m.clients[member.ID.Hex()] = member
+	return member
+}
+
+func assertLegCounts(t *testing.T, name string, client *models.Client, leftMembers, rightMembers, leftActives, rightActives int) {
+	t.Helper()
+	if client.LeftMembers != leftMembers || client.RightMembers != rightMembers ||
+		client.LeftActives != leftActives || client.RightActives != rightActives {
+		t.Errorf("%s: expected members %d/%d actives %d/%d, got members %d/%d actives %d/%d", name,
+			leftMembers, rightMembers, leftActives, rightActives,
+			client.LeftMembers, client.RightMembers, client.LeftActives, client.RightActives)
+	}
+}
+
+func TestLegCountService_IncrementalUpdates(t *testing.T) {
+	ctx := context.Background()
+	repo := &mockLegCountRepo{clients: make(map[string]*models.Client)}
+	service := NewLegCountService(repo, zap.NewNop())
+
+	// root -> a (gauche) -> c (gauche), root -> b (droite)
+	root := repo.enroll(nil, "")
+	a := repo.enroll(root, "left")
+	b := repo.enroll(root, "right")
+	c := repo.enroll(a, "left")
+	for _, member := range []*models.Client{a, b, c} {
+		if err := service.OnEnrollment(ctx, member); err != nil {
+			t.Fatalf("Erreur inattendue: %v", err)
+		}
+	}
+	assertLegCounts(t, "root", root, 2, 1, 0, 0)
+	assertLegCounts(t, "a", a, 1, 0, 0, 0)
+
+	// c devient actif: compté une seule fois même si la synchronisation est répétée
+	markActive(c)
+	for i := 0; i < 2; i++ {
+		if err := service.SyncActive(ctx, c); err != nil {
+			t.Fatalf("Erreur inattendue: %v", err)
+		}
+	}
+	assertLegCounts(t, "root", root, 2, 1, 1, 0)
+	assertLegCounts(t, "a", a, 1, 0, 1, 0)
+
+	markActive(b)
+	if err := service.SyncActive(ctx, b); err != nil {
+		t.Fatalf("Erreur inattendue: %v", err)
+	}
+
+	// La période d'activité de c est échue: il est retiré par la synchronisation des expirés
+	expired := time.Now().Add(-time.Hour)
+	c.ActiveUntil = &expired
+	synced, err := service.SyncExpired(ctx)
+	if err != nil {
+		t.Fatalf("Erreur inattendue: %v", err)
+	}
+	if synced != 1 {
+		t.Errorf("Expected 1 expired member, got %d", synced)
+	}
+	assertLegCounts(t, "root", root, 2, 1, 0, 1)
+	assertLegCounts(t, "a", a, 1, 0, 0, 0)
+
+	// La suppression de a retire tout son sous-arbre de la jambe gauche de root
+	markActive(c)
+	if err := service.SyncActive(ctx, c); err != nil {
+		t.Fatalf("Erreur inattendue: %v", err)
+	}
+	if err := service.OnDelete(ctx, a); err != nil {
+		t.Fatalf("Erreur inattendue: %v", err)
+	}
+	assertLegCounts(t, "root", root, 0, 1, 0, 1)
+}
+
+func TestLegCountService_OnPlacementCountsActivity(t *testing.T) {
+	ctx := context.Background()
+	repo := &mockLegCountRepo{clients: make(map[string]*models.Client)}
+	service := NewLegCountService(repo, zap.NewNop())
+
+	root := repo.enroll(nil, "")
+
+	// Membre de la salle d'attente devenu actif avant son placement: aucun ancêtre à mettre à jour
+	held := repo.enroll(nil, "")
+	markActive(held)
+	if err := service.SyncActive(ctx, held); err != nil {
+		t.Fatalf("Erreur inattendue: %v", err)
+	}
+	assertLegCounts(t, "root", root, 0, 0, 0, 0)
+
+	// Au placement il est compté comme membre et comme actif
+	held.PlacementParentID = &root.ID
+	position := "right"
+	held.Position = &position
+	root.RightChildID = &held.ID
+	if err := service.OnPlacement(ctx, held); err != nil {
+		t.Fatalf("Erreur inattendue: %v", err)
+	}
+	assertLegCounts(t, "root", root, 0, 1, 0, 1)
+}
+
+func TestLegCountService_Rebuild(t *testing.T) {
+	ctx := context.Background()
+	repo := &mockLegCountRepo{clients: make(map[string]*models.Client)}
+	service := NewLegCountService(repo, zap.NewNop())
+
+	root := repo.enroll(nil, "")
+	left := repo.enroll(root, "left")
+	right := repo.enroll(root, "right")
+	leaf := repo.enroll(left, "right")
+	markActive(left)
+	markActive(leaf)
+
+	corrected, err := service.Rebuild(ctx)
+	if err != nil {
+		t.Fatalf("Erreur inattendue: %v", err)
+	}
+	if corrected != 3 {
+		t.Errorf("Expected 3 corrected members (root, left and leaf), got %d", corrected)
+	}
+	assertLegCounts(t, "root", root, 2, 1, 2, 0)
+	assertLegCounts(t, "left", left, 0, 1, 0, 1)
+	assertLegCounts(t, "right", right, 0, 0, 0, 0)
+	if !left.CountedActive || !leaf.CountedActive || right.CountedActive {
+		t.Error("Expected only active members to be flagged as counted")
+	}
+
+	// Un second recalcul ne corrige plus rien
+	corrected, err = service.Rebuild(ctx)
+	if err != nil {
+		t.Fatalf("Erreur inattendue: %v", err)
+	}
+	if corrected != 0 {
+		t.Errorf("Expected no correction on a consistent tree, got %d", corrected)
+	}
+}
+
+// Test: le recalcul corrige l'écart lu sans écraser une inscription enregistrée pendant le recalcul
+func TestLegCountService_RebuildKeepsConcurrentUpdates(t *testing.T) {
+	ctx := context.Background()
+	repo := &mockLegCountRepo{clients: make(map[string]*models.Client)}
+	service := NewLegCountService(repo, zap.NewNop())
+
+	root := repo.enroll(nil, "")
+	left := repo.enroll(root, "left")
+	if _, err := service.Rebuild(ctx); err != nil {
+		t.Fatalf("Erreur inattendue: %v", err)
+	}
+	root.RightMembers = 5 // Compteur faussé, à corriger
+
+	repo.afterTreeRead = func() {
+		member := repo.enroll(left, "left")
+		if err := service.OnEnrollment(ctx, member); err != nil {
+			t.Fatalf("Erreur inattendue: %v", err)
+		}
+	}
+	corrected, err := service.Rebuild(ctx)
+	if err != nil {
+		t.Fatalf("Erreur inattendue: %v", err)
+	}
+	if corrected != 1 {
+		t.Errorf("Expected only the root to be corrected, got %d", corrected)
+	}
+	assertLegCounts(t, "root", root, 2, 0, 0, 0)
+	assertLegCounts(t, "left", left, 1, 0, 0, 0)
+}
@@ -0,0 +1,149 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"bureau/internal/models"
+
+	"go.uber.org/zap"
+)
+
+type legacyClientRepository interface {
+	clientRepository
+	UpdateBinaryPairs(ctx context.Context, id string, pairs int) error
+}
+
+// LegacyBinaryEngine est l'ancien appariement binaire: dès que les deux jambes atteignent le seuil,
+// le volume de la jambe faible est payé au taux de commission (type "binary-match") puis consommé
+// sur les deux jambes. Conservé derrière BinaryConfig.Engine jusqu'à la migration vers les cycles.
+type LegacyBinaryEngine struct {
+	clientRepo     legacyClientRepository
+	commissionRepo commissionRepository
+	wallet         walletLedger
+	logger         *zap.Logger
+	config         models.BinaryConfig
+	mu             sync.Mutex // Sérialise lecture des volumes et paiement
+}
+
+// NewLegacyBinaryEngine crée le moteur binaire legacy
+func NewLegacyBinaryEngine(
+	clientRepo legacyClientRepository,
+	commissionRepo commissionRepository,
+	wallet walletLedger,
+	logger *zap.Logger,
+	config models.BinaryConfig,
+) *LegacyBinaryEngine {
+	return &LegacyBinaryEngine{
+		clientRepo:     clientRepo,
+		commissionRepo: commissionRepo,
+		wallet:         wallet,
+		logger:         logger,
+		config:         config,
+	}
+}
+
+// ComputeBinaryCommission paie un appariement si les deux jambes atteignent le seuil de la configuration du moteur
+func (e *LegacyBinaryEngine) ComputeBinaryCommission(ctx context.Context, clientID string) (*models.BinaryCommissionResult, error) {
+	return e.computeWithPlan(ctx, clientID, &models.CompPlanVersion{Binary: e.config}, false)
+}
+
+// PreviewBinaryCommission simule l'appariement sans rien écrire
+func (e *LegacyBinaryEngine) PreviewBinaryCommission(ctx context.Context, clientID string) (*models.BinaryCommissionResult, error) {
+	return e.computeWithPlan(ctx, clientID, &models.CompPlanVersion{Binary: e.config}, true)
+}
+
+// computeWithPlan applique le seuil et le taux de la version du plan fournie
+// En mode simulation, le résultat est calculé sans rien écrire
+func (e *LegacyBinaryEngine) computeWithPlan(ctx context.Context, clientID string, plan *models.CompPlanVersion, simulate bool) (*models.BinaryCommissionResult, error) {
+	cfg := plan.Binary
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	client, err := e.clientRepo.GetByID(ctx, clientID)
+	if err != nil {
+		return &models.BinaryCommissionResult{
+			Success:     false,
+			DryRun:      simulate,
+			PlanVersion: plan.Version,
+			Reason:      "Client introuvable",
+		}, err
+	}
+
+	left, right := client.NetworkVolumeLeft, client.NetworkVolumeRight
+	result := &models.BinaryCommissionResult{
+		Success:              true,
+		Qualified:            true,
+		DryRun:               simulate,
+		PlanVersion:          plan.Version,
+		Legs:                 &models.BinaryLegs{LeftVolume: left, RightVolume: right},
+		LeftVolumeRemaining:  left,
+		RightVolumeRemaining: right,
+	}
+	if left < cfg.Threshold || right < cfg.Threshold || left <= 0 || right <= 0 {
+		result.Reason = fmt.Sprintf("Volume insuffisant: chaque jambe doit atteindre %s", cfg.Threshold)
+		return result, nil
+	}
+
+	// Le volume de la jambe faible est apparié puis consommé des deux côtés
+	matched := min(left, right)
+	amount := matched.MoneyAt(cfg.CommissionRate)
+	leftRemaining, rightRemaining := left-matched, right-matched
+
+	result.CyclesAvailable = 1
+	result.CyclesPaid = 1
+	result.Amount = amount
+	result.LeftVolumeRemaining = leftRemaining
+	result.RightVolumeRemaining = rightRemaining
+	if simulate {
+		return result, nil
+	}
+
+	created, err := e.commissionRepo.Create(ctx, &models.Commission{
+		ClientID:       client.ID,
+		SourceClientID: client.ID, // Auto-commission pour l'appariement binaire
+		Amount:         amount,
+		Level:          0,
+		Type:           "binary-match",
+		Date:           time.Now(),
+		PlanVersion:    plan.Version,
+	})
+	if err != nil {
+		return &models.BinaryCommissionResult{
+			Success: false,
+			Reason:  fmt.Sprintf("Erreur lors de l'enregistrement du paiement: %v", err),
+		}, err
+	}
+
+	if err := e.clientRepo.UpdateNetworkVolumes(ctx, clientID, leftRemaining, rightRemaining); err != nil {
+		return &models.BinaryCommissionResult{
+			Success: false,
+			Reason:  fmt.Sprintf("Erreur lors de la déduction des volumes: %v", err),
+		}, err
+	}
+
+	if _, err := e.wallet.Post(ctx, commissionEntry(created)); err != nil {
+		e.logger.Error("Failed to credit client wallet", zap.Error(err))
+	}
+	if err := e.clientRepo.UpdateBinaryPairs(ctx, clientID, client.BinaryPairs+1); err != nil {
+		e.logger.Error("Failed to update binary pairs", zap.Error(err))
+	}
+
+	e.logger.Info("Legacy binary commission paid",
+		zap.String("clientID", clientID),
+		zap.Float64("amount", amount.Float64()),
+		zap.Int("binaryPairs", client.BinaryPairs+1),
+	)
+
+	commissionID := created.ID.Hex()
+	result.CommissionID = &commissionID
+	return result, nil
+}
+
+// PeriodKey retourne le jour (UTC) d'une date: le moteur legacy n'a pas de plafond
+func (e *LegacyBinaryEngine) PeriodKey(date time.Time) string {
+	return date.UTC().Format("2006-01-02")
+}
@@ -0,0 +1,87 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"bureau/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+// matchingBonusCalculator verse aux parrains un pourcentage des commissions binary-cycle
+// de leurs filleuls, sur autant de générations que le plan définit de taux.
+// La génération 1 est le parrain direct du membre payé.
+type matchingBonusCalculator struct {
+	clientRepo     clientRepository
+	commissionRepo commissionRepository
+	wallet         walletLedger
+	logger         *zap.Logger
+}
+
+// pay remonte la ligne de parrainage depuis le bénéficiaire de la commission source et
+// enregistre une commission de matching pour chaque parrain actif à la date du paiement.
+// Un parrain inactif ne perçoit rien pour sa génération (pas de compression). Les écritures
+// partagent le contexte, et donc la transaction, de l'appelant.
+func (c *matchingBonusCalculator) pay(ctx context.Context, rates []float64, source *models.Commission, now time.Time) ([]*models.Commission, error) {
+	if len(rates) == 0 || source.Amount <= 0 {
+		return nil, nil
+	}
+
+	member, err := c.clientRepo.GetByID(ctx, source.ClientID.Hex())
+	if err != nil {
+		return nil, fmt.Errorf("failed to load commission earner: %w", err)
+	}
+
+	var paid []*models.Commission
+	visited := map[primitive.ObjectID]bool{source.ClientID: true}
+	for generation := 1; generation <= len(rates); generation++ {
+		if member == nil || member.SponsorID == nil || visited[*member.SponsorID] {
+			break // Sommet de l'arbre atteint (ou boucle dans les données)
+		}
+		visited[*member.SponsorID] = true
+
+		sponsor, err := c.clientRepo.GetByID(ctx, member.SponsorID.Hex())
+		if err != nil {
+			return nil, fmt.Errorf("failed to load sponsor of generation %d: %w", generation, err)
+		}
+		member = sponsor
+		if sponsor == nil {
+			break
+		}
+
+		amount := source.Amount.Mul(rates[generation-1])
+		if amount <= 0 || !IsActiveAt(sponsor, now) {
+			continue
+		}
+
+		commission, err := c.commissionRepo.Create(ctx, &models.Commission{
+			ID:                 primitive.NewObjectID(),
+			ClientID:           sponsor.ID,
+			SourceClientID:     source.ClientID,
+			Amount:             amount,
+			Level:              generation,
+			Type:               "matching",
+			Date:               now,
+			PlanVersion:        source.PlanVersion,
+			SourceCommissionID: &source.ID,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create matching commission: %w", err)
+		}
+		if _, err := c.wallet.Post(ctx, commissionEntry(commission)); err != nil {
+			return nil, fmt.Errorf("failed to credit sponsor wallet: %w", err)
+		}
+		paid = append(paid, commission)
+	}
+
+	if len(paid) > 0 {
+		c.logger.Info("Matching bonus paid",
+			zap.String("sourceClientID", source.ClientID.Hex()),
+			zap.Float64("sourceAmount", source.Amount.Float64()),
+			zap.Int("commissions", len(paid)))
+	}
+	return paid, nil
+}
@@ -0,0 +1,105 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"bureau/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestMatchingBonus_PaidUpTheSponsorLine(t *testing.T) {
+	service, clientRepo, commissionRepo, _ := createTestBinaryService()
+	service.config.CycleValue = models.NewMoney(20)
+	service.config.MatchingBonusRates = []float64{0.10, 0.05, 0.02, 0.01}
+	ctx := context.Background()
+
+	// top -> inactive -> sponsor -> earner (3 cycles à 20 = 60)
+	top := &models.Client{ID: primitive.NewObjectID(), TotalEarnings: models.NewMoney(10), WalletBalance: models.NewMoney(10)}
+	inactive := &models.Client{ID: primitive.NewObjectID(), SponsorID: &top.ID}
+	sponsor := &models.Client{ID: primitive.NewObjectID(), SponsorID: &inactive.ID}
+	leftChildID := primitive.NewObjectID()
+	rightChildID := primitive.NewObjectID()
+	earner := &models.Client{
+		ID:                 primitive.NewObjectID(),
+		SponsorID:          &sponsor.ID,
+		NetworkVolumeLeft:  models.NewVolume(3.0),
+		NetworkVolumeRight: models.NewVolume(5.0),
+		LeftChildID:        &leftChildID,
+		RightChildID:       &rightChildID,
+	}
+	for _, client := range []*models.Client{top, inactive, sponsor, earner, {ID: leftChildID}, {ID: rightChildID}} {
+		clientRepo.clients[client.ID.Hex()] = client
+	}
+	markActive(top)
+	markActive(sponsor)
+	markActive(clientRepo.clients[leftChildID.Hex()])
+	markActive(clientRepo.clients[rightChildID.Hex()])
+	countLegs(clientRepo)
+
+	result, err := service.ComputeBinaryCommission(ctx, earner.ID.Hex())
+	if err != nil {
+		t.Fatalf("Erreur inattendue: %v", err)
+	}
+	if result.Amount != models.NewMoney(60) {
+		t.Fatalf("Expected binary amount 60, got %s", result.Amount)
+	}
+
+	// Le parrain inactif (génération 2) ne perçoit rien; la ligne s'arrête au sommet
+	var matching []*models.Commission
+	for _, commission := range commissionRepo.commissions {
+		if commission.Type == "matching" {
+			matching = append(matching, commission)
+		}
+	}
+	if len(matching) != 2 {
+		t.Fatalf("Expected 2 matching commissions, got %d", len(matching))
+	}
+	expected := []struct {
+		clientID primitive.ObjectID
+		level    int
+		amount   models.Money
+	}{
+		{sponsor.ID, 1, models.NewMoney(6)},
+		{top.ID, 3, models.NewMoney(1.2)},
+	}
+	for i, want := range expected {
+		got := matching[i]
+		if got.ClientID != want.clientID || got.Level != want.level || got.Amount != want.amount || got.SourceClientID != earner.ID {
+			t.Errorf("Matching commission %d: expected %s level %d amount %s, got %+v", i, want.clientID.Hex(), want.level, want.amount, got)
+		}
+	}
+	if sponsor.WalletBalance != models.NewMoney(6) || top.TotalEarnings != models.NewMoney(11.2) || inactive.TotalEarnings != 0 {
+		t.Errorf("Unexpected sponsor earnings: sponsor %s, top %s, inactive %s", sponsor.WalletBalance, top.TotalEarnings, inactive.TotalEarnings)
+	}
+}
+
+func TestMatchingBonus_NotPaidOnPreview(t *testing.T) {
+	service, clientRepo, commissionRepo, _ := createTestBinaryService()
+	service.config.MatchingBonusRates = []float64{0.10}
+
+	sponsor := &models.Client{ID: primitive.NewObjectID()}
+	leftChildID := primitive.NewObjectID()
+	rightChildID := primitive.NewObjectID()
+	earner := &models.Client{
+		ID:                 primitive.NewObjectID(),
+		SponsorID:          &sponsor.ID,
+		NetworkVolumeLeft:  models.NewVolume(3.0),
+		NetworkVolumeRight: models.NewVolume(3.0),
+		LeftChildID:        &leftChildID,
+		RightChildID:       &rightChildID,
+	}
+	for _, client := range []*models.Client{sponsor, earner, {ID: leftChildID}, {ID: rightChildID}} {
+		markActive(client)
+		clientRepo.clients[client.ID.Hex()] = client
+	}
+	countLegs(clientRepo)
+
+	if _, err := service.PreviewBinaryCommission(context.Background(), earner.ID.Hex()); err != nil {
+		t.Fatalf("Erreur inattendue: %v", err)
+	}
+	if len(commissionRepo.commissions) != 0 || sponsor.TotalEarnings != 0 {
+		t.Errorf("Expected no commission written by a preview, got %d", len(commissionRepo.commissions))
+	}
+}
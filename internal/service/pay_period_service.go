@@ -0,0 +1,329 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"bureau/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+type payPeriodRepository interface {
+	GetOrCreate(ctx context.Context, period *models.PayPeriod) (*models.PayPeriod, error)
+	GetByKey(ctx context.Context, key string) (*models.PayPeriod, error)
+	GetAll(ctx context.Context, paging *models.PagingInput) ([]*models.PayPeriod, error)
+	Close(ctx context.Context, id primitive.ObjectID, closedAt time.Time, closedBy *primitive.ObjectID) (bool, error)
+	SetTotals(ctx context.Context, id primitive.ObjectID, members int, commissions, adjustments models.Money) error
+	CreateStatements(ctx context.Context, statements []*models.MemberStatement) error
+	GetStatementsByPeriod(ctx context.Context, periodID primitive.ObjectID, paging *models.PagingInput) ([]*models.MemberStatement, error)
+	GetStatementsByClientID(ctx context.Context, clientID primitive.ObjectID, paging *models.PagingInput) ([]*models.MemberStatement, error)
+}
+
+type periodCommissionRepository interface {
+	GetByDateRange(ctx context.Context, from, to time.Time) ([]*models.Commission, error)
+	AssignPeriod(ctx context.Context, from, to time.Time, periodID primitive.ObjectID) (int64, error)
+}
+
+type periodCycleRepository interface {
+	GetByDateRange(ctx context.Context, from, to time.Time) ([]*models.BinaryCycle, error)
+}
+
+type periodSaleRepository interface {
+	GetPaidByDateRange(ctx context.Context, from, to time.Time) ([]*models.Sale, error)
+}
+
+type periodClientRepository interface {
+	GetByIDs(ctx context.Context, ids []primitive.ObjectID) ([]*models.Client, error)
+}
+
+type periodRankEvaluator interface {
+	EvaluateAll(ctx context.Context, period *models.PayPeriod) (int, error)
+}
+
+// PayPeriodService découpe les commissions en périodes de paie (hebdomadaires ou mensuelles).
+// La clôture d'une période terminée fige ses commissions, produit un relevé par membre puis
+// évalue les rangs sur les volumes de la période; les reprises postérieures sont datées, et
+// donc relevées, dans la période ouverte.
+type PayPeriodService struct {
+	periodRepo     payPeriodRepository
+	commissionRepo periodCommissionRepository
+	cycleRepo      periodCycleRepository
+	saleRepo       periodSaleRepository
+	clientRepo     periodClientRepository
+	ranks          periodRankEvaluator
+	txHelper       transactionHelper
+	rule           models.PayPeriodRule
+	logger         *zap.Logger
+	now            func() time.Time
+	location       *time.Location // Fuseau du jour ouvré (bornes des périodes)
+}
+
+// NewPayPeriodService crée un nouveau service de périodes de paie
+func NewPayPeriodService(
+	periodRepo payPeriodRepository,
+	commissionRepo periodCommissionRepository,
+	cycleRepo periodCycleRepository,
+	saleRepo periodSaleRepository,
+	clientRepo periodClientRepository,
+	ranks periodRankEvaluator,
+	txHelper transactionHelper,
+	rule models.PayPeriodRule,
+	logger *zap.Logger,
+	location *time.Location,
+) *PayPeriodService {
+	return &PayPeriodService{
+		periodRepo:     periodRepo,
+		commissionRepo: commissionRepo,
+		cycleRepo:      cycleRepo,
+		saleRepo:       saleRepo,
+		clientRepo:     clientRepo,
+		ranks:          ranks,
+		txHelper:       txHelper,
+		rule:           rule,
+		logger:         logger,
+		now:            time.Now,
+		location:       locationOrUTC(location),
+	}
+}
+
+// periodAt retourne les bornes et la clé de la période de paie contenant date
+// Les bornes tombent à minuit dans le fuseau du jour ouvré
+func (s *PayPeriodService) periodAt(date time.Time) *models.PayPeriod {
+	period := &models.PayPeriod{Frequency: s.rule.Frequency, Status: models.PayPeriodStatusOpen}
+	if s.rule.Frequency == models.PayPeriodMonthly {
+		period.Start = startOfMonth(date, s.location)
+		period.End = period.Start.AddDate(0, 1, 0)
+		period.Key = period.Start.Format("2006-01")
+		return period
+	}
+
+	period.Start = startOfWeek(date, s.location, s.rule.WeekStartDay)
+	period.End = period.Start.AddDate(0, 0, 7)
+	period.Key = period.Start.Format("2006-01-02")
+	return period
+}
+
+// periodForKey retourne la période désignée par sa clé ("2006-01-02" pour une semaine, "2006-01" pour un mois)
+func (s *PayPeriodService) periodForKey(key string) (*models.PayPeriod, error) {
+	layout := "2006-01-02"
+	if s.rule.Frequency == models.PayPeriodMonthly {
+		layout = "2006-01"
+	}
+	date, err := time.ParseInLocation(layout, key, s.location)
+	if err != nil {
+		return nil, fmt.Errorf("clé de période invalide (format attendu %s)", layout)
+	}
+	period := s.periodAt(date)
+	if period.Key != key {
+		return nil, fmt.Errorf("%s n'est pas le début d'une période de paie (période %s)", key, period.Key)
+	}
+	return period, nil
+}
+
+// LastCompleted retourne les bornes et la clé de la dernière période terminée
+func (s *PayPeriodService) LastCompleted() *models.PayPeriod {
+	return s.periodAt(s.periodAt(s.now()).Start.AddDate(0, 0, -1))
+}
+
+// Current retourne la période ouverte en cours, créée au besoin
+func (s *PayPeriodService) Current(ctx context.Context) (*models.PayPeriod, error) {
+	return s.periodRepo.GetOrCreate(ctx, s.periodAt(s.now()))
+}
+
+// GetAll récupère les périodes, de la plus récente à la plus ancienne (la période en cours incluse)
+func (s *PayPeriodService) GetAll(ctx context.Context, paging *models.PagingInput) ([]*models.PayPeriod, error) {
+	if _, err := s.Current(ctx); err != nil {
+		return nil, err
+	}
+	return s.periodRepo.GetAll(ctx, paging)
+}
+
+// GetStatements récupère les relevés d'une période (vide tant qu'elle n'est pas clôturée)
+func (s *PayPeriodService) GetStatements(ctx context.Context, key string, paging *models.PagingInput) ([]*models.MemberStatement, error) {
+	period, err := s.periodRepo.GetByKey(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if period == nil {
+		return []*models.MemberStatement{}, nil
+	}
+	return s.periodRepo.GetStatementsByPeriod(ctx, period.ID, paging)
+}
+
+// GetMemberStatements récupère les relevés d'un membre, du plus récent au plus ancien
+func (s *PayPeriodService) GetMemberStatements(ctx context.Context, clientID primitive.ObjectID, paging *models.PagingInput) ([]*models.MemberStatement, error) {
+	return s.periodRepo.GetStatementsByClientID(ctx, clientID, paging)
+}
+
+// ClosePeriod clôture la période de clé key (la dernière période terminée si key est vide):
+// ses commissions sont figées et un relevé est produit pour chaque membre ayant des commissions,
+// des cycles ou des ventes payées dans la période. Tout est écrit dans une seule transaction.
+// Les rangs sont ensuite évalués sur les volumes de la période, hors transaction: un échec est
+// journalisé sans annuler la clôture (rankEvaluate réévalue alors les membres concernés).
+func (s *PayPeriodService) ClosePeriod(ctx context.Context, key string, closedBy *primitive.ObjectID) (*models.PayPeriod, error) {
+	now := s.now()
+	period := s.periodAt(s.periodAt(now).Start.AddDate(0, 0, -1))
+	if key != "" {
+		var err error
+		if period, err = s.periodForKey(key); err != nil {
+			return nil, err
+		}
+	}
+	if period.End.After(now) {
+		return nil, fmt.Errorf("la période %s n'est pas terminée", period.Key)
+	}
+
+	var closed *models.PayPeriod
+	err := s.inTransaction(ctx, func(txCtx context.Context) error {
+		stored, err := s.periodRepo.GetOrCreate(txCtx, period)
+		if err != nil {
+			return err
+		}
+		ok, err := s.periodRepo.Close(txCtx, stored.ID, now, closedBy)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return errors.New("la période est déjà clôturée")
+		}
+
+		statements, err := s.buildStatements(txCtx, stored, now)
+		if err != nil {
+			return err
+		}
+		if _, err := s.commissionRepo.AssignPeriod(txCtx, stored.Start, stored.End, stored.ID); err != nil {
+			return fmt.Errorf("échec du gel des commissions: %w", err)
+		}
+		if err := s.periodRepo.CreateStatements(txCtx, statements); err != nil {
+			return fmt.Errorf("échec de l'enregistrement des relevés: %w", err)
+		}
+
+		var commissions, adjustments models.Money
+		for _, statement := range statements {
+			commissions += statement.TotalCommissions
+			adjustments += statement.TotalAdjustments
+		}
+		stored.Status = models.PayPeriodStatusClosed
+		stored.ClosedAt = &now
+		stored.ClosedBy = closedBy
+		stored.MemberCount = len(statements)
+		stored.TotalCommissions = commissions
+		stored.TotalAdjustments = adjustments
+		closed = stored
+		return s.periodRepo.SetTotals(txCtx, stored.ID, stored.MemberCount, stored.TotalCommissions, stored.TotalAdjustments)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Pay period closed",
+		zap.String("period", closed.Key),
+		zap.Int("statements", closed.MemberCount),
+		zap.Float64("totalCommissions", closed.TotalCommissions.Float64()),
+		zap.Float64("totalAdjustments", closed.TotalAdjustments.Float64()))
+
+	if s.ranks != nil {
+		if _, err := s.ranks.EvaluateAll(ctx, closed); err != nil {
+			s.logger.Error("Failed to evaluate ranks after period close", zap.String("period", closed.Key), zap.Error(err))
+		}
+	}
+	return closed, nil
+}
+
+// buildStatements regroupe par membre les commissions, cycles et ventes payées de la période
+func (s *PayPeriodService) buildStatements(ctx context.Context, period *models.PayPeriod, now time.Time) ([]*models.MemberStatement, error) {
+	commissions, err := s.commissionRepo.GetByDateRange(ctx, period.Start, period.End)
+	if err != nil {
+		return nil, fmt.Errorf("échec de la lecture des commissions: %w", err)
+	}
+	cycles, err := s.cycleRepo.GetByDateRange(ctx, period.Start, period.End)
+	if err != nil {
+		return nil, fmt.Errorf("échec de la lecture des cycles: %w", err)
+	}
+	sales, err := s.saleRepo.GetPaidByDateRange(ctx, period.Start, period.End)
+	if err != nil {
+		return nil, fmt.Errorf("échec de la lecture des ventes: %w", err)
+	}
+
+	var ids []primitive.ObjectID
+	byMember := make(map[primitive.ObjectID]*models.MemberStatement)
+	statementFor := func(clientID primitive.ObjectID) *models.MemberStatement {
+		statement, ok := byMember[clientID]
+		if !ok {
+			statement = &models.MemberStatement{
+				PeriodID:    period.ID,
+				PeriodKey:   period.Key,
+				ClientID:    clientID,
+				Commissions: []models.StatementLine{},
+				Adjustments: []models.StatementLine{},
+				CreatedAt:   now,
+			}
+			byMember[clientID] = statement
+			ids = append(ids, clientID)
+		}
+		return statement
+	}
+
+	for _, commission := range commissions {
+		statement := statementFor(commission.ClientID)
+		line := models.StatementLine{
+			CommissionID:       commission.ID,
+			Type:               commission.Type,
+			Amount:             commission.Amount,
+			Level:              commission.Level,
+			SourceClientID:     commission.SourceClientID,
+			SaleID:             commission.SaleID,
+			SourceCommissionID: commission.SourceCommissionID,
+			Date:               commission.Date,
+		}
+		// Les reprises et les montants négatifs sont des ajustements
+		if commission.Type == "clawback" || commission.Amount < 0 {
+			statement.Adjustments = append(statement.Adjustments, line)
+			statement.TotalAdjustments += commission.Amount
+		} else {
+			statement.Commissions = append(statement.Commissions, line)
+			statement.TotalCommissions += commission.Amount
+		}
+	}
+	for _, cycle := range cycles {
+		statement := statementFor(cycle.ClientID)
+		statement.Cycles += cycle.Cycles
+		statement.PairedVolume += cycle.LeftVolumeUsed - cycle.ReversedVolume
+	}
+	for _, sale := range sales {
+		statementFor(sale.ClientID).PersonalVolume += sale.Points
+	}
+
+	if len(ids) > 0 {
+		clients, err := s.clientRepo.GetByIDs(ctx, ids)
+		if err != nil {
+			return nil, fmt.Errorf("échec de la lecture des membres: %w", err)
+		}
+		for _, client := range clients {
+			if statement, ok := byMember[client.ID]; ok {
+				statement.LeftVolumeCarry = client.NetworkVolumeLeft
+				statement.RightVolumeCarry = client.NetworkVolumeRight
+			}
+		}
+	}
+
+	statements := make([]*models.MemberStatement, 0, len(ids))
+	for _, id := range ids {
+		statement := byMember[id]
+		statement.NetAmount = statement.TotalCommissions + statement.TotalAdjustments
+		statements = append(statements, statement)
+	}
+	return statements, nil
+}
+
+// inTransaction exécute fn dans une transaction si le helper est disponible
+func (s *PayPeriodService) inTransaction(ctx context.Context, fn func(context.Context) error) error {
+	if s.txHelper == nil {
+		return fn(ctx)
+	}
+	return s.txHelper.ExecuteTransaction(ctx, fn)
+}
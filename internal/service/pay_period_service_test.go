@@ -0,0 +1,281 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"bureau/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+type mockPayPeriodRepo struct {
+	periods    map[string]*models.PayPeriod
+	statements []*models.MemberStatement
+}
+
+func (m *mockPayPeriodRepo) GetOrCreate(ctx context.Context, period *models.PayPeriod) (*models.PayPeriod, error) {
+	if stored, ok := m.periods[period.Key]; ok {
+		copied := *stored
+		return &copied, nil
+	}
+	stored := *period
+	stored.ID = primitive.NewObjectID()
+	stored.Status = models.PayPeriodStatusOpen
+	m.periods[period.Key] = &stored
+	copied := stored
+	return &copied, nil
+}
+
+func (m *mockPayPeriodRepo) GetByKey(ctx context.Context, key string) (*models.PayPeriod, error) {
+	return m.periods[key], nil
+}
+
+func (m *mockPayPeriodRepo) GetAll(ctx context.Context, paging *models.PagingInput) ([]*models.PayPeriod, error) {
+	var out []*models.PayPeriod
+	for _, period := range m.periods {
+		out = append(out, period)
+	}
+	return out, nil
+}
+
+func (m *mockPayPeriodRepo) Close(ctx context.Context, id primitive.ObjectID, closedAt time.Time, closedBy *primitive.ObjectID) (bool, error) {
+	for _, period := range m.periods {
+		if period.ID == id && period.Status == models.PayPeriodStatusOpen {
+			period.Status = models.PayPeriodStatusClosed
+			period.ClosedAt = &closedAt
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *mockPayPeriodRepo) SetTotals(ctx context.Context, id primitive.ObjectID, members int, commissions, adjustments models.Money) error {
+	return nil
+}
+
+func (m *mockPayPeriodRepo) CreateStatements(ctx context.Context, statements []*models.MemberStatement) error {
+	m.statements = append(m.statements, statements...)
+	return nil
+}
+
+func (m *mockPayPeriodRepo) GetStatementsByPeriod(ctx context.Context, periodID primitive.ObjectID, paging *models.PagingInput) ([]*models.MemberStatement, error) {
+	var out []*models.MemberStatement
+	for _, statement := range m.statements {
+		if statement.PeriodID == periodID {
+			out = append(out, statement)
+		}
+	}
+	return out, nil
+}
+
+func (m *mockPayPeriodRepo) GetStatementsByClientID(ctx context.Context, clientID primitive.ObjectID, paging *models.PagingInput) ([]*models.MemberStatement, error) {
+	var out []*models.MemberStatement
+	for _, statement := range m.statements {
+		if statement.ClientID == clientID {
+			out = append(out, statement)
+		}
+	}
+	return out, nil
+}
+
+// mockPeriodData sert les commissions, cycles, ventes et membres d'une période
+// et retient les périodes dont les rangs ont été évalués
+type mockPeriodData struct {
+	commissions []*models.Commission
+	cycles      []*models.BinaryCycle
+	sales       []*models.Sale
+	clients     []*models.Client
+	evaluated   []string
+}
+
+func inRange(date, from, to time.Time) bool {
+	return !date.Before(from) && date.Before(to)
+}
+
+func (m *mockPeriodData) GetByDateRange(ctx context.Context, from, to time.Time) ([]*models.Commission, error) {
+	var out []*models.Commission
+	for _, c := range m.commissions {
+		if inRange(c.Date, from, to) {
+			out = append(out, c)
+		}
+	}
+	return out, nil
+}
+
+func (m *mockPeriodData) AssignPeriod(ctx context.Context, from, to time.Time, periodID primitive.ObjectID) (int64, error) {
+	var count int64
+	for _, c := range m.commissions {
+		if inRange(c.Date, from, to) {
+			c.PeriodID = &periodID
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (m *mockPeriodData) GetPaidByDateRange(ctx context.Context, from, to time.Time) ([]*models.Sale, error) {
+	var out []*models.Sale
+	for _, sale := range m.sales {
+		if sale.Status == "paid" && inRange(sale.Date, from, to) {
+			out = append(out, sale)
+		}
+	}
+	return out, nil
+}
+
+func (m *mockPeriodData) GetByIDs(ctx context.Context, ids []primitive.ObjectID) ([]*models.Client, error) {
+	return m.clients, nil
+}
+
+func (m *mockPeriodData) EvaluateAll(ctx context.Context, period *models.PayPeriod) (int, error) {
+	m.evaluated = append(m.evaluated, period.Key)
+	return 0, nil
+}
+
+type mockPeriodCycles struct {
+	data *mockPeriodData
+}
+
+func (m *mockPeriodCycles) GetByDateRange(ctx context.Context, from, to time.Time) ([]*models.BinaryCycle, error) {
+	var out []*models.BinaryCycle
+	for _, cycle := range m.data.cycles {
+		if inRange(cycle.Date, from, to) {
+			out = append(out, cycle)
+		}
+	}
+	return out, nil
+}
+
+func createTestPayPeriodService(rule models.PayPeriodRule, now time.Time) (*PayPeriodService, *mockPayPeriodRepo, *mockPeriodData) {
+	repo := &mockPayPeriodRepo{periods: make(map[string]*models.PayPeriod)}
+	data := &mockPeriodData{}
+	service := NewPayPeriodService(repo, data, &mockPeriodCycles{data: data}, data, data, data, nil, rule, zap.NewNop(), time.UTC)
+	service.now = func() time.Time { return now }
+	return service, repo, data
+}
+
+func TestPayPeriod_Boundaries(t *testing.T) {
+	// Mercredi 15 octobre 2025
+	date := time.Date(2025, 10, 15, 18, 30, 0, 0, time.UTC)
+
+	weekly, _, _ := createTestPayPeriodService(models.PayPeriodRule{Frequency: models.PayPeriodWeekly, WeekStartDay: time.Monday}, date)
+	period := weekly.periodAt(date)
+	if period.Key != "2025-10-13" || !period.End.Equal(time.Date(2025, 10, 20, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected weekly period: %s -> %s", period.Key, period.End)
+	}
+	if _, err := weekly.periodForKey("2025-10-14"); err == nil {
+		t.Error("A key that is not a week start should be rejected")
+	}
+
+	monthly, _, _ := createTestPayPeriodService(models.PayPeriodRule{Frequency: models.PayPeriodMonthly}, date)
+	period = monthly.periodAt(date)
+	if period.Key != "2025-10" || !period.End.Equal(time.Date(2025, 11, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected monthly period: %s -> %s", period.Key, period.End)
+	}
+	if _, err := monthly.periodForKey("2025-10-01"); err == nil {
+		t.Error("A monthly key must use the 2006-01 format")
+	}
+}
+
+// Test: les périodes commencent à minuit dans le fuseau de l'entreprise
+func TestPayPeriod_BusinessTimezoneMidnight(t *testing.T) {
+	kinshasa := time.FixedZone("WAT", 60*60) // Africa/Kinshasa, UTC+1
+	// Dimanche 19 octobre 2025, 23h30 UTC: lundi 20 à 0h30 à Kinshasa
+	date := time.Date(2025, 10, 19, 23, 30, 0, 0, time.UTC)
+
+	weekly, _, _ := createTestPayPeriodService(models.PayPeriodRule{Frequency: models.PayPeriodWeekly, WeekStartDay: time.Monday}, date)
+	weekly.location = kinshasa
+	period := weekly.periodAt(date)
+	if period.Key != "2025-10-20" || !period.Start.Equal(time.Date(2025, 10, 19, 23, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected the week of Monday 20 starting at local midnight, got %s from %s", period.Key, period.Start)
+	}
+	if previous := weekly.periodAt(date.Add(-time.Hour)); previous.Key != "2025-10-13" {
+		t.Errorf("22h30 UTC is still Sunday in Kinshasa, got period %s", previous.Key)
+	}
+	byKey, err := weekly.periodForKey("2025-10-20")
+	if err != nil || !byKey.Start.Equal(period.Start) {
+		t.Errorf("Key lookup should give the same local boundaries: %+v, %v", byKey, err)
+	}
+
+	// Vendredi 31 octobre 2025, 23h30 UTC: 1er novembre à Kinshasa
+	monthEnd := time.Date(2025, 10, 31, 23, 30, 0, 0, time.UTC)
+	monthly, _, _ := createTestPayPeriodService(models.PayPeriodRule{Frequency: models.PayPeriodMonthly}, monthEnd)
+	monthly.location = kinshasa
+	if period := monthly.periodAt(monthEnd); period.Key != "2025-11" {
+		t.Errorf("Expected the November period after local midnight, got %s", period.Key)
+	}
+}
+
+func TestPayPeriod_CloseProducesStatementsAndFreezes(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 10, 21, 9, 0, 0, 0, time.UTC) // Mardi: la semaine du 13 est terminée
+	service, repo, data := createTestPayPeriodService(models.PayPeriodRule{Frequency: models.PayPeriodWeekly, WeekStartDay: time.Monday}, now)
+
+	member := &models.Client{ID: primitive.NewObjectID(), NetworkVolumeLeft: models.NewVolume(30), NetworkVolumeRight: models.NewVolume(5)}
+	buyer := primitive.NewObjectID()
+	inPeriod := time.Date(2025, 10, 16, 12, 0, 0, 0, time.UTC)
+	data.clients = []*models.Client{member}
+	data.commissions = []*models.Commission{
+		{ID: primitive.NewObjectID(), ClientID: member.ID, Type: "binary-cycle", Amount: models.NewMoney(40), Date: inPeriod},
+		{ID: primitive.NewObjectID(), ClientID: member.ID, Type: "direct", Amount: models.NewMoney(10), Date: inPeriod},
+		{ID: primitive.NewObjectID(), ClientID: member.ID, Type: "clawback", Amount: models.NewMoney(-7.5), Date: inPeriod},
+		{ID: primitive.NewObjectID(), ClientID: member.ID, Type: "binary-cycle", Amount: models.NewMoney(99), Date: now}, // Période ouverte
+	}
+	data.cycles = []*models.BinaryCycle{{ClientID: member.ID, Cycles: 2, LeftVolumeUsed: models.NewVolume(200), ReversedVolume: models.NewVolume(50), Date: inPeriod}}
+	data.sales = []*models.Sale{
+		{ClientID: buyer, Points: models.NewVolume(20), Status: "paid", Date: inPeriod},
+		{ClientID: buyer, Points: models.NewVolume(80), Status: "pending", Date: inPeriod},
+	}
+
+	if _, err := service.ClosePeriod(ctx, "2025-10-20", nil); err == nil {
+		t.Error("The current period must not be closable before its end")
+	}
+
+	period, err := service.ClosePeriod(ctx, "", nil)
+	if err != nil {
+		t.Fatalf("Erreur inattendue: %v", err)
+	}
+	if period.Key != "2025-10-13" || period.Status != models.PayPeriodStatusClosed || period.MemberCount != 2 {
+		t.Fatalf("Unexpected closed period: %+v", period)
+	}
+	if last := service.LastCompleted(); last.Key != period.Key {
+		t.Errorf("Expected the closed period to be the last completed one, got %s", last.Key)
+	}
+	if period.TotalCommissions != models.NewMoney(50) || period.TotalAdjustments != models.NewMoney(-7.5) {
+		t.Errorf("Unexpected period totals: %s / %s", period.TotalCommissions, period.TotalAdjustments)
+	}
+
+	statements, _ := service.GetMemberStatements(ctx, member.ID, nil)
+	if len(statements) != 1 {
+		t.Fatalf("Expected 1 statement for the member, got %d", len(statements))
+	}
+	statement := statements[0]
+	if len(statement.Commissions) != 2 || len(statement.Adjustments) != 1 || statement.NetAmount != models.NewMoney(42.5) {
+		t.Errorf("Unexpected statement lines: %+v", statement)
+	}
+	if statement.Cycles != 2 || statement.PairedVolume != models.NewVolume(150) || statement.LeftVolumeCarry != models.NewVolume(30) || statement.RightVolumeCarry != models.NewVolume(5) {
+		t.Errorf("Unexpected statement volumes: %+v", statement)
+	}
+	buyerStatements, _ := service.GetMemberStatements(ctx, buyer, nil)
+	if len(buyerStatements) != 1 || buyerStatements[0].PersonalVolume != models.NewVolume(20) {
+		t.Errorf("Expected the buyer's paid volume only, got %+v", buyerStatements)
+	}
+
+	for _, c := range data.commissions {
+		frozen := c.PeriodID != nil && *c.PeriodID == repo.periods["2025-10-13"].ID
+		if frozen != c.Date.Before(period.End) {
+			t.Errorf("Commission dated %s: frozen=%v", c.Date, frozen)
+		}
+	}
+
+	if _, err := service.ClosePeriod(ctx, "2025-10-13", nil); err == nil {
+		t.Error("Closing a period twice should fail")
+	}
+	// Les rangs sont évalués une seule fois, sur la période clôturée
+	if len(data.evaluated) != 1 || data.evaluated[0] != "2025-10-13" {
+		t.Errorf("Expected the ranks to be evaluated on the closed period, got %v", data.evaluated)
+	}
+}
@@ -46,7 +46,7 @@ func (s *PaymentService) GetByClientID(ctx context.Context, clientID string) ([]
 	return s.paymentRepo.GetByClientID(ctx, clientID)
 }
 
-func (s *PaymentService) GetTotalPayments(ctx context.Context, filter *models.FilterInput) (float64, error) {
+func (s *PaymentService) GetTotalPayments(ctx context.Context, filter *models.FilterInput) (models.Money, error) {
 	return s.paymentRepo.GetTotalPayments(ctx, filter)
 }
 
@@ -0,0 +1,409 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"bureau/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+type payoutBatchRepository interface {
+	Create(ctx context.Context, batch *models.PayoutBatch) (*models.PayoutBatch, error)
+	GetByID(ctx context.Context, id primitive.ObjectID) (*models.PayoutBatch, error)
+	GetAll(ctx context.Context, paging *models.PagingInput) ([]*models.PayoutBatch, error)
+	SaveResults(ctx context.Context, batch *models.PayoutBatch) error
+}
+
+type payoutWithdrawalRepository interface {
+	GetByID(ctx context.Context, id primitive.ObjectID) (*models.Withdrawal, error)
+	GetUnbatched(ctx context.Context, methods []string) ([]*models.Withdrawal, error)
+	AssignBatch(ctx context.Context, ids []primitive.ObjectID, batchID primitive.ObjectID) (int64, error)
+}
+
+type payoutClientRepository interface {
+	GetByIDs(ctx context.Context, ids []primitive.ObjectID) ([]*models.Client, error)
+	GetByMinWalletBalance(ctx context.Context, minBalance models.Money) ([]*models.Client, error)
+}
+
+// withdrawalPayer approuve et règle les retraits versés par lot (WithdrawalService)
+type withdrawalPayer interface {
+	Payout(ctx context.Context, clientID primitive.ObjectID, amount models.Money, method string, destination *string, adminID *primitive.ObjectID) (*models.Withdrawal, error)
+	SettlePayout(ctx context.Context, withdrawal *models.Withdrawal, completed bool, reason string) error
+}
+
+// PayoutBatchService regroupe des retraits approuvés en un lot de paiement mobile money et
+// produit le fichier à importer dans le portail de l'opérateur (M-Pesa, Airtel Money,
+// Orange Money ou CSV générique). Les paiements du lot restent en attente jusqu'à l'import
+// du fichier de résultat de l'opérateur, qui marque chacun comme effectué ou échoué.
+type PayoutBatchService struct {
+	batchRepo      payoutBatchRepository
+	withdrawalRepo payoutWithdrawalRepository
+	clientRepo     payoutClientRepository
+	payer          withdrawalPayer
+	txHelper       transactionHelper
+	rule           models.PayoutRule
+	logger         *zap.Logger
+	now            func() time.Time
+}
+
+// NewPayoutBatchService crée un nouveau service de lots de paiement
+func NewPayoutBatchService(
+	batchRepo payoutBatchRepository,
+	withdrawalRepo payoutWithdrawalRepository,
+	clientRepo payoutClientRepository,
+	payer withdrawalPayer,
+	txHelper transactionHelper,
+	rule models.PayoutRule,
+	logger *zap.Logger,
+) *PayoutBatchService {
+	return &PayoutBatchService{
+		batchRepo:      batchRepo,
+		withdrawalRepo: withdrawalRepo,
+		clientRepo:     clientRepo,
+		payer:          payer,
+		txHelper:       txHelper,
+		rule:           rule,
+		logger:         logger,
+		now:            time.Now,
+	}
+}
+
+// GetByID récupère un lot (nil s'il n'existe pas)
+func (s *PayoutBatchService) GetByID(ctx context.Context, id primitive.ObjectID) (*models.PayoutBatch, error) {
+	return s.batchRepo.GetByID(ctx, id)
+}
+
+// GetAll récupère les lots, du plus récent au plus ancien
+func (s *PayoutBatchService) GetAll(ctx context.Context, paging *models.PagingInput) ([]*models.PayoutBatch, error) {
+	return s.batchRepo.GetAll(ctx, paging)
+}
+
+// Create constitue un lot pour provider. Avec la source PayoutSourceWithdrawals, le lot reprend
+// les retraits approuvés qui ne sont dans aucun lot (méthode "mobile" pour un opérateur mobile
+// money, toutes méthodes pour le CSV générique). Avec PayoutSourceBalances, chaque portefeuille
+// d'au moins minBalance est retiré en entier puis inclus dans le lot; si le lot ne peut pas
+// être créé, ces retraits sont remboursés.
+func (s *PayoutBatchService) Create(ctx context.Context, provider, source string, minBalance models.Money, adminID *primitive.ObjectID) (*models.PayoutBatch, error) {
+	if !models.IsPayoutProvider(provider) {
+		return nil, fmt.Errorf("format de fichier inconnu: %s", provider)
+	}
+
+	var withdrawals []*models.Withdrawal
+	switch source {
+	case models.PayoutSourceWithdrawals:
+		var methods []string
+		if provider != models.PayoutProviderGeneric {
+			methods = []string{"mobile"}
+		}
+		var err error
+		withdrawals, err = s.withdrawalRepo.GetUnbatched(ctx, methods)
+		if err != nil {
+			return nil, fmt.Errorf("échec de la lecture des retraits approuvés: %w", err)
+		}
+	case models.PayoutSourceBalances:
+		if minBalance <= 0 {
+			return nil, errors.New("le seuil de solde doit être supérieur à 0")
+		}
+		var err error
+		withdrawals, err = s.payBalances(ctx, minBalance, adminID)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("source de lot inconnue: %s", source)
+	}
+
+	created, err := s.createBatch(ctx, provider, source, minBalance, adminID, withdrawals)
+	if source == models.PayoutSourceBalances {
+		// Les portefeuilles sont déjà débités: un retrait resté hors du lot est remboursé
+		s.refundUnbatched(ctx, withdrawals, created)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Payout batch created",
+		zap.String("batchID", created.ID.Hex()),
+		zap.String("provider", provider),
+		zap.String("source", source),
+		zap.Int("lines", len(created.Lines)),
+		zap.Float64("totalAmount", created.TotalAmount.Float64()))
+	return created, nil
+}
+
+// createBatch enregistre le lot des retraits et les y rattache dans une même transaction
+func (s *PayoutBatchService) createBatch(ctx context.Context, provider, source string, minBalance models.Money, adminID *primitive.ObjectID, withdrawals []*models.Withdrawal) (*models.PayoutBatch, error) {
+	batch := &models.PayoutBatch{
+		ID:        primitive.NewObjectID(),
+		Provider:  provider,
+		Source:    source,
+		Currency:  s.rule.Currency,
+		Status:    models.PayoutStatusPending,
+		CreatedAt: s.now(),
+		CreatedBy: adminID,
+	}
+	if source == models.PayoutSourceBalances {
+		batch.MinBalance = minBalance
+	}
+	lines, err := s.buildLines(ctx, provider, withdrawals)
+	if err != nil {
+		return nil, err
+	}
+	if len(lines) == 0 {
+		return nil, errors.New("aucun paiement à inclure dans le lot")
+	}
+	batch.Lines = lines
+	for _, line := range lines {
+		batch.TotalAmount += line.Amount
+	}
+
+	ids := make([]primitive.ObjectID, 0, len(lines))
+	for _, line := range lines {
+		ids = append(ids, line.WithdrawalID)
+	}
+	var created *models.PayoutBatch
+	err = s.inTransaction(ctx, func(txCtx context.Context) error {
+		assigned, err := s.withdrawalRepo.AssignBatch(txCtx, ids, batch.ID)
+		if err != nil {
+			return err
+		}
+		if int(assigned) != len(ids) {
+			return errors.New("des retraits ont été inclus dans un autre lot entre-temps, recommencer")
+		}
+		created, err = s.batchRepo.Create(txCtx, batch)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+// refundUnbatched rembourse les retraits qui ne figurent pas dans le lot (tous si batch est nil)
+func (s *PayoutBatchService) refundUnbatched(ctx context.Context, withdrawals []*models.Withdrawal, batch *models.PayoutBatch) {
+	batched := make(map[primitive.ObjectID]bool)
+	if batch != nil {
+		for _, line := range batch.Lines {
+			batched[line.WithdrawalID] = true
+		}
+	}
+	for _, withdrawal := range withdrawals {
+		if batched[withdrawal.ID] {
+			continue
+		}
+		if err := s.payer.SettlePayout(ctx, withdrawal, false, "retrait non inclus dans le lot de paiement"); err != nil {
+			s.logger.Error("Failed to refund unbatched wallet payout",
+				zap.String("withdrawalID", withdrawal.ID.Hex()),
+				zap.String("clientID", withdrawal.ClientID.Hex()),
+				zap.Error(err))
+		}
+	}
+}
+
+// payBalances retire en entier les portefeuilles d'au moins minBalance dont le membre a un
+// numéro de téléphone utilisable; un membre dont le retrait échoue est ignoré
+func (s *PayoutBatchService) payBalances(ctx context.Context, minBalance models.Money, adminID *primitive.ObjectID) ([]*models.Withdrawal, error) {
+	clients, err := s.clientRepo.GetByMinWalletBalance(ctx, minBalance)
+	if err != nil {
+		return nil, fmt.Errorf("échec de la lecture des portefeuilles: %w", err)
+	}
+
+	var withdrawals []*models.Withdrawal
+	for _, client := range clients {
+		if client.Phone == nil || normalizeMSISDN(*client.Phone, s.rule.CountryCode) == "" {
+			s.logger.Warn("Member without phone number skipped from payout batch", zap.String("clientID", client.ID.Hex()))
+			continue
+		}
+		withdrawal, err := s.payer.Payout(ctx, client.ID, client.WalletBalance, "mobile", client.Phone, adminID)
+		if err != nil {
+			s.logger.Warn("Wallet payout skipped", zap.String("clientID", client.ID.Hex()), zap.Error(err))
+			continue
+		}
+		withdrawals = append(withdrawals, withdrawal)
+	}
+	return withdrawals, nil
+}
+
+// buildLines produit une ligne par retrait. Le numéro est la destination du retrait, à
+// défaut le téléphone du membre; un retrait sans numéro n'est pas inclus dans un lot
+// mobile money.
+func (s *PayoutBatchService) buildLines(ctx context.Context, provider string, withdrawals []*models.Withdrawal) ([]models.PayoutLine, error) {
+	ids := make([]primitive.ObjectID, 0, len(withdrawals))
+	for _, withdrawal := range withdrawals {
+		ids = append(ids, withdrawal.ClientID)
+	}
+	clients, err := s.clientRepo.GetByIDs(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("échec de la lecture des membres: %w", err)
+	}
+	byID := make(map[primitive.ObjectID]*models.Client, len(clients))
+	for _, client := range clients {
+		byID[client.ID] = client
+	}
+
+	lines := make([]models.PayoutLine, 0, len(withdrawals))
+	for _, withdrawal := range withdrawals {
+		if withdrawal.PaymentID == nil {
+			continue
+		}
+		client := byID[withdrawal.ClientID]
+		phone := withdrawal.Destination
+		if (phone == nil || *phone == "") && client != nil {
+			phone = client.Phone
+		}
+		number := ""
+		if phone != nil {
+			number = normalizeMSISDN(*phone, s.rule.CountryCode)
+		}
+		if number == "" && provider != models.PayoutProviderGeneric {
+			s.logger.Warn("Withdrawal without phone number skipped from payout batch", zap.String("withdrawalID", withdrawal.ID.Hex()))
+			continue
+		}
+
+		line := models.PayoutLine{
+			Reference:    withdrawal.PaymentID.Hex(),
+			WithdrawalID: withdrawal.ID,
+			PaymentID:    *withdrawal.PaymentID,
+			ClientID:     withdrawal.ClientID,
+			Phone:        number,
+			Amount:       withdrawal.NetAmount,
+			Status:       models.PayoutStatusPending,
+		}
+		if client != nil {
+			line.Name = client.Name
+		}
+		lines = append(lines, line)
+	}
+	return lines, nil
+}
+
+// File produit le fichier du lot dans le format de son opérateur et retourne son nom
+func (s *PayoutBatchService) File(ctx context.Context, id primitive.ObjectID) (string, string, error) {
+	batch, err := s.batchRepo.GetByID(ctx, id)
+	if err != nil {
+		return "", "", err
+	}
+	if batch == nil {
+		return "", "", errors.New("lot de paiement introuvable")
+	}
+
+	content, err := renderPayoutFile(batch)
+	if err != nil {
+		return "", "", err
+	}
+	filename := fmt.Sprintf("payout-%s-%s-%s.csv", batch.Provider, batch.CreatedAt.Format("20060102"), batch.ID.Hex())
+	return filename, content, nil
+}
+
+// ImportResults applique le fichier de résultat de l'opérateur aux lignes en attente du lot.
+// Une ligne déjà réglée est ignorée: le même fichier peut être importé plusieurs fois. Une
+// référence inconnue du lot fait rejeter le fichier entier.
+func (s *PayoutBatchService) ImportResults(ctx context.Context, id primitive.ObjectID, content string) (*models.PayoutBatch, error) {
+	batch, err := s.batchRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if batch == nil {
+		return nil, errors.New("lot de paiement introuvable")
+	}
+	results, err := parsePayoutResults(content)
+	if err != nil {
+		return nil, err
+	}
+
+	byReference := make(map[string]int, len(batch.Lines))
+	for i, line := range batch.Lines {
+		byReference[line.Reference] = i
+	}
+	for _, result := range results {
+		if _, ok := byReference[result.reference]; !ok {
+			return nil, fmt.Errorf("référence %s absente du lot", result.reference)
+		}
+	}
+
+	for _, result := range results {
+		line := &batch.Lines[byReference[result.reference]]
+		if line.Status != models.PayoutStatusPending {
+			continue
+		}
+		if err := s.settle(ctx, line, result); err != nil {
+			message := err.Error()
+			line.Error = &message
+			s.logger.Error("Failed to settle payout line", zap.String("reference", line.Reference), zap.Error(err))
+		}
+	}
+
+	batch.CompletedCount, batch.FailedCount = 0, 0
+	pending := 0
+	for _, line := range batch.Lines {
+		switch line.Status {
+		case models.PayoutLineCompleted:
+			batch.CompletedCount++
+		case models.PayoutLineFailed:
+			batch.FailedCount++
+		default:
+			pending++
+		}
+	}
+	if pending == 0 {
+		batch.Status = models.PayoutStatusCompleted
+	}
+	now := s.now()
+	batch.ImportedAt = &now
+	if err := s.batchRepo.SaveResults(ctx, batch); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Payout results imported",
+		zap.String("batchID", batch.ID.Hex()),
+		zap.Int("completed", batch.CompletedCount),
+		zap.Int("failed", batch.FailedCount),
+		zap.Int("pending", pending))
+	return batch, nil
+}
+
+// settle règle le retrait d'une ligne selon le résultat de l'opérateur
+func (s *PayoutBatchService) settle(ctx context.Context, line *models.PayoutLine, result payoutResult) error {
+	withdrawal, err := s.withdrawalRepo.GetByID(ctx, line.WithdrawalID)
+	if err != nil {
+		return err
+	}
+	if withdrawal == nil {
+		return errors.New("retrait introuvable")
+	}
+
+	reason := result.reason
+	if !result.completed && reason == "" {
+		reason = "versement refusé par l'opérateur"
+	}
+	if err := s.payer.SettlePayout(ctx, withdrawal, result.completed, reason); err != nil {
+		return err
+	}
+
+	line.Status = models.PayoutLineFailed
+	if result.completed {
+		line.Status = models.PayoutLineCompleted
+	}
+	if result.providerReference != "" {
+		line.ProviderReference = &result.providerReference
+	}
+	if reason != "" && !result.completed {
+		line.Error = &reason
+	} else {
+		line.Error = nil
+	}
+	return nil
+}
+
+// inTransaction exécute fn dans une transaction si le helper est disponible
+func (s *PayoutBatchService) inTransaction(ctx context.Context, fn func(context.Context) error) error {
+	if s.txHelper == nil {
+		return fn(ctx)
+	}
+	return s.txHelper.ExecuteTransaction(ctx, fn)
+}
@@ -0,0 +1,312 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"bureau/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+type mockPayoutBatchRepo struct {
+	batches   []*models.PayoutBatch
+	createErr error
+}
+
+func (m *mockPayoutBatchRepo) Create(ctx context.Context, batch *models.PayoutBatch) (*models.PayoutBatch, error) {
+	if m.createErr != nil {
+		return nil, m.createErr
+	}
+	m.batches = append(m.batches, batch)
+	return batch, nil
+}
+
+func (m *mockPayoutBatchRepo) GetByID(ctx context.Context, id primitive.ObjectID) (*models.PayoutBatch, error) {
+	for _, b := range m.batches {
+		if b.ID == id {
+			copied := *b
+			copied.Lines = slices.Clone(b.Lines)
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *mockPayoutBatchRepo) GetAll(ctx context.Context, paging *models.PagingInput) ([]*models.PayoutBatch, error) {
+	return m.batches, nil
+}
+
+func (m *mockPayoutBatchRepo) SaveResults(ctx context.Context, batch *models.PayoutBatch) error {
+	for i, b := range m.batches {
+		if b.ID == batch.ID {
+			m.batches[i] = batch
+		}
+	}
+	return nil
+}
+
+type mockPayoutClientRepo struct {
+	clients map[string]*models.Client
+}
+
+func (m *mockPayoutClientRepo) GetByIDs(ctx context.Context, ids []primitive.ObjectID) ([]*models.Client, error) {
+	var out []*models.Client
+	for _, id := range ids {
+		if client, ok := m.clients[id.Hex()]; ok {
+			out = append(out, client)
+		}
+	}
+	return out, nil
+}
+
+func (m *mockPayoutClientRepo) GetByMinWalletBalance(ctx context.Context, minBalance models.Money) ([]*models.Client, error) {
+	var out []*models.Client
+	for _, client := range m.clients {
+		if client.WalletBalance >= minBalance {
+			out = append(out, client)
+		}
+	}
+	return out, nil
+}
+
+type payoutTestEnv struct {
+	*withdrawalTestEnv
+	batches *PayoutBatchService
+	repo    *mockPayoutBatchRepo
+}
+
+func createTestPayoutBatchService() *payoutTestEnv {
+	withdrawals := createTestWithdrawalService(models.WithdrawalRule{}, time.Now())
+	env := &payoutTestEnv{withdrawalTestEnv: withdrawals, repo: &mockPayoutBatchRepo{}}
+	env.batches = NewPayoutBatchService(env.repo, withdrawals.repo, &mockPayoutClientRepo{clients: withdrawals.clientRepo.clients},
+		withdrawals.service, nil, models.PayoutRule{Currency: "USD", CountryCode: "243"}, zap.NewNop())
+	return env
+}
+
+func (env *payoutTestEnv) addMobileMember(balance models.Money, phone string) *models.Client {
+	client := env.addMember(balance)
+	client.Name = "Membre " + phone
+	client.Phone = &phone
+	return client
+}
+
+// approved crée et approuve un retrait
+func (env *payoutTestEnv) approved(t *testing.T, client *models.Client, amount models.Money, method string) *models.Withdrawal {
+	t.Helper()
+	ctx := context.Background()
+	withdrawal, err := env.service.Request(ctx, client.ID, amount, method, nil)
+	if err != nil {
+		t.Fatalf("Erreur inattendue: %v", err)
+	}
+	withdrawal, err = env.service.Approve(ctx, withdrawal.ID, nil)
+	if err != nil {
+		t.Fatalf("Erreur inattendue: %v", err)
+	}
+	return withdrawal
+}
+
+func TestNormalizeMSISDN(t *testing.T) {
+	tests := []struct {
+		phone, countryCode, expected string
+	}{
+		{"+243 81 234 5678", "243", "243812345678"},
+		{"0812345678", "243", "243812345678"},
+		{"00243812345678", "243", "243812345678"},
+		{"0812345678", "", "0812345678"},
+		{"", "243", ""},
+	}
+	for _, tt := range tests {
+		if got := normalizeMSISDN(tt.phone, tt.countryCode); got != tt.expected {
+			t.Errorf("normalizeMSISDN(%q, %q) = %q, expected %q", tt.phone, tt.countryCode, got, tt.expected)
+		}
+	}
+}
+
+// Test: chaque opérateur a son en-tête et son séparateur
+func TestRenderPayoutFile(t *testing.T) {
+	line := models.PayoutLine{Reference: "ref1", Name: "Jean", Phone: "243812345678", Amount: models.NewMoney(12.5)}
+	batch := &models.PayoutBatch{Currency: "CDF", Lines: []models.PayoutLine{line}}
+
+	expected := map[string]string{
+		models.PayoutProviderMpesa:  "MSISDN,Amount,Name,Reference\n243812345678,12.50,Jean,ref1\n",
+		models.PayoutProviderAirtel: "Msisdn,Amount,Currency,Reference,Remarks\n243812345678,12.50,CDF,ref1,Jean\n",
+		models.PayoutProviderOrange: "Numero;Montant;Devise;Nom;Reference\n243812345678;12.50;CDF;Jean;ref1\n",
+	}
+	for provider, want := range expected {
+		batch.Provider = provider
+		got, err := renderPayoutFile(batch)
+		if err != nil {
+			t.Fatalf("%s: erreur inattendue: %v", provider, err)
+		}
+		if got != want {
+			t.Errorf("%s: expected %q, got %q", provider, want, got)
+		}
+	}
+
+	batch.Provider = "inconnu"
+	if _, err := renderPayoutFile(batch); err == nil {
+		t.Error("An unknown provider should be rejected")
+	}
+}
+
+// Test: le fichier de résultat est lu quel que soit le séparateur, la casse et la langue des statuts
+func TestParsePayoutResults(t *testing.T) {
+	content := "\ufeffRéférence;Statut;Transaction ID;Motif\nref1;Réussi;TX1;\nref2;ECHEC;;Numéro invalide\n;;;\n"
+	results, err := parsePayoutResults(content)
+	if err != nil {
+		t.Fatalf("Erreur inattendue: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 results, got %+v", results)
+	}
+	if !results[0].completed || results[0].providerReference != "TX1" {
+		t.Errorf("Unexpected first result: %+v", results[0])
+	}
+	if results[1].completed || results[1].reason != "Numéro invalide" {
+		t.Errorf("Unexpected second result: %+v", results[1])
+	}
+
+	if _, err := parsePayoutResults("Reference,Status\nref1,peut-être\n"); err == nil {
+		t.Error("An unknown status should be rejected")
+	}
+	if _, err := parsePayoutResults("Msisdn,Amount\n243812345678,10\n"); err == nil {
+		t.Error("A file without reference and status columns should be rejected")
+	}
+}
+
+// Test: le lot reprend les retraits mobiles approuvés, puis le résultat règle chaque paiement
+func TestPayoutBatch_CreateAndImport(t *testing.T) {
+	ctx := context.Background()
+	env := createTestPayoutBatchService()
+	alice := env.addMobileMember(models.NewMoney(100), "0811111111")
+	bob := env.addMobileMember(models.NewMoney(100), "0822222222")
+	paid := env.approved(t, alice, models.NewMoney(40), "mobile")
+	failed := env.approved(t, bob, models.NewMoney(30), "mobile")
+	env.approved(t, bob, models.NewMoney(20), "bank")
+
+	batch, err := env.batches.Create(ctx, models.PayoutProviderMpesa, models.PayoutSourceWithdrawals, 0, nil)
+	if err != nil {
+		t.Fatalf("Erreur inattendue: %v", err)
+	}
+	if len(batch.Lines) != 2 || batch.TotalAmount != models.NewMoney(70) || batch.Lines[0].Phone != "243811111111" {
+		t.Fatalf("Unexpected batch: %+v", batch)
+	}
+	for _, payment := range env.paymentRepo.payments[:2] {
+		if payment.Status != "pending" {
+			t.Errorf("Batched payments should await the provider result, got %s", payment.Status)
+		}
+	}
+	if _, err := env.batches.Create(ctx, models.PayoutProviderMpesa, models.PayoutSourceWithdrawals, 0, nil); err == nil {
+		t.Error("Withdrawals already in a batch should not be batched again")
+	}
+
+	_, content, err := env.batches.File(ctx, batch.ID)
+	if err != nil {
+		t.Fatalf("Erreur inattendue: %v", err)
+	}
+	if !strings.Contains(content, paid.PaymentID.Hex()) || !strings.Contains(content, failed.PaymentID.Hex()) {
+		t.Errorf("The file should reference every payment, got %q", content)
+	}
+
+	if _, err := env.batches.ImportResults(ctx, batch.ID, "Reference,Status\ninconnue,success\n"); err == nil {
+		t.Error("A result file with an unknown reference should be rejected")
+	}
+
+	result := "Reference,Status,Receipt,Reason\n" +
+		paid.PaymentID.Hex() + ",success,QK12,\n" +
+		failed.PaymentID.Hex() + ",failed,,Numéro inconnu\n"
+	imported, err := env.batches.ImportResults(ctx, batch.ID, result)
+	if err != nil {
+		t.Fatalf("Erreur inattendue: %v", err)
+	}
+	if imported.Status != models.PayoutStatusCompleted || imported.CompletedCount != 1 || imported.FailedCount != 1 {
+		t.Errorf("Unexpected imported batch: %+v", imported)
+	}
+	if line := imported.Lines[0]; line.ProviderReference == nil || *line.ProviderReference != "QK12" {
+		t.Errorf("The provider receipt should be kept, got %+v", line)
+	}
+	if env.paymentRepo.payments[0].Status != "completed" || env.paymentRepo.payments[1].Status != "failed" {
+		t.Errorf("Unexpected payment statuses: %s / %s", env.paymentRepo.payments[0].Status, env.paymentRepo.payments[1].Status)
+	}
+
+	// Seul le versement réussi sort de la caisse; l'échec est rendu au portefeuille
+	if alice.WalletBalance != models.NewMoney(60) || bob.WalletBalance != models.NewMoney(80) {
+		t.Errorf("Expected balances 60 / 80, got %s / %s", alice.WalletBalance, bob.WalletBalance)
+	}
+	if len(env.caisse.transactions) != 1 || env.caisse.transactions[0].Type != "sortie" || env.caisse.transactions[0].Amount != models.NewMoney(40) {
+		t.Errorf("Expected a single caisse sortie of 40, got %+v", env.caisse.transactions)
+	}
+
+	// Réimporter le même fichier ne rembourse pas une deuxième fois
+	if _, err := env.batches.ImportResults(ctx, batch.ID, result); err != nil {
+		t.Fatalf("Erreur inattendue: %v", err)
+	}
+	if bob.WalletBalance != models.NewMoney(80) {
+		t.Errorf("A second import must not refund twice, got %s", bob.WalletBalance)
+	}
+}
+
+// Test: en mode solde, chaque portefeuille au-dessus du seuil est retiré en entier
+func TestPayoutBatch_Balances(t *testing.T) {
+	ctx := context.Background()
+	env := createTestPayoutBatchService()
+	rich := env.addMobileMember(models.NewMoney(120), "0811111111")
+	env.addMobileMember(models.NewMoney(30), "0822222222")
+	env.addMember(models.NewMoney(500)) // sans téléphone
+
+	if _, err := env.batches.Create(ctx, models.PayoutProviderOrange, models.PayoutSourceBalances, 0, nil); err == nil {
+		t.Error("The balance source should require a threshold")
+	}
+
+	batch, err := env.batches.Create(ctx, models.PayoutProviderOrange, models.PayoutSourceBalances, models.NewMoney(50), nil)
+	if err != nil {
+		t.Fatalf("Erreur inattendue: %v", err)
+	}
+	if len(batch.Lines) != 1 || batch.Lines[0].ClientID != rich.ID || batch.Lines[0].Amount != models.NewMoney(120) {
+		t.Fatalf("Unexpected batch: %+v", batch)
+	}
+	if rich.WalletBalance != 0 {
+		t.Errorf("The whole balance should be withdrawn, got %s", rich.WalletBalance)
+	}
+}
+
+// Test: si le lot ne peut pas être enregistré, les portefeuilles débités sont remboursés
+func TestPayoutBatch_BalancesRefundedOnFailure(t *testing.T) {
+	ctx := context.Background()
+	env := createTestPayoutBatchService()
+	rich := env.addMobileMember(models.NewMoney(120), "0811111111")
+	invalid := env.addMobileMember(models.NewMoney(80), "inconnu") // aucun chiffre
+	env.repo.createErr = errors.New("écriture refusée")
+
+	if _, err := env.batches.Create(ctx, models.PayoutProviderMpesa, models.PayoutSourceBalances, models.NewMoney(50), nil); err == nil {
+		t.Fatal("The batch creation error should be returned")
+	}
+	if rich.WalletBalance != models.NewMoney(120) {
+		t.Errorf("The wallet should be refunded, got %s", rich.WalletBalance)
+	}
+	if invalid.WalletBalance != models.NewMoney(80) {
+		t.Errorf("A member without a usable number should not be debited, got %s", invalid.WalletBalance)
+	}
+	if len(env.repo.batches) != 0 {
+		t.Errorf("No batch should be recorded, got %d", len(env.repo.batches))
+	}
+	if len(env.withdrawalTestEnv.repo.withdrawals) != 1 {
+		t.Fatalf("Expected one wallet payout, got %d", len(env.withdrawalTestEnv.repo.withdrawals))
+	}
+	for _, withdrawal := range env.withdrawalTestEnv.repo.withdrawals {
+		if withdrawal.Status != models.WithdrawalStatusFailed {
+			t.Errorf("The withdrawal should be marked as failed, got %s", withdrawal.Status)
+		}
+	}
+	for _, payment := range env.paymentRepo.payments {
+		if payment.Status != "failed" {
+			t.Errorf("The payment should be marked as failed, got %s", payment.Status)
+		}
+	}
+}
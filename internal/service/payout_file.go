@@ -0,0 +1,191 @@
+package service
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"bureau/internal/models"
+)
+
+// payoutFormat décrit la mise en page CSV du fichier de paiement en masse d'un opérateur,
+// telle qu'attendue par l'import de son portail
+type payoutFormat struct {
+	separator rune
+	header    []string
+	row       func(line models.PayoutLine, currency string) []string
+}
+
+var payoutFormats = map[string]payoutFormat{
+	// Portail M-Pesa (paiements B2C en masse)
+	models.PayoutProviderMpesa: {
+		separator: ',',
+		header:    []string{"MSISDN", "Amount", "Name", "Reference"},
+		row: func(line models.PayoutLine, currency string) []string {
+			return []string{line.Phone, line.Amount.String(), line.Name, line.Reference}
+		},
+	},
+	// Portail Airtel Money (bulk disbursement)
+	models.PayoutProviderAirtel: {
+		separator: ',',
+		header:    []string{"Msisdn", "Amount", "Currency", "Reference", "Remarks"},
+		row: func(line models.PayoutLine, currency string) []string {
+			return []string{line.Phone, line.Amount.String(), currency, line.Reference, line.Name}
+		},
+	},
+	// Portail Orange Money (paiement de masse), séparateur point-virgule
+	models.PayoutProviderOrange: {
+		separator: ';',
+		header:    []string{"Numero", "Montant", "Devise", "Nom", "Reference"},
+		row: func(line models.PayoutLine, currency string) []string {
+			return []string{line.Phone, line.Amount.String(), currency, line.Name, line.Reference}
+		},
+	},
+	// Export générique pour un autre opérateur ou une banque
+	models.PayoutProviderGeneric: {
+		separator: ',',
+		header:    []string{"reference", "client_id", "name", "phone", "amount", "currency", "withdrawal_id"},
+		row: func(line models.PayoutLine, currency string) []string {
+			return []string{line.Reference, line.ClientID.Hex(), line.Name, line.Phone, line.Amount.String(), currency, line.WithdrawalID.Hex()}
+		},
+	},
+}
+
+// renderPayoutFile produit le fichier du lot dans le format de son opérateur
+func renderPayoutFile(batch *models.PayoutBatch) (string, error) {
+	format, ok := payoutFormats[batch.Provider]
+	if !ok {
+		return "", fmt.Errorf("format de fichier inconnu: %s", batch.Provider)
+	}
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Comma = format.separator
+	if err := w.Write(format.header); err != nil {
+		return "", err
+	}
+	for _, line := range batch.Lines {
+		if err := w.Write(format.row(line, batch.Currency)); err != nil {
+			return "", err
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// normalizeMSISDN met un numéro au format international sans "+" attendu par les portails.
+// Un numéro national (commençant par 0) reçoit l'indicatif countryCode s'il est fourni.
+func normalizeMSISDN(phone, countryCode string) string {
+	var digits strings.Builder
+	for _, r := range phone {
+		if r >= '0' && r <= '9' {
+			digits.WriteRune(r)
+		}
+	}
+	number := digits.String()
+	if strings.HasPrefix(number, "00") {
+		return number[2:]
+	}
+	if strings.HasPrefix(number, "0") && countryCode != "" {
+		return countryCode + number[1:]
+	}
+	return number
+}
+
+// payoutResult est une ligne du fichier de résultat d'un opérateur
+type payoutResult struct {
+	reference         string
+	completed         bool
+	providerReference string
+	reason            string
+}
+
+// Noms de colonnes reconnus dans les fichiers de résultat des opérateurs
+var (
+	payoutReferenceColumns = []string{"reference", "ref", "référence", "external reference", "reference externe", "référence externe"}
+	payoutStatusColumns    = []string{"status", "statut", "result", "résultat", "resultat"}
+	payoutReceiptColumns   = []string{"receipt", "receipt no", "transaction id", "transaction_id", "txn id", "id transaction", "reçu"}
+	payoutReasonColumns    = []string{"reason", "error", "message", "motif"}
+)
+
+// Statuts reconnus dans les fichiers de résultat
+var (
+	payoutCompletedStatuses = []string{"success", "successful", "completed", "complete", "paid", "ok", "réussi", "reussi", "succès", "succes"}
+	payoutFailedStatuses    = []string{"failed", "failure", "fail", "error", "rejected", "cancelled", "échec", "echec", "échoué", "echoue"}
+)
+
+// parsePayoutResults lit le fichier de résultat d'un opérateur: un CSV (virgule ou
+// point-virgule) avec au moins une colonne de référence et une colonne de statut
+func parsePayoutResults(content string) ([]payoutResult, error) {
+	content = strings.TrimPrefix(content, "\ufeff") // BOM des exports Excel
+	firstLine, _, _ := strings.Cut(content, "\n")
+	r := csv.NewReader(strings.NewReader(content))
+	if strings.Count(firstLine, ";") > strings.Count(firstLine, ",") {
+		r.Comma = ';'
+	}
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("fichier de résultat illisible: %w", err)
+	}
+	if len(records) == 0 {
+		return nil, errors.New("le fichier de résultat est vide")
+	}
+
+	header := records[0]
+	referenceCol := payoutColumn(header, payoutReferenceColumns)
+	statusCol := payoutColumn(header, payoutStatusColumns)
+	if referenceCol < 0 || statusCol < 0 {
+		return nil, errors.New("le fichier de résultat doit contenir une colonne de référence et une colonne de statut")
+	}
+	receiptCol := payoutColumn(header, payoutReceiptColumns)
+	reasonCol := payoutColumn(header, payoutReasonColumns)
+
+	results := make([]payoutResult, 0, len(records)-1)
+	for i, record := range records[1:] {
+		reference := payoutField(record, referenceCol)
+		if reference == "" {
+			continue
+		}
+		status := strings.ToLower(payoutField(record, statusCol))
+		result := payoutResult{
+			reference:         reference,
+			providerReference: payoutField(record, receiptCol),
+			reason:            payoutField(record, reasonCol),
+		}
+		switch {
+		case slices.Contains(payoutCompletedStatuses, status):
+			result.completed = true
+		case slices.Contains(payoutFailedStatuses, status):
+			result.completed = false
+		default:
+			return nil, fmt.Errorf("ligne %d: statut inconnu %q", i+2, status)
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+func payoutColumn(header []string, names []string) int {
+	for i, column := range header {
+		if slices.Contains(names, strings.ToLower(strings.TrimSpace(column))) {
+			return i
+		}
+	}
+	return -1
+}
+
+func payoutField(record []string, col int) string {
+	if col < 0 || col >= len(record) {
+		return ""
+	}
+	return strings.TrimSpace(record[col])
+}
@@ -0,0 +1,119 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"bureau/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type placementRepository interface {
+	GetByID(ctx context.Context, id string) (*models.Client, error)
+	GetByIDs(ctx context.Context, ids []primitive.ObjectID) ([]*models.Client, error)
+}
+
+// findPlacement cherche, selon la stratégie, le parent et la position ("left" ou "right")
+// d'un nouveau membre dans le sous-arbre de placement de root
+func findPlacement(ctx context.Context, repo placementRepository, root *models.Client, strategy string) (*models.Client, string, error) {
+	switch strategy {
+	case models.PlacementDirect:
+		if root.LeftChildID == nil {
+			return root, "left", nil
+		}
+		if root.RightChildID == nil {
+			return root, "right", nil
+		}
+		return nil, "", errors.New("ce parent de placement a déjà 2 enfants (les positions gauche et droite sont prises). Veuillez choisir un autre parent de placement ou une stratégie de placement automatique")
+	case models.PlacementExtremeLeft:
+		return placeAtEnd(ctx, repo, root, "left")
+	case models.PlacementExtremeRight:
+		return placeAtEnd(ctx, repo, root, "right")
+	case models.PlacementWeakerLeg:
+		return placeAtEnd(ctx, repo, root, weakerSide(root))
+	case models.PlacementBalanced:
+		return placeBreadthFirst(ctx, repo, root)
+	default:
+		return nil, "", fmt.Errorf("stratégie de placement inconnue %q", strategy)
+	}
+}
+
+// placeAtEnd descend depuis root en suivant toujours le côté side et place le membre
+// sous le dernier nœud de la branche
+func placeAtEnd(ctx context.Context, repo placementRepository, root *models.Client, side string) (*models.Client, string, error) {
+	visited := map[primitive.ObjectID]bool{root.ID: true}
+	node := root
+	for {
+		childID := node.LeftChildID
+		if side == "right" {
+			childID = node.RightChildID
+		}
+		if childID == nil {
+			return node, side, nil
+		}
+		if visited[*childID] {
+			return nil, "", fmt.Errorf("cycle détecté dans l'arbre de placement au niveau de %s", childID.Hex())
+		}
+		visited[*childID] = true
+
+		child, err := repo.GetByID(ctx, childID.Hex())
+		if err != nil {
+			return nil, "", fmt.Errorf("enfant introuvable %s: %w", childID.Hex(), err)
+		}
+		node = child
+	}
+}
+
+// placeBreadthFirst parcourt le sous-arbre niveau par niveau (un chargement par niveau)
+// et retourne la première position libre, gauche avant droite
+func placeBreadthFirst(ctx context.Context, repo placementRepository, root *models.Client) (*models.Client, string, error) {
+	visited := map[primitive.ObjectID]bool{root.ID: true}
+	level := []*models.Client{root}
+	for len(level) > 0 {
+		var childIDs []primitive.ObjectID
+		for _, node := range level {
+			if node.LeftChildID == nil {
+				return node, "left", nil
+			}
+			if node.RightChildID == nil {
+				return node, "right", nil
+			}
+			for _, id := range []primitive.ObjectID{*node.LeftChildID, *node.RightChildID} {
+				if !visited[id] {
+					visited[id] = true
+					childIDs = append(childIDs, id)
+				}
+			}
+		}
+
+		children, err := repo.GetByIDs(ctx, childIDs)
+		if err != nil {
+			return nil, "", fmt.Errorf("échec du chargement de l'arbre de placement: %w", err)
+		}
+		// Garder l'ordre gauche-droite du niveau: la requête ne le garantit pas
+		byID := make(map[primitive.ObjectID]*models.Client, len(children))
+		for _, child := range children {
+			byID[child.ID] = child
+		}
+		level = level[:0:0]
+		for _, id := range childIDs {
+			if child, ok := byID[id]; ok {
+				level = append(level, child)
+			}
+		}
+	}
+	return nil, "", errors.New("aucune position libre trouvée dans l'arbre de placement")
+}
+
+// weakerSide retourne la jambe de plus faible volume (confirmé et en attente), puis celle
+// qui compte le moins de membres; la gauche en cas d'égalité
+func weakerSide(client *models.Client) string {
+	left := client.NetworkVolumeLeft + client.PendingVolumeLeft
+	right := client.NetworkVolumeRight + client.PendingVolumeRight
+	if right < left || (right == left && client.RightMembers < client.LeftMembers) {
+		return "right"
+	}
+	return "left"
+}
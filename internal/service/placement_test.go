@@ -0,0 +1,131 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"bureau/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// mockPlacementRepo réutilise l'arbre du mock des compteurs de jambes
+type mockPlacementRepo struct {
+	*mockLegCountRepo
+}
+
+// GetByIDs retourne les clients dans l'ordre inverse de la demande, comme une requête $in
+// qui ne garantit aucun ordre
+func (m *mockPlacementRepo) GetByIDs(ctx context.Context, ids []primitive.ObjectID) ([]*models.Client, error) {
+	var clients []*models.Client
+	for i := len(ids) - 1; i >= 0; i-- {
+		if client, err := m.GetByID(ctx, ids[i].Hex()); err == nil {
+			clients = append(clients, client)
+		}
+	}
+	return clients, nil
+}
+
+// newPlacementTree construit root avec deux niveaux complets à gauche et un seul enfant
+// gauche sous la jambe droite:
+//
+//	     root
+//	   /      \
+//	  a        b
+//	 / \      /
+//	c   d    e
+func newPlacementTree() (*mockPlacementRepo, map[string]*models.Client) {
+	repo := &mockPlacementRepo{&mockLegCountRepo{clients: make(map[string]*models.Client)}}
+	root := repo.enroll(nil, "")
+	a := repo.enroll(root, "left")
+	b := repo.enroll(root, "right")
+	c := repo.enroll(a, "left")
+	d := repo.enroll(a, "right")
+	e := repo.enroll(b, "left")
+	return repo, map[string]*models.Client{"root": root, "a": a, "b": b, "c": c, "d": d, "e": e}
+}
+
+func TestFindPlacement_Strategies(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		strategy string
+		setup    func(nodes map[string]*models.Client)
+		parent   string
+		position string
+	}{
+		{strategy: models.PlacementExtremeLeft, parent: "c", position: "left"},
+		{strategy: models.PlacementExtremeRight, parent: "b", position: "right"},
+		// Le premier trou en largeur, gauche avant droite: b n'a pas d'enfant droit
+		{strategy: models.PlacementBalanced, parent: "b", position: "right"},
+		{
+			strategy: models.PlacementWeakerLeg,
+			setup: func(nodes map[string]*models.Client) {
+				nodes["root"].NetworkVolumeLeft = models.NewVolume(300)
+				nodes["root"].NetworkVolumeRight = models.NewVolume(100)
+				nodes["root"].PendingVolumeRight = models.NewVolume(250)
+			},
+			parent:   "c",
+			position: "left",
+		},
+		{
+			strategy: models.PlacementWeakerLeg,
+			setup: func(nodes map[string]*models.Client) {
+				nodes["root"].NetworkVolumeLeft = models.NewVolume(300)
+				nodes["root"].NetworkVolumeRight = models.NewVolume(100)
+			},
+			parent:   "b",
+			position: "right",
+		},
+	}
+
+	for _, tt := range tests {
+		repo, nodes := newPlacementTree()
+		if tt.setup != nil {
+			tt.setup(nodes)
+		}
+		parent, position, err := findPlacement(ctx, repo, nodes["root"], tt.strategy)
+		if err != nil {
+			t.Fatalf("%s: erreur inattendue: %v", tt.strategy, err)
+		}
+		if parent.ID != nodes[tt.parent].ID || position != tt.position {
+			t.Errorf("%s: expected %s/%s, got %s/%s", tt.strategy, tt.parent, tt.position, parent.ID.Hex(), position)
+		}
+	}
+}
+
+func TestFindPlacement_BalancedKeepsLevelOrder(t *testing.T) {
+	ctx := context.Background()
+	repo, nodes := newPlacementTree()
+	// La jambe droite complète le niveau 2: le premier trou est sous c (le plus à gauche)
+	repo.enroll(nodes["b"], "right")
+
+	parent, position, err := findPlacement(ctx, repo, nodes["root"], models.PlacementBalanced)
+	if err != nil {
+		t.Fatalf("Erreur inattendue: %v", err)
+	}
+	if parent.ID != nodes["c"].ID || position != "left" {
+		t.Errorf("Expected c/left, got %s/%s", parent.ID.Hex(), position)
+	}
+}
+
+func TestFindPlacement_Direct(t *testing.T) {
+	ctx := context.Background()
+	repo, nodes := newPlacementTree()
+
+	parent, position, err := findPlacement(ctx, repo, nodes["b"], models.PlacementDirect)
+	if err != nil {
+		t.Fatalf("Erreur inattendue: %v", err)
+	}
+	if parent.ID != nodes["b"].ID || position != "right" {
+		t.Errorf("Expected b/right, got %s/%s", parent.ID.Hex(), position)
+	}
+
+	// Un parent complet n'est pas débordé en placement direct
+	if _, _, err := findPlacement(ctx, repo, nodes["root"], models.PlacementDirect); err == nil {
+		t.Error("Expected an error when both positions of the parent are taken")
+	}
+	if _, _, err := findPlacement(ctx, repo, nodes["root"], "random"); err == nil {
+		t.Error("Expected an error for an unknown strategy")
+	}
+}
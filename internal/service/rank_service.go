@@ -0,0 +1,271 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"bureau/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+type rankRepository interface {
+	Save(ctx context.Context, rank *models.RankDefinition) (*models.RankDefinition, error)
+	GetAll(ctx context.Context) ([]*models.RankDefinition, error)
+	Delete(ctx context.Context, code string) (bool, error)
+}
+
+type rankHistoryRepository interface {
+	Create(ctx context.Context, entry *models.RankHistory) (*models.RankHistory, error)
+	GetByClientID(ctx context.Context, clientID string) ([]*models.RankHistory, error)
+}
+
+type rankClientRepository interface {
+	GetByID(ctx context.Context, id string) (*models.Client, error)
+	GetAllIDs(ctx context.Context) ([]primitive.ObjectID, error)
+	UpdateRank(ctx context.Context, id string, rank, highestRank string) error
+}
+
+type pairedVolumeRepository interface {
+	GetPairedVolume(ctx context.Context, clientID primitive.ObjectID, from, to time.Time) (models.Volume, error)
+	GetPairedVolumes(ctx context.Context, from, to time.Time) (map[primitive.ObjectID]models.Volume, error)
+}
+
+type personalVolumeRepository interface {
+	GetPaidPoints(ctx context.Context, clientID primitive.ObjectID, from, to time.Time) (models.Volume, error)
+	GetPaidPointsByClient(ctx context.Context, from, to time.Time) (map[primitive.ObjectID]models.Volume, error)
+}
+
+// RankService gère les définitions de rangs et l'évaluation du rang des membres
+// Le rang est évalué sur les volumes d'une période de paie: à sa clôture pour tous les membres
+// (EvaluateAll) ou à la demande pour un membre (Evaluate); chaque changement est enregistré
+// dans l'historique des rangs.
+type RankService struct {
+	rankRepo    rankRepository
+	historyRepo rankHistoryRepository
+	clientRepo  rankClientRepository
+	cycleRepo   pairedVolumeRepository
+	saleRepo    personalVolumeRepository
+	logger      *zap.Logger
+	now         func() time.Time
+}
+
+// NewRankService crée un nouveau service de rangs
+func NewRankService(rankRepo rankRepository, historyRepo rankHistoryRepository, clientRepo rankClientRepository, cycleRepo pairedVolumeRepository, saleRepo personalVolumeRepository, logger *zap.Logger) *RankService {
+	return &RankService{
+		rankRepo:    rankRepo,
+		historyRepo: historyRepo,
+		clientRepo:  clientRepo,
+		cycleRepo:   cycleRepo,
+		saleRepo:    saleRepo,
+		logger:      logger,
+		now:         time.Now,
+	}
+}
+
+// Definitions récupère les définitions de rangs, du niveau le plus bas au plus haut
+func (s *RankService) Definitions(ctx context.Context) ([]*models.RankDefinition, error) {
+	return s.rankRepo.GetAll(ctx)
+}
+
+// SaveDefinition crée ou met à jour une définition de rang (identifiée par son code)
+func (s *RankService) SaveDefinition(ctx context.Context, rank *models.RankDefinition) (*models.RankDefinition, error) {
+	rank.Code = strings.TrimSpace(rank.Code)
+	rank.Name = strings.TrimSpace(rank.Name)
+	if err := validateRankDefinition(rank); err != nil {
+		return nil, err
+	}
+
+	saved, err := s.rankRepo.Save(ctx, rank)
+	if err != nil {
+		return nil, fmt.Errorf("échec de l'enregistrement du rang (code et niveau doivent être uniques): %w", err)
+	}
+
+	s.logger.Info("Rank definition saved", zap.String("code", saved.Code), zap.Int("level", saved.Level))
+	return saved, nil
+}
+
+// DeleteDefinition supprime une définition de rang
+// Les membres qui la portent la perdent à leur prochaine évaluation.
+func (s *RankService) DeleteDefinition(ctx context.Context, code string) (bool, error) {
+	deleted, err := s.rankRepo.Delete(ctx, code)
+	if err != nil {
+		return false, fmt.Errorf("échec de la suppression du rang: %w", err)
+	}
+	if !deleted {
+		return false, fmt.Errorf("rang %q introuvable", code)
+	}
+	return true, nil
+}
+
+// History récupère l'historique des rangs d'un membre, du plus récent au plus ancien
+func (s *RankService) History(ctx context.Context, clientID string) ([]*models.RankHistory, error) {
+	return s.historyRepo.GetByClientID(ctx, clientID)
+}
+
+// Evaluate recalcule à la demande le rang d'un membre sur les volumes de la période donnée
+func (s *RankService) Evaluate(ctx context.Context, clientID string, period *models.PayPeriod) (*models.RankEvaluation, error) {
+	client, err := s.clientRepo.GetByID(ctx, clientID)
+	if err != nil {
+		return nil, errors.New("client introuvable")
+	}
+	ranks, err := s.rankRepo.GetAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("échec du chargement des rangs: %w", err)
+	}
+	paired, err := s.cycleRepo.GetPairedVolume(ctx, client.ID, period.Start, period.End)
+	if err != nil {
+		return nil, fmt.Errorf("échec du calcul du volume apparié: %w", err)
+	}
+	personal, err := s.saleRepo.GetPaidPoints(ctx, client.ID, period.Start, period.End)
+	if err != nil {
+		return nil, fmt.Errorf("échec du calcul du volume personnel: %w", err)
+	}
+
+	return s.evaluate(ctx, client, ranks, period, paired, personal, models.RankSourceOnDemand)
+}
+
+// EvaluateAll recalcule le rang de tous les membres sur les volumes de la période (clôture
+// de période) et retourne le nombre de membres dont le rang a changé
+func (s *RankService) EvaluateAll(ctx context.Context, period *models.PayPeriod) (int, error) {
+	ranks, err := s.rankRepo.GetAll(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("échec du chargement des rangs: %w", err)
+	}
+	paired, err := s.cycleRepo.GetPairedVolumes(ctx, period.Start, period.End)
+	if err != nil {
+		return 0, fmt.Errorf("échec du calcul des volumes appariés: %w", err)
+	}
+	personal, err := s.saleRepo.GetPaidPointsByClient(ctx, period.Start, period.End)
+	if err != nil {
+		return 0, fmt.Errorf("échec du calcul des volumes personnels: %w", err)
+	}
+	ids, err := s.clientRepo.GetAllIDs(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("échec de la lecture des membres: %w", err)
+	}
+
+	changed := 0
+	for _, id := range ids {
+		if err := ctx.Err(); err != nil {
+			return changed, err
+		}
+		client, err := s.clientRepo.GetByID(ctx, id.Hex())
+		if err != nil {
+			s.logger.Error("Failed to load client for rank evaluation", zap.String("clientID", id.Hex()), zap.Error(err))
+			continue
+		}
+		evaluation, err := s.evaluate(ctx, client, ranks, period, paired[id], personal[id], models.RankSourcePeriodClose)
+		if err != nil {
+			s.logger.Error("Failed to evaluate rank", zap.String("clientID", id.Hex()), zap.Error(err))
+			continue
+		}
+		if evaluation.Changed {
+			changed++
+		}
+	}
+
+	s.logger.Info("Ranks evaluated", zap.String("period", period.Key), zap.Int("members", len(ids)), zap.Int("changed", changed))
+	return changed, nil
+}
+
+// evaluate attribue au membre le rang le plus élevé dont il remplit les conditions avec les
+// volumes de la période, met à jour son meilleur rang et historise le changement
+func (s *RankService) evaluate(ctx context.Context, client *models.Client, ranks []*models.RankDefinition, period *models.PayPeriod, paired, personal models.Volume, source string) (*models.RankEvaluation, error) {
+	evaluation := &models.RankEvaluation{
+		ClientID:       client.ID,
+		PreviousRank:   client.Rank,
+		HighestRank:    client.HighestRank,
+		PairedVolume:   paired,
+		PersonalVolume: personal,
+		QualifiedLegs:  qualifiedLegs(client),
+		PeriodKey:      period.Key,
+	}
+
+	var achieved *models.RankDefinition
+	levels := make(map[string]int, len(ranks))
+	for _, rank := range ranks {
+		levels[rank.Code] = rank.Level
+		if evaluation.PairedVolume >= rank.MinPairedVolume &&
+			evaluation.PersonalVolume >= rank.MinPersonalVolume &&
+			evaluation.QualifiedLegs >= rank.MinQualifiedLegs &&
+			(achieved == nil || rank.Level > achieved.Level) {
+			achieved = rank
+		}
+	}
+	if achieved != nil {
+		evaluation.Rank = achieved.Code
+		if evaluation.HighestRank == "" || achieved.Level > levels[evaluation.HighestRank] {
+			evaluation.HighestRank = achieved.Code
+		}
+	}
+
+	evaluation.Changed = evaluation.Rank != client.Rank
+	if !evaluation.Changed && evaluation.HighestRank == client.HighestRank {
+		return evaluation, nil
+	}
+
+	if err := s.clientRepo.UpdateRank(ctx, client.ID.Hex(), evaluation.Rank, evaluation.HighestRank); err != nil {
+		return nil, fmt.Errorf("échec de l'enregistrement du rang: %w", err)
+	}
+	if !evaluation.Changed {
+		return evaluation, nil
+	}
+
+	entry := &models.RankHistory{
+		ClientID:       client.ID,
+		Rank:           evaluation.Rank,
+		Level:          levels[evaluation.Rank],
+		PreviousRank:   client.Rank,
+		PreviousLevel:  levels[client.Rank],
+		PairedVolume:   evaluation.PairedVolume,
+		PersonalVolume: evaluation.PersonalVolume,
+		QualifiedLegs:  evaluation.QualifiedLegs,
+		PeriodKey:      period.Key,
+		Source:         source,
+		AchievedAt:     s.now(),
+	}
+	if _, err := s.historyRepo.Create(ctx, entry); err != nil {
+		return nil, fmt.Errorf("échec de l'historisation du rang: %w", err)
+	}
+
+	s.logger.Info("Rank changed",
+		zap.String("clientID", client.ID.Hex()),
+		zap.String("from", client.Rank),
+		zap.String("to", evaluation.Rank),
+		zap.String("source", source))
+	return evaluation, nil
+}
+
+// qualifiedLegs compte les jambes qui contiennent au moins un membre actif
+func qualifiedLegs(client *models.Client) int {
+	legs := 0
+	if client.LeftActives > 0 {
+		legs++
+	}
+	if client.RightActives > 0 {
+		legs++
+	}
+	return legs
+}
+
+// validateRankDefinition vérifie la cohérence d'une définition de rang
+func validateRankDefinition(rank *models.RankDefinition) error {
+	if rank.Code == "" || rank.Name == "" {
+		return errors.New("le code et le nom du rang sont obligatoires")
+	}
+	if rank.Level < 1 {
+		return errors.New("le niveau du rang doit être supérieur ou égal à 1")
+	}
+	if rank.MinPairedVolume < 0 || rank.MinPersonalVolume < 0 {
+		return errors.New("les volumes minimums ne peuvent pas être négatifs")
+	}
+	if rank.MinQualifiedLegs < 0 || rank.MinQualifiedLegs > 2 {
+		return errors.New("le nombre de jambes qualifiées doit être compris entre 0 et 2")
+	}
+	return nil
+}
@@ -0,0 +1,230 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"bureau/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+type mockRankRepo struct {
+	ranks []*models.RankDefinition
+}
+
+func (m *mockRankRepo) Save(ctx context.Context, rank *models.RankDefinition) (*models.RankDefinition, error) {
+	m.ranks = append(m.ranks, rank)
+	return rank, nil
+}
+
+func (m *mockRankRepo) GetAll(ctx context.Context) ([]*models.RankDefinition, error) {
+	return m.ranks, nil
+}
+
+func (m *mockRankRepo) Delete(ctx context.Context, code string) (bool, error) {
+	return false, nil
+}
+
+type mockRankHistoryRepo struct {
+	entries []*models.RankHistory
+}
+
+func (m *mockRankHistoryRepo) Create(ctx context.Context, entry *models.RankHistory) (*models.RankHistory, error) {
+	m.entries = append(m.entries, entry)
+	return entry, nil
+}
+
+func (m *mockRankHistoryRepo) GetByClientID(ctx context.Context, clientID string) ([]*models.RankHistory, error) {
+	return m.entries, nil
+}
+
+type mockRankClientRepo struct {
+	clients map[string]*models.Client
+}
+
+func (m *mockRankClientRepo) GetByID(ctx context.Context, id string) (*models.Client, error) {
+	client, ok := m.clients[id]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	copied := *client
+	return &copied, nil
+}
+
+func (m *mockRankClientRepo) GetAllIDs(ctx context.Context) ([]primitive.ObjectID, error) {
+	var ids []primitive.ObjectID
+	for _, client := range m.clients {
+		ids = append(ids, client.ID)
+	}
+	return ids, nil
+}
+
+func (m *mockRankClientRepo) UpdateRank(ctx context.Context, id string, rank, highestRank string) error {
+	m.clients[id].Rank = rank
+	m.clients[id].HighestRank = highestRank
+	return nil
+}
+
+// mockRankVolumes fournit les volumes apparié et personnel de la période et retient l'intervalle demandé
+type mockRankVolumes struct {
+	paired   map[primitive.ObjectID]models.Volume
+	personal map[primitive.ObjectID]models.Volume
+	from, to time.Time
+}
+
+func (m *mockRankVolumes) GetPairedVolume(ctx context.Context, clientID primitive.ObjectID, from, to time.Time) (models.Volume, error) {
+	m.from, m.to = from, to
+	return m.paired[clientID], nil
+}
+
+func (m *mockRankVolumes) GetPairedVolumes(ctx context.Context, from, to time.Time) (map[primitive.ObjectID]models.Volume, error) {
+	m.from, m.to = from, to
+	return m.paired, nil
+}
+
+func (m *mockRankVolumes) GetPaidPoints(ctx context.Context, clientID primitive.ObjectID, from, to time.Time) (models.Volume, error) {
+	return m.personal[clientID], nil
+}
+
+func (m *mockRankVolumes) GetPaidPointsByClient(ctx context.Context, from, to time.Time) (map[primitive.ObjectID]models.Volume, error) {
+	return m.personal, nil
+}
+
+var testRankPeriod = &models.PayPeriod{
+	Key:   "2025-03-03",
+	Start: time.Date(2025, 3, 3, 0, 0, 0, 0, time.UTC),
+	End:   time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC),
+}
+
+func createTestRankService() (*RankService, *mockRankClientRepo, *mockRankHistoryRepo, *mockRankVolumes) {
+	rankRepo := &mockRankRepo{ranks: []*models.RankDefinition{
+		{Code: "bronze", Name: "Bronze", Level: 1, MinPersonalVolume: models.NewVolume(50)},
+		{Code: "silver", Name: "Argent", Level: 2, MinPersonalVolume: models.NewVolume(50), MinPairedVolume: models.NewVolume(500), MinQualifiedLegs: 2},
+		{Code: "gold", Name: "Or", Level: 3, MinPersonalVolume: models.NewVolume(100), MinPairedVolume: models.NewVolume(2000), MinQualifiedLegs: 2},
+	}}
+	clientRepo := &mockRankClientRepo{clients: make(map[string]*models.Client)}
+	historyRepo := &mockRankHistoryRepo{}
+	volumes := &mockRankVolumes{paired: make(map[primitive.ObjectID]models.Volume), personal: make(map[primitive.ObjectID]models.Volume)}
+	return NewRankService(rankRepo, historyRepo, clientRepo, volumes, volumes, zap.NewNop()), clientRepo, historyRepo, volumes
+}
+
+func TestRankService_EvaluatePromotesAndRecordsHistory(t *testing.T) {
+	ctx := context.Background()
+	service, clientRepo, historyRepo, volumes := createTestRankService()
+
+	client := &models.Client{ID: primitive.NewObjectID(), LeftActives: 1, RightActives: 3}
+	clientRepo.clients[client.ID.Hex()] = client
+	volumes.personal[client.ID] = models.NewVolume(60)
+
+	evaluation, err := service.Evaluate(ctx, client.ID.Hex(), testRankPeriod)
+	if err != nil {
+		t.Fatalf("Erreur inattendue: %v", err)
+	}
+	if evaluation.Rank != "bronze" || !evaluation.Changed || client.HighestRank != "bronze" {
+		t.Errorf("Expected bronze without paired volume, got %+v", evaluation)
+	}
+
+	// Le volume apparié qualifie pour argent, pas pour or (points personnels insuffisants)
+	volumes.paired[client.ID] = models.NewVolume(2500)
+	evaluation, err = service.Evaluate(ctx, client.ID.Hex(), testRankPeriod)
+	if err != nil {
+		t.Fatalf("Erreur inattendue: %v", err)
+	}
+	if evaluation.Rank != "silver" || evaluation.PreviousRank != "bronze" || client.Rank != "silver" {
+		t.Errorf("Expected promotion to silver, got %+v", evaluation)
+	}
+
+	// Une nouvelle évaluation sans changement n'ajoute rien à l'historique
+	if _, err := service.Evaluate(ctx, client.ID.Hex(), testRankPeriod); err != nil {
+		t.Fatalf("Erreur inattendue: %v", err)
+	}
+	if len(historyRepo.entries) != 2 {
+		t.Fatalf("Expected 2 history entries, got %d", len(historyRepo.entries))
+	}
+	last := historyRepo.entries[1]
+	if last.Rank != "silver" || last.Level != 2 || last.PreviousLevel != 1 || last.Source != models.RankSourceOnDemand || last.PeriodKey != "2025-03-03" {
+		t.Errorf("Unexpected history entry: %+v", last)
+	}
+}
+
+func TestRankService_EvaluateAllKeepsHighestRank(t *testing.T) {
+	ctx := context.Background()
+	service, clientRepo, historyRepo, volumes := createTestRankService()
+
+	// Un membre argent qui n'a plus qu'une jambe active redescend bronze mais garde son meilleur rang
+	member := &models.Client{ID: primitive.NewObjectID(), LeftActives: 2, Rank: "silver", HighestRank: "silver"}
+	newcomer := &models.Client{ID: primitive.NewObjectID()}
+	clientRepo.clients[member.ID.Hex()] = member
+	clientRepo.clients[newcomer.ID.Hex()] = newcomer
+	volumes.personal[member.ID] = models.NewVolume(60)
+	volumes.personal[newcomer.ID] = models.NewVolume(10)
+	volumes.paired[member.ID] = models.NewVolume(800)
+
+	changed, err := service.EvaluateAll(ctx, testRankPeriod)
+	if err != nil {
+		t.Fatalf("Erreur inattendue: %v", err)
+	}
+	if changed != 1 {
+		t.Errorf("Expected 1 rank change, got %d", changed)
+	}
+	if member.Rank != "bronze" || member.HighestRank != "silver" {
+		t.Errorf("Expected bronze with silver as highest rank, got %s/%s", member.Rank, member.HighestRank)
+	}
+	if newcomer.Rank != "" || newcomer.HighestRank != "" {
+		t.Errorf("Expected no rank for the newcomer, got %s/%s", newcomer.Rank, newcomer.HighestRank)
+	}
+	if len(historyRepo.entries) != 1 || historyRepo.entries[0].Source != models.RankSourcePeriodClose {
+		t.Errorf("Expected one period-close history entry, got %+v", historyRepo.entries)
+	}
+}
+
+// Test: le rang est évalué sur les volumes de la période, pas sur les points cumulés du membre
+func TestRankService_EvaluatesPeriodVolumes(t *testing.T) {
+	ctx := context.Background()
+	service, clientRepo, historyRepo, volumes := createTestRankService()
+
+	// Bronze au cumul, mais aucune vente payée ni aucun cycle dans la période
+	client := &models.Client{ID: primitive.NewObjectID(), Points: models.NewVolume(5000), LeftActives: 1, RightActives: 1, Rank: "bronze", HighestRank: "bronze"}
+	clientRepo.clients[client.ID.Hex()] = client
+
+	if _, err := service.EvaluateAll(ctx, testRankPeriod); err != nil {
+		t.Fatalf("Erreur inattendue: %v", err)
+	}
+	if !volumes.from.Equal(testRankPeriod.Start) || !volumes.to.Equal(testRankPeriod.End) {
+		t.Errorf("Expected the period window, got [%s, %s)", volumes.from, volumes.to)
+	}
+	if client.Rank != "" || client.HighestRank != "bronze" {
+		t.Errorf("Expected no rank for an inactive period, got %s/%s", client.Rank, client.HighestRank)
+	}
+	if len(historyRepo.entries) != 1 || historyRepo.entries[0].PersonalVolume != 0 || historyRepo.entries[0].PeriodKey != testRankPeriod.Key {
+		t.Errorf("Expected one history entry with the period volumes, got %+v", historyRepo.entries)
+	}
+}
+
+func TestRankService_SaveDefinitionValidates(t *testing.T) {
+	service, _, _, _ := createTestRankService()
+
+	invalid := []*models.RankDefinition{
+		{Code: " ", Name: "Vide", Level: 1},
+		{Code: "zero", Name: "Zéro", Level: 0},
+		{Code: "legs", Name: "Jambes", Level: 4, MinQualifiedLegs: 3},
+		{Code: "neg", Name: "Négatif", Level: 4, MinPairedVolume: models.NewVolume(-1)},
+	}
+	for _, rank := range invalid {
+		if _, err := service.SaveDefinition(context.Background(), rank); err == nil {
+			t.Errorf("Expected %+v to be rejected", rank)
+		}
+	}
+
+	saved, err := service.SaveDefinition(context.Background(), &models.RankDefinition{Code: " diamond ", Name: "Diamant", Level: 4})
+	if err != nil {
+		t.Fatalf("Erreur inattendue: %v", err)
+	}
+	if saved.Code != "diamond" {
+		t.Errorf("Expected trimmed code, got %q", saved.Code)
+	}
+}
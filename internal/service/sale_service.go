@@ -2,6 +2,8 @@ package service
 
 import (
 	"context"
+	"errors"
+	"fmt"
 
 	"bureau/internal/models"
 	"bureau/internal/store"
@@ -10,14 +12,26 @@ import (
 )
 
 type SaleService struct {
-	saleRepo *store.SaleRepository
-	logger   *zap.Logger
+	saleRepo    *store.SaleRepository
+	productRepo *store.ProductRepository
+	clients     *ClientService
+	caisse      *CaisseService
+	fastStart   *FastStartBonusService
+	clawback    *ClawbackService
+	txHelper    transactionHelper
+	logger      *zap.Logger
 }
 
-func NewSaleService(saleRepo *store.SaleRepository, logger *zap.Logger) *SaleService {
+func NewSaleService(saleRepo *store.SaleRepository, productRepo *store.ProductRepository, clients *ClientService, caisse *CaisseService, fastStart *FastStartBonusService, clawback *ClawbackService, txHelper transactionHelper, logger *zap.Logger) *SaleService {
 	return &SaleService{
-		saleRepo: saleRepo,
-		logger:   logger,
+		saleRepo:    saleRepo,
+		productRepo: productRepo,
+		clients:     clients,
+		caisse:      caisse,
+		fastStart:   fastStart,
+		clawback:    clawback,
+		txHelper:    txHelper,
+		logger:      logger,
 	}
 }
 
@@ -29,16 +43,211 @@ func (s *SaleService) GetByID(ctx context.Context, id string) (*models.Sale, err
 	return s.saleRepo.GetByID(ctx, id)
 }
 
-func (s *SaleService) Create(ctx context.Context, sale *models.Sale) (*models.Sale, error) {
-	return s.saleRepo.Create(ctx, sale)
+// Create enregistre la vente de buyer avec ses effets dans une même transaction: sortie de stock,
+// points de l'acheteur et volume de son upline, entrée de caisse du montant encaissé et, pour une
+// vente payée, bonus de démarrage rapide du parrain. Le moteur binaire évalue ensuite les
+// ancêtres dont le volume confirmé a augmenté.
+func (s *SaleService) Create(ctx context.Context, sale *models.Sale, buyer *models.Client) (*models.Sale, error) {
+	var created *models.Sale
+	var credited []*models.Client
+	err := s.inTransaction(ctx, func(txCtx context.Context) error {
+		if sale.ProductID != nil && sale.Quantity > 0 && s.productRepo != nil {
+			if err := s.productRepo.DecrementStock(txCtx, *sale.ProductID, sale.Quantity); err != nil {
+				return fmt.Errorf("échec de la mise à jour du stock: %w", err)
+			}
+		}
+
+		var err error
+		created, err = s.saleRepo.Create(txCtx, sale)
+		if err != nil {
+			return err
+		}
+
+		// Les points = points du produit × quantité achetée; ils ne sont confirmés que lorsque la vente est payée
+		// Une vente payée sans points met tout de même à jour l'activité de l'acheteur
+		if s.clients != nil {
+			credited, err = s.clients.ApplySaleVolume(txCtx, buyer, created.Points, created.Status)
+			if err != nil {
+				return fmt.Errorf("échec de l'ajout des points au client: %w", err)
+			}
+		}
+
+		if err := s.recordCaisseEntry(txCtx, created, buyer); err != nil {
+			return err
+		}
+		return s.payFastStartBonus(txCtx, created)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.evaluateBinary(ctx, credited)
+	return created, nil
+}
+
+// recordCaisseEntry ajoute à la caisse le montant encaissé à la création d'une vente:
+// le montant total d'une vente payée, le montant déjà payé d'une vente partielle
+func (s *SaleService) recordCaisseEntry(ctx context.Context, sale *models.Sale, buyer *models.Client) error {
+	if s.caisse == nil {
+		return nil
+	}
+
+	var amount models.Money
+	var desc string
+	switch {
+	case sale.Status == "paid":
+		amount = sale.Amount
+		desc = fmt.Sprintf("Vente de produit - Client: %s", buyer.Name)
+	case sale.Status == "partial" && sale.PaidAmount != nil:
+		amount = *sale.PaidAmount
+		desc = fmt.Sprintf("Vente partielle - Client: %s (Montant payé: %s / %s)", buyer.Name, *sale.PaidAmount, sale.Amount)
+	default:
+		return nil
+	}
+
+	saleRef := sale.ID.Hex()
+	refType := "sale"
+	_, err := s.caisse.AddTransaction(ctx, &models.CaisseTransaction{
+		Type:          "entree",
+		Amount:        amount,
+		Description:   &desc,
+		Reference:     &saleRef,
+		ReferenceType: &refType,
+	})
+	if err != nil {
+		return fmt.Errorf("échec de l'entrée de caisse: %w", err)
+	}
+	return nil
 }
 
+// evaluateBinary fait évaluer par le moteur binaire, après la transaction de la vente,
+// les ancêtres dont le volume confirmé a augmenté
+func (s *SaleService) evaluateBinary(ctx context.Context, credited []*models.Client) {
+	if s.clients == nil {
+		return
+	}
+	s.clients.EvaluateBinary(ctx, credited)
+}
+
+// Update met à jour une vente; le passage au statut payé confirme son volume et déclenche le
+// bonus de démarrage rapide du parrain, l'annulation reprend les effets de la vente, dans la
+// même transaction. Une vente annulée ne peut plus être modifiée, une vente payée ne peut plus
+// revenir en attente (voir validateStatusChange); le client, le produit, la quantité et le
+// montant d'une vente enregistrée ne changent plus (voir validateSaleEdit).
 func (s *SaleService) Update(ctx context.Context, id string, sale *models.Sale) (*models.Sale, error) {
-	return s.saleRepo.Update(ctx, id, sale)
+	var updated *models.Sale
+	var credited []*models.Client
+	err := s.inTransaction(ctx, func(txCtx context.Context) error {
+		existing, err := s.saleRepo.GetByID(txCtx, id)
+		if err != nil {
+			return fmt.Errorf("vente introuvable: %w", err)
+		}
+		if err := validateStatusChange(existing.Status, sale.Status); err != nil {
+			return err
+		}
+		if err := validateSaleEdit(existing, sale); err != nil {
+			return err
+		}
+		updated, err = s.saleRepo.Update(txCtx, id, sale)
+		if err != nil {
+			return err
+		}
+		if updated.Status == "cancelled" {
+			return s.reverse(txCtx, existing, models.ClawbackReasonCancelled)
+		}
+
+		// Confirmer le volume lorsque la vente passe à "paid" (l'activité de l'acheteur est
+		// recalculée même pour une vente sans points)
+		if existing.Status != updated.Status && s.clients != nil {
+			buyer, err := s.clients.GetByID(txCtx, existing.ClientID.Hex())
+			if err != nil {
+				return fmt.Errorf("acheteur introuvable: %w", err)
+			}
+			credited, err = s.clients.TransitionSaleVolume(txCtx, buyer, existing.Points, existing.Status, updated.Status)
+			if err != nil {
+				return fmt.Errorf("échec de la mise à jour du volume: %w", err)
+			}
+		}
+		return s.payFastStartBonus(txCtx, updated)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.evaluateBinary(ctx, credited)
+	return updated, nil
+}
+
+// validateStatusChange refuse de modifier une vente annulée et de ramener une vente payée en
+// attente ou partielle: son volume confirmé a pu être apparié par des cycles et ses commissions
+// versées. Seule l'annulation, qui passe par la reprise, retire ce volume.
+func validateStatusChange(oldStatus, newStatus string) error {
+	if oldStatus == "cancelled" {
+		return errors.New("une vente annulée ne peut plus être modifiée")
+	}
+	if oldStatus == "paid" && newStatus != "paid" && newStatus != "cancelled" {
+		return errors.New("une vente payée ne peut pas revenir en attente: annulez-la pour reprendre ses effets")
+	}
+	return nil
+}
+
+// validateSaleEdit refuse de changer l'acheteur, le produit, la quantité ou le montant d'une
+// vente: son stock, son volume, sa caisse et ses commissions ont été enregistrés sur ces valeurs
+// et la reprise les relit sur le document de la vente
+func validateSaleEdit(existing, sale *models.Sale) error {
+	sameProduct := (existing.ProductID == nil) == (sale.ProductID == nil) &&
+		(existing.ProductID == nil || *existing.ProductID == *sale.ProductID)
+	if existing.ClientID != sale.ClientID || !sameProduct || existing.Quantity != sale.Quantity || existing.Amount != sale.Amount {
+		return errors.New("le client, le produit, la quantité et le montant d'une vente ne peuvent plus être modifiés: annulez-la et enregistrez une nouvelle vente")
+	}
+	return nil
+}
+
+// reverse reprend les effets d'une vente (voir ClawbackService)
+func (s *SaleService) reverse(ctx context.Context, sale *models.Sale, reason string) error {
+	if s.clawback == nil {
+		return nil
+	}
+	if _, err := s.clawback.ReverseSale(ctx, sale, reason); err != nil {
+		return fmt.Errorf("échec de la reprise de la vente: %w", err)
+	}
+	return nil
+}
+
+func (s *SaleService) payFastStartBonus(ctx context.Context, sale *models.Sale) error {
+	if s.fastStart == nil {
+		return nil
+	}
+	if _, err := s.fastStart.OnSalePaid(ctx, sale); err != nil {
+		return fmt.Errorf("échec du bonus de démarrage rapide: %w", err)
+	}
+	return nil
+}
+
+// inTransaction exécute fn dans une transaction si le helper est disponible
+func (s *SaleService) inTransaction(ctx context.Context, fn func(context.Context) error) error {
+	if s.txHelper == nil {
+		return fn(ctx)
+	}
+	return s.txHelper.ExecuteTransaction(ctx, fn)
 }
 
+// Delete supprime une vente et reprend ses effets dans la même transaction
+// (une vente déjà annulée a déjà été reprise)
 func (s *SaleService) Delete(ctx context.Context, id string) (bool, error) {
-	err := s.saleRepo.Delete(ctx, id)
+	err := s.inTransaction(ctx, func(txCtx context.Context) error {
+		existing, err := s.saleRepo.GetByID(txCtx, id)
+		if err != nil {
+			return fmt.Errorf("vente introuvable: %w", err)
+		}
+		if err := s.saleRepo.Delete(txCtx, id); err != nil {
+			return err
+		}
+		if existing.Status == "cancelled" {
+			return nil
+		}
+		return s.reverse(txCtx, existing, models.ClawbackReasonDeleted)
+	})
 	return err == nil, err
 }
 
@@ -50,7 +259,7 @@ func (s *SaleService) GetBySponsorID(ctx context.Context, sponsorID string) ([]*
 	return s.saleRepo.GetBySponsorID(ctx, sponsorID)
 }
 
-func (s *SaleService) GetTotalSales(ctx context.Context, filter *models.FilterInput) (float64, error) {
+func (s *SaleService) GetTotalSales(ctx context.Context, filter *models.FilterInput) (models.Money, error) {
 	return s.saleRepo.GetTotalSales(ctx, filter)
 }
 
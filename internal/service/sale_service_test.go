@@ -0,0 +1,65 @@
+package service
+
+import (
+	"testing"
+
+	"bureau/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestValidateStatusChange(t *testing.T) {
+	tests := []struct {
+		oldStatus string
+		newStatus string
+		wantError bool
+	}{
+		{"pending", "paid", false},
+		{"pending", "partial", false},
+		{"partial", "paid", false},
+		{"pending", "cancelled", false},
+		{"paid", "paid", false},
+		{"paid", "cancelled", false},
+		// Le volume d'une vente payée a pu être apparié: seule l'annulation le reprend
+		{"paid", "pending", true},
+		{"paid", "partial", true},
+		{"cancelled", "paid", true},
+		{"cancelled", "cancelled", true},
+	}
+
+	for _, tt := range tests {
+		err := validateStatusChange(tt.oldStatus, tt.newStatus)
+		if (err != nil) != tt.wantError {
+			t.Errorf("validateStatusChange(%q, %q) error = %v, wantError %v", tt.oldStatus, tt.newStatus, err, tt.wantError)
+		}
+	}
+}
+
+func TestValidateSaleEdit(t *testing.T) {
+	clientID := primitive.NewObjectID()
+	productID := primitive.NewObjectID()
+	existing := &models.Sale{ClientID: clientID, ProductID: &productID, Quantity: 2, Amount: models.NewMoney(40), Status: "pending"}
+
+	otherProduct := primitive.NewObjectID()
+	tests := []struct {
+		name      string
+		edit      func(s *models.Sale)
+		wantError bool
+	}{
+		{"note et statut", func(s *models.Sale) { note := "livrée"; s.Note = &note; s.Status = "paid" }, false},
+		{"même produit, autre pointeur", func(s *models.Sale) { same := productID; s.ProductID = &same }, false},
+		{"autre client", func(s *models.Sale) { s.ClientID = primitive.NewObjectID() }, true},
+		{"autre produit", func(s *models.Sale) { s.ProductID = &otherProduct }, true},
+		{"sans produit", func(s *models.Sale) { s.ProductID = nil }, true},
+		{"autre quantité", func(s *models.Sale) { s.Quantity = 3 }, true},
+		{"autre montant", func(s *models.Sale) { s.Amount = models.NewMoney(50) }, true},
+	}
+
+	for _, tt := range tests {
+		sale := *existing
+		tt.edit(&sale)
+		if err := validateSaleEdit(existing, &sale); (err != nil) != tt.wantError {
+			t.Errorf("%s: error = %v, wantError %v", tt.name, err, tt.wantError)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"bureau/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+// walletLedger enregistre une écriture au journal et l'applique au portefeuille du membre
+type walletLedger interface {
+	Post(ctx context.Context, entry *models.WalletTransaction) (*models.WalletTransaction, error)
+}
+
+type walletRepository interface {
+	walletLedger
+	GetByClientID(ctx context.Context, clientID primitive.ObjectID, paging *models.PagingInput) ([]*models.WalletTransaction, error)
+	LedgerTotals(ctx context.Context) (map[primitive.ObjectID]models.WalletTotals, error)
+	Projections(ctx context.Context) (map[primitive.ObjectID]models.WalletTotals, error)
+	SetProjection(ctx context.Context, clientID primitive.ObjectID, totals models.WalletTotals) error
+}
+
+// commissionEntry construit l'écriture de portefeuille d'une commission: crédit d'un gain,
+// débit d'une reprise (montant négatif). Les deux modifient aussi le total des gains.
+func commissionEntry(commission *models.Commission) *models.WalletTransaction {
+	entry := &models.WalletTransaction{
+		ClientID:       commission.ClientID,
+		Direction:      models.WalletCredit,
+		Type:           models.WalletEntryCommission,
+		Amount:         commission.Amount,
+		Earnings:       commission.Amount,
+		CounterAccount: models.WalletAccountCommissions,
+		ReferenceType:  models.WalletReferenceCommission,
+		ReferenceID:    commission.ID,
+		Description:    commission.Type,
+		CreatedAt:      commission.Date,
+	}
+	if commission.Amount < 0 {
+		entry.Direction = models.WalletDebit
+		entry.Type = models.WalletEntryClawback
+		entry.Amount = -commission.Amount
+	}
+	return entry
+}
+
+// WalletService expose le journal des portefeuilles et vérifie que la projection portée par
+// les clients (walletBalance, totalEarnings) correspond à la somme des écritures
+type WalletService struct {
+	walletRepo walletRepository
+	logger     *zap.Logger
+}
+
+// NewWalletService crée un nouveau service de portefeuille
+func NewWalletService(walletRepo walletRepository, logger *zap.Logger) *WalletService {
+	return &WalletService{
+		walletRepo: walletRepo,
+		logger:     logger,
+	}
+}
+
+// GetTransactions récupère les écritures d'un membre, de la plus récente à la plus ancienne
+func (s *WalletService) GetTransactions(ctx context.Context, clientID primitive.ObjectID, paging *models.PagingInput) ([]*models.WalletTransaction, error) {
+	return s.walletRepo.GetByClientID(ctx, clientID, paging)
+}
+
+// Check compare la projection de chaque client au journal et retourne les écarts
+func (s *WalletService) Check(ctx context.Context) ([]models.WalletDiscrepancy, error) {
+	ledger, err := s.walletRepo.LedgerTotals(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("échec de la lecture du journal: %w", err)
+	}
+	projections, err := s.walletRepo.Projections(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("échec de la lecture des portefeuilles: %w", err)
+	}
+
+	return compareWallets(ledger, projections), nil
+}
+
+// Rebuild remplace la projection des clients en écart par les totaux du journal et
+// retourne le nombre de clients corrigés
+func (s *WalletService) Rebuild(ctx context.Context) (int, error) {
+	discrepancies, err := s.Check(ctx)
+	if err != nil {
+		return 0, err
+	}
+	for _, d := range discrepancies {
+		if err := s.walletRepo.SetProjection(ctx, d.ClientID, d.Ledger); err != nil {
+			return 0, fmt.Errorf("échec de la correction du portefeuille %s: %w", d.ClientID.Hex(), err)
+		}
+	}
+
+	s.logger.Info("Wallet projections rebuilt", zap.Int("corrected", len(discrepancies)))
+	return len(discrepancies), nil
+}
+
+// OpenBalances reprend dans le journal les soldes antérieurs à sa mise en place: une écriture
+// d'ouverture porte l'écart entre la projection et le journal de chaque client. À lancer une
+// seule fois, à la migration; ensuite les écarts se corrigent avec Rebuild.
+func (s *WalletService) OpenBalances(ctx context.Context) (int, error) {
+	discrepancies, err := s.Check(ctx)
+	if err != nil {
+		return 0, err
+	}
+	for _, d := range discrepancies {
+		entry := &models.WalletTransaction{
+			ClientID:       d.ClientID,
+			Direction:      models.WalletCredit,
+			Type:           models.WalletEntryOpening,
+			Amount:         d.Projection.Balance - d.Ledger.Balance,
+			Earnings:       d.Projection.Earnings - d.Ledger.Earnings,
+			CounterAccount: models.WalletAccountOpening,
+			ReferenceType:  models.WalletReferenceClient,
+			ReferenceID:    d.ClientID,
+		}
+		if entry.Amount < 0 {
+			entry.Direction = models.WalletDebit
+			entry.Amount = -entry.Amount
+		}
+		// L'écriture ne doit pas déplacer la projection qu'elle reprend
+		if _, err := s.walletRepo.Post(ctx, entry); err != nil {
+			return 0, fmt.Errorf("échec de l'ouverture du portefeuille %s: %w", d.ClientID.Hex(), err)
+		}
+		if err := s.walletRepo.SetProjection(ctx, d.ClientID, d.Projection); err != nil {
+			return 0, err
+		}
+	}
+
+	s.logger.Info("Wallet opening balances recorded", zap.Int("clients", len(discrepancies)))
+	return len(discrepancies), nil
+}
+
+// compareWallets retourne les clients dont la projection s'écarte du journal, au centime
+// près. Un client sans écriture doit avoir un portefeuille vide.
+func compareWallets(ledger, projections map[primitive.ObjectID]models.WalletTotals) []models.WalletDiscrepancy {
+	var discrepancies []models.WalletDiscrepancy
+	for clientID, projection := range projections {
+		totals := ledger[clientID]
+		if totals != projection {
+			discrepancies = append(discrepancies, models.WalletDiscrepancy{
+				ClientID:   clientID,
+				Ledger:     totals,
+				Projection: projection,
+			})
+		}
+	}
+	sort.Slice(discrepancies, func(i, j int) bool {
+		return discrepancies[i].ClientID.Hex() < discrepancies[j].ClientID.Hex()
+	})
+	return discrepancies
+}
@@ -0,0 +1,146 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"bureau/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+// mockWalletRepo tient le journal en mémoire et l'applique aux clients des mocks, comme
+// WalletRepository l'applique à la collection clients
+type mockWalletRepo struct {
+	clients map[string]*models.Client
+	entries []*models.WalletTransaction
+}
+
+func newMockWalletRepo(clients map[string]*models.Client) *mockWalletRepo {
+	return &mockWalletRepo{clients: clients}
+}
+
+func (m *mockWalletRepo) Post(ctx context.Context, entry *models.WalletTransaction) (*models.WalletTransaction, error) {
+	if entry.ID.IsZero() {
+		entry.ID = primitive.NewObjectID()
+	}
+	if client, ok := m.clients[entry.ClientID.Hex()]; ok {
+		client.WalletBalance += entry.SignedAmount()
+		client.TotalEarnings += entry.Earnings
+		entry.BalanceAfter = client.WalletBalance
+	}
+	m.entries = append(m.entries, entry)
+	return entry, nil
+}
+
+func (m *mockWalletRepo) Debit(ctx context.Context, entry *models.WalletTransaction) (*models.WalletTransaction, error) {
+	if client, ok := m.clients[entry.ClientID.Hex()]; !ok || client.WalletBalance < entry.Amount {
+		return nil, errors.New("solde du portefeuille insuffisant")
+	}
+	return m.Post(ctx, entry)
+}
+
+func (m *mockWalletRepo) GetByClientID(ctx context.Context, clientID primitive.ObjectID, paging *models.PagingInput) ([]*models.WalletTransaction, error) {
+	var out []*models.WalletTransaction
+	for _, entry := range m.entries {
+		if entry.ClientID == clientID {
+			out = append(out, entry)
+		}
+	}
+	return out, nil
+}
+
+func (m *mockWalletRepo) LedgerTotals(ctx context.Context) (map[primitive.ObjectID]models.WalletTotals, error) {
+	totals := make(map[primitive.ObjectID]models.WalletTotals)
+	for _, entry := range m.entries {
+		t := totals[entry.ClientID]
+		t.Balance += entry.SignedAmount()
+		t.Earnings += entry.Earnings
+		totals[entry.ClientID] = t
+	}
+	return totals, nil
+}
+
+func (m *mockWalletRepo) Projections(ctx context.Context) (map[primitive.ObjectID]models.WalletTotals, error) {
+	projections := make(map[primitive.ObjectID]models.WalletTotals)
+	for _, client := range m.clients {
+		projections[client.ID] = models.WalletTotals{Balance: client.WalletBalance, Earnings: client.TotalEarnings}
+	}
+	return projections, nil
+}
+
+func (m *mockWalletRepo) SetProjection(ctx context.Context, clientID primitive.ObjectID, totals models.WalletTotals) error {
+	if client, ok := m.clients[clientID.Hex()]; ok {
+		client.WalletBalance = totals.Balance
+		client.TotalEarnings = totals.Earnings
+	}
+	return nil
+}
+
+func TestCommissionEntry_CreditAndClawback(t *testing.T) {
+	earner := primitive.NewObjectID()
+
+	credit := commissionEntry(&models.Commission{ID: primitive.NewObjectID(), ClientID: earner, Type: "matching", Amount: models.NewMoney(12.5)})
+	if credit.Direction != models.WalletCredit || credit.Type != models.WalletEntryCommission || credit.Amount != models.NewMoney(12.5) || credit.Earnings != models.NewMoney(12.5) {
+		t.Errorf("Unexpected commission entry: %+v", credit)
+	}
+
+	debit := commissionEntry(&models.Commission{ID: primitive.NewObjectID(), ClientID: earner, Type: "clawback", Amount: models.NewMoney(-4)})
+	if debit.Direction != models.WalletDebit || debit.Type != models.WalletEntryClawback || debit.Amount != models.NewMoney(4) || debit.Earnings != models.NewMoney(-4) {
+		t.Errorf("Unexpected clawback entry: %+v", debit)
+	}
+	if debit.SignedAmount() != models.NewMoney(-4) || debit.CounterAccount != models.WalletAccountCommissions {
+		t.Errorf("A clawback must debit the wallet against the commissions account: %+v", debit)
+	}
+}
+
+func TestWalletService_CheckRebuildAndOpen(t *testing.T) {
+	ctx := context.Background()
+	member := &models.Client{ID: primitive.NewObjectID()}
+	legacy := &models.Client{ID: primitive.NewObjectID(), WalletBalance: models.NewMoney(30), TotalEarnings: models.NewMoney(30)} // Solde antérieur au journal
+	clients := map[string]*models.Client{member.ID.Hex(): member, legacy.ID.Hex(): legacy}
+	repo := newMockWalletRepo(clients)
+	service := NewWalletService(repo, zap.NewNop())
+
+	for _, amount := range []models.Money{models.NewMoney(40), models.NewMoney(15), models.NewMoney(-10)} {
+		if _, err := repo.Post(ctx, commissionEntry(&models.Commission{ID: primitive.NewObjectID(), ClientID: member.ID, Amount: amount})); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if member.WalletBalance != models.NewMoney(45) || repo.entries[2].BalanceAfter != models.NewMoney(45) {
+		t.Fatalf("Expected a running balance of 45, got %s", member.WalletBalance)
+	}
+
+	// Une écriture directe sur le client (ancien code) fait diverger la projection
+	member.WalletBalance = models.NewMoney(60)
+	discrepancies, err := service.Check(ctx)
+	if err != nil {
+		t.Fatalf("Erreur inattendue: %v", err)
+	}
+	if len(discrepancies) != 2 {
+		t.Fatalf("Expected 2 discrepancies (drift and pre-ledger balance), got %+v", discrepancies)
+	}
+
+	// Ouverture: le solde antérieur est repris au journal sans bouger la projection
+	if _, err := service.OpenBalances(ctx); err != nil {
+		t.Fatalf("Erreur inattendue: %v", err)
+	}
+	if legacy.WalletBalance != models.NewMoney(30) || member.WalletBalance != models.NewMoney(60) {
+		t.Errorf("Opening entries must not move the projection, got %s / %s", legacy.WalletBalance, member.WalletBalance)
+	}
+	if discrepancies, _ := service.Check(ctx); len(discrepancies) != 0 {
+		t.Errorf("Expected no discrepancy after opening, got %+v", discrepancies)
+	}
+
+	// Reconstruction: la projection suit le journal
+	member.WalletBalance, member.TotalEarnings = 0, 0
+	corrected, err := service.Rebuild(ctx)
+	if err != nil || corrected != 1 {
+		t.Fatalf("Expected 1 corrected wallet, got %d (%v)", corrected, err)
+	}
+	if member.WalletBalance != models.NewMoney(60) || member.TotalEarnings != models.NewMoney(45) {
+		t.Errorf("Expected the projection rebuilt from the ledger (60/45), got %s/%s", member.WalletBalance, member.TotalEarnings)
+	}
+}
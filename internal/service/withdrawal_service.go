@@ -0,0 +1,466 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"bureau/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+type withdrawalRepository interface {
+	Create(ctx context.Context, withdrawal *models.Withdrawal) (*models.Withdrawal, error)
+	GetByID(ctx context.Context, id primitive.ObjectID) (*models.Withdrawal, error)
+	GetAll(ctx context.Context, clientID *primitive.ObjectID, status *string, paging *models.PagingInput) ([]*models.Withdrawal, error)
+	SumAmounts(ctx context.Context, clientID primitive.ObjectID, statuses []string, since time.Time) (models.Money, error)
+	Review(ctx context.Context, id primitive.ObjectID, status string, reviewedAt time.Time, reviewedBy *primitive.ObjectID, reason *string) (bool, error)
+	MarkFailed(ctx context.Context, id primitive.ObjectID, reason string) (bool, error)
+	MarkPaid(ctx context.Context, id, caisseTransactionID primitive.ObjectID) (bool, error)
+	SetPayout(ctx context.Context, id, paymentID, walletTransactionID primitive.ObjectID) error
+}
+
+type withdrawalClientRepository interface {
+	GetByID(ctx context.Context, id string) (*models.Client, error)
+}
+
+// walletDebitor débite le portefeuille d'un membre si son solde le couvre
+type walletDebitor interface {
+	walletLedger
+	Debit(ctx context.Context, entry *models.WalletTransaction) (*models.WalletTransaction, error)
+}
+
+type withdrawalPaymentRepository interface {
+	Create(ctx context.Context, payment *models.Payment) (*models.Payment, error)
+	UpdateStatus(ctx context.Context, id primitive.ObjectID, status string) error
+}
+
+type withdrawalCaisse interface {
+	AddTransaction(ctx context.Context, transaction *models.CaisseTransaction) (*models.CaisseTransaction, error)
+}
+
+// WithdrawalService gère les demandes de retrait du portefeuille. Un membre demande un
+// retrait dans la limite de son solde disponible (solde moins les demandes en attente), du
+// montant minimum et du plafond journalier; un admin l'approuve ou la rejette. L'approbation
+// débite le portefeuille et crée le paiement du montant net des frais, en attente; la sortie
+// de caisse n'est enregistrée qu'à la confirmation du versement.
+type WithdrawalService struct {
+	withdrawalRepo withdrawalRepository
+	clientRepo     withdrawalClientRepository
+	wallet         walletDebitor
+	paymentRepo    withdrawalPaymentRepository
+	caisse         withdrawalCaisse
+	txHelper       transactionHelper
+	rule           models.WithdrawalRule
+	logger         *zap.Logger
+	now            func() time.Time
+	location       *time.Location // Fuseau du jour ouvré (plafond journalier)
+}
+
+// NewWithdrawalService crée un nouveau service de retraits
+func NewWithdrawalService(
+	withdrawalRepo withdrawalRepository,
+	clientRepo withdrawalClientRepository,
+	wallet walletDebitor,
+	paymentRepo withdrawalPaymentRepository,
+	caisse withdrawalCaisse,
+	txHelper transactionHelper,
+	rule models.WithdrawalRule,
+	logger *zap.Logger,
+	location *time.Location,
+) *WithdrawalService {
+	return &WithdrawalService{
+		withdrawalRepo: withdrawalRepo,
+		clientRepo:     clientRepo,
+		wallet:         wallet,
+		paymentRepo:    paymentRepo,
+		caisse:         caisse,
+		txHelper:       txHelper,
+		rule:           rule,
+		logger:         logger,
+		now:            time.Now,
+		location:       locationOrUTC(location),
+	}
+}
+
+// GetByID récupère une demande de retrait (nil si elle n'existe pas)
+func (s *WithdrawalService) GetByID(ctx context.Context, id primitive.ObjectID) (*models.Withdrawal, error) {
+	return s.withdrawalRepo.GetByID(ctx, id)
+}
+
+// GetAll récupère les demandes, de la plus récente à la plus ancienne
+func (s *WithdrawalService) GetAll(ctx context.Context, clientID *primitive.ObjectID, status *string, paging *models.PagingInput) ([]*models.Withdrawal, error) {
+	return s.withdrawalRepo.GetAll(ctx, clientID, status, paging)
+}
+
+// Request enregistre une demande de retrait en attente. Le solde n'est pas encore débité:
+// deux demandes concurrentes peuvent passer, mais l'approbation revérifie le solde.
+func (s *WithdrawalService) Request(ctx context.Context, clientID primitive.ObjectID, amount models.Money, method string, destination *string) (*models.Withdrawal, error) {
+	if amount <= 0 {
+		return nil, errors.New("le montant doit être supérieur à 0")
+	}
+	if amount < s.rule.MinAmount {
+		return nil, fmt.Errorf("le montant minimum d'un retrait est de %s", s.rule.MinAmount)
+	}
+	fee := s.rule.Fee(amount)
+	net := amount - fee
+	if net <= 0 {
+		return nil, fmt.Errorf("le montant ne couvre pas les frais de retrait (%s)", fee)
+	}
+
+	client, err := s.clientRepo.GetByID(ctx, clientID.Hex())
+	if err != nil {
+		return nil, err
+	}
+	if client == nil {
+		return nil, errors.New("client introuvable")
+	}
+
+	pending, err := s.withdrawalRepo.SumAmounts(ctx, clientID, []string{models.WithdrawalStatusPending}, time.Time{})
+	if err != nil {
+		return nil, fmt.Errorf("échec de la lecture des retraits en attente: %w", err)
+	}
+	available := client.WalletBalance - pending
+	if amount > available {
+		return nil, fmt.Errorf("solde du portefeuille insuffisant: %s disponible", max(available, 0))
+	}
+
+	now := s.now()
+	if s.rule.DailyLimit > 0 {
+		requested, err := s.withdrawalRepo.SumAmounts(ctx, clientID,
+			[]string{models.WithdrawalStatusPending, models.WithdrawalStatusApproved}, startOfDay(now, s.location))
+		if err != nil {
+			return nil, fmt.Errorf("échec de la lecture des retraits du jour: %w", err)
+		}
+		if requested+amount > s.rule.DailyLimit {
+			return nil, fmt.Errorf("plafond journalier de retrait dépassé: %s déjà demandé sur %s", requested, s.rule.DailyLimit)
+		}
+	}
+
+	created, err := s.withdrawalRepo.Create(ctx, &models.Withdrawal{
+		ClientID:    clientID,
+		Amount:      amount,
+		Fee:         fee,
+		NetAmount:   net,
+		Method:      method,
+		Destination: destination,
+		Status:      models.WithdrawalStatusPending,
+		RequestedAt: now,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Withdrawal requested",
+		zap.String("withdrawalID", created.ID.Hex()),
+		zap.String("clientID", clientID.Hex()),
+		zap.Float64("amount", amount.Float64()),
+		zap.Float64("fee", fee.Float64()))
+	return created, nil
+}
+
+// Approve approuve une demande en attente: débit du portefeuille (refusé si le solde ne
+// couvre plus le montant) et paiement en attente du montant net. Tout ou rien.
+func (s *WithdrawalService) Approve(ctx context.Context, id primitive.ObjectID, adminID *primitive.ObjectID) (*models.Withdrawal, error) {
+	var approved *models.Withdrawal
+	err := s.inTransaction(ctx, func(txCtx context.Context) error {
+		withdrawal, err := s.pending(txCtx, id)
+		if err != nil {
+			return err
+		}
+		approved, err = s.approve(txCtx, withdrawal, adminID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Withdrawal approved",
+		zap.String("withdrawalID", approved.ID.Hex()),
+		zap.String("clientID", approved.ClientID.Hex()),
+		zap.Float64("amount", approved.Amount.Float64()),
+		zap.Float64("netAmount", approved.NetAmount.Float64()))
+	return approved, nil
+}
+
+// approve approuve une demande en attente dans la transaction de l'appelant: débit du
+// portefeuille et paiement en attente du montant net
+func (s *WithdrawalService) approve(ctx context.Context, withdrawal *models.Withdrawal, adminID *primitive.ObjectID) (*models.Withdrawal, error) {
+	now := s.now()
+	ok, err := s.withdrawalRepo.Review(ctx, withdrawal.ID, models.WithdrawalStatusApproved, now, adminID, nil)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errors.New("cette demande de retrait a déjà été traitée")
+	}
+
+	entry, err := s.wallet.Debit(ctx, &models.WalletTransaction{
+		ClientID:       withdrawal.ClientID,
+		Direction:      models.WalletDebit,
+		Type:           models.WalletEntryWithdrawal,
+		Amount:         withdrawal.Amount,
+		CounterAccount: models.WalletAccountWithdrawals,
+		ReferenceType:  models.WalletReferenceWithdrawal,
+		ReferenceID:    withdrawal.ID,
+		Description:    withdrawal.Method,
+		CreatedAt:      now,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("échec du débit du portefeuille: %w", err)
+	}
+
+	desc := fmt.Sprintf("Retrait %s", withdrawal.ID.Hex())
+	payment, err := s.paymentRepo.Create(ctx, &models.Payment{
+		ClientID:    withdrawal.ClientID,
+		Amount:      withdrawal.NetAmount,
+		Date:        now,
+		Method:      withdrawal.Method,
+		Status:      "pending", // Effectué à la confirmation du versement (SettlePayout)
+		Description: &desc,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("échec de la création du paiement: %w", err)
+	}
+
+	if err := s.withdrawalRepo.SetPayout(ctx, withdrawal.ID, payment.ID, entry.ID); err != nil {
+		return nil, err
+	}
+
+	withdrawal.Status = models.WithdrawalStatusApproved
+	withdrawal.ReviewedAt = &now
+	withdrawal.ReviewedBy = adminID
+	withdrawal.PaymentID = &payment.ID
+	withdrawal.WalletTransactionID = &entry.ID
+	return withdrawal, nil
+}
+
+// Reject rejette une demande en attente; le portefeuille n'est pas touché
+func (s *WithdrawalService) Reject(ctx context.Context, id primitive.ObjectID, adminID *primitive.ObjectID, reason string) (*models.Withdrawal, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, errors.New("le motif du rejet est requis")
+	}
+
+	withdrawal, err := s.pending(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	now := s.now()
+	ok, err := s.withdrawalRepo.Review(ctx, id, models.WithdrawalStatusRejected, now, adminID, &reason)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errors.New("cette demande de retrait a déjà été traitée")
+	}
+
+	withdrawal.Status = models.WithdrawalStatusRejected
+	withdrawal.ReviewedAt = &now
+	withdrawal.ReviewedBy = adminID
+	withdrawal.RejectionReason = &reason
+
+	s.logger.Info("Withdrawal rejected", zap.String("withdrawalID", id.Hex()), zap.String("reason", reason))
+	return withdrawal, nil
+}
+
+// Payout crée et approuve d'un coup le retrait d'un montant choisi par un admin (lot de
+// paiement sur les soldes): les frais s'appliquent, pas le minimum ni le plafond journalier
+func (s *WithdrawalService) Payout(ctx context.Context, clientID primitive.ObjectID, amount models.Money, method string, destination *string, adminID *primitive.ObjectID) (*models.Withdrawal, error) {
+	fee := s.rule.Fee(amount)
+	net := amount - fee
+	if net <= 0 {
+		return nil, fmt.Errorf("le montant ne couvre pas les frais de retrait (%s)", fee)
+	}
+
+	var approved *models.Withdrawal
+	err := s.inTransaction(ctx, func(txCtx context.Context) error {
+		withdrawal, err := s.withdrawalRepo.Create(txCtx, &models.Withdrawal{
+			ClientID:    clientID,
+			Amount:      amount,
+			Fee:         fee,
+			NetAmount:   net,
+			Method:      method,
+			Destination: destination,
+			Status:      models.WithdrawalStatusPending,
+			RequestedAt: s.now(),
+		})
+		if err != nil {
+			return err
+		}
+		approved, err = s.approve(txCtx, withdrawal, adminID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Withdrawal paid out",
+		zap.String("withdrawalID", approved.ID.Hex()),
+		zap.String("clientID", clientID.Hex()),
+		zap.Float64("amount", amount.Float64()))
+	return approved, nil
+}
+
+// Settle confirme le versement d'un retrait approuvé payé hors lot (espèces, virement):
+// completed à false le déclare échoué pour le motif reason
+func (s *WithdrawalService) Settle(ctx context.Context, id primitive.ObjectID, completed bool, reason string) (*models.Withdrawal, error) {
+	reason = strings.TrimSpace(reason)
+	if !completed && reason == "" {
+		return nil, errors.New("le motif de l'échec est requis")
+	}
+
+	withdrawal, err := s.withdrawalRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if withdrawal == nil {
+		return nil, errors.New("demande de retrait introuvable")
+	}
+	if withdrawal.Status != models.WithdrawalStatusApproved {
+		return nil, errors.New("ce retrait n'est pas en attente de versement")
+	}
+	if withdrawal.PayoutBatchID != nil {
+		return nil, errors.New("ce retrait est réglé par le résultat de son lot de paiement")
+	}
+	if err := s.SettlePayout(ctx, withdrawal, completed, reason); err != nil {
+		return nil, err
+	}
+	return s.withdrawalRepo.GetByID(ctx, id)
+}
+
+// SettlePayout enregistre le résultat du versement d'un retrait approuvé. Un versement réussi
+// enregistre la sortie de caisse du montant net et marque le paiement comme effectué; un échec
+// marque le paiement et le retrait comme échoués et rend le montant au portefeuille.
+func (s *WithdrawalService) SettlePayout(ctx context.Context, withdrawal *models.Withdrawal, completed bool, reason string) error {
+	if withdrawal.PaymentID == nil {
+		return errors.New("ce retrait n'a pas de paiement")
+	}
+	if completed {
+		return s.completePayout(ctx, withdrawal)
+	}
+
+	err := s.inTransaction(ctx, func(txCtx context.Context) error {
+		ok, err := s.withdrawalRepo.MarkFailed(txCtx, withdrawal.ID, reason)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return errors.New("ce retrait n'est plus approuvé")
+		}
+
+		if _, err := s.wallet.Post(txCtx, &models.WalletTransaction{
+			ClientID:       withdrawal.ClientID,
+			Direction:      models.WalletCredit,
+			Type:           models.WalletEntryRefund,
+			Amount:         withdrawal.Amount,
+			CounterAccount: models.WalletAccountWithdrawals,
+			ReferenceType:  models.WalletReferenceWithdrawal,
+			ReferenceID:    withdrawal.ID,
+			Description:    reason,
+			CreatedAt:      s.now(),
+		}); err != nil {
+			return fmt.Errorf("échec du remboursement du portefeuille: %w", err)
+		}
+
+		// Une sortie déjà enregistrée (retrait approuvé avant la confirmation des versements)
+		// est annulée par une entrée
+		if withdrawal.CaisseTransactionID != nil {
+			paymentRef := withdrawal.PaymentID.Hex()
+			refType := "payment"
+			desc := fmt.Sprintf("Versement échoué du retrait %s", withdrawal.ID.Hex())
+			if _, err := s.caisse.AddTransaction(txCtx, &models.CaisseTransaction{
+				Type:          "entree",
+				Amount:        withdrawal.NetAmount,
+				Description:   &desc,
+				Reference:     &paymentRef,
+				ReferenceType: &refType,
+			}); err != nil {
+				return fmt.Errorf("échec de l'entrée de caisse: %w", err)
+			}
+		}
+
+		return s.paymentRepo.UpdateStatus(txCtx, *withdrawal.PaymentID, "failed")
+	})
+	if err != nil {
+		return err
+	}
+
+	s.logger.Warn("Withdrawal payout failed, amount refunded",
+		zap.String("withdrawalID", withdrawal.ID.Hex()),
+		zap.Float64("amount", withdrawal.Amount.Float64()),
+		zap.String("reason", reason))
+	return nil
+}
+
+// completePayout enregistre la sortie de caisse d'un versement confirmé et marque le paiement
+// comme effectué
+func (s *WithdrawalService) completePayout(ctx context.Context, withdrawal *models.Withdrawal) error {
+	if withdrawal.CaisseTransactionID != nil {
+		// Sortie enregistrée à l'approbation, avant la confirmation des versements
+		return s.paymentRepo.UpdateStatus(ctx, *withdrawal.PaymentID, "completed")
+	}
+
+	var sortie *models.CaisseTransaction
+	err := s.inTransaction(ctx, func(txCtx context.Context) error {
+		paymentRef := withdrawal.PaymentID.Hex()
+		refType := "payment"
+		desc := fmt.Sprintf("Retrait du portefeuille - %s", withdrawal.ClientID.Hex())
+		var err error
+		sortie, err = s.caisse.AddTransaction(txCtx, &models.CaisseTransaction{
+			Type:          "sortie",
+			Amount:        withdrawal.NetAmount,
+			Description:   &desc,
+			Reference:     &paymentRef,
+			ReferenceType: &refType,
+		})
+		if err != nil {
+			return fmt.Errorf("échec de la sortie de caisse: %w", err)
+		}
+
+		ok, err := s.withdrawalRepo.MarkPaid(txCtx, withdrawal.ID, sortie.ID)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return errors.New("ce retrait a déjà été versé ou n'est plus approuvé")
+		}
+		return s.paymentRepo.UpdateStatus(txCtx, *withdrawal.PaymentID, "completed")
+	})
+	if err != nil {
+		return err
+	}
+	withdrawal.CaisseTransactionID = &sortie.ID
+
+	s.logger.Info("Withdrawal payout confirmed",
+		zap.String("withdrawalID", withdrawal.ID.Hex()),
+		zap.Float64("netAmount", withdrawal.NetAmount.Float64()))
+	return nil
+}
+
+// pending récupère une demande et vérifie qu'elle est encore en attente
+func (s *WithdrawalService) pending(ctx context.Context, id primitive.ObjectID) (*models.Withdrawal, error) {
+	withdrawal, err := s.withdrawalRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if withdrawal == nil {
+		return nil, errors.New("demande de retrait introuvable")
+	}
+	if withdrawal.Status != models.WithdrawalStatusPending {
+		return nil, errors.New("cette demande de retrait a déjà été traitée")
+	}
+	return withdrawal, nil
+}
+
+// inTransaction exécute fn dans une transaction si le helper est disponible
+func (s *WithdrawalService) inTransaction(ctx context.Context, fn func(context.Context) error) error {
+	if s.txHelper == nil {
+		return fn(ctx)
+	}
+	return s.txHelper.ExecuteTransaction(ctx, fn)
+}
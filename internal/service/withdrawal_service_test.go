@@ -0,0 +1,337 @@
+package service
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"bureau/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+type mockWithdrawalRepo struct {
+	withdrawals []*models.Withdrawal
+}
+
+func (m *mockWithdrawalRepo) Create(ctx context.Context, withdrawal *models.Withdrawal) (*models.Withdrawal, error) {
+	withdrawal.ID = primitive.NewObjectID()
+	m.withdrawals = append(m.withdrawals, withdrawal)
+	return withdrawal, nil
+}
+
+func (m *mockWithdrawalRepo) GetByID(ctx context.Context, id primitive.ObjectID) (*models.Withdrawal, error) {
+	for _, w := range m.withdrawals {
+		if w.ID == id {
+			copied := *w
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *mockWithdrawalRepo) GetAll(ctx context.Context, clientID *primitive.ObjectID, status *string, paging *models.PagingInput) ([]*models.Withdrawal, error) {
+	var out []*models.Withdrawal
+	for _, w := range m.withdrawals {
+		if (clientID == nil || w.ClientID == *clientID) && (status == nil || w.Status == *status) {
+			out = append(out, w)
+		}
+	}
+	return out, nil
+}
+
+func (m *mockWithdrawalRepo) SumAmounts(ctx context.Context, clientID primitive.ObjectID, statuses []string, since time.Time) (models.Money, error) {
+	var total models.Money
+	for _, w := range m.withdrawals {
+		if w.ClientID == clientID && slices.Contains(statuses, w.Status) && !w.RequestedAt.Before(since) {
+			total += w.Amount
+		}
+	}
+	return total, nil
+}
+
+func (m *mockWithdrawalRepo) Review(ctx context.Context, id primitive.ObjectID, status string, reviewedAt time.Time, reviewedBy *primitive.ObjectID, reason *string) (bool, error) {
+	for _, w := range m.withdrawals {
+		if w.ID == id && w.Status == models.WithdrawalStatusPending {
+			w.Status = status
+			w.ReviewedAt = &reviewedAt
+			w.ReviewedBy = reviewedBy
+			w.RejectionReason = reason
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *mockWithdrawalRepo) SetPayout(ctx context.Context, id, paymentID, walletTransactionID primitive.ObjectID) error {
+	for _, w := range m.withdrawals {
+		if w.ID == id {
+			w.PaymentID = &paymentID
+			w.WalletTransactionID = &walletTransactionID
+		}
+	}
+	return nil
+}
+
+func (m *mockWithdrawalRepo) MarkPaid(ctx context.Context, id, caisseTransactionID primitive.ObjectID) (bool, error) {
+	for _, w := range m.withdrawals {
+		if w.ID == id && w.Status == models.WithdrawalStatusApproved && w.CaisseTransactionID == nil {
+			w.CaisseTransactionID = &caisseTransactionID
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *mockWithdrawalRepo) MarkFailed(ctx context.Context, id primitive.ObjectID, reason string) (bool, error) {
+	for _, w := range m.withdrawals {
+		if w.ID == id && w.Status == models.WithdrawalStatusApproved {
+			w.Status = models.WithdrawalStatusFailed
+			w.FailureReason = &reason
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *mockWithdrawalRepo) GetUnbatched(ctx context.Context, methods []string) ([]*models.Withdrawal, error) {
+	var out []*models.Withdrawal
+	for _, w := range m.withdrawals {
+		if w.Status == models.WithdrawalStatusApproved && w.PayoutBatchID == nil && (len(methods) == 0 || slices.Contains(methods, w.Method)) {
+			out = append(out, w)
+		}
+	}
+	return out, nil
+}
+
+func (m *mockWithdrawalRepo) AssignBatch(ctx context.Context, ids []primitive.ObjectID, batchID primitive.ObjectID) (int64, error) {
+	var assigned int64
+	for _, w := range m.withdrawals {
+		if slices.Contains(ids, w.ID) && w.PayoutBatchID == nil {
+			w.PayoutBatchID = &batchID
+			assigned++
+		}
+	}
+	return assigned, nil
+}
+
+type mockPaymentRepo struct {
+	payments []*models.Payment
+}
+
+func (m *mockPaymentRepo) Create(ctx context.Context, payment *models.Payment) (*models.Payment, error) {
+	payment.ID = primitive.NewObjectID()
+	m.payments = append(m.payments, payment)
+	return payment, nil
+}
+
+func (m *mockPaymentRepo) UpdateStatus(ctx context.Context, id primitive.ObjectID, status string) error {
+	for _, p := range m.payments {
+		if p.ID == id {
+			p.Status = status
+		}
+	}
+	return nil
+}
+
+type withdrawalTestEnv struct {
+	service     *WithdrawalService
+	repo        *mockWithdrawalRepo
+	clientRepo  *mockClientRepo
+	wallet      *mockWalletRepo
+	paymentRepo *mockPaymentRepo
+	caisse      *mockCaisse
+}
+
+func createTestWithdrawalService(rule models.WithdrawalRule, now time.Time) *withdrawalTestEnv {
+	clientRepo := &mockClientRepo{clients: make(map[string]*models.Client)}
+	env := &withdrawalTestEnv{
+		repo:        &mockWithdrawalRepo{},
+		clientRepo:  clientRepo,
+		wallet:      newMockWalletRepo(clientRepo.clients),
+		paymentRepo: &mockPaymentRepo{},
+		caisse:      &mockCaisse{},
+	}
+	env.service = NewWithdrawalService(env.repo, env.clientRepo, env.wallet, env.paymentRepo, env.caisse, nil, rule, zap.NewNop(), time.UTC)
+	env.service.now = func() time.Time { return now }
+	return env
+}
+
+func (env *withdrawalTestEnv) addMember(balance models.Money) *models.Client {
+	client := &models.Client{ID: primitive.NewObjectID(), WalletBalance: balance, TotalEarnings: balance}
+	env.clientRepo.clients[client.ID.Hex()] = client
+	return client
+}
+
+// Test: la demande applique le minimum, les frais, le solde disponible et le plafond journalier
+func TestWithdrawalRequest_Rules(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 3, 14, 10, 0, 0, 0, time.UTC)
+	env := createTestWithdrawalService(models.WithdrawalRule{MinAmount: models.NewMoney(10), FeeRate: 0.02, FeeAmount: models.NewMoney(1), DailyLimit: models.NewMoney(100)}, now)
+	member := env.addMember(models.NewMoney(150))
+
+	if _, err := env.service.Request(ctx, member.ID, models.NewMoney(5), "mobile", nil); err == nil {
+		t.Error("A withdrawal below the minimum should be rejected")
+	}
+
+	withdrawal, err := env.service.Request(ctx, member.ID, models.NewMoney(60), "mobile", nil)
+	if err != nil {
+		t.Fatalf("Erreur inattendue: %v", err)
+	}
+	if withdrawal.Status != models.WithdrawalStatusPending || withdrawal.Fee != models.NewMoney(2.2) || withdrawal.NetAmount != models.NewMoney(57.8) {
+		t.Errorf("Unexpected withdrawal: %+v", withdrawal)
+	}
+	if member.WalletBalance != models.NewMoney(150) {
+		t.Errorf("A request must not debit the wallet, got %s", member.WalletBalance)
+	}
+
+	// 60 déjà demandés aujourd'hui: 50 de plus dépasseraient le plafond de 100
+	if _, err := env.service.Request(ctx, member.ID, models.NewMoney(50), "mobile", nil); err == nil {
+		t.Error("The daily limit should be enforced")
+	}
+
+	// Le lendemain, le plafond repart mais la demande en attente réserve 60 sur 150
+	env.service.now = func() time.Time { return now.AddDate(0, 0, 1) }
+	if _, err := env.service.Request(ctx, member.ID, models.NewMoney(95), "mobile", nil); err == nil {
+		t.Error("Pending withdrawals should reserve the wallet balance")
+	}
+	if _, err := env.service.Request(ctx, member.ID, models.NewMoney(90), "mobile", nil); err != nil {
+		t.Errorf("Expected 90 to fit the available balance: %v", err)
+	}
+}
+
+// Test: l'approbation débite le portefeuille du montant demandé et crée le paiement en attente du montant net
+func TestWithdrawalApprove_DebitsWalletAndPaysNet(t *testing.T) {
+	ctx := context.Background()
+	env := createTestWithdrawalService(models.WithdrawalRule{FeeAmount: models.NewMoney(2)}, time.Now())
+	member := env.addMember(models.NewMoney(100))
+	adminID := primitive.NewObjectID()
+
+	withdrawal, err := env.service.Request(ctx, member.ID, models.NewMoney(40), "mobile", nil)
+	if err != nil {
+		t.Fatalf("Erreur inattendue: %v", err)
+	}
+	approved, err := env.service.Approve(ctx, withdrawal.ID, &adminID)
+	if err != nil {
+		t.Fatalf("Erreur inattendue: %v", err)
+	}
+
+	if approved.Status != models.WithdrawalStatusApproved || approved.PaymentID == nil || approved.CaisseTransactionID != nil || approved.WalletTransactionID == nil {
+		t.Errorf("Unexpected approved withdrawal: %+v", approved)
+	}
+	if member.WalletBalance != models.NewMoney(60) || member.TotalEarnings != models.NewMoney(100) {
+		t.Errorf("Expected the wallet debited by 40 without touching earnings, got %s / %s", member.WalletBalance, member.TotalEarnings)
+	}
+	entry := env.wallet.entries[0]
+	if entry.Type != models.WalletEntryWithdrawal || entry.Direction != models.WalletDebit || entry.ReferenceID != withdrawal.ID {
+		t.Errorf("Unexpected wallet entry: %+v", entry)
+	}
+	if len(env.paymentRepo.payments) != 1 || env.paymentRepo.payments[0].Amount != models.NewMoney(38) || env.paymentRepo.payments[0].Status != "pending" {
+		t.Errorf("Expected a pending payment of the net amount (38), got %+v", env.paymentRepo.payments)
+	}
+	if len(env.caisse.transactions) != 0 {
+		t.Errorf("The caisse sortie should wait for the payout confirmation, got %+v", env.caisse.transactions)
+	}
+
+	// Une demande ne peut être traitée qu'une fois
+	if _, err := env.service.Approve(ctx, withdrawal.ID, &adminID); err == nil {
+		t.Error("A withdrawal should not be approved twice")
+	}
+	if _, err := env.service.Reject(ctx, withdrawal.ID, &adminID, "doublon"); err == nil {
+		t.Error("An approved withdrawal should not be rejected")
+	}
+}
+
+// Test: l'approbation est refusée si le solde a baissé depuis la demande (reprise)
+func TestWithdrawalApprove_InsufficientBalance(t *testing.T) {
+	ctx := context.Background()
+	env := createTestWithdrawalService(models.WithdrawalRule{}, time.Now())
+	member := env.addMember(models.NewMoney(50))
+
+	withdrawal, err := env.service.Request(ctx, member.ID, models.NewMoney(50), "cash", nil)
+	if err != nil {
+		t.Fatalf("Erreur inattendue: %v", err)
+	}
+	member.WalletBalance = models.NewMoney(20)
+
+	if _, err := env.service.Approve(ctx, withdrawal.ID, nil); err == nil {
+		t.Fatal("The approval should fail when the wallet no longer covers the amount")
+	}
+	if len(env.paymentRepo.payments) != 0 || len(env.caisse.transactions) != 0 {
+		t.Error("No payment or caisse sortie should be recorded when the debit fails")
+	}
+}
+
+// Test: le rejet exige un motif et laisse le portefeuille intact
+func TestWithdrawalReject(t *testing.T) {
+	ctx := context.Background()
+	env := createTestWithdrawalService(models.WithdrawalRule{}, time.Now())
+	member := env.addMember(models.NewMoney(80))
+
+	withdrawal, err := env.service.Request(ctx, member.ID, models.NewMoney(30), "bank", nil)
+	if err != nil {
+		t.Fatalf("Erreur inattendue: %v", err)
+	}
+	if _, err := env.service.Reject(ctx, withdrawal.ID, nil, "  "); err == nil {
+		t.Error("A rejection without reason should be refused")
+	}
+	rejected, err := env.service.Reject(ctx, withdrawal.ID, nil, "compte invalide")
+	if err != nil {
+		t.Fatalf("Erreur inattendue: %v", err)
+	}
+	if rejected.Status != models.WithdrawalStatusRejected || rejected.RejectionReason == nil || *rejected.RejectionReason != "compte invalide" {
+		t.Errorf("Unexpected rejected withdrawal: %+v", rejected)
+	}
+	if member.WalletBalance != models.NewMoney(80) || len(env.wallet.entries) != 0 {
+		t.Errorf("A rejection must not touch the wallet, got %s", member.WalletBalance)
+	}
+}
+
+// Test: la confirmation d'un versement hors lot enregistre la sortie de caisse une seule fois
+func TestWithdrawalSettle(t *testing.T) {
+	ctx := context.Background()
+	env := createTestWithdrawalService(models.WithdrawalRule{FeeAmount: models.NewMoney(1)}, time.Now())
+	member := env.addMember(models.NewMoney(100))
+
+	paid, err := env.service.Payout(ctx, member.ID, models.NewMoney(30), "cash", nil, nil)
+	if err != nil {
+		t.Fatalf("Erreur inattendue: %v", err)
+	}
+	settled, err := env.service.Settle(ctx, paid.ID, true, "")
+	if err != nil {
+		t.Fatalf("Erreur inattendue: %v", err)
+	}
+	if settled.CaisseTransactionID == nil || env.paymentRepo.payments[0].Status != "completed" {
+		t.Errorf("Expected a completed payment with its caisse sortie, got %+v", settled)
+	}
+	if len(env.caisse.transactions) != 1 || env.caisse.transactions[0].Type != "sortie" || env.caisse.transactions[0].Amount != models.NewMoney(29) {
+		t.Errorf("Expected a caisse sortie of the net amount (29), got %+v", env.caisse.transactions)
+	}
+	if _, err := env.service.Settle(ctx, paid.ID, true, ""); err != nil {
+		t.Fatalf("Erreur inattendue: %v", err)
+	}
+	if len(env.caisse.transactions) != 1 {
+		t.Errorf("A second confirmation must not post another sortie, got %d", len(env.caisse.transactions))
+	}
+
+	// Un versement échoué rend le montant sans toucher à la caisse
+	failed, err := env.service.Payout(ctx, member.ID, models.NewMoney(20), "bank", nil, nil)
+	if err != nil {
+		t.Fatalf("Erreur inattendue: %v", err)
+	}
+	if _, err := env.service.Settle(ctx, failed.ID, false, ""); err == nil {
+		t.Error("A failed payout should require a reason")
+	}
+	settled, err = env.service.Settle(ctx, failed.ID, false, "compte clôturé")
+	if err != nil {
+		t.Fatalf("Erreur inattendue: %v", err)
+	}
+	if settled.Status != models.WithdrawalStatusFailed || env.paymentRepo.payments[1].Status != "failed" {
+		t.Errorf("Expected the withdrawal and payment to fail, got %s / %s", settled.Status, env.paymentRepo.payments[1].Status)
+	}
+	if member.WalletBalance != models.NewMoney(70) || len(env.caisse.transactions) != 1 {
+		t.Errorf("Expected the 20 refunded and no caisse entry, got %s / %d", member.WalletBalance, len(env.caisse.transactions))
+	}
+}
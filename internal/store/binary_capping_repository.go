@@ -13,6 +13,10 @@ import (
 )
 
 // BinaryCappingRepository gère les limites journalières/hebdomadaires des cycles
+// Un document est tenu par client et par jour; le total hebdomadaire est la somme
+// des documents journaliers compris dans la semaine. Les débuts de jour et de semaine
+// sont fournis par l'appelant, à minuit dans le fuseau du jour ouvré: ils ne sont pas
+// retronqués ici (Truncate ramènerait à minuit UTC)
 type BinaryCappingRepository struct {
 	collection *mongo.Collection
 }
@@ -24,10 +28,9 @@ func NewBinaryCappingRepository(db *mongo.Database) *BinaryCappingRepository {
 	}
 }
 
-// GetByClientIDAndDate récupère le capping pour un client et une date donnée
-func (r *BinaryCappingRepository) GetByClientIDAndDate(ctx context.Context, clientID primitive.ObjectID, date time.Time) (*models.BinaryCapping, error) {
-	dateStart := date.Truncate(24 * time.Hour)
-	
+// GetByClientIDAndDate récupère le capping pour un client et le jour commençant à dateStart
+// CyclesPaidThisWeek est calculé à partir de tous les jours de la semaine commençant à weekStart
+func (r *BinaryCappingRepository) GetByClientIDAndDate(ctx context.Context, clientID primitive.ObjectID, dateStart time.Time, weekStart time.Time) (*models.BinaryCapping, error) {
 	var capping models.BinaryCapping
 	err := r.collection.FindOne(ctx, bson.M{
 		"clientId": clientID,
@@ -37,56 +40,136 @@ func (r *BinaryCappingRepository) GetByClientIDAndDate(ctx context.Context, clie
 	if err == mongo.ErrNoDocuments {
 		// Créer un nouveau capping
 		capping = models.BinaryCapping{
-			ID:                primitive.NewObjectID(),
-			ClientID:          clientID,
-			Date:              dateStart,
-			CyclesPaidToday:   0,
+			ID:                 primitive.NewObjectID(),
+			ClientID:           clientID,
+			Date:               dateStart,
+			WeekStart:          weekStart,
+			CyclesPaidToday:    0,
 			CyclesPaidThisWeek: 0,
-			LastResetDate:     dateStart,
+			LastResetDate:      dateStart,
 		}
 		_, err = r.collection.InsertOne(ctx, capping)
+		if mongo.IsDuplicateKeyError(err) {
+			// Créé entre-temps par un calcul concurrent: on relit le document existant
+			err = r.collection.FindOne(ctx, bson.M{"clientId": clientID, "date": dateStart}).Decode(&capping)
+		}
+		if err != nil {
+			return nil, err
+		}
+	} else if err != nil {
+		return nil, err
+	} else if capping.LastResetDate.Before(dateStart) {
+		// Vérifier si on doit reset (nouveau jour)
+		capping.CyclesPaidToday = 0
+		capping.LastResetDate = dateStart
+		err = r.Update(ctx, &capping)
 		if err != nil {
 			return nil, err
 		}
-		return &capping, nil
 	}
 
+	weekly, err := r.GetWeeklyCycles(ctx, clientID, weekStart)
 	if err != nil {
 		return nil, err
 	}
+	capping.WeekStart = weekStart
+	capping.CyclesPaidThisWeek = weekly
 
-	// Vérifier si on doit reset (nouveau jour)
+	return &capping, nil
+}
+
+// Peek lit les compteurs d'un client pour un jour sans créer ni modifier de document
+// Utilisé par les simulations, qui ne doivent rien écrire
+func (r *BinaryCappingRepository) Peek(ctx context.Context, clientID primitive.ObjectID, dateStart time.Time, weekStart time.Time) (*models.BinaryCapping, error) {
+	capping := models.BinaryCapping{
+		ClientID:      clientID,
+		Date:          dateStart,
+		LastResetDate: dateStart,
+	}
+	err := r.collection.FindOne(ctx, bson.M{
+		"clientId": clientID,
+		"date":     dateStart,
+	}).Decode(&capping)
+	if err != nil && err != mongo.ErrNoDocuments {
+		return nil, err
+	}
 	if capping.LastResetDate.Before(dateStart) {
 		capping.CyclesPaidToday = 0
-		capping.LastResetDate = dateStart
-		err = r.Update(ctx, &capping)
-		if err != nil {
-			return nil, err
-		}
 	}
 
+	weekly, err := r.GetWeeklyCycles(ctx, clientID, weekStart)
+	if err != nil {
+		return nil, err
+	}
+	capping.WeekStart = weekStart
+	capping.CyclesPaidThisWeek = weekly
+
 	return &capping, nil
 }
 
+// GetWeeklyCycles retourne le nombre de cycles payés pendant la semaine commençant à weekStart
+func (r *BinaryCappingRepository) GetWeeklyCycles(ctx context.Context, clientID primitive.ObjectID, weekStart time.Time) (int, error) {
+	pipeline := []bson.M{
+		{"$match": bson.M{
+			"clientId": clientID,
+			"date": bson.M{
+				"$gte": weekStart,
+				"$lt":  weekStart.AddDate(0, 0, 7),
+			},
+		}},
+		{"$group": bson.M{
+			"_id":   nil,
+			"total": bson.M{"$sum": "$cyclesPaidToday"},
+		}},
+	}
+
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var result []struct {
+		Total int `bson:"total"`
+	}
+	if err = cursor.All(ctx, &result); err != nil {
+		return 0, err
+	}
+
+	if len(result) == 0 {
+		return 0, nil
+	}
+
+	return result[0].Total, nil
+}
+
 // Update met à jour un capping
 func (r *BinaryCappingRepository) Update(ctx context.Context, capping *models.BinaryCapping) error {
 	_, err := r.collection.UpdateOne(
 		ctx,
 		bson.M{"_id": capping.ID},
 		bson.M{"$set": bson.M{
-			"cyclesPaidToday":    capping.CyclesPaidToday,
-			"cyclesPaidThisWeek": capping.CyclesPaidThisWeek,
-			"lastResetDate":      capping.LastResetDate,
+			"cyclesPaidToday": capping.CyclesPaidToday,
+			"lastResetDate":   capping.LastResetDate,
 		}},
 		options.Update().SetUpsert(true),
 	)
 	return err
 }
 
-// IncrementCycles incrémente le nombre de cycles payés
-func (r *BinaryCappingRepository) IncrementCycles(ctx context.Context, clientID primitive.ObjectID, date time.Time, cycles int) error {
-	dateStart := date.Truncate(24 * time.Hour)
-	
+// IncrementCycles incrémente atomiquement les cycles payés du jour
+// Le total hebdomadaire en découle directement (voir GetWeeklyCycles).
+// Deux upserts concurrents du premier cycle du jour peuvent se heurter à l'index unique
+// (clientId, date): le perdant est rejoué, et trouve alors le document à incrémenter.
+func (r *BinaryCappingRepository) IncrementCycles(ctx context.Context, clientID primitive.ObjectID, dateStart time.Time, weekStart time.Time, cycles int) error {
+	err := r.incrementCycles(ctx, clientID, dateStart, weekStart, cycles)
+	if mongo.IsDuplicateKeyError(err) {
+		err = r.incrementCycles(ctx, clientID, dateStart, weekStart, cycles)
+	}
+	return err
+}
+
+func (r *BinaryCappingRepository) incrementCycles(ctx context.Context, clientID primitive.ObjectID, dateStart time.Time, weekStart time.Time, cycles int) error {
 	_, err := r.collection.UpdateOne(
 		ctx,
 		bson.M{
@@ -98,10 +181,10 @@ func (r *BinaryCappingRepository) IncrementCycles(ctx context.Context, clientID
 				"cyclesPaidToday": cycles,
 			},
 			"$setOnInsert": bson.M{
-				"clientId":          clientID,
-				"date":              dateStart,
-				"cyclesPaidThisWeek": 0,
-				"lastResetDate":     dateStart,
+				"clientId":      clientID,
+				"date":          dateStart,
+				"weekStart":     weekStart,
+				"lastResetDate": dateStart,
 			},
 		},
 		options.Update().SetUpsert(true),
@@ -109,16 +192,41 @@ func (r *BinaryCappingRepository) IncrementCycles(ctx context.Context, clientID
 	return err
 }
 
+// mergeDuplicateCappings fusionne les documents de capping d'un même client et d'un même jour
+// (créés en concurrence avant l'index unique): les cycles payés sont cumulés sur le premier
+// document et les autres sont supprimés
+func mergeDuplicateCappings(ctx context.Context, collection *mongo.Collection) error {
+	pipeline := []bson.M{
+		{"$sort": bson.M{"_id": 1}},
+		{"$group": bson.M{
+			"_id":    bson.M{"clientId": "$clientId", "date": "$date"},
+			"ids":    bson.M{"$push": "$_id"},
+			"cycles": bson.M{"$sum": "$cyclesPaidToday"},
+			"count":  bson.M{"$sum": 1},
+		}},
+		{"$match": bson.M{"count": bson.M{"$gt": 1}}},
+	}
 
+	cursor, err := collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
 
-
-
-
-
-
-
-
-
-
-
-
+	for cursor.Next(ctx) {
+		var group struct {
+			IDs    []primitive.ObjectID `bson:"ids"`
+			Cycles int                  `bson:"cycles"`
+		}
+		if err := cursor.Decode(&group); err != nil {
+			return err
+		}
+		if _, err := collection.UpdateOne(ctx, bson.M{"_id": group.IDs[0]}, bson.M{"$set": bson.M{"cyclesPaidToday": group.Cycles}}); err != nil {
+			return err
+		}
+		if _, err := collection.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": group.IDs[1:]}}); err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
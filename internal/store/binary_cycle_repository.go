@@ -0,0 +1,168 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"bureau/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// BinaryCycleRepository conserve l'historique des cycles binaires payés
+type BinaryCycleRepository struct {
+	collection *mongo.Collection
+}
+
+// NewBinaryCycleRepository crée un nouveau repository pour l'historique des cycles
+func NewBinaryCycleRepository(db *mongo.Database) *BinaryCycleRepository {
+	return &BinaryCycleRepository{
+		collection: db.Collection("binary_cycles"),
+	}
+}
+
+// Create enregistre un cycle payé
+func (r *BinaryCycleRepository) Create(ctx context.Context, cycle *models.BinaryCycle) (*models.BinaryCycle, error) {
+	if cycle.ID.IsZero() {
+		cycle.ID = primitive.NewObjectID()
+	}
+	if cycle.ProcessedAt.IsZero() {
+		cycle.ProcessedAt = time.Now()
+	}
+	if cycle.Date.IsZero() {
+		cycle.Date = cycle.ProcessedAt
+	}
+
+	_, err := r.collection.InsertOne(ctx, cycle)
+	if err != nil {
+		return nil, err
+	}
+
+	return cycle, nil
+}
+
+// GetByClientID récupère l'historique des cycles d'un client, du plus récent au plus ancien
+func (r *BinaryCycleRepository) GetByClientID(ctx context.Context, clientID string, filter *models.FilterInput, paging *models.PagingInput) ([]*models.BinaryCycle, error) {
+	objectID, err := primitive.ObjectIDFromHex(clientID)
+	if err != nil {
+		return nil, err
+	}
+
+	query := bson.M{"clientId": objectID}
+	if filter != nil {
+		if filter.DateFrom != nil {
+			query["date"] = bson.M{"$gte": *filter.DateFrom}
+		}
+		if filter.DateTo != nil {
+			if query["date"] == nil {
+				query["date"] = bson.M{}
+			}
+			query["date"].(bson.M)["$lte"] = *filter.DateTo
+		}
+	}
+
+	opts := options.Find()
+	if paging != nil {
+		if paging.Limit != nil {
+			opts.SetLimit(int64(*paging.Limit))
+		}
+		if paging.Page != nil && paging.Limit != nil {
+			skip := int64(*paging.Page-1) * int64(*paging.Limit)
+			opts.SetSkip(skip)
+		}
+	}
+	opts.SetSort(bson.D{{Key: "date", Value: -1}})
+
+	cursor, err := r.collection.Find(ctx, query, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var cycles []*models.BinaryCycle
+	if err = cursor.All(ctx, &cycles); err != nil {
+		return nil, err
+	}
+
+	return cycles, nil
+}
+
+// GetByCommissionID récupère le cycle associé à une commission binaire
+func (r *BinaryCycleRepository) GetByCommissionID(ctx context.Context, commissionID primitive.ObjectID) (*models.BinaryCycle, error) {
+	var cycle models.BinaryCycle
+	err := r.collection.FindOne(ctx, bson.M{"commissionId": commissionID}).Decode(&cycle)
+	if err != nil {
+		return nil, err
+	}
+
+	return &cycle, nil
+}
+
+// GetByDateRange récupère les cycles payés dans [from, to)
+func (r *BinaryCycleRepository) GetByDateRange(ctx context.Context, from, to time.Time) ([]*models.BinaryCycle, error) {
+	cursor, err := r.collection.Find(ctx, bson.M{"date": bson.M{"$gte": from, "$lt": to}})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var cycles []*models.BinaryCycle
+	if err = cursor.All(ctx, &cycles); err != nil {
+		return nil, err
+	}
+
+	return cycles, nil
+}
+
+// AddReversedVolume ajoute au cycle du volume apparié repris après l'annulation d'une vente
+func (r *BinaryCycleRepository) AddReversedVolume(ctx context.Context, id primitive.ObjectID, volume models.Volume) error {
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$inc": bson.M{"reversedVolume": volume}})
+	return err
+}
+
+// GetPairedVolume retourne le volume apparié d'un client sur [from, to): volume utilisé par ses
+// cycles payés dans l'intervalle, net du volume repris après l'annulation de ventes
+func (r *BinaryCycleRepository) GetPairedVolume(ctx context.Context, clientID primitive.ObjectID, from, to time.Time) (models.Volume, error) {
+	volumes, err := r.sumPairedVolume(ctx, bson.M{"clientId": clientID, "date": bson.M{"$gte": from, "$lt": to}})
+	if err != nil {
+		return 0, err
+	}
+	return volumes[clientID], nil
+}
+
+// GetPairedVolumes retourne le volume apparié sur [from, to) de tous les clients ayant des cycles payés dans l'intervalle
+func (r *BinaryCycleRepository) GetPairedVolumes(ctx context.Context, from, to time.Time) (map[primitive.ObjectID]models.Volume, error) {
+	return r.sumPairedVolume(ctx, bson.M{"date": bson.M{"$gte": from, "$lt": to}})
+}
+
+func (r *BinaryCycleRepository) sumPairedVolume(ctx context.Context, match bson.M) (map[primitive.ObjectID]models.Volume, error) {
+	pipeline := []bson.M{
+		{"$match": match},
+		{"$group": bson.M{
+			"_id":    "$clientId",
+			"volume": bson.M{"$sum": bson.M{"$subtract": bson.A{"$leftVolumeUsed", bson.M{"$ifNull": bson.A{"$reversedVolume", 0}}}}},
+		}},
+	}
+
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	volumes := make(map[primitive.ObjectID]models.Volume)
+	for cursor.Next(ctx) {
+		var doc struct {
+			ClientID primitive.ObjectID `bson:"_id"`
+			Volume   models.Volume      `bson:"volume"`
+		}
+		if err := cursor.Decode(&doc); err != nil {
+			return nil, err
+		}
+		volumes[doc.ClientID] = doc.Volume
+	}
+	return volumes, cursor.Err()
+}
@@ -0,0 +1,206 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"bureau/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// BinaryRunRepository gère les exécutions batch des commissions binaires
+// et les verrous de paiement par client et par période
+type BinaryRunRepository struct {
+	collection *mongo.Collection
+	claims     *mongo.Collection
+}
+
+// NewBinaryRunRepository crée un nouveau repository pour les exécutions batch
+func NewBinaryRunRepository(db *mongo.Database) *BinaryRunRepository {
+	return &BinaryRunRepository{
+		collection: db.Collection("binary_runs"),
+		claims:     db.Collection("binary_run_claims"),
+	}
+}
+
+// Create enregistre le démarrage d'une exécution
+func (r *BinaryRunRepository) Create(ctx context.Context, run *models.BinaryCommissionRun) (*models.BinaryCommissionRun, error) {
+	if run.ID.IsZero() {
+		run.ID = primitive.NewObjectID()
+	}
+	if run.StartedAt.IsZero() {
+		run.StartedAt = time.Now()
+	}
+	if run.Errors == nil {
+		run.Errors = []models.BinaryRunError{}
+	}
+
+	_, err := r.collection.InsertOne(ctx, run)
+	if err != nil {
+		return nil, err
+	}
+
+	return run, nil
+}
+
+// GetByID récupère une exécution
+func (r *BinaryRunRepository) GetByID(ctx context.Context, id string) (*models.BinaryCommissionRun, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	var run models.BinaryCommissionRun
+	err = r.collection.FindOne(ctx, bson.M{"_id": objectID}).Decode(&run)
+	if err != nil {
+		return nil, err
+	}
+
+	return &run, nil
+}
+
+// GetAll récupère les exécutions, de la plus récente à la plus ancienne
+func (r *BinaryRunRepository) GetAll(ctx context.Context, paging *models.PagingInput) ([]*models.BinaryCommissionRun, error) {
+	opts := options.Find()
+	if paging != nil {
+		if paging.Limit != nil {
+			opts.SetLimit(int64(*paging.Limit))
+		}
+		if paging.Page != nil && paging.Limit != nil {
+			skip := int64(*paging.Page-1) * int64(*paging.Limit)
+			opts.SetSkip(skip)
+		}
+	}
+	opts.SetSort(bson.D{{Key: "startedAt", Value: -1}})
+
+	cursor, err := r.collection.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var runs []*models.BinaryCommissionRun
+	if err = cursor.All(ctx, &runs); err != nil {
+		return nil, err
+	}
+
+	return runs, nil
+}
+
+// SetTotal enregistre le nombre de clients à traiter
+func (r *BinaryRunRepository) SetTotal(ctx context.Context, runID primitive.ObjectID, total int) error {
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": runID}, bson.M{
+		"$set": bson.M{"clientsTotal": total},
+	})
+	return err
+}
+
+// RecordProcessed incrémente atomiquement les compteurs après le traitement d'un client
+func (r *BinaryRunRepository) RecordProcessed(ctx context.Context, runID primitive.ObjectID, cycles int, amount models.Money) error {
+	inc := bson.M{"clientsProcessed": 1}
+	if cycles > 0 {
+		inc["clientsPaid"] = 1
+		inc["cyclesPaid"] = cycles
+		inc["totalPaid"] = amount
+	}
+
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": runID}, bson.M{"$inc": inc})
+	return err
+}
+
+// RecordSkipped comptabilise un client déjà payé pour la période
+func (r *BinaryRunRepository) RecordSkipped(ctx context.Context, runID primitive.ObjectID) error {
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": runID}, bson.M{
+		"$inc": bson.M{"clientsProcessed": 1, "clientsSkipped": 1},
+	})
+	return err
+}
+
+// RecordError ajoute l'erreur d'un client à l'exécution
+func (r *BinaryRunRepository) RecordError(ctx context.Context, runID primitive.ObjectID, runErr models.BinaryRunError) error {
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": runID}, bson.M{
+		"$inc":  bson.M{"clientsProcessed": 1},
+		"$push": bson.M{"errors": runErr},
+	})
+	return err
+}
+
+// Finish clôture une exécution avec son statut final
+func (r *BinaryRunRepository) Finish(ctx context.Context, runID primitive.ObjectID, status string) error {
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": runID}, bson.M{
+		"$set": bson.M{
+			"status":     status,
+			"finishedAt": time.Now(),
+		},
+	})
+	return err
+}
+
+// Claim réserve le paiement d'un client pour une période, pour la durée lease
+// Retourne false si le client a déjà été payé pour cette période ou si une autre exécution
+// tient encore sa réservation; une réservation expirée sans paiement est reprise
+func (r *BinaryRunRepository) Claim(ctx context.Context, clientID primitive.ObjectID, period string, runID primitive.ObjectID, lease time.Duration) (bool, error) {
+	now := time.Now()
+	expiresAt := now.Add(lease)
+	claim := models.BinaryRunClaim{
+		ID:        claimID(clientID, period),
+		ClientID:  clientID,
+		Period:    period,
+		RunID:     runID,
+		CreatedAt: now,
+		ExpiresAt: &expiresAt,
+	}
+
+	_, err := r.claims.InsertOne(ctx, claim)
+	if err == nil {
+		return true, nil
+	}
+	if !mongo.IsDuplicateKeyError(err) {
+		return false, err
+	}
+
+	// Réservation existante: reprise seulement si elle n'a pas abouti et que son bail est échu
+	result, err := r.claims.UpdateOne(ctx,
+		bson.M{
+			"_id":          claim.ID,
+			"commissionId": bson.M{"$exists": false},
+			"expiresAt":    bson.M{"$not": bson.M{"$gte": now}},
+		},
+		bson.M{"$set": bson.M{
+			"runId":     runID,
+			"createdAt": now,
+			"expiresAt": expiresAt,
+		}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount > 0, nil
+}
+
+// CompleteClaim rattache la commission payée à la réservation, qui n'expire plus
+func (r *BinaryRunRepository) CompleteClaim(ctx context.Context, clientID primitive.ObjectID, period string, commissionID primitive.ObjectID, amount models.Money) error {
+	_, err := r.claims.UpdateOne(ctx, bson.M{"_id": claimID(clientID, period)}, bson.M{
+		"$set": bson.M{
+			"commissionId": commissionID,
+			"amount":       amount,
+		},
+		"$unset": bson.M{"expiresAt": ""},
+	})
+	return err
+}
+
+// ReleaseClaim libère la réservation quand rien n'a été payé, pour qu'une exécution ultérieure
+// de la même période puisse reprendre ce client
+func (r *BinaryRunRepository) ReleaseClaim(ctx context.Context, clientID primitive.ObjectID, period string) error {
+	_, err := r.claims.DeleteOne(ctx, bson.M{"_id": claimID(clientID, period)})
+	return err
+}
+
+func claimID(clientID primitive.ObjectID, period string) string {
+	return period + ":" + clientID.Hex()
+}
@@ -51,7 +51,7 @@ func (r *CaisseRepository) GetOrCreate(ctx context.Context) (*models.Caisse, err
 }
 
 // UpdateBalance updates the caisse balance
-func (r *CaisseRepository) UpdateBalance(ctx context.Context, balance, totalEntrees, totalSorties float64) error {
+func (r *CaisseRepository) UpdateBalance(ctx context.Context, balance, totalEntrees, totalSorties models.Money) error {
 	_, err := r.collection.UpdateOne(
 		ctx,
 		bson.M{},
@@ -127,6 +127,41 @@ func (r *CaisseRepository) GetTransactions(ctx context.Context, filter *models.F
 	return transactions, nil
 }
 
+// GetTransactionsByDateRange gets the transactions dated in [from, to), oldest first
+func (r *CaisseRepository) GetTransactionsByDateRange(ctx context.Context, from, to time.Time) ([]*models.CaisseTransaction, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "date", Value: 1}})
+	cursor, err := r.transactionCollection.Find(ctx, bson.M{
+		"date": bson.M{"$gte": from, "$lt": to},
+	}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var transactions []*models.CaisseTransaction
+	if err = cursor.All(ctx, &transactions); err != nil {
+		return nil, err
+	}
+
+	return transactions, nil
+}
+
+// GetTransactionsByReference gets the transactions linked to a reference (ID of sale or payment)
+func (r *CaisseRepository) GetTransactionsByReference(ctx context.Context, reference string) ([]*models.CaisseTransaction, error) {
+	cursor, err := r.transactionCollection.Find(ctx, bson.M{"reference": reference})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var transactions []*models.CaisseTransaction
+	if err = cursor.All(ctx, &transactions); err != nil {
+		return nil, err
+	}
+
+	return transactions, nil
+}
+
 // GetTransactionByID gets a transaction by ID
 func (r *CaisseRepository) GetTransactionByID(ctx context.Context, id string) (*models.CaisseTransaction, error) {
 	objectID, err := primitive.ObjectIDFromHex(id)
@@ -0,0 +1,94 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"bureau/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrSaleAlreadyReversed est retourné lorsqu'une reprise existe déjà pour la vente
+var ErrSaleAlreadyReversed = errors.New("cette vente a déjà été reprise")
+
+// ClawbackRepository conserve les reprises de ventes annulées ou supprimées
+type ClawbackRepository struct {
+	collection *mongo.Collection
+}
+
+// NewClawbackRepository crée un nouveau repository pour les reprises
+func NewClawbackRepository(db *mongo.Database) *ClawbackRepository {
+	return &ClawbackRepository{
+		collection: db.Collection("clawbacks"),
+	}
+}
+
+// Create enregistre une reprise; l'index unique sur saleId garantit une seule reprise par vente
+func (r *ClawbackRepository) Create(ctx context.Context, clawback *models.Clawback) (*models.Clawback, error) {
+	if clawback.ID.IsZero() {
+		clawback.ID = primitive.NewObjectID()
+	}
+	if clawback.CreatedAt.IsZero() {
+		clawback.CreatedAt = time.Now()
+	}
+	if clawback.Commissions == nil {
+		clawback.Commissions = []models.ClawbackCommission{}
+	}
+
+	_, err := r.collection.InsertOne(ctx, clawback)
+	if mongo.IsDuplicateKeyError(err) {
+		return nil, ErrSaleAlreadyReversed
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return clawback, nil
+}
+
+// GetBySaleID récupère la reprise d'une vente, nil si la vente n'a pas été reprise
+func (r *ClawbackRepository) GetBySaleID(ctx context.Context, saleID primitive.ObjectID) (*models.Clawback, error) {
+	var clawback models.Clawback
+	err := r.collection.FindOne(ctx, bson.M{"saleId": saleID}).Decode(&clawback)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &clawback, nil
+}
+
+// GetAll récupère les reprises, de la plus récente à la plus ancienne
+func (r *ClawbackRepository) GetAll(ctx context.Context, paging *models.PagingInput) ([]*models.Clawback, error) {
+	opts := options.Find()
+	if paging != nil {
+		if paging.Limit != nil {
+			opts.SetLimit(int64(*paging.Limit))
+		}
+		if paging.Page != nil && paging.Limit != nil {
+			skip := int64(*paging.Page-1) * int64(*paging.Limit)
+			opts.SetSkip(skip)
+		}
+	}
+	opts.SetSort(bson.D{{Key: "createdAt", Value: -1}})
+
+	cursor, err := r.collection.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var clawbacks []*models.Clawback
+	if err = cursor.All(ctx, &clawbacks); err != nil {
+		return nil, err
+	}
+
+	return clawbacks, nil
+}
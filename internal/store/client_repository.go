@@ -99,7 +99,7 @@ func (r *ClientRepository) GetAll(ctx context.Context, filter *models.FilterInpu
 }
 
 // GetNetworkVolumesStats calculates total left and right network volumes using aggregation
-func (r *ClientRepository) GetNetworkVolumesStats(ctx context.Context) (leftVolume, rightVolume float64, err error) {
+func (r *ClientRepository) GetNetworkVolumesStats(ctx context.Context) (leftVolume, rightVolume models.Volume, err error) {
 	pipeline := []bson.M{
 		{"$group": bson.M{
 			"_id":         nil,
@@ -114,7 +114,10 @@ func (r *ClientRepository) GetNetworkVolumesStats(ctx context.Context) (leftVolu
 	}
 	defer cursor.Close(ctx)
 
-	var result []bson.M
+	var result []struct {
+		LeftVolume  models.Volume `bson:"leftVolume"`
+		RightVolume models.Volume `bson:"rightVolume"`
+	}
 	if err = cursor.All(ctx, &result); err != nil {
 		return 0, 0, err
 	}
@@ -123,10 +126,7 @@ func (r *ClientRepository) GetNetworkVolumesStats(ctx context.Context) (leftVolu
 		return 0, 0, nil
 	}
 
-	leftVol, _ := result[0]["leftVolume"].(float64)
-	rightVol, _ := result[0]["rightVolume"].(float64)
-
-	return leftVol, rightVol, nil
+	return result[0].LeftVolume, result[0].RightVolume, nil
 }
 
 // GetTotalBinaryPairs calculates total binary pairs using aggregation
@@ -217,7 +217,7 @@ func (r *ClientRepository) UpdateBinaryFields(ctx context.Context, id string, le
 	return err
 }
 
-func (r *ClientRepository) UpdateNetworkVolumes(ctx context.Context, id string, leftVolume, rightVolume float64) error {
+func (r *ClientRepository) UpdateNetworkVolumes(ctx context.Context, id string, leftVolume, rightVolume models.Volume) error {
 	objectID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
 		return err
@@ -232,7 +232,324 @@ func (r *ClientRepository) UpdateNetworkVolumes(ctx context.Context, id string,
 	return err
 }
 
-func (r *ClientRepository) UpdateEarnings(ctx context.Context, id string, totalEarnings, walletBalance float64) error {
+// DeductLegVolumes retire atomiquement le volume apparié par des cycles des deux jambes
+// confirmées, si chacune le couvre encore. Retourne le client mis à jour, ou nil si une jambe
+// ne couvre plus ce volume: le volume crédité en parallèle par $inc n'est jamais écrasé.
+func (r *ClientRepository) DeductLegVolumes(ctx context.Context, id string, volume models.Volume) (*models.Client, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	var client models.Client
+	err = r.collection.FindOneAndUpdate(ctx,
+		bson.M{
+			"_id":                objectID,
+			"networkVolumeLeft":  bson.M{"$gte": volume},
+			"networkVolumeRight": bson.M{"$gte": volume},
+		},
+		bson.M{"$inc": bson.M{
+			"networkVolumeLeft":  -volume,
+			"networkVolumeRight": -volume,
+		}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&client)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &client, nil
+}
+
+// IncrementLegVolumes ajoute atomiquement du volume confirmé et en attente à une jambe ("left" ou "right")
+// Les montants peuvent être négatifs (confirmation ou annulation d'une vente)
+func (r *ClientRepository) IncrementLegVolumes(ctx context.Context, id string, side string, confirmed, pending models.Volume) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	confirmedField, pendingField := "networkVolumeRight", "pendingVolumeRight"
+	if side == "left" {
+		confirmedField, pendingField = "networkVolumeLeft", "pendingVolumeLeft"
+	}
+
+	_, err = r.collection.UpdateOne(ctx, bson.M{"_id": objectID}, bson.M{
+		"$inc": bson.M{
+			confirmedField: confirmed,
+			pendingField:   pending,
+		},
+	})
+	return err
+}
+
+// IncrementPoints ajoute atomiquement des points confirmés et en attente à un client
+func (r *ClientRepository) IncrementPoints(ctx context.Context, id string, confirmed, pending models.Volume) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.collection.UpdateOne(ctx, bson.M{"_id": objectID}, bson.M{
+		"$inc": bson.M{
+			"points":        confirmed,
+			"pendingPoints": pending,
+		},
+	})
+	return err
+}
+
+// UpdateActiveUntil enregistre la fin de la période d'activité (nil la supprime: membre inactif)
+func (r *ClientRepository) UpdateActiveUntil(ctx context.Context, id string, activeUntil *time.Time) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	update := bson.M{"$unset": bson.M{"activeUntil": ""}}
+	if activeUntil != nil {
+		update = bson.M{"$set": bson.M{"activeUntil": *activeUntil}}
+	}
+
+	_, err = r.collection.UpdateOne(ctx, bson.M{"_id": objectID}, update)
+	return err
+}
+
+// IncrementLegCounts ajoute atomiquement des membres et des actifs à une jambe d'un client
+func (r *ClientRepository) IncrementLegCounts(ctx context.Context, id string, side string, members, actives int) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	membersField, activesField := "rightMembers", "rightActives"
+	if side == "left" {
+		membersField, activesField = "leftMembers", "leftActives"
+	}
+
+	_, err = r.collection.UpdateOne(ctx, bson.M{"_id": objectID}, bson.M{
+		"$inc": bson.M{
+			membersField: members,
+			activesField: actives,
+		},
+	})
+	return err
+}
+
+// SetCountedActive bascule l'indicateur countedActive d'un client et indique s'il a changé:
+// seul l'appelant qui a effectué la bascule répercute le changement sur les ancêtres
+func (r *ClientRepository) SetCountedActive(ctx context.Context, id string, active bool) (bool, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return false, err
+	}
+
+	result, err := r.collection.UpdateOne(ctx,
+		bson.M{"_id": objectID, "countedActive": bson.M{"$ne": active}},
+		bson.M{"$set": bson.M{"countedActive": active}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount > 0, nil
+}
+
+// MarkFastStartBonusPaid enregistre le versement du bonus de démarrage rapide d'un membre et
+// indique si l'appelant l'a effectué: un membre ne déclenche ce bonus qu'une seule fois
+func (r *ClientRepository) MarkFastStartBonusPaid(ctx context.Context, id string, paidAt time.Time) (bool, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return false, err
+	}
+
+	result, err := r.collection.UpdateOne(ctx,
+		bson.M{"_id": objectID, "fastStartBonusPaidAt": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"fastStartBonusPaidAt": paidAt}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount > 0, nil
+}
+
+// IncrementQueuedVolume met en file du volume confirmé et en attente pour un membre de la salle
+// d'attente et indique s'il y était encore: sinon le volume doit être crédité à son upline
+func (r *ClientRepository) IncrementQueuedVolume(ctx context.Context, id string, confirmed, pending models.Volume) (bool, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return false, err
+	}
+
+	result, err := r.collection.UpdateOne(ctx,
+		bson.M{"_id": objectID, "holdingTankUntil": bson.M{"$exists": true}},
+		bson.M{"$inc": bson.M{"queuedVolume": confirmed, "queuedPendingVolume": pending}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount > 0, nil
+}
+
+// ReleaseFromHoldingTank place un membre de la salle d'attente sous parentID et vide sa file de volume.
+// Retourne le membre tel qu'il était avant le placement (avec le volume en file), ou nil
+// s'il n'est pas (ou plus) en salle d'attente: seul l'appelant qui l'a libéré crédite sa file.
+func (r *ClientRepository) ReleaseFromHoldingTank(ctx context.Context, id string, parentID primitive.ObjectID, position string) (*models.Client, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	var client models.Client
+	err = r.collection.FindOneAndUpdate(ctx,
+		bson.M{"_id": objectID, "holdingTankUntil": bson.M{"$exists": true}},
+		bson.M{
+			"$set":   bson.M{"placementParentId": parentID, "position": position},
+			"$unset": bson.M{"holdingTankUntil": "", "queuedVolume": "", "queuedPendingVolume": ""},
+		},
+		options.FindOneAndUpdate().SetReturnDocument(options.Before),
+	).Decode(&client)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &client, nil
+}
+
+// GetExpiredHoldingTank retourne les membres de la salle d'attente dont le délai de placement est échu,
+// par date d'inscription (un parrain lui-même en attente est placé avant ses filleuls)
+func (r *ClientRepository) GetExpiredHoldingTank(ctx context.Context, now time.Time) ([]*models.Client, error) {
+	opts := options.Find().SetSort(bson.M{"joinDate": 1})
+
+	cursor, err := r.collection.Find(ctx, bson.M{"holdingTankUntil": bson.M{"$lte": now}}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var clients []*models.Client
+	if err = cursor.All(ctx, &clients); err != nil {
+		return nil, err
+	}
+	return clients, nil
+}
+
+// GetExpiredCountedActives retourne les clients encore comptés comme actifs dont la période d'activité est échue
+func (r *ClientRepository) GetExpiredCountedActives(ctx context.Context, now time.Time) ([]*models.Client, error) {
+	filter := bson.M{
+		"countedActive": true,
+		"$or": []bson.M{
+			{"activeUntil": bson.M{"$lte": now}},
+			{"activeUntil": bson.M{"$exists": false}},
+		},
+	}
+
+	cursor, err := r.collection.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var clients []*models.Client
+	if err = cursor.All(ctx, &clients); err != nil {
+		return nil, err
+	}
+	return clients, nil
+}
+
+// GetByMinWalletBalance retourne les clients dont le solde du portefeuille atteint minBalance,
+// du plus gros solde au plus petit
+func (r *ClientRepository) GetByMinWalletBalance(ctx context.Context, minBalance models.Money) ([]*models.Client, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "walletBalance", Value: -1}})
+	cursor, err := r.collection.Find(ctx, bson.M{"walletBalance": bson.M{"$gte": minBalance}}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var clients []*models.Client
+	if err = cursor.All(ctx, &clients); err != nil {
+		return nil, err
+	}
+	return clients, nil
+}
+
+// BackfillPlacementParents renseigne placementParentId sur les clients placés avant la séparation
+// du parrain et du parent de placement: leur sponsorId désignait alors leur parent dans l'arbre.
+// Retourne le nombre de clients mis à jour; un second passage ne modifie plus rien.
+func (r *ClientRepository) BackfillPlacementParents(ctx context.Context) (int64, error) {
+	result, err := r.collection.UpdateMany(ctx,
+		bson.M{
+			"placementParentId": bson.M{"$exists": false},
+			"sponsorId":         bson.M{"$exists": true},
+			"position":          bson.M{"$exists": true},
+		},
+		mongo.Pipeline{{{Key: "$set", Value: bson.M{"placementParentId": "$sponsorId"}}}},
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
+
+// GetPlacementTree retourne tous les clients réduits aux champs de l'arbre de placement
+// et de l'activité, pour recalculer les compteurs de jambes
+func (r *ClientRepository) GetPlacementTree(ctx context.Context) ([]*models.Client, error) {
+	opts := options.Find().SetProjection(bson.M{
+		"clientId":          1,
+		"placementParentId": 1,
+		"position":          1,
+		"leftChildId":       1,
+		"rightChildId":      1,
+		"activeUntil":       1,
+		"leftMembers":       1,
+		"rightMembers":      1,
+		"leftActives":       1,
+		"rightActives":      1,
+		"countedActive":     1,
+	})
+
+	cursor, err := r.collection.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var clients []*models.Client
+	if err = cursor.All(ctx, &clients); err != nil {
+		return nil, err
+	}
+	return clients, nil
+}
+
+// ApplyLegCountDeltas ajoute en une seule écriture groupée les corrections de compteurs de jambes
+func (r *ClientRepository) ApplyLegCountDeltas(ctx context.Context, deltas []models.LegCountDelta) error {
+	if len(deltas) == 0 {
+		return nil
+	}
+
+	writes := make([]mongo.WriteModel, 0, len(deltas))
+	for _, delta := range deltas {
+		writes = append(writes, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"_id": delta.ClientID}).
+			SetUpdate(bson.M{"$inc": bson.M{
+				"leftMembers":  delta.LeftMembers,
+				"rightMembers": delta.RightMembers,
+				"leftActives":  delta.LeftActives,
+				"rightActives": delta.RightActives,
+			}}))
+	}
+
+	_, err := r.collection.BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false))
+	return err
+}
+
+// UpdateRank enregistre le rang courant et le meilleur rang d'un client
+func (r *ClientRepository) UpdateRank(ctx context.Context, id string, rank, highestRank string) error {
 	objectID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
 		return err
@@ -240,8 +557,8 @@ func (r *ClientRepository) UpdateEarnings(ctx context.Context, id string, totalE
 
 	_, err = r.collection.UpdateOne(ctx, bson.M{"_id": objectID}, bson.M{
 		"$set": bson.M{
-			"totalEarnings": totalEarnings,
-			"walletBalance": walletBalance,
+			"rank":        rank,
+			"highestRank": highestRank,
 		},
 	})
 	return err
@@ -270,7 +587,7 @@ func (r *ClientRepository) UpdatePasswordByClientID(ctx context.Context, clientI
 	return err
 }
 
-func (r *ClientRepository) UpdatePoints(ctx context.Context, id string, points float64) error {
+func (r *ClientRepository) UpdatePoints(ctx context.Context, id string, points models.Volume) error {
 	objectID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
 		return err
@@ -284,7 +601,7 @@ func (r *ClientRepository) UpdatePoints(ctx context.Context, id string, points f
 	return err
 }
 
-func (r *ClientRepository) AddPoints(ctx context.Context, id string, pointsToAdd float64) error {
+func (r *ClientRepository) AddPoints(ctx context.Context, id string, pointsToAdd models.Volume) error {
 	objectID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
 		return err
@@ -337,6 +654,50 @@ func (r *ClientRepository) Count(ctx context.Context, filter *models.FilterInput
 	return r.collection.CountDocuments(ctx, query)
 }
 
+// GetAllIDs retourne les IDs de tous les clients sans charger les documents complets
+func (r *ClientRepository) GetAllIDs(ctx context.Context) ([]primitive.ObjectID, error) {
+	opts := options.Find().SetProjection(bson.M{"_id": 1}).SetSort(bson.M{"joinDate": 1})
+
+	cursor, err := r.collection.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var ids []primitive.ObjectID
+	for cursor.Next(ctx) {
+		var doc struct {
+			ID primitive.ObjectID `bson:"_id"`
+		}
+		if err := cursor.Decode(&doc); err != nil {
+			return nil, err
+		}
+		ids = append(ids, doc.ID)
+	}
+
+	return ids, cursor.Err()
+}
+
+// GetByIDs charge en une requête les clients dont l'ID figure dans ids (ordre non garanti)
+func (r *ClientRepository) GetByIDs(ctx context.Context, ids []primitive.ObjectID) ([]*models.Client, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	cursor, err := r.collection.Find(ctx, bson.M{"_id": bson.M{"$in": ids}})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var clients []*models.Client
+	if err = cursor.All(ctx, &clients); err != nil {
+		return nil, err
+	}
+
+	return clients, nil
+}
+
 func (r *ClientRepository) GetBySponsorID(ctx context.Context, sponsorID string) ([]*models.Client, error) {
 	objectID, err := primitive.ObjectIDFromHex(sponsorID)
 	if err != nil {
@@ -449,6 +810,7 @@ func (r *ClientRepository) GetSubtreeWithGraphLookup(ctx context.Context, rootID
 				"address":            bson.M{"$first": "$address"},
 				"avatar":             bson.M{"$first": "$avatar"},
 				"sponsorId":          bson.M{"$first": "$sponsorId"},
+				"placementParentId":  bson.M{"$first": "$placementParentId"},
 				"position":           bson.M{"$first": "$position"},
 				"leftChildId":        bson.M{"$first": "$leftChildId"},
 				"rightChildId":       bson.M{"$first": "$rightChildId"},
@@ -459,6 +821,11 @@ func (r *ClientRepository) GetSubtreeWithGraphLookup(ctx context.Context, rootID
 				"totalEarnings":      bson.M{"$first": "$totalEarnings"},
 				"walletBalance":      bson.M{"$first": "$walletBalance"},
 				"points":             bson.M{"$first": "$points"},
+				"activeUntil":        bson.M{"$first": "$activeUntil"},
+				"leftMembers":        bson.M{"$first": "$leftMembers"},
+				"rightMembers":       bson.M{"$first": "$rightMembers"},
+				"leftActives":        bson.M{"$first": "$leftActives"},
+				"rightActives":       bson.M{"$first": "$rightActives"},
 			},
 		},
 	}
@@ -14,17 +14,28 @@ import (
 
 type CommissionRepository struct {
 	collection *mongo.Collection
+	periods    *mongo.Collection
 }
 
 func NewCommissionRepository(db *mongo.Database) *CommissionRepository {
 	return &CommissionRepository{
 		collection: db.Collection("commissions"),
+		periods:    db.Collection("pay_periods"),
 	}
 }
 
 func (r *CommissionRepository) Create(ctx context.Context, commission *models.Commission) (*models.Commission, error) {
 	commission.Date = time.Now()
 
+	// Aucune commission ne peut être datée dans une période de paie clôturée
+	closed, err := isClosedAt(ctx, r.periods, commission.Date)
+	if err != nil {
+		return nil, err
+	}
+	if closed {
+		return nil, ErrPeriodClosed
+	}
+
 	result, err := r.collection.InsertOne(ctx, commission)
 	if err != nil {
 		return nil, err
@@ -136,11 +147,87 @@ func (r *CommissionRepository) GetBySourceClientID(ctx context.Context, sourceCl
 	return commissions, nil
 }
 
+// GetBySaleID récupère les commissions rattachées à une vente (bonus de démarrage rapide, reprises)
+func (r *CommissionRepository) GetBySaleID(ctx context.Context, saleID primitive.ObjectID) ([]*models.Commission, error) {
+	cursor, err := r.collection.Find(ctx, bson.M{"saleId": saleID})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var commissions []*models.Commission
+	if err = cursor.All(ctx, &commissions); err != nil {
+		return nil, err
+	}
+
+	return commissions, nil
+}
+
+// GetBySourceCommissionID récupère les commissions calculées sur une autre commission (matching)
+func (r *CommissionRepository) GetBySourceCommissionID(ctx context.Context, commissionID primitive.ObjectID) ([]*models.Commission, error) {
+	cursor, err := r.collection.Find(ctx, bson.M{"sourceCommissionId": commissionID})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var commissions []*models.Commission
+	if err = cursor.All(ctx, &commissions); err != nil {
+		return nil, err
+	}
+
+	return commissions, nil
+}
+
+// GetByDateRange récupère les commissions datées dans [from, to), de la plus ancienne à la plus récente
+func (r *CommissionRepository) GetByDateRange(ctx context.Context, from, to time.Time) ([]*models.Commission, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "date", Value: 1}})
+	cursor, err := r.collection.Find(ctx, bson.M{"date": bson.M{"$gte": from, "$lt": to}}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var commissions []*models.Commission
+	if err = cursor.All(ctx, &commissions); err != nil {
+		return nil, err
+	}
+
+	return commissions, nil
+}
+
+// AssignPeriod fige les commissions datées dans [from, to) en les rattachant à une période clôturée
+func (r *CommissionRepository) AssignPeriod(ctx context.Context, from, to time.Time, periodID primitive.ObjectID) (int64, error) {
+	result, err := r.collection.UpdateMany(ctx,
+		bson.M{"date": bson.M{"$gte": from, "$lt": to}},
+		bson.M{"$set": bson.M{"periodId": periodID}},
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
+
+// ensureNotFrozen refuse la modification d'une commission figée par la clôture de sa période
+func (r *CommissionRepository) ensureNotFrozen(ctx context.Context, objectID primitive.ObjectID) error {
+	count, err := r.collection.CountDocuments(ctx, bson.M{"_id": objectID, "periodId": bson.M{"$exists": true}})
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrPeriodClosed
+	}
+	return nil
+}
+
 func (r *CommissionRepository) Update(ctx context.Context, id string, commission *models.Commission) (*models.Commission, error) {
 	objectID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
 		return nil, err
 	}
+	if err := r.ensureNotFrozen(ctx, objectID); err != nil {
+		return nil, err
+	}
 
 	update := bson.M{
 		"$set": bson.M{
@@ -164,6 +251,9 @@ func (r *CommissionRepository) Delete(ctx context.Context, id string) error {
 	if err != nil {
 		return err
 	}
+	if err := r.ensureNotFrozen(ctx, objectID); err != nil {
+		return err
+	}
 
 	_, err = r.collection.DeleteOne(ctx, bson.M{"_id": objectID})
 	return err
@@ -193,7 +283,7 @@ func (r *CommissionRepository) Count(ctx context.Context, filter *models.FilterI
 	return r.collection.CountDocuments(ctx, query)
 }
 
-func (r *CommissionRepository) GetTotalCommissions(ctx context.Context, filter *models.FilterInput) (float64, error) {
+func (r *CommissionRepository) GetTotalCommissions(ctx context.Context, filter *models.FilterInput) (models.Money, error) {
 	query := bson.M{}
 
 	if filter != nil {
@@ -223,7 +313,9 @@ func (r *CommissionRepository) GetTotalCommissions(ctx context.Context, filter *
 	}
 	defer cursor.Close(ctx)
 
-	var result []bson.M
+	var result []struct {
+		Total models.Money `bson:"total"`
+	}
 	if err = cursor.All(ctx, &result); err != nil {
 		return 0, err
 	}
@@ -232,12 +324,7 @@ func (r *CommissionRepository) GetTotalCommissions(ctx context.Context, filter *
 		return 0, nil
 	}
 
-	total, ok := result[0]["total"].(float64)
-	if !ok {
-		return 0, nil
-	}
-
-	return total, nil
+	return result[0].Total, nil
 }
 
 
@@ -0,0 +1,143 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"bureau/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrCompPlanNotDraft est renvoyée quand on tente d'activer une version qui n'est plus un brouillon
+var ErrCompPlanNotDraft = errors.New("la version du plan n'est pas un brouillon")
+
+// CompPlanRepository gère les versions du plan de rémunération
+type CompPlanRepository struct {
+	collection *mongo.Collection
+}
+
+// NewCompPlanRepository crée un nouveau repository pour les versions du plan
+func NewCompPlanRepository(db *mongo.Database) *CompPlanRepository {
+	return &CompPlanRepository{
+		collection: db.Collection("comp_plans"),
+	}
+}
+
+// Create enregistre un brouillon avec le prochain numéro de version
+// L'index unique sur version fait échouer une création concurrente avec le même numéro.
+func (r *CompPlanRepository) Create(ctx context.Context, plan *models.CompPlanVersion) (*models.CompPlanVersion, error) {
+	var last models.CompPlanVersion
+	err := r.collection.FindOne(ctx, bson.M{}, options.FindOne().SetSort(bson.D{{Key: "version", Value: -1}})).Decode(&last)
+	if err != nil && err != mongo.ErrNoDocuments {
+		return nil, err
+	}
+
+	plan.ID = primitive.NewObjectID()
+	plan.Version = last.Version + 1
+	plan.Status = models.CompPlanStatusDraft
+	plan.CreatedAt = time.Now()
+
+	_, err = r.collection.InsertOne(ctx, plan)
+	if err != nil {
+		return nil, err
+	}
+
+	return plan, nil
+}
+
+// GetByID récupère une version
+func (r *CompPlanRepository) GetByID(ctx context.Context, id string) (*models.CompPlanVersion, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	var plan models.CompPlanVersion
+	err = r.collection.FindOne(ctx, bson.M{"_id": objectID}).Decode(&plan)
+	if err != nil {
+		return nil, err
+	}
+
+	return &plan, nil
+}
+
+// GetAll récupère les versions, de la plus récente à la plus ancienne
+func (r *CompPlanRepository) GetAll(ctx context.Context, paging *models.PagingInput) ([]*models.CompPlanVersion, error) {
+	opts := options.Find()
+	if paging != nil {
+		if paging.Limit != nil {
+			opts.SetLimit(int64(*paging.Limit))
+		}
+		if paging.Page != nil && paging.Limit != nil {
+			skip := int64(*paging.Page-1) * int64(*paging.Limit)
+			opts.SetSkip(skip)
+		}
+	}
+	opts.SetSort(bson.D{{Key: "version", Value: -1}})
+
+	cursor, err := r.collection.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var plans []*models.CompPlanVersion
+	if err = cursor.All(ctx, &plans); err != nil {
+		return nil, err
+	}
+
+	return plans, nil
+}
+
+// Activate rend un brouillon actif à partir de effectiveFrom
+func (r *CompPlanRepository) Activate(ctx context.Context, id primitive.ObjectID, effectiveFrom time.Time, activatedBy *primitive.ObjectID) (*models.CompPlanVersion, error) {
+	now := time.Now()
+	set := bson.M{
+		"status":        models.CompPlanStatusActive,
+		"effectiveFrom": effectiveFrom,
+		"activatedAt":   now,
+	}
+	if activatedBy != nil {
+		set["activatedBy"] = *activatedBy
+	}
+
+	var plan models.CompPlanVersion
+	err := r.collection.FindOneAndUpdate(ctx,
+		bson.M{"_id": id, "status": models.CompPlanStatusDraft},
+		bson.M{"$set": set},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&plan)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrCompPlanNotDraft
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &plan, nil
+}
+
+// GetEffective récupère la version active en vigueur à une date
+// À date d'effet égale, la version la plus récente l'emporte.
+func (r *CompPlanRepository) GetEffective(ctx context.Context, at time.Time) (*models.CompPlanVersion, error) {
+	opts := options.FindOne().SetSort(bson.D{{Key: "effectiveFrom", Value: -1}, {Key: "version", Value: -1}})
+
+	var plan models.CompPlanVersion
+	err := r.collection.FindOne(ctx, bson.M{
+		"status":        models.CompPlanStatusActive,
+		"effectiveFrom": bson.M{"$lte": at},
+	}, opts).Decode(&plan)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &plan, nil
+}
@@ -0,0 +1,130 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"bureau/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// JobRepository gère les baux des tâches planifiées et leur historique d'exécution
+type JobRepository struct {
+	locks      *mongo.Collection
+	executions *mongo.Collection
+}
+
+// NewJobRepository crée un nouveau repository pour le planificateur
+func NewJobRepository(db *mongo.Database) *JobRepository {
+	return &JobRepository{
+		locks:      db.Collection("job_locks"),
+		executions: db.Collection("job_executions"),
+	}
+}
+
+// AcquireLease tente de prendre le bail d'une tâche pour une occurrence planifiée
+// Le bail n'est accordé que s'il est libre (ou expiré) et que l'occurrence n'a pas déjà été
+// prise en charge par une autre instance. Retourne false si une autre instance détient la tâche.
+func (r *JobRepository) AcquireLease(ctx context.Context, jobName, owner string, slot time.Time, lease time.Duration) (bool, error) {
+	now := time.Now()
+
+	filter := bson.M{
+		"_id":         jobName,
+		"lockedUntil": bson.M{"$lte": now},
+		"lastSlot":    bson.M{"$lt": slot},
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"owner":       owner,
+			"lockedUntil": now.Add(lease),
+			"lastSlot":    slot,
+			"acquiredAt":  now,
+		},
+	}
+
+	// Si le document n'existe pas, l'upsert le crée; s'il existe sans correspondre au filtre,
+	// l'upsert entre en collision sur _id et le bail est refusé
+	_, err := r.locks.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	if mongo.IsDuplicateKeyError(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// RenewLease prolonge le bail détenu par owner
+func (r *JobRepository) RenewLease(ctx context.Context, jobName, owner string, lease time.Duration) error {
+	_, err := r.locks.UpdateOne(ctx,
+		bson.M{"_id": jobName, "owner": owner},
+		bson.M{"$set": bson.M{"lockedUntil": time.Now().Add(lease)}},
+	)
+	return err
+}
+
+// ReleaseLease libère le bail détenu par owner
+func (r *JobRepository) ReleaseLease(ctx context.Context, jobName, owner string) error {
+	_, err := r.locks.UpdateOne(ctx,
+		bson.M{"_id": jobName, "owner": owner},
+		bson.M{"$set": bson.M{"lockedUntil": time.Now()}},
+	)
+	return err
+}
+
+// CreateExecution enregistre le démarrage d'une exécution
+func (r *JobRepository) CreateExecution(ctx context.Context, execution *models.JobExecution) (*models.JobExecution, error) {
+	if execution.ID.IsZero() {
+		execution.ID = primitive.NewObjectID()
+	}
+	if execution.StartedAt.IsZero() {
+		execution.StartedAt = time.Now()
+	}
+
+	_, err := r.executions.InsertOne(ctx, execution)
+	if err != nil {
+		return nil, err
+	}
+
+	return execution, nil
+}
+
+// FinishExecution enregistre la fin d'une exécution
+func (r *JobRepository) FinishExecution(ctx context.Context, id primitive.ObjectID, status string, errMsg *string) error {
+	set := bson.M{
+		"status":     status,
+		"finishedAt": time.Now(),
+	}
+	if errMsg != nil {
+		set["error"] = *errMsg
+	}
+
+	_, err := r.executions.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": set})
+	return err
+}
+
+// GetExecutions récupère les dernières exécutions d'une tâche, de la plus récente à la plus ancienne
+func (r *JobRepository) GetExecutions(ctx context.Context, jobName string, limit int) ([]*models.JobExecution, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "startedAt", Value: -1}})
+	if limit > 0 {
+		opts.SetLimit(int64(limit))
+	}
+
+	cursor, err := r.executions.Find(ctx, bson.M{"jobName": jobName}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var executions []*models.JobExecution
+	if err = cursor.All(ctx, &executions); err != nil {
+		return nil, err
+	}
+
+	return executions, nil
+}
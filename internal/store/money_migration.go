@@ -0,0 +1,185 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"bureau/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// legacyNumberTypes sont les types BSON des montants et volumes enregistrés avant models.Money
+var legacyNumberTypes = bson.A{"double", "int", "long"}
+
+// moneyCollection liste les champs models.Money et models.Volume d'une collection
+type moneyCollection struct {
+	name   string
+	fields []string            // Champs, éventuellement de sous-documents ("binary.threshold")
+	arrays map[string][]string // Tableaux de sous-documents et leurs champs
+}
+
+var moneyCollections = []moneyCollection{
+	{name: "clients", fields: []string{
+		"totalEarnings", "walletBalance", "points", "networkVolumeLeft", "networkVolumeRight",
+		"pendingPoints", "pendingVolumeLeft", "pendingVolumeRight", "queuedVolume", "queuedPendingVolume",
+	}},
+	{name: "products", fields: []string{"price", "points"}},
+	{name: "sales", fields: []string{"amount", "paidAmount", "points"}},
+	{name: "payments", fields: []string{"amount"}},
+	{name: "commissions", fields: []string{"amount"}},
+	{name: "caisse", fields: []string{"balance", "totalEntrees", "totalSorties"}},
+	{name: "caisse_transactions", fields: []string{"amount"}},
+	{name: "binary_cycles", fields: []string{
+		"amount", "leftVolumeBefore", "rightVolumeBefore", "leftVolumeUsed", "rightVolumeUsed", "reversedVolume",
+	}},
+	{name: "binary_runs", fields: []string{"totalPaid"}},
+	{name: "binary_run_claims", fields: []string{"amount"}},
+	{name: "comp_plans", fields: []string{"binary.threshold", "binary.cycleValue", "binary.minVolumePerLeg"}},
+	{name: "ranks", fields: []string{"minPairedVolume", "minPersonalVolume"}},
+	{name: "rank_history", fields: []string{"pairedVolume", "personalVolume"}},
+	{
+		name:   "clawbacks",
+		fields: []string{"volume", "pairedVolume", "caisseAmount", "totalAmount"},
+		arrays: map[string][]string{"commissions": {"amount"}},
+	},
+	{name: "pay_periods", fields: []string{"totalCommissions", "totalAdjustments"}},
+	{
+		name: "member_statements",
+		fields: []string{
+			"personalVolume", "pairedVolume", "leftVolumeCarry", "rightVolumeCarry",
+			"totalCommissions", "totalAdjustments", "netAmount",
+		},
+		arrays: map[string][]string{"commissions": {"amount"}, "adjustments": {"amount"}},
+	},
+	{name: "wallet_transactions", fields: []string{"amount", "earnings", "balanceAfter"}},
+	{name: "withdrawals", fields: []string{"amount", "fee", "netAmount"}},
+	{
+		name:   "payout_batches",
+		fields: []string{"minBalance", "totalAmount"},
+		arrays: map[string][]string{"lines": {"amount"}},
+	},
+}
+
+// MoneyMigrationResult est le nombre de documents convertis d'une collection
+type MoneyMigrationResult struct {
+	Collection string
+	Modified   int64
+}
+
+// MigrateMoneyFields convertit en Decimal128, arrondis au centime, les montants et volumes
+// enregistrés en nombres flottants ou entiers avant models.Money. Seuls les documents ayant
+// encore un tel champ sont modifiés: un second passage ne modifie plus rien. L'arrondi est
+// celui de models.NewMoney (demi-centime éloigné de zéro), et non le $round de MongoDB qui
+// arrondit au pair.
+func MigrateMoneyFields(ctx context.Context, db *mongo.Database) ([]MoneyMigrationResult, error) {
+	var results []MoneyMigrationResult
+	for _, c := range moneyCollections {
+		var filter bson.A
+		for _, field := range c.fields {
+			filter = append(filter, bson.M{field: bson.M{"$type": legacyNumberTypes}})
+		}
+		for array, fields := range c.arrays {
+			for _, field := range fields {
+				filter = append(filter, bson.M{array + "." + field: bson.M{"$type": legacyNumberTypes}})
+			}
+		}
+
+		modified, err := c.migrate(ctx, db.Collection(c.name), bson.M{"$or": filter})
+		results = append(results, MoneyMigrationResult{Collection: c.name, Modified: modified})
+		if err != nil {
+			return results, fmt.Errorf("%s: %w", c.name, err)
+		}
+	}
+	return results, nil
+}
+
+// migrate convertit un à un les documents de la collection correspondant au filtre
+func (c moneyCollection) migrate(ctx context.Context, collection *mongo.Collection, filter bson.M) (int64, error) {
+	cursor, err := collection.Find(ctx, filter)
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var modified int64
+	for cursor.Next(ctx) {
+		var doc bson.M
+		if err := cursor.Decode(&doc); err != nil {
+			return modified, err
+		}
+		set := c.convert(doc)
+		if len(set) == 0 {
+			continue
+		}
+		result, err := collection.UpdateOne(ctx, bson.M{"_id": doc["_id"]}, bson.M{"$set": set})
+		if err != nil {
+			return modified, err
+		}
+		modified += result.ModifiedCount
+	}
+	return modified, cursor.Err()
+}
+
+// convert retourne les champs du document à remplacer: chaque ancien nombre devient un
+// montant arrondi au centime; un champ absent, nul ou déjà en Decimal128 est laissé tel quel
+func (c moneyCollection) convert(doc bson.M) bson.M {
+	set := bson.M{}
+	for _, field := range c.fields {
+		if amount, ok := legacyMoney(lookupField(doc, field)); ok {
+			set[field] = amount
+		}
+	}
+	for array, fields := range c.arrays {
+		items, ok := doc[array].(bson.A)
+		if !ok {
+			continue
+		}
+		changed := false
+		for _, item := range items {
+			sub, ok := item.(bson.M)
+			if !ok {
+				continue
+			}
+			for _, field := range fields {
+				if amount, ok := legacyMoney(sub[field]); ok {
+					sub[field] = amount
+					changed = true
+				}
+			}
+		}
+		if changed {
+			set[array] = items
+		}
+	}
+	return set
+}
+
+// lookupField lit un champ, éventuellement d'un sous-document ("binary.threshold")
+func lookupField(doc bson.M, path string) interface{} {
+	var value interface{} = doc
+	for _, key := range strings.Split(path, ".") {
+		sub, ok := value.(bson.M)
+		if !ok {
+			return nil
+		}
+		value = sub[key]
+	}
+	return value
+}
+
+// legacyMoney arrondit au centime un montant ou un volume enregistré avant models.Money
+// (même représentation en centièmes pour les deux types)
+func legacyMoney(value interface{}) (models.Money, bool) {
+	switch n := value.(type) {
+	case float64:
+		return models.NewMoney(n), true
+	case int32:
+		return models.NewMoney(float64(n)), true
+	case int64:
+		return models.NewMoney(float64(n)), true
+	}
+	return 0, false
+}
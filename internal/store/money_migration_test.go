@@ -0,0 +1,71 @@
+package store
+
+import (
+	"testing"
+
+	"bureau/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// decodeLegacy relit un document comme le fait la migration
+func decodeLegacy(t *testing.T, doc interface{}) bson.M {
+	t.Helper()
+	data, err := bson.Marshal(doc)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	var decoded bson.M
+	if err := bson.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	return decoded
+}
+
+// Test: un demi-centime est arrondi en s'éloignant de zéro, comme models.NewMoney
+func TestMoneyMigration_RoundsHalfAwayFromZero(t *testing.T) {
+	c := moneyCollection{fields: []string{"a", "b", "c", "d", "e"}}
+	already, _ := primitive.ParseDecimal128("3.33")
+	set := c.convert(decodeLegacy(t, bson.M{"a": 1.005, "b": 0.125, "c": -2.675, "d": int32(40), "e": already}))
+
+	expected := map[string]models.Money{"a": 101, "b": 13, "c": -268, "d": 4000}
+	for field, want := range expected {
+		if got := set[field]; got != want {
+			t.Errorf("%s: expected %s, got %v", field, want, got)
+		}
+	}
+	if _, ok := set["e"]; ok {
+		t.Error("A Decimal128 field should be left untouched")
+	}
+}
+
+// Test: les champs de sous-documents et de tableaux sont convertis
+func TestMoneyMigration_NestedFields(t *testing.T) {
+	c := moneyCollection{
+		fields: []string{"binary.threshold", "totalAmount"},
+		arrays: map[string][]string{"lines": {"amount"}},
+	}
+	set := c.convert(decodeLegacy(t, bson.M{
+		"binary": bson.M{"threshold": 0.005},
+		"lines":  bson.A{bson.M{"amount": 2.345, "phone": "243812345678"}, bson.M{"amount": int64(7)}},
+	}))
+
+	if got := set["binary.threshold"]; got != models.Money(1) {
+		t.Errorf("Expected 0.01, got %v", got)
+	}
+	if _, ok := set["totalAmount"]; ok {
+		t.Error("A missing field should not be set")
+	}
+	lines, ok := set["lines"].(bson.A)
+	if !ok || len(lines) != 2 {
+		t.Fatalf("Expected the converted lines, got %v", set["lines"])
+	}
+	first := lines[0].(bson.M)
+	if first["amount"] != models.Money(235) || first["phone"] != "243812345678" {
+		t.Errorf("Unexpected first line: %v", first)
+	}
+	if lines[1].(bson.M)["amount"] != models.Money(700) {
+		t.Errorf("Unexpected second line: %v", lines[1])
+	}
+}
@@ -6,6 +6,7 @@ import (
 
 	"bureau/internal/config"
 
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 	"go.uber.org/zap"
@@ -62,12 +63,22 @@ func createIndexes(ctx context.Context, db *mongo.Database) error {
 		{
 			Keys: map[string]interface{}{"sponsorId": 1},
 		},
+		{
+			Keys: map[string]interface{}{"placementParentId": 1},
+		},
+		{
+			Keys:    map[string]interface{}{"holdingTankUntil": 1},
+			Options: options.Index().SetSparse(true),
+		},
 		{
 			Keys: map[string]interface{}{"leftChildId": 1},
 		},
 		{
 			Keys: map[string]interface{}{"rightChildId": 1},
 		},
+		{
+			Keys: bson.D{{Key: "countedActive", Value: 1}, {Key: "activeUntil", Value: 1}},
+		},
 	})
 	if err != nil {
 		return err
@@ -113,28 +124,202 @@ func createIndexes(ctx context.Context, db *mongo.Database) error {
 		{
 			Keys: map[string]interface{}{"date": -1},
 		},
+		{
+			Keys:    map[string]interface{}{"saleId": 1},
+			Options: options.Index().SetSparse(true),
+		},
+		{
+			Keys:    map[string]interface{}{"sourceCommissionId": 1},
+			Options: options.Index().SetSparse(true),
+		},
 	})
 	if err != nil {
 		return err
 	}
 
-	// Admins indexes
-	adminsCollection := db.Collection("admins")
-	_, err = adminsCollection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+	// Binary cycles indexes
+	binaryCyclesCollection := db.Collection("binary_cycles")
+	_, err = binaryCyclesCollection.Indexes().CreateMany(ctx, []mongo.IndexModel{
 		{
-			Keys:    map[string]interface{}{"email": 1},
+			Keys: bson.D{{Key: "clientId", Value: 1}, {Key: "date", Value: -1}},
+		},
+		{
+			Keys: map[string]interface{}{"commissionId": 1},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	// Binary capping indexes: un seul document de compteurs par client et par jour.
+	// Les doublons créés avant l'index sont fusionnés avant de le reconstruire
+	binaryCappingCollection := db.Collection("binary_capping")
+	cappingIndexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "clientId", Value: 1}, {Key: "date", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+	}
+	_, err = binaryCappingCollection.Indexes().CreateMany(ctx, cappingIndexes)
+	if mongo.IsDuplicateKeyError(err) {
+		if err = mergeDuplicateCappings(ctx, binaryCappingCollection); err != nil {
+			return err
+		}
+		_, err = binaryCappingCollection.Indexes().CreateMany(ctx, cappingIndexes)
+	}
+	if err != nil {
+		return err
+	}
+
+	// Comp plan versions indexes
+	compPlansCollection := db.Collection("comp_plans")
+	_, err = compPlansCollection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    map[string]interface{}{"version": 1},
 			Options: options.Index().SetUnique(true),
 		},
+		{
+			Keys: bson.D{{Key: "status", Value: 1}, {Key: "effectiveFrom", Value: -1}},
+		},
 	})
 	if err != nil {
 		return err
 	}
 
-	return nil
-}
+	// Ranks indexes
+	ranksCollection := db.Collection("ranks")
+	_, err = ranksCollection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    map[string]interface{}{"code": 1},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys:    map[string]interface{}{"level": 1},
+			Options: options.Index().SetUnique(true),
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	// Rank history indexes
+	rankHistoryCollection := db.Collection("rank_history")
+	_, err = rankHistoryCollection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "clientId", Value: 1}, {Key: "achievedAt", Value: -1}},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	// Clawbacks indexes: une vente n'est reprise qu'une fois
+	clawbacksCollection := db.Collection("clawbacks")
+	_, err = clawbacksCollection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    map[string]interface{}{"saleId": 1},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: map[string]interface{}{"createdAt": -1},
+		},
+	})
+	if err != nil {
+		return err
+	}
 
+	// Pay periods indexes
+	payPeriodsCollection := db.Collection("pay_periods")
+	_, err = payPeriodsCollection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    map[string]interface{}{"key": 1},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "status", Value: 1}, {Key: "start", Value: 1}, {Key: "end", Value: 1}},
+		},
+	})
+	if err != nil {
+		return err
+	}
 
+	// Member statements indexes
+	statementsCollection := db.Collection("member_statements")
+	_, err = statementsCollection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "periodId", Value: 1}, {Key: "clientId", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "clientId", Value: 1}, {Key: "createdAt", Value: -1}},
+		},
+	})
+	if err != nil {
+		return err
+	}
 
+	// Wallet ledger indexes
+	walletCollection := db.Collection("wallet_transactions")
+	_, err = walletCollection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "referenceType", Value: 1}, {Key: "referenceId", Value: 1}, {Key: "type", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "clientId", Value: 1}, {Key: "createdAt", Value: -1}},
+		},
+	})
+	if err != nil {
+		return err
+	}
 
+	// Withdrawals indexes
+	withdrawalsCollection := db.Collection("withdrawals")
+	_, err = withdrawalsCollection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "clientId", Value: 1}, {Key: "requestedAt", Value: -1}},
+		},
+		{
+			Keys: bson.D{{Key: "status", Value: 1}, {Key: "requestedAt", Value: -1}},
+		},
+	})
+	if err != nil {
+		return err
+	}
 
+	// Payout batches indexes
+	payoutBatchesCollection := db.Collection("payout_batches")
+	_, err = payoutBatchesCollection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "createdAt", Value: -1}},
+		},
+	})
+	if err != nil {
+		return err
+	}
 
+	// Caisse transactions indexes
+	caisseTransactionsCollection := db.Collection("caisse_transactions")
+	_, err = caisseTransactionsCollection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys: map[string]interface{}{"date": -1},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	// Admins indexes
+	adminsCollection := db.Collection("admins")
+	_, err = adminsCollection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    map[string]interface{}{"email": 1},
+			Options: options.Index().SetUnique(true),
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
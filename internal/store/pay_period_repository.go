@@ -0,0 +1,202 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"bureau/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrPeriodClosed est retourné lorsqu'une écriture viserait une période de paie clôturée
+var ErrPeriodClosed = errors.New("la période de paie est clôturée")
+
+// PayPeriodRepository gère les périodes de paie et les relevés produits à leur clôture
+type PayPeriodRepository struct {
+	collection *mongo.Collection
+	statements *mongo.Collection
+}
+
+// NewPayPeriodRepository crée un nouveau repository pour les périodes de paie
+func NewPayPeriodRepository(db *mongo.Database) *PayPeriodRepository {
+	return &PayPeriodRepository{
+		collection: db.Collection("pay_periods"),
+		statements: db.Collection("member_statements"),
+	}
+}
+
+// GetOrCreate retourne la période de même clé, créée ouverte si elle n'existe pas encore
+func (r *PayPeriodRepository) GetOrCreate(ctx context.Context, period *models.PayPeriod) (*models.PayPeriod, error) {
+	update := bson.M{
+		"$setOnInsert": bson.M{
+			"frequency":        period.Frequency,
+			"start":            period.Start,
+			"end":              period.End,
+			"status":           models.PayPeriodStatusOpen,
+			"memberCount":      0,
+			"totalCommissions": 0.0,
+			"totalAdjustments": 0.0,
+			"createdAt":        time.Now(),
+		},
+	}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var stored models.PayPeriod
+	err := r.collection.FindOneAndUpdate(ctx, bson.M{"key": period.Key}, update, opts).Decode(&stored)
+	if err != nil {
+		return nil, err
+	}
+
+	return &stored, nil
+}
+
+// GetByKey récupère une période, nil si elle n'a jamais été ouverte
+func (r *PayPeriodRepository) GetByKey(ctx context.Context, key string) (*models.PayPeriod, error) {
+	var period models.PayPeriod
+	err := r.collection.FindOne(ctx, bson.M{"key": key}).Decode(&period)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &period, nil
+}
+
+// GetAll récupère les périodes, de la plus récente à la plus ancienne
+func (r *PayPeriodRepository) GetAll(ctx context.Context, paging *models.PagingInput) ([]*models.PayPeriod, error) {
+	opts := options.Find()
+	if paging != nil {
+		if paging.Limit != nil {
+			opts.SetLimit(int64(*paging.Limit))
+		}
+		if paging.Page != nil && paging.Limit != nil {
+			skip := int64(*paging.Page-1) * int64(*paging.Limit)
+			opts.SetSkip(skip)
+		}
+	}
+	opts.SetSort(bson.D{{Key: "start", Value: -1}})
+
+	cursor, err := r.collection.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var periods []*models.PayPeriod
+	if err = cursor.All(ctx, &periods); err != nil {
+		return nil, err
+	}
+
+	return periods, nil
+}
+
+// Close passe une période ouverte au statut clôturé
+// Retourne false si la période est déjà clôturée
+func (r *PayPeriodRepository) Close(ctx context.Context, id primitive.ObjectID, closedAt time.Time, closedBy *primitive.ObjectID) (bool, error) {
+	set := bson.M{
+		"status":   models.PayPeriodStatusClosed,
+		"closedAt": closedAt,
+	}
+	if closedBy != nil {
+		set["closedBy"] = *closedBy
+	}
+
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": id, "status": models.PayPeriodStatusOpen}, bson.M{"$set": set})
+	if err != nil {
+		return false, err
+	}
+
+	return result.ModifiedCount == 1, nil
+}
+
+// SetTotals enregistre les totaux des relevés d'une période clôturée
+func (r *PayPeriodRepository) SetTotals(ctx context.Context, id primitive.ObjectID, members int, commissions, adjustments models.Money) error {
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{
+		"$set": bson.M{
+			"memberCount":      members,
+			"totalCommissions": commissions,
+			"totalAdjustments": adjustments,
+		},
+	})
+	return err
+}
+
+// IsClosedAt indique si date tombe dans une période clôturée
+func (r *PayPeriodRepository) IsClosedAt(ctx context.Context, date time.Time) (bool, error) {
+	return isClosedAt(ctx, r.collection, date)
+}
+
+func isClosedAt(ctx context.Context, periods *mongo.Collection, date time.Time) (bool, error) {
+	count, err := periods.CountDocuments(ctx, bson.M{
+		"status": models.PayPeriodStatusClosed,
+		"start":  bson.M{"$lte": date},
+		"end":    bson.M{"$gt": date},
+	}, options.Count().SetLimit(1))
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+// CreateStatements enregistre les relevés d'une période
+func (r *PayPeriodRepository) CreateStatements(ctx context.Context, statements []*models.MemberStatement) error {
+	if len(statements) == 0 {
+		return nil
+	}
+
+	docs := make([]interface{}, 0, len(statements))
+	for _, statement := range statements {
+		if statement.ID.IsZero() {
+			statement.ID = primitive.NewObjectID()
+		}
+		docs = append(docs, statement)
+	}
+
+	_, err := r.statements.InsertMany(ctx, docs)
+	return err
+}
+
+// GetStatementsByPeriod récupère les relevés d'une période
+func (r *PayPeriodRepository) GetStatementsByPeriod(ctx context.Context, periodID primitive.ObjectID, paging *models.PagingInput) ([]*models.MemberStatement, error) {
+	return r.findStatements(ctx, bson.M{"periodId": periodID}, paging)
+}
+
+// GetStatementsByClientID récupère les relevés d'un membre, du plus récent au plus ancien
+func (r *PayPeriodRepository) GetStatementsByClientID(ctx context.Context, clientID primitive.ObjectID, paging *models.PagingInput) ([]*models.MemberStatement, error) {
+	return r.findStatements(ctx, bson.M{"clientId": clientID}, paging)
+}
+
+func (r *PayPeriodRepository) findStatements(ctx context.Context, query bson.M, paging *models.PagingInput) ([]*models.MemberStatement, error) {
+	opts := options.Find()
+	if paging != nil {
+		if paging.Limit != nil {
+			opts.SetLimit(int64(*paging.Limit))
+		}
+		if paging.Page != nil && paging.Limit != nil {
+			skip := int64(*paging.Page-1) * int64(*paging.Limit)
+			opts.SetSkip(skip)
+		}
+	}
+	opts.SetSort(bson.D{{Key: "createdAt", Value: -1}, {Key: "clientId", Value: 1}})
+
+	cursor, err := r.statements.Find(ctx, query, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var statements []*models.MemberStatement
+	if err = cursor.All(ctx, &statements); err != nil {
+		return nil, err
+	}
+
+	return statements, nil
+}
@@ -144,6 +144,12 @@ func (r *PaymentRepository) Update(ctx context.Context, id string, payment *mode
 	return &updatedPayment, nil
 }
 
+// UpdateStatus met à jour le statut d'un paiement ("completed", "pending", "failed")
+func (r *PaymentRepository) UpdateStatus(ctx context.Context, id primitive.ObjectID, status string) error {
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"status": status}})
+	return err
+}
+
 func (r *PaymentRepository) Delete(ctx context.Context, id string) error {
 	objectID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
@@ -182,7 +188,7 @@ func (r *PaymentRepository) Count(ctx context.Context, filter *models.FilterInpu
 	return r.collection.CountDocuments(ctx, query)
 }
 
-func (r *PaymentRepository) GetTotalPayments(ctx context.Context, filter *models.FilterInput) (float64, error) {
+func (r *PaymentRepository) GetTotalPayments(ctx context.Context, filter *models.FilterInput) (models.Money, error) {
 	query := bson.M{}
 
 	if filter != nil {
@@ -212,7 +218,9 @@ func (r *PaymentRepository) GetTotalPayments(ctx context.Context, filter *models
 	}
 	defer cursor.Close(ctx)
 
-	var result []bson.M
+	var result []struct {
+		Total models.Money `bson:"total"`
+	}
 	if err = cursor.All(ctx, &result); err != nil {
 		return 0, err
 	}
@@ -221,12 +229,7 @@ func (r *PaymentRepository) GetTotalPayments(ctx context.Context, filter *models
 		return 0, nil
 	}
 
-	total, ok := result[0]["total"].(float64)
-	if !ok {
-		return 0, nil
-	}
-
-	return total, nil
+	return result[0].Total, nil
 }
 
 
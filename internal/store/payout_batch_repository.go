@@ -0,0 +1,93 @@
+package store
+
+import (
+	"context"
+
+	"bureau/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// PayoutBatchRepository gère les lots de paiement mobile money et leurs lignes
+type PayoutBatchRepository struct {
+	collection *mongo.Collection
+}
+
+// NewPayoutBatchRepository crée un nouveau repository pour les lots de paiement
+func NewPayoutBatchRepository(db *mongo.Database) *PayoutBatchRepository {
+	return &PayoutBatchRepository{
+		collection: db.Collection("payout_batches"),
+	}
+}
+
+// Create enregistre un lot avec ses lignes
+func (r *PayoutBatchRepository) Create(ctx context.Context, batch *models.PayoutBatch) (*models.PayoutBatch, error) {
+	if batch.ID.IsZero() {
+		batch.ID = primitive.NewObjectID()
+	}
+
+	if _, err := r.collection.InsertOne(ctx, batch); err != nil {
+		return nil, err
+	}
+
+	return batch, nil
+}
+
+// GetByID récupère un lot, nil s'il n'existe pas
+func (r *PayoutBatchRepository) GetByID(ctx context.Context, id primitive.ObjectID) (*models.PayoutBatch, error) {
+	var batch models.PayoutBatch
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&batch)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &batch, nil
+}
+
+// GetAll récupère les lots, du plus récent au plus ancien
+func (r *PayoutBatchRepository) GetAll(ctx context.Context, paging *models.PagingInput) ([]*models.PayoutBatch, error) {
+	opts := options.Find()
+	if paging != nil {
+		if paging.Limit != nil {
+			opts.SetLimit(int64(*paging.Limit))
+		}
+		if paging.Page != nil && paging.Limit != nil {
+			skip := int64(*paging.Page-1) * int64(*paging.Limit)
+			opts.SetSkip(skip)
+		}
+	}
+	opts.SetSort(bson.D{{Key: "createdAt", Value: -1}})
+
+	cursor, err := r.collection.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var batches []*models.PayoutBatch
+	if err = cursor.All(ctx, &batches); err != nil {
+		return nil, err
+	}
+
+	return batches, nil
+}
+
+// SaveResults enregistre les lignes, les compteurs et le statut d'un lot après l'import d'un résultat
+func (r *PayoutBatchRepository) SaveResults(ctx context.Context, batch *models.PayoutBatch) error {
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": batch.ID}, bson.M{
+		"$set": bson.M{
+			"lines":          batch.Lines,
+			"status":         batch.Status,
+			"completedCount": batch.CompletedCount,
+			"failedCount":    batch.FailedCount,
+			"importedAt":     batch.ImportedAt,
+		},
+	})
+	return err
+}
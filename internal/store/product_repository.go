@@ -2,6 +2,7 @@ package store
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"bureau/internal/models"
@@ -12,6 +13,9 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+// ErrInsufficientStock est retournée quand le stock du produit ne couvre pas la quantité demandée
+var ErrInsufficientStock = errors.New("stock insuffisant")
+
 type ProductRepository struct {
 	collection *mongo.Collection
 }
@@ -116,6 +120,30 @@ func (r *ProductRepository) Update(ctx context.Context, id string, product *mode
 	return &updatedProduct, nil
 }
 
+// IncrementStock ajoute atomiquement une quantité (négative pour un retrait) au stock d'un produit
+func (r *ProductRepository) IncrementStock(ctx context.Context, id primitive.ObjectID, quantity int) error {
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{
+		"$inc": bson.M{"stock": quantity},
+		"$set": bson.M{"updatedAt": time.Now()},
+	})
+	return err
+}
+
+// DecrementStock retire quantity du stock du produit, de façon atomique, s'il est suffisant
+func (r *ProductRepository) DecrementStock(ctx context.Context, id primitive.ObjectID, quantity int) error {
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": id, "stock": bson.M{"$gte": quantity}}, bson.M{
+		"$inc": bson.M{"stock": -quantity},
+		"$set": bson.M{"updatedAt": time.Now()},
+	})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrInsufficientStock
+	}
+	return nil
+}
+
 func (r *ProductRepository) Delete(ctx context.Context, id string) error {
 	objectID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
@@ -0,0 +1,60 @@
+package store
+
+import (
+	"context"
+
+	"bureau/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// RankHistoryRepository conserve l'historique des changements de rang
+type RankHistoryRepository struct {
+	collection *mongo.Collection
+}
+
+// NewRankHistoryRepository crée un nouveau repository pour l'historique des rangs
+func NewRankHistoryRepository(db *mongo.Database) *RankHistoryRepository {
+	return &RankHistoryRepository{
+		collection: db.Collection("rank_history"),
+	}
+}
+
+// Create enregistre un changement de rang
+func (r *RankHistoryRepository) Create(ctx context.Context, entry *models.RankHistory) (*models.RankHistory, error) {
+	if entry.ID.IsZero() {
+		entry.ID = primitive.NewObjectID()
+	}
+
+	_, err := r.collection.InsertOne(ctx, entry)
+	if err != nil {
+		return nil, err
+	}
+
+	return entry, nil
+}
+
+// GetByClientID récupère l'historique des rangs d'un client, du plus récent au plus ancien
+func (r *RankHistoryRepository) GetByClientID(ctx context.Context, clientID string) ([]*models.RankHistory, error) {
+	objectID, err := primitive.ObjectIDFromHex(clientID)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "achievedAt", Value: -1}})
+	cursor, err := r.collection.Find(ctx, bson.M{"clientId": objectID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var history []*models.RankHistory
+	if err = cursor.All(ctx, &history); err != nil {
+		return nil, err
+	}
+
+	return history, nil
+}
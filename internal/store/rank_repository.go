@@ -0,0 +1,80 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"bureau/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// RankRepository gère les définitions de rangs
+type RankRepository struct {
+	collection *mongo.Collection
+}
+
+// NewRankRepository crée un nouveau repository pour les définitions de rangs
+func NewRankRepository(db *mongo.Database) *RankRepository {
+	return &RankRepository{
+		collection: db.Collection("ranks"),
+	}
+}
+
+// Save crée ou remplace la définition portant le même code
+// Les index uniques sur code et level font échouer deux rangs de même niveau.
+func (r *RankRepository) Save(ctx context.Context, rank *models.RankDefinition) (*models.RankDefinition, error) {
+	now := time.Now()
+	var saved models.RankDefinition
+	err := r.collection.FindOneAndUpdate(ctx,
+		bson.M{"code": rank.Code},
+		bson.M{
+			"$set": bson.M{
+				"name":              rank.Name,
+				"level":             rank.Level,
+				"minPairedVolume":   rank.MinPairedVolume,
+				"minPersonalVolume": rank.MinPersonalVolume,
+				"minQualifiedLegs":  rank.MinQualifiedLegs,
+				"updatedAt":         now,
+			},
+			"$setOnInsert": bson.M{
+				"_id":       primitive.NewObjectID(),
+				"createdAt": now,
+			},
+		},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(&saved)
+	if err != nil {
+		return nil, err
+	}
+
+	return &saved, nil
+}
+
+// GetAll récupère les définitions, du niveau le plus bas au plus haut
+func (r *RankRepository) GetAll(ctx context.Context) ([]*models.RankDefinition, error) {
+	cursor, err := r.collection.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "level", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var ranks []*models.RankDefinition
+	if err = cursor.All(ctx, &ranks); err != nil {
+		return nil, err
+	}
+
+	return ranks, nil
+}
+
+// Delete supprime une définition et indique si elle existait
+func (r *RankRepository) Delete(ctx context.Context, code string) (bool, error) {
+	result, err := r.collection.DeleteOne(ctx, bson.M{"code": code})
+	if err != nil {
+		return false, err
+	}
+	return result.DeletedCount > 0, nil
+}
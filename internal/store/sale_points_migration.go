@@ -0,0 +1,61 @@
+package store
+
+import (
+	"context"
+
+	"bureau/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// BackfillSalePoints renseigne les points des ventes enregistrées avant que la vente porte ses
+// points (points du produit × quantité, 0 pour une vente sans produit ou dont le produit a été
+// supprimé). Sans eux, ces ventes ne comptent pas dans l'activité et leur reprise ne retire
+// aucun volume. Seules les ventes sans champ points sont modifiées: un second passage ne
+// modifie plus rien.
+func BackfillSalePoints(ctx context.Context, db *mongo.Database) (int64, error) {
+	sales := db.Collection("sales")
+	products := db.Collection("products")
+
+	cursor, err := sales.Find(ctx, bson.M{"points": bson.M{"$exists": false}})
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	productPoints := make(map[primitive.ObjectID]models.Volume)
+	var modified int64
+	for cursor.Next(ctx) {
+		var sale models.Sale
+		if err := cursor.Decode(&sale); err != nil {
+			return modified, err
+		}
+
+		var points models.Volume
+		if sale.ProductID != nil {
+			perUnit, ok := productPoints[*sale.ProductID]
+			if !ok {
+				var product models.Product
+				err := products.FindOne(ctx, bson.M{"_id": *sale.ProductID}).Decode(&product)
+				if err != nil && err != mongo.ErrNoDocuments {
+					return modified, err
+				}
+				perUnit = product.Points
+				productPoints[*sale.ProductID] = perUnit
+			}
+			points = perUnit * models.Volume(sale.Quantity)
+		}
+
+		result, err := sales.UpdateOne(ctx,
+			bson.M{"_id": sale.ID, "points": bson.M{"$exists": false}},
+			bson.M{"$set": bson.M{"points": points}},
+		)
+		if err != nil {
+			return modified, err
+		}
+		modified += result.ModifiedCount
+	}
+	return modified, cursor.Err()
+}
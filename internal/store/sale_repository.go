@@ -104,21 +104,24 @@ func (r *SaleRepository) Create(ctx context.Context, sale *models.Sale) (*models
 	return sale, nil
 }
 
+// Update modifie les champs éditables d'une vente (statut, montant payé, note). La date
+// d'origine est conservée: la reprise d'une annulation retire les cycles payés depuis cette date.
 func (r *SaleRepository) Update(ctx context.Context, id string, sale *models.Sale) (*models.Sale, error) {
 	objectID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
 		return nil, err
 	}
 
-	sale.ID = objectID
-	sale.Date = time.Now()
-
-	_, err = r.collection.ReplaceOne(ctx, bson.M{"_id": objectID}, sale)
+	_, err = r.collection.UpdateOne(ctx, bson.M{"_id": objectID}, bson.M{"$set": bson.M{
+		"status":     sale.Status,
+		"paidAmount": sale.PaidAmount,
+		"note":       sale.Note,
+	}})
 	if err != nil {
 		return nil, err
 	}
 
-	return sale, nil
+	return r.GetByID(ctx, id)
 }
 
 func (r *SaleRepository) Delete(ctx context.Context, id string) error {
@@ -151,6 +154,68 @@ func (r *SaleRepository) GetByClientID(ctx context.Context, clientID string) ([]
 	return sales, nil
 }
 
+// GetPaidByDateRange récupère les ventes payées datées dans [from, to)
+func (r *SaleRepository) GetPaidByDateRange(ctx context.Context, from, to time.Time) ([]*models.Sale, error) {
+	cursor, err := r.collection.Find(ctx, bson.M{
+		"status": "paid",
+		"date":   bson.M{"$gte": from, "$lt": to},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var sales []*models.Sale
+	if err = cursor.All(ctx, &sales); err != nil {
+		return nil, err
+	}
+
+	return sales, nil
+}
+
+// GetPaidPoints retourne les points des ventes payées d'un client datées dans [from, to)
+func (r *SaleRepository) GetPaidPoints(ctx context.Context, clientID primitive.ObjectID, from, to time.Time) (models.Volume, error) {
+	points, err := r.sumPaidPoints(ctx, bson.M{"clientId": clientID, "status": "paid", "date": bson.M{"$gte": from, "$lt": to}})
+	if err != nil {
+		return 0, err
+	}
+	return points[clientID], nil
+}
+
+// GetPaidPointsByClient retourne, par client, les points des ventes payées datées dans [from, to)
+func (r *SaleRepository) GetPaidPointsByClient(ctx context.Context, from, to time.Time) (map[primitive.ObjectID]models.Volume, error) {
+	return r.sumPaidPoints(ctx, bson.M{"status": "paid", "date": bson.M{"$gte": from, "$lt": to}})
+}
+
+func (r *SaleRepository) sumPaidPoints(ctx context.Context, match bson.M) (map[primitive.ObjectID]models.Volume, error) {
+	pipeline := []bson.M{
+		{"$match": match},
+		{"$group": bson.M{
+			"_id":    "$clientId",
+			"points": bson.M{"$sum": "$points"},
+		}},
+	}
+
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	points := make(map[primitive.ObjectID]models.Volume)
+	for cursor.Next(ctx) {
+		var doc struct {
+			ClientID primitive.ObjectID `bson:"_id"`
+			Points   models.Volume      `bson:"points"`
+		}
+		if err := cursor.Decode(&doc); err != nil {
+			return nil, err
+		}
+		points[doc.ClientID] = doc.Points
+	}
+	return points, cursor.Err()
+}
+
 func (r *SaleRepository) GetBySponsorID(ctx context.Context, sponsorID string) ([]*models.Sale, error) {
 	objectID, err := primitive.ObjectIDFromHex(sponsorID)
 	if err != nil {
@@ -171,7 +236,7 @@ func (r *SaleRepository) GetBySponsorID(ctx context.Context, sponsorID string) (
 	return sales, nil
 }
 
-func (r *SaleRepository) GetTotalSales(ctx context.Context, filter *models.FilterInput) (float64, error) {
+func (r *SaleRepository) GetTotalSales(ctx context.Context, filter *models.FilterInput) (models.Money, error) {
 	// Build filter
 	filterDoc := bson.M{}
 	if filter != nil {
@@ -209,7 +274,9 @@ func (r *SaleRepository) GetTotalSales(ctx context.Context, filter *models.Filte
 	}
 	defer cursor.Close(ctx)
 
-	var result []bson.M
+	var result []struct {
+		Total models.Money `bson:"total"`
+	}
 	if err = cursor.All(ctx, &result); err != nil {
 		return 0, err
 	}
@@ -218,10 +285,5 @@ func (r *SaleRepository) GetTotalSales(ctx context.Context, filter *models.Filte
 		return 0, nil
 	}
 
-	total, ok := result[0]["total"].(float64)
-	if !ok {
-		return 0, nil
-	}
-
-	return total, nil
+	return result[0].Total, nil
 }
@@ -0,0 +1,232 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"bureau/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrWalletEntryExists est retourné lorsque la référence a déjà donné lieu à une écriture de ce type
+var ErrWalletEntryExists = errors.New("cette écriture a déjà été enregistrée au portefeuille")
+
+// ErrInsufficientBalance est retourné lorsqu'un débit dépasserait le solde du portefeuille
+var ErrInsufficientBalance = errors.New("solde du portefeuille insuffisant")
+
+// WalletRepository tient le journal des portefeuilles (collection wallet_transactions) et sa
+// projection sur les clients (walletBalance, totalEarnings)
+type WalletRepository struct {
+	collection *mongo.Collection
+	clients    *mongo.Collection
+}
+
+// NewWalletRepository crée un nouveau repository pour le journal des portefeuilles
+func NewWalletRepository(db *mongo.Database) *WalletRepository {
+	return &WalletRepository{
+		collection: db.Collection("wallet_transactions"),
+		clients:    db.Collection("clients"),
+	}
+}
+
+// Post enregistre une écriture puis l'applique à la projection du client par incrément
+// atomique: deux écritures concurrentes ne peuvent plus s'écraser. Le solde courant
+// (BalanceAfter) est celui retourné par l'incrément. L'écriture est insérée en premier:
+// si la projection échoue hors transaction, le journal reste la référence et
+// la projection se reconstruit (cmd/walletcheck -fix).
+func (r *WalletRepository) Post(ctx context.Context, entry *models.WalletTransaction) (*models.WalletTransaction, error) {
+	if entry.ID.IsZero() {
+		entry.ID = primitive.NewObjectID()
+	}
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now()
+	}
+
+	_, err := r.collection.InsertOne(ctx, entry)
+	if mongo.IsDuplicateKeyError(err) {
+		return nil, ErrWalletEntryExists
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var projection struct {
+		WalletBalance models.Money `bson:"walletBalance"`
+	}
+	opts := options.FindOneAndUpdate().
+		SetReturnDocument(options.After).
+		SetProjection(bson.M{"walletBalance": 1})
+	err = r.clients.FindOneAndUpdate(ctx, bson.M{"_id": entry.ClientID}, bson.M{
+		"$inc": bson.M{
+			"walletBalance": entry.SignedAmount(),
+			"totalEarnings": entry.Earnings,
+		},
+	}, opts).Decode(&projection)
+	if err == mongo.ErrNoDocuments {
+		return nil, errors.New("client introuvable pour l'écriture de portefeuille")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	entry.BalanceAfter = projection.WalletBalance
+	if _, err := r.collection.UpdateOne(ctx, bson.M{"_id": entry.ID}, bson.M{
+		"$set": bson.M{"balanceAfter": entry.BalanceAfter},
+	}); err != nil {
+		return nil, err
+	}
+
+	return entry, nil
+}
+
+// Debit enregistre un débit seulement si le solde du portefeuille le couvre. Contrairement
+// à Post, la projection est décrémentée en premier, sous condition de solde: deux débits
+// concurrents ne peuvent pas rendre le portefeuille négatif. L'écriture est ensuite insérée
+// avec le solde retourné par la décrémentation.
+func (r *WalletRepository) Debit(ctx context.Context, entry *models.WalletTransaction) (*models.WalletTransaction, error) {
+	if entry.Direction != models.WalletDebit || entry.Amount <= 0 {
+		return nil, errors.New("un débit doit porter un montant positif")
+	}
+	if entry.ID.IsZero() {
+		entry.ID = primitive.NewObjectID()
+	}
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now()
+	}
+
+	var projection struct {
+		WalletBalance models.Money `bson:"walletBalance"`
+	}
+	opts := options.FindOneAndUpdate().
+		SetReturnDocument(options.After).
+		SetProjection(bson.M{"walletBalance": 1})
+	err := r.clients.FindOneAndUpdate(ctx, bson.M{
+		"_id":           entry.ClientID,
+		"walletBalance": bson.M{"$gte": entry.Amount},
+	}, bson.M{
+		"$inc": bson.M{
+			"walletBalance": -entry.Amount,
+			"totalEarnings": entry.Earnings,
+		},
+	}, opts).Decode(&projection)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrInsufficientBalance
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	entry.BalanceAfter = projection.WalletBalance
+	_, err = r.collection.InsertOne(ctx, entry)
+	if mongo.IsDuplicateKeyError(err) {
+		return nil, ErrWalletEntryExists
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return entry, nil
+}
+
+// GetByClientID récupère les écritures d'un membre, de la plus récente à la plus ancienne
+func (r *WalletRepository) GetByClientID(ctx context.Context, clientID primitive.ObjectID, paging *models.PagingInput) ([]*models.WalletTransaction, error) {
+	opts := options.Find()
+	if paging != nil {
+		if paging.Limit != nil {
+			opts.SetLimit(int64(*paging.Limit))
+		}
+		if paging.Page != nil && paging.Limit != nil {
+			skip := int64(*paging.Page-1) * int64(*paging.Limit)
+			opts.SetSkip(skip)
+		}
+	}
+	opts.SetSort(bson.D{{Key: "createdAt", Value: -1}, {Key: "_id", Value: -1}})
+
+	cursor, err := r.collection.Find(ctx, bson.M{"clientId": clientID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var entries []*models.WalletTransaction
+	if err = cursor.All(ctx, &entries); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// LedgerTotals somme les écritures du journal par membre
+func (r *WalletRepository) LedgerTotals(ctx context.Context) (map[primitive.ObjectID]models.WalletTotals, error) {
+	pipeline := []bson.M{
+		{"$group": bson.M{
+			"_id": "$clientId",
+			"balance": bson.M{"$sum": bson.M{
+				"$cond": bson.A{bson.M{"$eq": bson.A{"$direction", models.WalletDebit}}, bson.M{"$multiply": bson.A{"$amount", -1}}, "$amount"},
+			}},
+			"earnings": bson.M{"$sum": "$earnings"},
+		}},
+	}
+
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var rows []struct {
+		ClientID primitive.ObjectID `bson:"_id"`
+		Balance  models.Money       `bson:"balance"`
+		Earnings models.Money       `bson:"earnings"`
+	}
+	if err = cursor.All(ctx, &rows); err != nil {
+		return nil, err
+	}
+
+	totals := make(map[primitive.ObjectID]models.WalletTotals, len(rows))
+	for _, row := range rows {
+		totals[row.ClientID] = models.WalletTotals{Balance: row.Balance, Earnings: row.Earnings}
+	}
+	return totals, nil
+}
+
+// Projections lit le solde et le total des gains enregistrés sur chaque client
+func (r *WalletRepository) Projections(ctx context.Context) (map[primitive.ObjectID]models.WalletTotals, error) {
+	opts := options.Find().SetProjection(bson.M{"walletBalance": 1, "totalEarnings": 1})
+	cursor, err := r.clients.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var rows []struct {
+		ID            primitive.ObjectID `bson:"_id"`
+		WalletBalance models.Money       `bson:"walletBalance"`
+		TotalEarnings models.Money       `bson:"totalEarnings"`
+	}
+	if err = cursor.All(ctx, &rows); err != nil {
+		return nil, err
+	}
+
+	projections := make(map[primitive.ObjectID]models.WalletTotals, len(rows))
+	for _, row := range rows {
+		projections[row.ID] = models.WalletTotals{Balance: row.WalletBalance, Earnings: row.TotalEarnings}
+	}
+	return projections, nil
+}
+
+// SetProjection remplace le solde et le total des gains d'un client (reconstruction depuis le journal)
+func (r *WalletRepository) SetProjection(ctx context.Context, clientID primitive.ObjectID, totals models.WalletTotals) error {
+	_, err := r.clients.UpdateOne(ctx, bson.M{"_id": clientID}, bson.M{
+		"$set": bson.M{
+			"walletBalance": totals.Balance,
+			"totalEarnings": totals.Earnings,
+		},
+	})
+	return err
+}
@@ -0,0 +1,227 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"bureau/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// WithdrawalRepository gère les demandes de retrait du portefeuille
+type WithdrawalRepository struct {
+	collection *mongo.Collection
+}
+
+// NewWithdrawalRepository crée un nouveau repository pour les demandes de retrait
+func NewWithdrawalRepository(db *mongo.Database) *WithdrawalRepository {
+	return &WithdrawalRepository{
+		collection: db.Collection("withdrawals"),
+	}
+}
+
+// Create enregistre une demande de retrait
+func (r *WithdrawalRepository) Create(ctx context.Context, withdrawal *models.Withdrawal) (*models.Withdrawal, error) {
+	if withdrawal.ID.IsZero() {
+		withdrawal.ID = primitive.NewObjectID()
+	}
+
+	if _, err := r.collection.InsertOne(ctx, withdrawal); err != nil {
+		return nil, err
+	}
+
+	return withdrawal, nil
+}
+
+// GetByID récupère une demande, nil si elle n'existe pas
+func (r *WithdrawalRepository) GetByID(ctx context.Context, id primitive.ObjectID) (*models.Withdrawal, error) {
+	var withdrawal models.Withdrawal
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&withdrawal)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &withdrawal, nil
+}
+
+// GetAll récupère les demandes, de la plus récente à la plus ancienne, filtrées par membre
+// et par statut s'ils sont fournis
+func (r *WithdrawalRepository) GetAll(ctx context.Context, clientID *primitive.ObjectID, status *string, paging *models.PagingInput) ([]*models.Withdrawal, error) {
+	query := bson.M{}
+	if clientID != nil {
+		query["clientId"] = *clientID
+	}
+	if status != nil {
+		query["status"] = *status
+	}
+
+	opts := options.Find()
+	if paging != nil {
+		if paging.Limit != nil {
+			opts.SetLimit(int64(*paging.Limit))
+		}
+		if paging.Page != nil && paging.Limit != nil {
+			skip := int64(*paging.Page-1) * int64(*paging.Limit)
+			opts.SetSkip(skip)
+		}
+	}
+	opts.SetSort(bson.D{{Key: "requestedAt", Value: -1}, {Key: "_id", Value: -1}})
+
+	cursor, err := r.collection.Find(ctx, query, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var withdrawals []*models.Withdrawal
+	if err = cursor.All(ctx, &withdrawals); err != nil {
+		return nil, err
+	}
+
+	return withdrawals, nil
+}
+
+// SumAmounts somme les montants des demandes d'un membre ayant l'un des statuts donnés,
+// demandées depuis since (toutes si since est zéro)
+func (r *WithdrawalRepository) SumAmounts(ctx context.Context, clientID primitive.ObjectID, statuses []string, since time.Time) (models.Money, error) {
+	match := bson.M{
+		"clientId": clientID,
+		"status":   bson.M{"$in": statuses},
+	}
+	if !since.IsZero() {
+		match["requestedAt"] = bson.M{"$gte": since}
+	}
+	pipeline := []bson.M{
+		{"$match": match},
+		{"$group": bson.M{"_id": nil, "total": bson.M{"$sum": "$amount"}}},
+	}
+
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var rows []struct {
+		Total models.Money `bson:"total"`
+	}
+	if err = cursor.All(ctx, &rows); err != nil {
+		return 0, err
+	}
+	if len(rows) == 0 {
+		return 0, nil
+	}
+
+	return rows[0].Total, nil
+}
+
+// Review passe une demande en attente au statut donné (approuvée ou rejetée)
+// Retourne false si la demande n'est plus en attente
+func (r *WithdrawalRepository) Review(ctx context.Context, id primitive.ObjectID, status string, reviewedAt time.Time, reviewedBy *primitive.ObjectID, reason *string) (bool, error) {
+	set := bson.M{
+		"status":     status,
+		"reviewedAt": reviewedAt,
+	}
+	if reviewedBy != nil {
+		set["reviewedBy"] = *reviewedBy
+	}
+	if reason != nil {
+		set["rejectionReason"] = *reason
+	}
+
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": id, "status": models.WithdrawalStatusPending}, bson.M{"$set": set})
+	if err != nil {
+		return false, err
+	}
+
+	return result.ModifiedCount == 1, nil
+}
+
+// GetUnbatched récupère les retraits approuvés qui ne font partie d'aucun lot de paiement,
+// du plus ancien au plus récent, limités aux méthodes données si methods n'est pas vide
+func (r *WithdrawalRepository) GetUnbatched(ctx context.Context, methods []string) ([]*models.Withdrawal, error) {
+	query := bson.M{
+		"status":        models.WithdrawalStatusApproved,
+		"payoutBatchId": bson.M{"$exists": false},
+	}
+	if len(methods) > 0 {
+		query["method"] = bson.M{"$in": methods}
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "requestedAt", Value: 1}, {Key: "_id", Value: 1}})
+	cursor, err := r.collection.Find(ctx, query, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var withdrawals []*models.Withdrawal
+	if err = cursor.All(ctx, &withdrawals); err != nil {
+		return nil, err
+	}
+
+	return withdrawals, nil
+}
+
+// AssignBatch rattache des retraits à un lot de paiement, sauf ceux déjà rattachés à un autre lot
+// Retourne le nombre de retraits rattachés
+func (r *WithdrawalRepository) AssignBatch(ctx context.Context, ids []primitive.ObjectID, batchID primitive.ObjectID) (int64, error) {
+	result, err := r.collection.UpdateMany(ctx, bson.M{
+		"_id":           bson.M{"$in": ids},
+		"payoutBatchId": bson.M{"$exists": false},
+	}, bson.M{"$set": bson.M{"payoutBatchId": batchID}})
+	if err != nil {
+		return 0, err
+	}
+
+	return result.ModifiedCount, nil
+}
+
+// MarkPaid enregistre la sortie de caisse du versement confirmé d'un retrait approuvé
+// Retourne false si le retrait n'est plus approuvé ou si son versement est déjà enregistré
+func (r *WithdrawalRepository) MarkPaid(ctx context.Context, id, caisseTransactionID primitive.ObjectID) (bool, error) {
+	result, err := r.collection.UpdateOne(ctx, bson.M{
+		"_id":                 id,
+		"status":              models.WithdrawalStatusApproved,
+		"caisseTransactionId": bson.M{"$exists": false},
+	}, bson.M{"$set": bson.M{"caisseTransactionId": caisseTransactionID}})
+	if err != nil {
+		return false, err
+	}
+
+	return result.ModifiedCount == 1, nil
+}
+
+// MarkFailed passe un retrait approuvé au statut échoué
+// Retourne false si le retrait n'est pas (ou plus) approuvé
+func (r *WithdrawalRepository) MarkFailed(ctx context.Context, id primitive.ObjectID, reason string) (bool, error) {
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": id, "status": models.WithdrawalStatusApproved}, bson.M{
+		"$set": bson.M{
+			"status":        models.WithdrawalStatusFailed,
+			"failureReason": reason,
+		},
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return result.ModifiedCount == 1, nil
+}
+
+// SetPayout enregistre le paiement et l'écriture de portefeuille d'une demande approuvée
+func (r *WithdrawalRepository) SetPayout(ctx context.Context, id, paymentID, walletTransactionID primitive.ObjectID) error {
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{
+		"$set": bson.M{
+			"paymentId":           paymentID,
+			"walletTransactionId": walletTransactionID,
+		},
+	})
+	return err
+}
@@ -5,6 +5,8 @@ import (
 	"regexp"
 	"strings"
 
+	"bureau/internal/models"
+
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
@@ -13,6 +15,7 @@ var (
 	ErrNegativeAmount      = errors.New("le montant doit être positif")
 	ErrZeroAmount          = errors.New("le montant doit être supérieur à zéro")
 	ErrNegativePrice       = errors.New("le prix doit être positif")
+	ErrNegativePoints      = errors.New("les points doivent être positifs")
 	ErrNegativeStock        = errors.New("le stock doit être positif ou zéro")
 	ErrNegativeQuantity     = errors.New("la quantité doit être positive")
 	ErrZeroQuantity         = errors.New("la quantité doit être supérieure à zéro")
@@ -50,7 +53,7 @@ func ValidateObjectIDPtr(id *string) error {
 }
 
 // ValidateAmount validates that an amount is positive
-func ValidateAmount(amount float64) error {
+func ValidateAmount(amount models.Money) error {
 	if amount < 0 {
 		return ErrNegativeAmount
 	}
@@ -58,7 +61,7 @@ func ValidateAmount(amount float64) error {
 }
 
 // ValidateAmountPositive validates that an amount is strictly positive
-func ValidateAmountPositive(amount float64) error {
+func ValidateAmountPositive(amount models.Money) error {
 	if amount <= 0 {
 		return ErrZeroAmount
 	}
@@ -66,7 +69,7 @@ func ValidateAmountPositive(amount float64) error {
 }
 
 // ValidateAmountPtr validates that a pointer to amount is positive (if not nil)
-func ValidateAmountPtr(amount *float64) error {
+func ValidateAmountPtr(amount *models.Money) error {
 	if amount == nil {
 		return nil // nil is valid for optional fields
 	}
@@ -74,13 +77,21 @@ func ValidateAmountPtr(amount *float64) error {
 }
 
 // ValidatePrice validates that a price is positive
-func ValidatePrice(price float64) error {
+func ValidatePrice(price models.Money) error {
 	if price < 0 {
 		return ErrNegativePrice
 	}
 	return nil
 }
 
+// ValidatePoints validates that points are positive
+func ValidatePoints(points models.Volume) error {
+	if points < 0 {
+		return ErrNegativePoints
+	}
+	return nil
+}
+
 // ValidateStock validates that stock is non-negative
 func ValidateStock(stock int32) error {
 	if stock < 0 {
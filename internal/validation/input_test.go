@@ -1,6 +1,10 @@
 package validation
 
-import "testing"
+import (
+	"testing"
+
+	"bureau/internal/models"
+)
 
 func TestValidateObjectID(t *testing.T) {
 	tests := []struct {
@@ -31,12 +35,12 @@ func TestValidateObjectID(t *testing.T) {
 func TestValidateAmount(t *testing.T) {
 	tests := []struct {
 		name      string
-		amount    float64
+		amount    models.Money
 		wantError bool
 	}{
-		{"Positive amount", 100.0, false},
+		{"Positive amount", models.NewMoney(100.0), false},
 		{"Zero amount", 0.0, false},
-		{"Negative amount", -10.0, true},
+		{"Negative amount", models.NewMoney(-10.0), true},
 	}
 
 	for _, tt := range tests {
@@ -55,12 +59,12 @@ func TestValidateAmount(t *testing.T) {
 func TestValidateAmountPositive(t *testing.T) {
 	tests := []struct {
 		name      string
-		amount    float64
+		amount    models.Money
 		wantError bool
 	}{
-		{"Positive amount", 100.0, false},
+		{"Positive amount", models.NewMoney(100.0), false},
 		{"Zero amount", 0.0, true},
-		{"Negative amount", -10.0, true},
+		{"Negative amount", models.NewMoney(-10.0), true},
 	}
 
 	for _, tt := range tests {
@@ -2,17 +2,20 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
+	_ "time/tzdata" // Base des fuseaux embarquée: BUSINESS_TIMEZONE ne dépend pas de l'image
 
 	"bureau/graph"
 	"bureau/internal/auth"
 	"bureau/internal/config"
 	"bureau/internal/models"
+	"bureau/internal/scheduler"
 	"bureau/internal/service"
 	"bureau/internal/store"
 
@@ -91,42 +94,173 @@ func main() {
 	adminRepo := store.NewAdminRepository(db)
 	caisseRepo := store.NewCaisseRepository(db)
 	binaryCappingRepo := store.NewBinaryCappingRepository(db)
+	binaryCycleRepo := store.NewBinaryCycleRepository(db)
+	binaryRunRepo := store.NewBinaryRunRepository(db)
+	jobRepo := store.NewJobRepository(db)
+	compPlanRepo := store.NewCompPlanRepository(db)
+	rankRepo := store.NewRankRepository(db)
+	rankHistoryRepo := store.NewRankHistoryRepository(db)
+	clawbackRepo := store.NewClawbackRepository(db)
+	payPeriodRepo := store.NewPayPeriodRepository(db)
+	walletRepo := store.NewWalletRepository(db)
+	withdrawalRepo := store.NewWithdrawalRepository(db)
+	payoutBatchRepo := store.NewPayoutBatchRepository(db)
 
 	// Initialize JWT service
 	jwtService := auth.NewJWTService(cfg, logger)
 
+	// Les jours, semaines et périodes commencent à minuit dans le fuseau de l'entreprise
+	location, err := time.LoadLocation(cfg.BusinessTimezone)
+	if err != nil {
+		logger.Fatal("Invalid business timezone", zap.String("timezone", cfg.BusinessTimezone), zap.Error(err))
+	}
+
 	// Initialize services
 	productService := service.NewProductService(productRepo, logger)
-	clientService := service.NewClientService(clientRepo, saleRepo, commissionRepo, logger, cfg.BinaryThreshold, cfg.BinaryCommissionRate, cfg.DefaultProductPrice)
-	saleService := service.NewSaleService(saleRepo, logger)
 	paymentService := service.NewPaymentService(paymentRepo, logger)
-	commissionService := service.NewCommissionService(commissionRepo, clientRepo, logger, cfg.BinaryCommissionRate, cfg.BinaryThreshold)
-	adminService := service.NewAdminService(adminRepo, clientRepo, productRepo, saleRepo, commissionRepo, logger)
+	commissionService := service.NewCommissionService(commissionRepo, clientRepo, logger)
+	adminService := service.NewAdminService(adminRepo, clientRepo, productRepo, saleRepo, commissionRepo, logger, location)
 	authService := service.NewAuthService(adminRepo, jwtService, logger)
-	caisseService := service.NewCaisseService(caisseRepo, logger)
-	
+	caisseService := service.NewCaisseService(caisseRepo, logger, location)
+	walletService := service.NewWalletService(walletRepo, logger)
+
 	// Initialize Transaction Helper for atomic operations
 	txHelper := store.NewTransactionHelper(client)
-	
+
+	// Le bonus de démarrage rapide est versé dans la transaction de la vente qui le déclenche
+	fastStartBonusService := service.NewFastStartBonusService(clientRepo, saleRepo, commissionRepo, walletRepo, models.FastStartBonusRule{
+		Rate:   cfg.FastStartBonusRate,
+		Amount: models.NewMoney(cfg.FastStartBonusAmount),
+	}, logger)
+
 	// Initialize Binary Commission Service with new algorithm
 	binaryConfig := models.BinaryConfig{
-		CycleValue:         cfg.BinaryCycleValue,
+		Engine:             cfg.BinaryEngine,
+		Threshold:          models.NewVolume(cfg.BinaryThreshold),
+		CycleValue:         models.NewMoney(cfg.BinaryCycleValue),
 		CommissionRate:     cfg.BinaryCommissionRate,
 		DailyCycleLimit:    cfg.BinaryDailyCycleLimit,
 		WeeklyCycleLimit:   cfg.BinaryWeeklyCycleLimit,
-		MinVolumePerLeg:    cfg.BinaryMinVolumePerLeg,
+		WeekStartDay:       cfg.BinaryWeekStartDay,
+		MinVolumePerLeg:    models.NewVolume(cfg.BinaryMinVolumePerLeg),
 		RequireDirectLeft:  true,
 		RequireDirectRight: true,
+		MatchingBonusRates: cfg.MatchingBonusRates,
 	}
 	binaryCommissionService := service.NewBinaryCommissionService(
 		clientRepo,
 		commissionRepo,
-		saleRepo,
 		binaryCappingRepo,
+		binaryCycleRepo,
+		walletRepo,
 		logger,
 		binaryConfig,
 		txHelper,
+		location,
 	)
+	legacyBinaryEngine := service.NewLegacyBinaryEngine(clientRepo, commissionRepo, walletRepo, logger, binaryConfig)
+
+	// Les règles viennent de la version du plan en vigueur (la configuration d'environnement sert de version 0)
+	compPlanService := service.NewCompPlanService(compPlanRepo, logger, binaryConfig)
+	binaryEngine := service.NewPlanBinaryEngine(compPlanService, binaryCommissionService, legacyBinaryEngine)
+	activityService := service.NewActivityService(clientRepo, saleRepo, logger, models.ActivityRule{
+		MinPoints:  models.NewVolume(cfg.ActivityMinPoints),
+		Window:     cfg.ActivityWindow,
+		WindowDays: cfg.ActivityWindowDays,
+	}, location)
+	legCountService := service.NewLegCountService(clientRepo, logger)
+	rankService := service.NewRankService(rankRepo, rankHistoryRepo, clientRepo, binaryCycleRepo, saleRepo, logger)
+	if !models.IsPayPeriodFrequency(cfg.PayPeriodFrequency) {
+		logger.Fatal("Invalid pay period frequency", zap.String("frequency", cfg.PayPeriodFrequency))
+	}
+	for _, strategy := range []string{cfg.PlacementStrategy, cfg.HoldingTankStrategy} {
+		if !models.IsPlacementStrategy(strategy) {
+			logger.Fatal("Invalid placement strategy", zap.String("strategy", strategy))
+		}
+	}
+	clientService := service.NewClientService(clientRepo, saleRepo, binaryEngine, activityService, legCountService, logger, cfg.PlacementStrategy, models.HoldingTankRule{
+		Days:     cfg.HoldingTankDays,
+		Strategy: cfg.HoldingTankStrategy,
+	}, txHelper)
+	// L'annulation ou la suppression d'une vente est reprise dans la transaction de la vente, comme
+	// son stock, son volume et son entrée de caisse à la création
+	clawbackService := service.NewClawbackService(clawbackRepo, clientRepo, commissionRepo, binaryCycleRepo, caisseService, productRepo, clientService, walletRepo, logger)
+	saleService := service.NewSaleService(saleRepo, productRepo, clientService, caisseService, fastStartBonusService, clawbackService, txHelper, logger)
+	// La clôture d'une période évalue ensuite les rangs sur les volumes de la période
+	payPeriodService := service.NewPayPeriodService(payPeriodRepo, commissionRepo, binaryCycleRepo, saleRepo, clientRepo, rankService, txHelper, models.PayPeriodRule{
+		Frequency:    cfg.PayPeriodFrequency,
+		WeekStartDay: cfg.PayPeriodWeekStartDay,
+	}, logger, location)
+	// L'approbation d'un retrait débite le portefeuille et crée le paiement dans une même transaction;
+	// la sortie de caisse est enregistrée à la confirmation du versement
+	withdrawalService := service.NewWithdrawalService(withdrawalRepo, clientRepo, walletRepo, paymentRepo, caisseService, txHelper, models.WithdrawalRule{
+		MinAmount:  models.NewMoney(cfg.WithdrawalMinAmount),
+		FeeRate:    cfg.WithdrawalFeeRate,
+		FeeAmount:  models.NewMoney(cfg.WithdrawalFeeAmount),
+		DailyLimit: models.NewMoney(cfg.WithdrawalDailyLimit),
+	}, logger, location)
+	payoutBatchService := service.NewPayoutBatchService(payoutBatchRepo, withdrawalRepo, clientRepo, withdrawalService, txHelper, models.PayoutRule{
+		Currency:    cfg.PayoutCurrency,
+		CountryCode: cfg.PayoutCountryCode,
+	}, logger)
+	binaryBatchService := service.NewBinaryBatchService(binaryEngine, clientRepo, binaryRunRepo, logger, cfg.BinaryBatchWorkers)
+
+	// Initialize scheduler (Mongo lease so only one instance runs each job occurrence)
+	instanceID, _ := os.Hostname()
+	instanceID = fmt.Sprintf("%s-%d", instanceID, os.Getpid())
+	jobScheduler := scheduler.New(jobRepo, logger, instanceID, cfg.SchedulerLeaseDuration, location)
+	// Les compteurs de capping sont tenus par jour: aucune remise à zéro planifiée n'est nécessaire
+	if err := jobScheduler.Register(scheduler.Job{
+		Name:        "binary-commission-batch",
+		Schedule:    cfg.BinaryBatchSchedule,
+		Description: "Calcul des commissions binaires sur tout le réseau",
+		Run: func(ctx context.Context) error {
+			_, err := binaryBatchService.Run(ctx)
+			return err
+		},
+	}); err != nil {
+		logger.Fatal("Failed to register scheduled job", zap.Error(err))
+	}
+	// Recalcule les dates de fin d'activité (reprise des données, changement de règle)
+	// puis les compteurs d'actifs des jambes qui en dépendent
+	if err := jobScheduler.Register(scheduler.Job{
+		Name:        "activity-refresh",
+		Schedule:    cfg.ActivityRefreshSchedule,
+		Description: "Recalcul de l'activité des membres et des compteurs de jambes",
+		Run: func(ctx context.Context) error {
+			if _, err := activityService.RefreshAll(ctx); err != nil {
+				return err
+			}
+			_, err := legCountService.Rebuild(ctx)
+			return err
+		},
+	}); err != nil {
+		logger.Fatal("Failed to register scheduled job", zap.Error(err))
+	}
+	// Retire des compteurs d'actifs les membres dont la période d'activité vient d'échoir
+	if err := jobScheduler.Register(scheduler.Job{
+		Name:        "leg-count-expire",
+		Schedule:    cfg.LegCountExpireSchedule,
+		Description: "Mise à jour des actifs de jambe après expiration de l'activité",
+		Run: func(ctx context.Context) error {
+			_, err := legCountService.SyncExpired(ctx)
+			return err
+		},
+	}); err != nil {
+		logger.Fatal("Failed to register scheduled job", zap.Error(err))
+	}
+	// Place automatiquement les membres dont le délai de salle d'attente est échu
+	if err := jobScheduler.Register(scheduler.Job{
+		Name:        "holding-tank-auto-place",
+		Schedule:    cfg.HoldingTankSchedule,
+		Description: "Placement automatique des membres de la salle d'attente",
+		Run: func(ctx context.Context) error {
+			_, err := clientService.AutoPlaceExpired(ctx)
+			return err
+		},
+	}); err != nil {
+		logger.Fatal("Failed to register scheduled job", zap.Error(err))
+	}
 
 	// Initialize GraphQL resolver
 	resolver := graph.NewResolver(
@@ -139,6 +273,16 @@ func main() {
 		adminService,
 		caisseService,
 		binaryCommissionService,
+		binaryEngine,
+		binaryBatchService,
+		compPlanService,
+		rankService,
+		clawbackService,
+		payPeriodService,
+		walletService,
+		withdrawalService,
+		payoutBatchService,
+		jobScheduler,
 	)
 
 	// Create GraphQL handler
@@ -212,6 +356,11 @@ func main() {
 		}
 	}()
 
+	schedulerCtx, stopScheduler := context.WithCancel(context.Background())
+	if cfg.SchedulerEnabled {
+		jobScheduler.Start(schedulerCtx)
+	}
+
 	// Graceful shutdown
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
@@ -224,5 +373,8 @@ func main() {
 	if err := server.Shutdown(shutdownCtx); err != nil {
 		logger.Fatal("Server forced to shutdown", zap.Error(err))
 	}
+
+	stopScheduler()
+	jobScheduler.Wait()
 	logger.Info("Server exited")
 }
@@ -470,3 +470,183 @@ func TestVolumePropagation_Tree(t *testing.T) {
 	}
 }
 
+// TestVolumePropagation_AncestorSide tests that each ancestor is credited on its own leg
+func TestVolumePropagation_AncestorSide(t *testing.T) {
+	tc := SetupTestEnvironment(t)
+	defer TeardownTestEnvironment(t, tc)
+
+	// Root -> Right (right leg) -> Right Left (left leg of Right)
+	rootID := CreateTestClient(t, tc, "Root", nil)
+	_ = CreateTestClient(t, tc, "Left", &rootID)
+	rightID := CreateTestClient(t, tc, "Right", &rootID)
+	rightLeftID := CreateTestClient(t, tc, "Right Left", &rightID)
+
+	productID := CreateTestProduct(t, tc, "Test Product")
+
+	query := `
+		query($id: ID!) {
+			client(id: $id) {
+				networkVolumeLeft
+				networkVolumeRight
+			}
+		}
+	`
+	volumes := func(id string) (float64, float64) {
+		resp := ExecuteGraphQL(t, tc, query, map[string]interface{}{"id": id}, tc.AdminToken)
+		AssertNoErrors(t, resp)
+		data := resp.Data["client"].(map[string]interface{})
+		return data["networkVolumeLeft"].(float64), data["networkVolumeRight"].(float64)
+	}
+
+	rootLeft, rootRight := volumes(rootID)
+	rightLeft, rightRight := volumes(rightID)
+
+	saleID := CreateTestSale(t, tc, rightLeftID, productID, 100.0, "paid")
+
+	// Test product has 10 points, quantity 1
+	newRootLeft, newRootRight := volumes(rootID)
+	if newRootRight-rootRight != 10 || newRootLeft != rootLeft {
+		t.Errorf("Root should get 10 on the right leg only, got left +%.2f right +%.2f", newRootLeft-rootLeft, newRootRight-rootRight)
+	}
+	newRightLeft, newRightRight := volumes(rightID)
+	if newRightLeft-rightLeft != 10 || newRightRight != rightRight {
+		t.Errorf("Right should get 10 on the left leg only, got left +%.2f right +%.2f", newRightLeft-rightLeft, newRightRight-rightRight)
+	}
+
+	resp := ExecuteGraphQL(t, tc, `query($id: ID!) { sale(id: $id) { side } }`, map[string]interface{}{"id": saleID}, tc.AdminToken)
+	AssertNoErrors(t, resp)
+	sale := resp.Data["sale"].(map[string]interface{})
+	if sale["side"] != "left" {
+		t.Errorf("Sale side should be the buyer's leg (left), got %v", sale["side"])
+	}
+}
+
+// TestSaleVolume_PendingToConfirmed tests that volume is pending until the sale is paid and removed on cancel
+func TestSaleVolume_PendingToConfirmed(t *testing.T) {
+	tc := SetupTestEnvironment(t)
+	defer TeardownTestEnvironment(t, tc)
+
+	rootID := CreateTestClient(t, tc, "Root", nil)
+	leftID := CreateTestClient(t, tc, "Left", &rootID)
+	productID := CreateTestProduct(t, tc, "Test Product")
+
+	query := `
+		query($id: ID!) {
+			client(id: $id) {
+				points
+				pendingPoints
+				networkVolumeLeft
+				pendingVolumeLeft
+			}
+		}
+	`
+	fetch := func(id string) map[string]interface{} {
+		resp := ExecuteGraphQL(t, tc, query, map[string]interface{}{"id": id}, tc.AdminToken)
+		AssertNoErrors(t, resp)
+		return resp.Data["client"].(map[string]interface{})
+	}
+	initialRoot := fetch(rootID)
+	initialConfirmed := initialRoot["networkVolumeLeft"].(float64)
+
+	saleID := CreateTestSale(t, tc, leftID, productID, 100.0, "pending")
+
+	left := fetch(leftID)
+	root := fetch(rootID)
+	if left["pendingPoints"].(float64) != 10 || left["points"].(float64) != 0 {
+		t.Errorf("Pending sale should only add pending points, got %v", left)
+	}
+	if root["pendingVolumeLeft"].(float64) != 10 || root["networkVolumeLeft"].(float64) != initialConfirmed {
+		t.Errorf("Pending sale should only add pending leg volume, got %v", root)
+	}
+
+	updateSale := func(status string) {
+		mutation := `
+			mutation($id: ID!, $clientId: ID!, $productId: ID!, $status: String!) {
+				saleUpdate(id: $id, input: {clientId: $clientId, productId: $productId, quantity: 1, amount: 100.0, status: $status}) {
+					id
+				}
+			}
+		`
+		resp := ExecuteGraphQL(t, tc, mutation, map[string]interface{}{
+			"id": saleID, "clientId": leftID, "productId": productID, "status": status,
+		}, tc.AdminToken)
+		AssertNoErrors(t, resp)
+	}
+
+	updateSale("paid")
+	left = fetch(leftID)
+	root = fetch(rootID)
+	if left["pendingPoints"].(float64) != 0 || left["points"].(float64) != 10 {
+		t.Errorf("Paid sale should confirm points, got %v", left)
+	}
+	if root["pendingVolumeLeft"].(float64) != 0 || root["networkVolumeLeft"].(float64) != initialConfirmed+10 {
+		t.Errorf("Paid sale should confirm leg volume, got %v", root)
+	}
+
+	updateSale("cancelled")
+	left = fetch(leftID)
+	root = fetch(rootID)
+	if left["points"].(float64) != 0 || root["networkVolumeLeft"].(float64) != initialConfirmed {
+		t.Errorf("Cancelled sale should remove volume, got %v / %v", left, root)
+	}
+}
+
+// TestPreviewBinaryCommission_WritesNothing verifies the preview query reports a dry run without paying
+func TestPreviewBinaryCommission_WritesNothing(t *testing.T) {
+	tc := SetupTestEnvironment(t)
+	defer TeardownTestEnvironment(t, tc)
+
+	rootID := CreateTestClient(t, tc, "Root", nil)
+	leftID := CreateTestClient(t, tc, "Left", &rootID)
+	rightID := CreateTestClient(t, tc, "Right", &rootID)
+
+	productID := CreateTestProduct(t, tc, "Test Product")
+	CreateTestSale(t, tc, leftID, productID, 100.0, "pending")
+	CreateTestSale(t, tc, rightID, productID, 100.0, "pending")
+
+	query := `
+		query {
+			previewBinaryCommission(clientId: $rootId) {
+				dryRun
+				commissionId
+				qualification {
+					isQualified
+				}
+				legs {
+					leftVolume
+					rightVolume
+				}
+				cyclesAvailable
+				cyclesPaid
+				amount
+			}
+		}
+	`
+	variables := map[string]interface{}{
+		"rootId": rootID,
+	}
+
+	resp := ExecuteGraphQL(t, tc, query, variables, tc.AdminToken)
+	AssertNoErrors(t, resp)
+
+	preview := resp.Data["previewBinaryCommission"].(map[string]interface{})
+	if preview["dryRun"] != true {
+		t.Errorf("Expected dryRun=true, got %v", preview["dryRun"])
+	}
+	if preview["commissionId"] != nil {
+		t.Errorf("Expected no commission id, got %v", preview["commissionId"])
+	}
+
+	// A second preview must see exactly the same volumes: nothing was consumed
+	resp = ExecuteGraphQL(t, tc, query, variables, tc.AdminToken)
+	AssertNoErrors(t, resp)
+
+	again := resp.Data["previewBinaryCommission"].(map[string]interface{})
+	if again["cyclesAvailable"] != preview["cyclesAvailable"] || again["amount"] != preview["amount"] {
+		t.Errorf("Preview must not consume volume (first=%v, second=%v)", preview, again)
+	}
+
+	// Les volumes et gains d'un membre ne sont pas visibles sans authentification
+	resp = ExecuteGraphQL(t, tc, query, variables, "")
+	AssertHasErrors(t, resp)
+}
@@ -480,3 +480,47 @@ func TestCaisse_PaymentLinkedTransaction(t *testing.T) {
 }
 
 
+
+// TestCaisseDailyReport_Today tests the report of the current business day
+func TestCaisseDailyReport_Today(t *testing.T) {
+	tc := SetupTestEnvironment(t)
+	defer TeardownTestEnvironment(t, tc)
+
+	for _, mutation := range []string{
+		`mutation { caisseAddTransaction(input: { type: "entree", amount: 100.0, description: "Report entry" }) { id } }`,
+		`mutation { caisseAddTransaction(input: { type: "sortie", amount: 40.0, description: "Report exit" }) { id } }`,
+	} {
+		resp := ExecuteGraphQL(t, tc, mutation, nil, tc.AdminToken)
+		AssertNoErrors(t, resp)
+	}
+
+	query := `
+		query {
+			caisseDailyReport {
+				date
+				start
+				end
+				totalEntrees
+				totalSorties
+				net
+				transactionCount
+			}
+		}
+	`
+
+	resp := ExecuteGraphQL(t, tc, query, nil, tc.AdminToken)
+	AssertNoErrors(t, resp)
+
+	report := resp.Data["caisseDailyReport"].(map[string]interface{})
+	if report["totalEntrees"].(float64) != 100.0 || report["totalSorties"].(float64) != 40.0 {
+		t.Errorf("Unexpected daily totals: %v / %v", report["totalEntrees"], report["totalSorties"])
+	}
+	if report["net"].(float64) != 60.0 || report["transactionCount"].(float64) != 2 {
+		t.Errorf("Unexpected daily report: %v", report)
+	}
+
+	resp = ExecuteGraphQL(t, tc, `query { caisseDailyReport(date: "13/03/2025") { date } }`, nil, tc.AdminToken)
+	if len(resp.Errors) == 0 {
+		t.Error("An invalid date should be rejected")
+	}
+}
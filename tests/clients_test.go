@@ -1,6 +1,7 @@
 package tests
 
 import (
+	"fmt"
 	"testing"
 )
 
@@ -109,6 +110,205 @@ func TestClientCreate_WithPosition(t *testing.T) {
 	}
 }
 
+// TestClientCreate_WithPlacementParent tests placing a member under a downline member of its sponsor
+func TestClientCreate_WithPlacementParent(t *testing.T) {
+	tc := SetupTestEnvironment(t)
+	defer TeardownTestEnvironment(t, tc)
+
+	rootID := CreateTestClient(t, tc, "Root Client", nil)
+	leftID := CreateTestClient(t, tc, "Left Client", &rootID)
+
+	query := fmt.Sprintf(`
+		mutation {
+			clientCreate(input: {
+				name: "Spillover Client"
+				password: "Test123@client"
+				sponsorId: "%s"
+				placementParentId: "%s"
+				position: "right"
+			}) {
+				id
+				sponsorId
+				placementParentId
+				position
+			}
+		}
+	`, rootID, leftID)
+
+	resp := ExecuteGraphQL(t, tc, query, nil, tc.AdminToken)
+	AssertNoErrors(t, resp)
+
+	data := resp.Data["clientCreate"].(map[string]interface{})
+	if data["sponsorId"] != rootID {
+		t.Errorf("Sponsor should stay the enroller, got %v", data["sponsorId"])
+	}
+	if data["placementParentId"] != leftID || data["position"] != "right" {
+		t.Errorf("Client should be placed right of the left client, got %v/%v", data["placementParentId"], data["position"])
+	}
+
+	// Un parent de placement hors de l'arbre du sponsor est refusé
+	otherRootID := CreateTestClient(t, tc, "Other Root", nil)
+	query = fmt.Sprintf(`
+		mutation {
+			clientCreate(input: {
+				name: "Misplaced Client"
+				password: "Test123@client"
+				sponsorId: "%s"
+				placementParentId: "%s"
+			}) {
+				id
+			}
+		}
+	`, leftID, otherRootID)
+
+	resp = ExecuteGraphQL(t, tc, query, nil, tc.AdminToken)
+	AssertHasErrors(t, resp)
+}
+
+// TestClientCreate_PlacementStrategy tests spillover placement when the sponsor is full
+func TestClientCreate_PlacementStrategy(t *testing.T) {
+	tc := SetupTestEnvironment(t)
+	defer TeardownTestEnvironment(t, tc)
+
+	rootID := CreateTestClient(t, tc, "Root Client", nil)
+	leftID := CreateTestClient(t, tc, "Left Client", &rootID)
+	_ = CreateTestClient(t, tc, "Right Client", &rootID)
+
+	query := fmt.Sprintf(`
+		mutation {
+			clientCreate(input: {
+				name: "Spillover Client"
+				password: "Test123@client"
+				sponsorId: "%s"
+				placementStrategy: "extreme-left"
+			}) {
+				sponsorId
+				placementParentId
+				position
+			}
+		}
+	`, rootID)
+
+	resp := ExecuteGraphQL(t, tc, query, nil, tc.AdminToken)
+	AssertNoErrors(t, resp)
+
+	data := resp.Data["clientCreate"].(map[string]interface{})
+	if data["sponsorId"] != rootID {
+		t.Errorf("Sponsor should stay the enroller, got %v", data["sponsorId"])
+	}
+	if data["placementParentId"] != leftID || data["position"] != "left" {
+		t.Errorf("Client should spill over left of the left client, got %v/%v", data["placementParentId"], data["position"])
+	}
+
+	// Le placement direct ne déborde pas sous un sponsor complet
+	query = fmt.Sprintf(`
+		mutation {
+			clientCreate(input: {
+				name: "Direct Client"
+				password: "Test123@client"
+				sponsorId: "%s"
+				placementStrategy: "direct"
+			}) {
+				id
+			}
+		}
+	`, rootID)
+
+	resp = ExecuteGraphQL(t, tc, query, nil, tc.AdminToken)
+	AssertHasErrors(t, resp)
+}
+
+// TestClientCreate_HoldingTank tests enrollment without placement and later placement
+func TestClientCreate_HoldingTank(t *testing.T) {
+	tc := SetupTestEnvironment(t)
+	defer TeardownTestEnvironment(t, tc)
+
+	rootID := CreateTestClient(t, tc, "Root Client", nil)
+	leftID := CreateTestClient(t, tc, "Left Client", &rootID)
+
+	query := fmt.Sprintf(`
+		mutation {
+			clientCreate(input: {
+				name: "Waiting Client"
+				password: "Test123@client"
+				sponsorId: "%s"
+				holdingTank: true
+			}) {
+				id
+				placementParentId
+				position
+				holdingTankUntil
+			}
+		}
+	`, rootID)
+
+	resp := ExecuteGraphQL(t, tc, query, nil, tc.AdminToken)
+	AssertNoErrors(t, resp)
+
+	data := resp.Data["clientCreate"].(map[string]interface{})
+	waitingID := data["id"].(string)
+	if data["placementParentId"] != nil || data["position"] != nil {
+		t.Errorf("Holding tank member should not be placed, got %v/%v", data["placementParentId"], data["position"])
+	}
+	if data["holdingTankUntil"] == nil {
+		t.Error("Holding tank member should have a placement deadline")
+	}
+
+	// Achat payé pendant l'attente: son volume est mis en file
+	productID := CreateTestProduct(t, tc, "Test Product")
+	CreateTestSale(t, tc, waitingID, productID, 100.0, "paid")
+
+	query = fmt.Sprintf(`
+		mutation {
+			placeClient(clientId: "%s", parentId: "%s", side: "right") {
+				placementParentId
+				position
+				holdingTankUntil
+			}
+		}
+	`, waitingID, leftID)
+
+	resp = ExecuteGraphQL(t, tc, query, nil, tc.AdminToken)
+	AssertNoErrors(t, resp)
+
+	data = resp.Data["placeClient"].(map[string]interface{})
+	if data["placementParentId"] != leftID || data["position"] != "right" {
+		t.Errorf("Client should be placed right of the left client, got %v/%v", data["placementParentId"], data["position"])
+	}
+	if data["holdingTankUntil"] != nil {
+		t.Error("Placed member should leave the holding tank")
+	}
+
+	// Le volume mis en file est crédité à la nouvelle upline
+	query = `
+		query {
+			client(id: $id) {
+				rightChildId
+				networkVolumeRight
+			}
+		}
+	`
+	resp = ExecuteGraphQL(t, tc, query, map[string]interface{}{"id": leftID}, tc.AdminToken)
+	AssertNoErrors(t, resp)
+	leftData := resp.Data["client"].(map[string]interface{})
+	if leftData["rightChildId"] != waitingID {
+		t.Errorf("Left client should have the placed member as right child, got %v", leftData["rightChildId"])
+	}
+	if volume, _ := leftData["networkVolumeRight"].(float64); volume != 10 {
+		t.Errorf("Queued volume 10 should be credited to the upline, got %v", leftData["networkVolumeRight"])
+	}
+
+	// Un membre déjà placé ne peut plus l'être
+	resp = ExecuteGraphQL(t, tc, fmt.Sprintf(`
+		mutation {
+			placeClient(clientId: "%s", parentId: "%s", side: "left") {
+				id
+			}
+		}
+	`, waitingID, leftID), nil, tc.AdminToken)
+	AssertHasErrors(t, resp)
+}
+
 // TestClientCreate_PasswordValidation tests password validation
 func TestClientCreate_PasswordValidation(t *testing.T) {
 	tc := SetupTestEnvironment(t)
@@ -420,3 +620,45 @@ func TestClientCreate_NetworkVolumeCalculation(t *testing.T) {
 	}
 }
 
+// TestClientCreate_NoEnrollmentVolume vérifie que l'inscription n'ajoute aucun volume aux jambes de l'upline
+func TestClientCreate_NoEnrollmentVolume(t *testing.T) {
+	tc := SetupTestEnvironment(t)
+	defer TeardownTestEnvironment(t, tc)
+
+	rootID := CreateTestClient(t, tc, "Root", nil)
+	leftID := CreateTestClient(t, tc, "Left", &rootID)
+	_ = CreateTestClient(t, tc, "Right", &rootID)
+	_ = CreateTestClient(t, tc, "Left Left", &leftID)
+
+	query := `
+		query {
+			client(id: $rootId) {
+				networkVolumeLeft
+				networkVolumeRight
+				pendingVolumeLeft
+				pendingVolumeRight
+			}
+		}
+	`
+	resp := ExecuteGraphQL(t, tc, query, map[string]interface{}{"rootId": rootID}, tc.AdminToken)
+	AssertNoErrors(t, resp)
+
+	client := resp.Data["client"].(map[string]interface{})
+	for _, field := range []string{"networkVolumeLeft", "networkVolumeRight", "pendingVolumeLeft", "pendingVolumeRight"} {
+		if client[field].(float64) != 0 {
+			t.Errorf("Enrollment should not credit %s, got %v", field, client[field])
+		}
+	}
+
+	// Seule une vente payée alimente la jambe
+	productID := CreateTestProduct(t, tc, "Test Product")
+	CreateTestSale(t, tc, leftID, productID, 100.0, "paid")
+
+	resp = ExecuteGraphQL(t, tc, query, map[string]interface{}{"rootId": rootID}, tc.AdminToken)
+	AssertNoErrors(t, resp)
+
+	client = resp.Data["client"].(map[string]interface{})
+	if client["networkVolumeLeft"].(float64) != 10 || client["networkVolumeRight"].(float64) != 0 {
+		t.Errorf("Expected left 10 and right 0 after the paid sale, got %v/%v", client["networkVolumeLeft"], client["networkVolumeRight"])
+	}
+}
@@ -337,3 +337,87 @@ func TestCommission_ClientEarningsUpdate(t *testing.T) {
 	// Earnings may or may not be updated depending on service implementation
 }
 
+
+// TestClosePeriod tests closing the last ended pay period and the open period guard
+func TestClosePeriod(t *testing.T) {
+	tc := SetupTestEnvironment(t)
+	defer TeardownTestEnvironment(t, tc)
+
+	closeMutation := `
+		mutation($key: String) {
+			closePeriod(key: $key) {
+				key
+				status
+				closedAt
+				memberCount
+			}
+		}
+	`
+
+	// Sans clé: la dernière période terminée
+	resp := ExecuteGraphQL(t, tc, closeMutation, map[string]interface{}{}, tc.AdminToken)
+	AssertNoErrors(t, resp)
+	closed := resp.Data["closePeriod"].(map[string]interface{})
+	if closed["status"] != "closed" || closed["closedAt"] == nil {
+		t.Fatalf("Expected a closed period, got %v", closed)
+	}
+
+	// Une seconde clôture est refusée
+	resp = ExecuteGraphQL(t, tc, closeMutation, map[string]interface{}{"key": closed["key"]}, tc.AdminToken)
+	AssertHasErrors(t, resp)
+
+	resp = ExecuteGraphQL(t, tc, `
+		query {
+			payPeriods {
+				key
+				status
+			}
+		}
+	`, nil, tc.AdminToken)
+	AssertNoErrors(t, resp)
+	periods := resp.Data["payPeriods"].([]interface{})
+	if len(periods) != 2 {
+		t.Fatalf("Expected the open and the closed period, got %v", periods)
+	}
+	current := periods[0].(map[string]interface{})
+	if current["status"] != "open" {
+		t.Errorf("Expected the current period to be open, got %v", current)
+	}
+
+	// La période en cours n'est pas terminée
+	resp = ExecuteGraphQL(t, tc, closeMutation, map[string]interface{}{"key": current["key"]}, tc.AdminToken)
+	AssertHasErrors(t, resp)
+}
+
+// TestWalletTransactions tests the wallet ledger query of a member
+func TestWalletTransactions(t *testing.T) {
+	tc := SetupTestEnvironment(t)
+	defer TeardownTestEnvironment(t, tc)
+
+	clientID := CreateTestClient(t, tc, "Wallet Client", nil)
+
+	query := `
+		query($clientId: ID!) {
+			walletTransactions(clientId: $clientId) {
+				id
+				direction
+				type
+				amount
+				balanceAfter
+			}
+		}
+	`
+	variables := map[string]interface{}{
+		"clientId": clientID,
+	}
+
+	resp := ExecuteGraphQL(t, tc, query, variables, tc.AdminToken)
+	AssertNoErrors(t, resp)
+	if entries := resp.Data["walletTransactions"].([]interface{}); len(entries) != 0 {
+		t.Errorf("Expected an empty ledger for a new member, got %v", entries)
+	}
+
+	// Sans authentification
+	resp = ExecuteGraphQL(t, tc, query, variables, "")
+	AssertHasErrors(t, resp)
+}
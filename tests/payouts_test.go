@@ -0,0 +1,146 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"bureau/internal/models"
+	"bureau/internal/store"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// TestPayoutBatch_ExportAndImport tests a mobile-money batch from export to a failed result
+func TestPayoutBatch_ExportAndImport(t *testing.T) {
+	tc := SetupTestEnvironment(t)
+	defer TeardownTestEnvironment(t, tc)
+
+	clientID := CreateTestClient(t, tc, "Payout Client", nil)
+	clientToken := loginTestClient(t, tc, clientID)
+
+	clientOID, _ := primitive.ObjectIDFromHex(clientID)
+	_, err := store.NewWalletRepository(tc.MongoDB).Post(context.Background(), &models.WalletTransaction{
+		ClientID:       clientOID,
+		Direction:      models.WalletCredit,
+		Type:           models.WalletEntryOpening,
+		Amount:         models.NewMoney(100),
+		Earnings:       models.NewMoney(100),
+		CounterAccount: models.WalletAccountOpening,
+		ReferenceType:  models.WalletReferenceClient,
+		ReferenceID:    clientOID,
+	})
+	if err != nil {
+		t.Fatalf("Failed to credit the wallet: %v", err)
+	}
+
+	resp := ExecuteGraphQL(t, tc, `
+		mutation {
+			requestWithdrawal(input: { amount: 40, method: "mobile", destination: "+243 810 000 000" }) {
+				id
+			}
+		}
+	`, nil, clientToken)
+	AssertNoErrors(t, resp)
+	withdrawalID := resp.Data["requestWithdrawal"].(map[string]interface{})["id"]
+
+	resp = ExecuteGraphQL(t, tc, `
+		mutation($id: ID!) {
+			approveWithdrawal(id: $id) {
+				paymentId
+			}
+		}
+	`, map[string]interface{}{"id": withdrawalID}, tc.AdminToken)
+	AssertNoErrors(t, resp)
+	paymentID := resp.Data["approveWithdrawal"].(map[string]interface{})["paymentId"].(string)
+
+	createMutation := `
+		mutation {
+			createPayoutBatch(input: { provider: "orange", source: "withdrawals" }) {
+				id
+				status
+				lineCount
+				totalAmount
+				lines {
+					reference
+					phone
+				}
+			}
+		}
+	`
+	// Seul un admin crée un lot
+	resp = ExecuteGraphQL(t, tc, createMutation, nil, clientToken)
+	AssertHasErrors(t, resp)
+
+	resp = ExecuteGraphQL(t, tc, createMutation, nil, tc.AdminToken)
+	AssertNoErrors(t, resp)
+	batch := resp.Data["createPayoutBatch"].(map[string]interface{})
+	line := batch["lines"].([]interface{})[0].(map[string]interface{})
+	if batch["status"] != "pending" || batch["lineCount"] != 1.0 || batch["totalAmount"] != 40.0 || line["reference"] != paymentID || line["phone"] != "243810000000" {
+		t.Fatalf("Unexpected batch: %v", batch)
+	}
+
+	resp = ExecuteGraphQL(t, tc, `
+		query($id: ID!) {
+			payoutBatchFile(id: $id) {
+				filename
+				content
+			}
+		}
+	`, map[string]interface{}{"id": batch["id"]}, tc.AdminToken)
+	AssertNoErrors(t, resp)
+	file := resp.Data["payoutBatchFile"].(map[string]interface{})
+	if !strings.HasPrefix(file["content"].(string), "Numero;Montant;Devise;Nom;Reference\n243810000000;40.00;") {
+		t.Errorf("Unexpected Orange Money file: %v", file)
+	}
+
+	resp = ExecuteGraphQL(t, tc, `
+		mutation($id: ID!, $content: String!) {
+			importPayoutResults(batchId: $id, content: $content) {
+				status
+				failedCount
+				lines {
+					status
+					error
+				}
+			}
+		}
+	`, map[string]interface{}{
+		"id":      batch["id"],
+		"content": fmt.Sprintf("Reference;Statut;Motif\n%s;Echec;Compte inactif\n", paymentID),
+	}, tc.AdminToken)
+	AssertNoErrors(t, resp)
+	imported := resp.Data["importPayoutResults"].(map[string]interface{})
+	if imported["status"] != "completed" || imported["failedCount"] != 1.0 {
+		t.Fatalf("Unexpected imported batch: %v", imported)
+	}
+
+	// Le versement échoué est rendu au portefeuille
+	resp = ExecuteGraphQL(t, tc, `
+		query($clientId: ID!) {
+			walletTransactions(clientId: $clientId) {
+				type
+				balanceAfter
+			}
+		}
+	`, map[string]interface{}{"clientId": clientID}, clientToken)
+	AssertNoErrors(t, resp)
+	latest := resp.Data["walletTransactions"].([]interface{})[0].(map[string]interface{})
+	if latest["type"] != "refund" || latest["balanceAfter"] != 100.0 {
+		t.Errorf("Expected a refund restoring 100, got %v", latest)
+	}
+
+	resp = ExecuteGraphQL(t, tc, `
+		query {
+			withdrawals(status: "failed") {
+				failureReason
+			}
+		}
+	`, nil, clientToken)
+	AssertNoErrors(t, resp)
+	failed := resp.Data["withdrawals"].([]interface{})
+	if len(failed) != 1 || failed[0].(map[string]interface{})["failureReason"] != "Compte inactif" {
+		t.Errorf("Expected the failed withdrawal, got %v", failed)
+	}
+}
@@ -464,6 +464,117 @@ func TestSaleDelete(t *testing.T) {
 	AssertNoErrors(t, resp)
 }
 
+// TestSaleDelete_Clawback tests that deleting a paid sale reverses its volume, caisse entry and stock
+func TestSaleDelete_Clawback(t *testing.T) {
+	tc := SetupTestEnvironment(t)
+	defer TeardownTestEnvironment(t, tc)
+
+	rootID := CreateTestClient(t, tc, "Root", nil)
+	leftID := CreateTestClient(t, tc, "Left", &rootID)
+	productID := CreateTestProduct(t, tc, "Test Product")
+
+	query := `
+		query($clientId: ID!, $productId: ID!) {
+			client(id: $clientId) {
+				points
+				networkVolumeLeft
+			}
+			product(id: $productId) {
+				stock
+			}
+			caisse {
+				balance
+			}
+		}
+	`
+	fetch := func(clientID string) map[string]interface{} {
+		resp := ExecuteGraphQL(t, tc, query, map[string]interface{}{"clientId": clientID, "productId": productID}, tc.AdminToken)
+		AssertNoErrors(t, resp)
+		return resp.Data
+	}
+	before := fetch(rootID)
+
+	saleID := CreateTestSale(t, tc, leftID, productID, 100.0, "paid")
+
+	resp := ExecuteGraphQL(t, tc, `
+		mutation($id: ID!) {
+			saleDelete(id: $id)
+		}
+	`, map[string]interface{}{"id": saleID}, tc.AdminToken)
+	AssertNoErrors(t, resp)
+
+	after := fetch(rootID)
+	if after["client"].(map[string]interface{})["networkVolumeLeft"] != before["client"].(map[string]interface{})["networkVolumeLeft"] {
+		t.Errorf("Deleted sale should remove leg volume, got %v / %v", after["client"], before["client"])
+	}
+	if after["product"].(map[string]interface{})["stock"] != before["product"].(map[string]interface{})["stock"] {
+		t.Errorf("Deleted sale should restore stock, got %v / %v", after["product"], before["product"])
+	}
+	if after["caisse"].(map[string]interface{})["balance"] != before["caisse"].(map[string]interface{})["balance"] {
+		t.Errorf("Deleted sale should refund the caisse, got %v / %v", after["caisse"], before["caisse"])
+	}
+	if left := fetch(leftID)["client"].(map[string]interface{}); left["points"].(float64) != 0 {
+		t.Errorf("Deleted sale should remove the buyer's points, got %v", left)
+	}
+
+	resp = ExecuteGraphQL(t, tc, `
+		query($saleId: ID!) {
+			clawback(saleId: $saleId) {
+				saleId
+				reason
+				saleStatus
+				volume
+				stockRestored
+				caisseAmount
+			}
+		}
+	`, map[string]interface{}{"saleId": saleID}, tc.AdminToken)
+	AssertNoErrors(t, resp)
+	clawback, ok := resp.Data["clawback"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("Expected a clawback record for the sale, got %v", resp.Data)
+	}
+	if clawback["saleId"] != saleID || clawback["reason"] != "deleted" || clawback["saleStatus"] != "paid" ||
+		clawback["volume"].(float64) != 10 || clawback["stockRestored"].(float64) != 1 || clawback["caisseAmount"].(float64) != 100 {
+		t.Errorf("Unexpected clawback: %v", clawback)
+	}
+}
+
+// TestSaleUpdate_CancelledIsFinal tests that a cancelled sale is reversed once and can no longer be updated
+func TestSaleUpdate_CancelledIsFinal(t *testing.T) {
+	tc := SetupTestEnvironment(t)
+	defer TeardownTestEnvironment(t, tc)
+
+	clientID := CreateTestClient(t, tc, "Test Client", nil)
+	productID := CreateTestProduct(t, tc, "Test Product")
+	saleID := CreateTestSale(t, tc, clientID, productID, 100.0, "paid")
+
+	mutation := `
+		mutation($id: ID!, $clientId: ID!, $productId: ID!, $status: String!) {
+			saleUpdate(id: $id, input: {clientId: $clientId, productId: $productId, quantity: 1, amount: 100.0, status: $status}) {
+				id
+			}
+		}
+	`
+	variables := map[string]interface{}{"id": saleID, "clientId": clientID, "productId": productID, "status": "cancelled"}
+	AssertNoErrors(t, ExecuteGraphQL(t, tc, mutation, variables, tc.AdminToken))
+
+	variables["status"] = "paid"
+	AssertHasErrors(t, ExecuteGraphQL(t, tc, mutation, variables, tc.AdminToken))
+
+	resp := ExecuteGraphQL(t, tc, `
+		query($saleId: ID!) {
+			clawback(saleId: $saleId) {
+				reason
+			}
+		}
+	`, map[string]interface{}{"saleId": saleID}, tc.AdminToken)
+	AssertNoErrors(t, resp)
+	if clawback, ok := resp.Data["clawback"].(map[string]interface{}); !ok || clawback["reason"] != "cancelled" {
+		t.Errorf("Expected a cancelled clawback, got %v", resp.Data)
+	}
+}
+
 // TestSaleCreate_PartialPaidAmountValidation tests paidAmount validation for partial status
 func TestSaleCreate_PartialPaidAmountValidation(t *testing.T) {
 	tc := SetupTestEnvironment(t)
@@ -496,3 +607,89 @@ func TestSaleCreate_PartialPaidAmountValidation(t *testing.T) {
 }
 
 
+// TestSaleUpdate_PaidToPendingRejected vérifie qu'une vente payée ne peut pas revenir en attente:
+// son volume resterait retiré des jambes sans reprise des cycles et commissions payés
+func TestSaleUpdate_PaidToPendingRejected(t *testing.T) {
+	tc := SetupTestEnvironment(t)
+	defer TeardownTestEnvironment(t, tc)
+
+	rootID := CreateTestClient(t, tc, "Root", nil)
+	leftID := CreateTestClient(t, tc, "Left", &rootID)
+	productID := CreateTestProduct(t, tc, "Test Product")
+	saleID := CreateTestSale(t, tc, leftID, productID, 100.0, "paid")
+
+	mutation := `
+		mutation($id: ID!, $clientId: ID!, $productId: ID!, $status: String!) {
+			saleUpdate(id: $id, input: {clientId: $clientId, productId: $productId, quantity: 1, amount: 100.0, paidAmount: 50.0, status: $status}) {
+				status
+			}
+		}
+	`
+	for _, status := range []string{"pending", "partial"} {
+		variables := map[string]interface{}{"id": saleID, "clientId": leftID, "productId": productID, "status": status}
+		AssertHasErrors(t, ExecuteGraphQL(t, tc, mutation, variables, tc.AdminToken))
+	}
+
+	// Le volume confirmé reste sur la jambe et la vente reste payée
+	resp := ExecuteGraphQL(t, tc, `
+		query($rootId: ID!, $saleId: ID!) {
+			client(id: $rootId) {
+				networkVolumeLeft
+				pendingVolumeLeft
+			}
+			sale(id: $saleId) {
+				status
+			}
+		}
+	`, map[string]interface{}{"rootId": rootID, "saleId": saleID}, tc.AdminToken)
+	AssertNoErrors(t, resp)
+
+	root := resp.Data["client"].(map[string]interface{})
+	if root["networkVolumeLeft"].(float64) != 10 || root["pendingVolumeLeft"].(float64) != 0 {
+		t.Errorf("Confirmed volume should stay on the leg, got %v confirmed / %v pending", root["networkVolumeLeft"], root["pendingVolumeLeft"])
+	}
+	if sale := resp.Data["sale"].(map[string]interface{}); sale["status"] != "paid" {
+		t.Errorf("Sale should stay paid, got %v", sale["status"])
+	}
+}
+
+// TestSaleUpdate_KeepsSaleFacts vérifie qu'une modification ne change ni l'acheteur ni la date
+// de la vente, et qu'une modification de la note seule garde le statut payé
+func TestSaleUpdate_KeepsSaleFacts(t *testing.T) {
+	tc := SetupTestEnvironment(t)
+	defer TeardownTestEnvironment(t, tc)
+
+	clientID := CreateTestClient(t, tc, "Test Client", nil)
+	otherID := CreateTestClient(t, tc, "Other Client", nil)
+	productID := CreateTestProduct(t, tc, "Test Product")
+	saleID := CreateTestSale(t, tc, clientID, productID, 100.0, "paid")
+
+	resp := ExecuteGraphQL(t, tc, `
+		query($id: ID!) {
+			sale(id: $id) {
+				date
+			}
+		}
+	`, map[string]interface{}{"id": saleID}, tc.AdminToken)
+	AssertNoErrors(t, resp)
+	date := resp.Data["sale"].(map[string]interface{})["date"]
+
+	mutation := `
+		mutation($id: ID!, $clientId: ID!, $productId: ID!) {
+			saleUpdate(id: $id, input: {clientId: $clientId, productId: $productId, quantity: 1, amount: 100.0, note: "livrée"}) {
+				status
+				date
+				note
+			}
+		}
+	`
+	resp = ExecuteGraphQL(t, tc, mutation, map[string]interface{}{"id": saleID, "clientId": otherID, "productId": productID}, tc.AdminToken)
+	AssertHasErrors(t, resp)
+
+	resp = ExecuteGraphQL(t, tc, mutation, map[string]interface{}{"id": saleID, "clientId": clientID, "productId": productID}, tc.AdminToken)
+	AssertNoErrors(t, resp)
+	updated := resp.Data["saleUpdate"].(map[string]interface{})
+	if updated["status"] != "paid" || updated["note"] != "livrée" || updated["date"] != date {
+		t.Errorf("Expected the paid sale with its original date and the new note, got %v", updated)
+	}
+}
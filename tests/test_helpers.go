@@ -15,6 +15,7 @@ import (
 	"bureau/internal/auth"
 	"bureau/internal/config"
 	"bureau/internal/models"
+	"bureau/internal/scheduler"
 	"bureau/internal/service"
 	"bureau/internal/store"
 
@@ -61,7 +62,7 @@ func SetupTestEnvironment(t *testing.T) *TestConfig {
 		_ = godotenv.Load()
 		t.Logf("Note: Could not load env.test, using default/test environment variables")
 	}
-	
+
 	// Override with test database name
 	os.Setenv("MONGO_DB_NAME", "mlm_test_db")
 	cfg := config.Load()
@@ -102,42 +103,107 @@ func SetupTestEnvironment(t *testing.T) *TestConfig {
 	adminRepo := store.NewAdminRepository(db)
 	caisseRepo := store.NewCaisseRepository(db)
 	binaryCappingRepo := store.NewBinaryCappingRepository(db)
+	binaryCycleRepo := store.NewBinaryCycleRepository(db)
+	binaryRunRepo := store.NewBinaryRunRepository(db)
+	jobRepo := store.NewJobRepository(db)
+	compPlanRepo := store.NewCompPlanRepository(db)
+	rankRepo := store.NewRankRepository(db)
+	rankHistoryRepo := store.NewRankHistoryRepository(db)
+	clawbackRepo := store.NewClawbackRepository(db)
+	payPeriodRepo := store.NewPayPeriodRepository(db)
+	walletRepo := store.NewWalletRepository(db)
+	withdrawalRepo := store.NewWithdrawalRepository(db)
+	payoutBatchRepo := store.NewPayoutBatchRepository(db)
 
 	// Initialize JWT service
 	jwtService := auth.NewJWTService(cfg, logger)
 
+	// Les jours, semaines et périodes commencent à minuit dans le fuseau de l'entreprise
+	location, err := time.LoadLocation(cfg.BusinessTimezone)
+	if err != nil {
+		t.Fatalf("Invalid business timezone %q: %v", cfg.BusinessTimezone, err)
+	}
+
 	// Initialize services
 	productService := service.NewProductService(productRepo, logger)
-	clientService := service.NewClientService(clientRepo, saleRepo, commissionRepo, logger, cfg.BinaryThreshold, cfg.BinaryCommissionRate, cfg.DefaultProductPrice)
-	saleService := service.NewSaleService(saleRepo, logger)
 	paymentService := service.NewPaymentService(paymentRepo, logger)
-	commissionService := service.NewCommissionService(commissionRepo, clientRepo, logger, cfg.BinaryCommissionRate, cfg.BinaryThreshold)
-	adminService := service.NewAdminService(adminRepo, clientRepo, productRepo, saleRepo, commissionRepo, logger)
+	commissionService := service.NewCommissionService(commissionRepo, clientRepo, logger)
+	adminService := service.NewAdminService(adminRepo, clientRepo, productRepo, saleRepo, commissionRepo, logger, location)
 	authService := service.NewAuthService(adminRepo, jwtService, logger)
-	caisseService := service.NewCaisseService(caisseRepo, logger)
+	caisseService := service.NewCaisseService(caisseRepo, logger, location)
+	walletService := service.NewWalletService(walletRepo, logger)
 
 	// Initialize Transaction Helper
 	txHelper := store.NewTransactionHelper(mongoClient)
 
+	// Le bonus de démarrage rapide est versé dans la transaction de la vente qui le déclenche
+	fastStartBonusService := service.NewFastStartBonusService(clientRepo, saleRepo, commissionRepo, walletRepo, models.FastStartBonusRule{
+		Rate:   cfg.FastStartBonusRate,
+		Amount: models.NewMoney(cfg.FastStartBonusAmount),
+	}, logger)
+
 	// Initialize Binary Commission Service
 	binaryConfig := models.BinaryConfig{
-		CycleValue:         cfg.BinaryCycleValue,
+		Engine:             cfg.BinaryEngine,
+		Threshold:          models.NewVolume(cfg.BinaryThreshold),
+		CycleValue:         models.NewMoney(cfg.BinaryCycleValue),
 		CommissionRate:     cfg.BinaryCommissionRate,
 		DailyCycleLimit:    cfg.BinaryDailyCycleLimit,
 		WeeklyCycleLimit:   cfg.BinaryWeeklyCycleLimit,
-		MinVolumePerLeg:    cfg.BinaryMinVolumePerLeg,
+		WeekStartDay:       cfg.BinaryWeekStartDay,
+		MinVolumePerLeg:    models.NewVolume(cfg.BinaryMinVolumePerLeg),
 		RequireDirectLeft:  true,
 		RequireDirectRight: true,
+		MatchingBonusRates: cfg.MatchingBonusRates,
 	}
 	binaryCommissionService := service.NewBinaryCommissionService(
 		clientRepo,
 		commissionRepo,
-		saleRepo,
 		binaryCappingRepo,
+		binaryCycleRepo,
+		walletRepo,
 		logger,
 		binaryConfig,
 		txHelper,
+		location,
 	)
+	legacyBinaryEngine := service.NewLegacyBinaryEngine(clientRepo, commissionRepo, walletRepo, logger, binaryConfig)
+
+	// Les règles viennent de la version du plan en vigueur (la configuration d'environnement sert de version 0)
+	compPlanService := service.NewCompPlanService(compPlanRepo, logger, binaryConfig)
+	binaryEngine := service.NewPlanBinaryEngine(compPlanService, binaryCommissionService, legacyBinaryEngine)
+	activityService := service.NewActivityService(clientRepo, saleRepo, logger, models.ActivityRule{
+		MinPoints:  models.NewVolume(cfg.ActivityMinPoints),
+		Window:     cfg.ActivityWindow,
+		WindowDays: cfg.ActivityWindowDays,
+	}, location)
+	legCountService := service.NewLegCountService(clientRepo, logger)
+	rankService := service.NewRankService(rankRepo, rankHistoryRepo, clientRepo, binaryCycleRepo, saleRepo, logger)
+	clientService := service.NewClientService(clientRepo, saleRepo, binaryEngine, activityService, legCountService, logger, cfg.PlacementStrategy, models.HoldingTankRule{
+		Days:     cfg.HoldingTankDays,
+		Strategy: cfg.HoldingTankStrategy,
+	}, txHelper)
+	// L'annulation ou la suppression d'une vente est reprise dans la transaction de la vente
+	clawbackService := service.NewClawbackService(clawbackRepo, clientRepo, commissionRepo, binaryCycleRepo, caisseService, productRepo, clientService, walletRepo, logger)
+	saleService := service.NewSaleService(saleRepo, productRepo, clientService, caisseService, fastStartBonusService, clawbackService, txHelper, logger)
+	payPeriodService := service.NewPayPeriodService(payPeriodRepo, commissionRepo, binaryCycleRepo, saleRepo, clientRepo, rankService, txHelper, models.PayPeriodRule{
+		Frequency:    cfg.PayPeriodFrequency,
+		WeekStartDay: cfg.PayPeriodWeekStartDay,
+	}, logger, location)
+	// L'approbation d'un retrait débite le portefeuille et crée le paiement dans une même transaction;
+	// la sortie de caisse est enregistrée à la confirmation du versement
+	withdrawalService := service.NewWithdrawalService(withdrawalRepo, clientRepo, walletRepo, paymentRepo, caisseService, txHelper, models.WithdrawalRule{
+		MinAmount:  models.NewMoney(cfg.WithdrawalMinAmount),
+		FeeRate:    cfg.WithdrawalFeeRate,
+		FeeAmount:  models.NewMoney(cfg.WithdrawalFeeAmount),
+		DailyLimit: models.NewMoney(cfg.WithdrawalDailyLimit),
+	}, logger, location)
+	payoutBatchService := service.NewPayoutBatchService(payoutBatchRepo, withdrawalRepo, clientRepo, withdrawalService, txHelper, models.PayoutRule{
+		Currency:    cfg.PayoutCurrency,
+		CountryCode: cfg.PayoutCountryCode,
+	}, logger)
+	binaryBatchService := service.NewBinaryBatchService(binaryEngine, clientRepo, binaryRunRepo, logger, cfg.BinaryBatchWorkers)
+	jobScheduler := scheduler.New(jobRepo, logger, "test", cfg.SchedulerLeaseDuration, location)
 
 	// Initialize GraphQL resolver
 	resolver := graph.NewResolver(
@@ -150,6 +216,16 @@ func SetupTestEnvironment(t *testing.T) *TestConfig {
 		adminService,
 		caisseService,
 		binaryCommissionService,
+		binaryEngine,
+		binaryBatchService,
+		compPlanService,
+		rankService,
+		clawbackService,
+		payPeriodService,
+		walletService,
+		withdrawalService,
+		payoutBatchService,
+		jobScheduler,
 	)
 
 	// Create GraphQL handler
@@ -186,7 +262,7 @@ func SetupTestEnvironment(t *testing.T) *TestConfig {
 	testAdmin := &models.Admin{
 		Name:         "Test Admin",
 		Email:        "test-admin@test.com",
-		PasswordHash:  hashedPassword,
+		PasswordHash: hashedPassword,
 		Role:         "admin",
 	}
 	createdAdmin, err := adminRepo.Create(ctx, testAdmin)
@@ -203,9 +279,9 @@ func SetupTestEnvironment(t *testing.T) *TestConfig {
 	return &TestConfig{
 		MongoDB:     db,
 		MongoClient: mongoClient,
-		Resolver:   resolver,
-		Server:     testServer,
-		AdminToken: authPayload.AccessToken,
+		Resolver:    resolver,
+		Server:      testServer,
+		AdminToken:  authPayload.AccessToken,
 		TestAdminID: createdAdmin.ID.Hex(),
 	}
 }
@@ -370,4 +446,3 @@ func AssertHasErrors(t *testing.T, resp *GraphQLResponse) {
 		t.Error("Expected GraphQL errors but got none")
 	}
 }
-
@@ -0,0 +1,147 @@
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"bureau/internal/models"
+	"bureau/internal/store"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// loginTestClient connects a client created by CreateTestClient and returns its access token
+func loginTestClient(t *testing.T, tc *TestConfig, clientID string) string {
+	resp := ExecuteGraphQL(t, tc, `
+		query($clientId: ID!) {
+			client(id: $clientId) {
+				clientId
+			}
+		}
+	`, map[string]interface{}{"clientId": clientID}, tc.AdminToken)
+	AssertNoErrors(t, resp)
+	memberID := resp.Data["client"].(map[string]interface{})["clientId"].(string)
+
+	resp = ExecuteGraphQL(t, tc, `
+		mutation($clientId: String!) {
+			clientLogin(input: { clientId: $clientId, password: "Test123@client" }) {
+				accessToken
+			}
+		}
+	`, map[string]interface{}{"clientId": memberID}, "")
+	AssertNoErrors(t, resp)
+	return resp.Data["clientLogin"].(map[string]interface{})["accessToken"].(string)
+}
+
+// TestWithdrawal_RequestAndApprove tests a member withdrawal from request to payout
+func TestWithdrawal_RequestAndApprove(t *testing.T) {
+	tc := SetupTestEnvironment(t)
+	defer TeardownTestEnvironment(t, tc)
+
+	clientID := CreateTestClient(t, tc, "Withdrawal Client", nil)
+	clientToken := loginTestClient(t, tc, clientID)
+
+	// Crédit du portefeuille par le journal
+	clientOID, _ := primitive.ObjectIDFromHex(clientID)
+	_, err := store.NewWalletRepository(tc.MongoDB).Post(context.Background(), &models.WalletTransaction{
+		ClientID:       clientOID,
+		Direction:      models.WalletCredit,
+		Type:           models.WalletEntryOpening,
+		Amount:         models.NewMoney(100),
+		Earnings:       models.NewMoney(100),
+		CounterAccount: models.WalletAccountOpening,
+		ReferenceType:  models.WalletReferenceClient,
+		ReferenceID:    clientOID,
+	})
+	if err != nil {
+		t.Fatalf("Failed to credit the wallet: %v", err)
+	}
+
+	requestMutation := `
+		mutation($amount: Float!) {
+			requestWithdrawal(input: { amount: $amount, method: "mobile", destination: "+243810000000" }) {
+				id
+				status
+				netAmount
+			}
+		}
+	`
+
+	// Un admin ne demande pas de retrait
+	resp := ExecuteGraphQL(t, tc, requestMutation, map[string]interface{}{"amount": 40.0}, tc.AdminToken)
+	AssertHasErrors(t, resp)
+
+	// Au-delà du solde
+	resp = ExecuteGraphQL(t, tc, requestMutation, map[string]interface{}{"amount": 150.0}, clientToken)
+	AssertHasErrors(t, resp)
+
+	resp = ExecuteGraphQL(t, tc, requestMutation, map[string]interface{}{"amount": 40.0}, clientToken)
+	AssertNoErrors(t, resp)
+	requested := resp.Data["requestWithdrawal"].(map[string]interface{})
+	if requested["status"] != "pending" {
+		t.Fatalf("Expected a pending withdrawal, got %v", requested)
+	}
+
+	approveMutation := `
+		mutation($id: ID!) {
+			approveWithdrawal(id: $id) {
+				status
+				paymentId
+				caisseTransactionId
+				walletTransactionId
+			}
+		}
+	`
+	// Seul un admin approuve
+	resp = ExecuteGraphQL(t, tc, approveMutation, map[string]interface{}{"id": requested["id"]}, clientToken)
+	AssertHasErrors(t, resp)
+
+	resp = ExecuteGraphQL(t, tc, approveMutation, map[string]interface{}{"id": requested["id"]}, tc.AdminToken)
+	AssertNoErrors(t, resp)
+	approved := resp.Data["approveWithdrawal"].(map[string]interface{})
+	if approved["status"] != "approved" || approved["paymentId"] == nil || approved["caisseTransactionId"] != nil || approved["walletTransactionId"] == nil {
+		t.Fatalf("Unexpected approved withdrawal: %v", approved)
+	}
+
+	// La sortie de caisse est enregistrée à la confirmation du versement
+	resp = ExecuteGraphQL(t, tc, `
+		mutation($id: ID!) {
+			settleWithdrawal(id: $id, completed: true) {
+				status
+				caisseTransactionId
+			}
+		}
+	`, map[string]interface{}{"id": requested["id"]}, tc.AdminToken)
+	AssertNoErrors(t, resp)
+	settled := resp.Data["settleWithdrawal"].(map[string]interface{})
+	if settled["status"] != "approved" || settled["caisseTransactionId"] == nil {
+		t.Fatalf("Unexpected settled withdrawal: %v", settled)
+	}
+
+	resp = ExecuteGraphQL(t, tc, `
+		query($clientId: ID!) {
+			walletTransactions(clientId: $clientId) {
+				type
+				direction
+				balanceAfter
+			}
+		}
+	`, map[string]interface{}{"clientId": clientID}, clientToken)
+	AssertNoErrors(t, resp)
+	latest := resp.Data["walletTransactions"].([]interface{})[0].(map[string]interface{})
+	if latest["type"] != "withdrawal" || latest["direction"] != "debit" || latest["balanceAfter"] != 60.0 {
+		t.Errorf("Expected a withdrawal debit leaving 60, got %v", latest)
+	}
+
+	resp = ExecuteGraphQL(t, tc, `
+		query {
+			withdrawals(status: "approved") {
+				id
+			}
+		}
+	`, nil, clientToken)
+	AssertNoErrors(t, resp)
+	if withdrawals := resp.Data["withdrawals"].([]interface{}); len(withdrawals) != 1 {
+		t.Errorf("Expected the member's approved withdrawal, got %v", withdrawals)
+	}
+}